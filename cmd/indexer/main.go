@@ -1,9 +1,10 @@
 // Command indexer starts the document indexing service.
 //
-// The indexer consumes document-ingest events from Kafka, tokenises their
-// content (with Porter stemming), and writes inverted-index entries into the
-// appropriate shard. Each shard periodically flushes its in-memory index to
-// immutable on-disk segments for durability.
+// The indexer consumes document-ingest events from Kafka, analyses their
+// content with the configured analyzer (Snowball stemming by default), and
+// writes inverted-index entries into the appropriate shard. Each shard
+// periodically flushes its in-memory index to immutable on-disk segments for
+// durability, and merges small segments into larger ones in the background.
 //
 // Usage:
 //
@@ -69,7 +70,8 @@ func main() {
 
 	for shardID, engine := range router.GetAllEngines() {
 		engine.StartFlushLoop(ctx)
-		slog.Info("flush loop started", "shard_id", shardID)
+		engine.StartMergeLoop(ctx)
+		slog.Info("flush and merge loops started", "shard_id", shardID)
 	}
 	var sqlDB *sql.DB
 	if db != nil {
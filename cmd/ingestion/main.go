@@ -19,6 +19,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/ingestion/handler"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/ingestion/publisher"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/config"
@@ -50,10 +51,16 @@ func main() {
 	producer := kafka.NewProducer(cfg.Kafka, cfg.Kafka.Topics.DocumentIngest)
 	defer producer.Close()
 	slog.Info("kafka producer initialized", "topic", cfg.Kafka.Topics.DocumentIngest)
+	schema, err := index.ParseSchema(cfg.Indexer.Metadata)
+	if err != nil {
+		slog.Error("invalid metadata schema", "error", err)
+		os.Exit(1)
+	}
 	pub := publisher.New(db, producer)
-	h := handler.New(pub)
+	h := handler.New(pub, schema)
 	mux := http.NewServeMux()
 	mux.HandleFunc("POST /api/v1/documents", h.Ingest)
+	mux.HandleFunc("DELETE /api/v1/documents/{id}", h.Delete)
 	mux.HandleFunc("GET /health", h.Health)
 
 	server := &http.Server{
@@ -2,7 +2,8 @@
 //
 // The searcher loads shard data from disk, connects to Redis for query caching,
 // starts an analytics collector/aggregator pipeline via Kafka, and exposes an
-// HTTP API for full-text search, cache management, analytics, and health checks.
+// HTTP API for full-text search, query suggestions, cache management, analytics,
+// and health checks.
 //
 // Usage:
 //
@@ -22,10 +23,15 @@ import (
 	"time"
 
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/analytics"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/shard"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/cache"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/executor"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/handler"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/highlight"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/suggest"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/config"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/health"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/kafka"
@@ -66,6 +72,9 @@ func main() {
 		m.ActiveShards.Set(float64(numShards))
 		slog.Info("prometheus metrics enabled", "port", cfg.Metrics.Port)
 	}
+	// The indexer process owns writes (and the write-ahead log) for the
+	// shared data directory; the searcher only reads segments.
+	cfg.Indexer.ReadOnly = true
 	router, err := shard.NewRouter(cfg.Indexer, numShards)
 	if err != nil {
 		slog.Error("failed to create shard router", "error", err)
@@ -120,9 +129,12 @@ func main() {
 	defer collector.Close()
 	slog.Info("analytics collector started", "topic", cfg.Kafka.Topics.AnalyticsEvents)
 
-	analyticsConsumer := kafka.NewConsumer(cfg.Kafka, cfg.Kafka.Topics.AnalyticsEvents, nil)
-	aggregator := analytics.NewAggregator(analyticsConsumer)
-	analyticsConsumer = kafka.NewConsumer(cfg.Kafka, cfg.Kafka.Topics.AnalyticsEvents, analytics.HandleEvent(aggregator))
+	// The consumer's handler records into the aggregator that owns it, which
+	// the analytics endpoint and the suggester read from.
+	var aggregator *analytics.Aggregator
+	analyticsConsumer := kafka.NewConsumer(cfg.Kafka, cfg.Kafka.Topics.AnalyticsEvents, func(ctx context.Context, key, value []byte) error {
+		return analytics.HandleEvent(aggregator)(ctx, key, value)
+	})
 	aggregator = analytics.NewAggregator(analyticsConsumer)
 	analyticsH := analytics.NewHandler(aggregator)
 
@@ -148,10 +160,55 @@ func main() {
 		}
 		return health.ComponentHealth{Status: health.StatusUp}
 	})
-	exec := executor.NewSharded(router.GetAllEngines())
-	h := handler.New(exec, queryCache, collector, m, cfg.Search.DefaultLimit, cfg.Search.MaxResults)
+	analyzer, err := analysis.Build(cfg.Indexer.Analyzer, cfg.Indexer.Analyzers)
+	if err != nil {
+		slog.Error("invalid analyzer", "error", err)
+		os.Exit(1)
+	}
+	schema, err := index.ParseSchema(cfg.Indexer.Metadata)
+	if err != nil {
+		slog.Error("invalid metadata schema", "error", err)
+		os.Exit(1)
+	}
+	ranking, err := ranker.ParseConfig(cfg.Search.Ranking)
+	if err != nil {
+		slog.Error("invalid ranking configuration", "error", err)
+		os.Exit(1)
+	}
+	rewrite, err := executor.ParseRewrite(cfg.Search.Expansion.Rewrite)
+	if err != nil {
+		slog.Error("invalid term expansion configuration", "error", err)
+		os.Exit(1)
+	}
+	exec := executor.NewSharded(router.GetAllEngines(), ranking, executor.ExpansionConfig{
+		MaxExpansions: cfg.Search.Expansion.MaxExpansions,
+		Rewrite:       rewrite,
+	})
+	encoder, err := highlight.ParseEncoder(cfg.Search.Highlight.Encoder)
+	if err != nil {
+		slog.Error("invalid highlight configuration", "error", err)
+		os.Exit(1)
+	}
+	highlighting := highlight.Config{
+		PreTag:       cfg.Search.Highlight.PreTag,
+		PostTag:      cfg.Search.Highlight.PostTag,
+		FragmentSize: cfg.Search.Highlight.FragmentSize,
+		NumFragments: cfg.Search.Highlight.NumFragments,
+		Encoder:      encoder,
+	}
+	suggester := suggest.New(router.GetAllEngines(), analyzer, aggregator, suggest.Config{
+		MaxItems:       cfg.Search.Suggest.MaxItems,
+		PopularQueries: cfg.Search.Suggest.PopularQueries,
+	})
+	suggester.Refresh()
+	go suggester.Run(ctx, cfg.Search.Suggest.RefreshInterval)
+	slog.Info("query suggestions enabled", "refresh_interval", cfg.Search.Suggest.RefreshInterval)
+
+	h := handler.New(exec, analyzer, schema, queryCache, suggester, collector, m, highlighting, cfg.Search.DefaultLimit, cfg.Search.MaxResults, cfg.Search.MaxOffset)
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /api/v1/search", h.Search)
+	mux.HandleFunc("GET /api/v1/search/explain", h.Explain)
+	mux.HandleFunc("GET /api/v1/suggest", h.Suggest)
 	mux.HandleFunc("GET /api/v1/cache/stats", h.CacheStats)
 	mux.HandleFunc("POST /api/v1/cache/invalidate", h.CacheInvalidate)
 	mux.HandleFunc("GET /api/v1/analytics", analyticsH.Stats)
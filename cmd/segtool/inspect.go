@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/segment"
+)
+
+// runInfo prints a segment's header, counts, section sizes and corpus
+// statistics.
+func runInfo(args []string) int {
+	flags := flag.NewFlagSet("info", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, "usage: segtool info <segment>") }
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	r, ok := openSegment(flags.Arg(0))
+	if !ok {
+		return 1
+	}
+	defer r.Close()
+
+	h := r.Header()
+	stats := r.Stats()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "segment:\t%s\n", r.Name())
+	fmt.Fprintf(w, "version:\t%d\n", h.Version)
+	fmt.Fprintf(w, "file size:\t%d bytes\n", r.Size())
+	fmt.Fprintf(w, "terms:\t%d\n", r.Terms())
+	fmt.Fprintf(w, "documents:\t%d (%d live, %d deleted)\n", r.DocCount(), r.LiveDocCount(), r.LiveDocs().DeletedCount())
+	fmt.Fprintf(w, "postings:\toffset %d, %d bytes\n", h.PostOffset, h.PostSize)
+	fmt.Fprintf(w, "dictionary:\toffset %d, %d bytes\n", h.DictOffset, h.DictSize)
+	fmt.Fprintf(w, "document table:\toffset %d, %d bytes\n", h.DocsOffset, h.DocsSize)
+	if n := r.StoredSize(); n > 0 {
+		fmt.Fprintf(w, "stored fields:\t%d bytes\n", n)
+	}
+	if n := r.ImpactsSize(); n > 0 {
+		fmt.Fprintf(w, "impacts:\t%d bytes\n", n)
+	}
+	if types := r.ValueTypes(); len(types) > 0 {
+		keys := types.Keys()
+		for i, key := range keys {
+			keys[i] = fmt.Sprintf("%s (%s)", key, types[key])
+		}
+		fmt.Fprintf(w, "doc values:\t%s\n", strings.Join(keys, ", "))
+	}
+	fmt.Fprintf(w, "live tokens:\t%d\n", stats.Tokens)
+	fields := make([]string, 0, len(stats.FieldTokens))
+	for field := range stats.FieldTokens {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		fmt.Fprintf(w, "  %s tokens:\t%d\n", field, stats.FieldTokens[field])
+	}
+	if sources := r.Sources(); len(sources) > 0 {
+		fmt.Fprintf(w, "merged from:\t%s\n", strings.Join(sources, ", "))
+	}
+	w.Flush()
+	return 0
+}
+
+// runTerms prints the dictionary terms, optionally restricted to a prefix,
+// with their document frequencies. Frequencies include deleted documents.
+func runTerms(args []string) int {
+	flags := flag.NewFlagSet("terms", flag.ExitOnError)
+	prefix := flags.String("prefix", "", "only print terms starting with this prefix")
+	limit := flags.Int("limit", 0, "stop after this many terms (0 for no limit)")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: segtool terms [-prefix p] [-limit n] <segment>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	r, ok := openSegment(flags.Arg(0))
+	if !ok {
+		return 1
+	}
+	defer r.Close()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TERM\tDOC FREQ")
+	count := 0
+	err := r.TermsWithPrefix(*prefix, func(t segment.TermInfo) bool {
+		fmt.Fprintf(w, "%s\t%d\n", t.Term, t.DocFreq)
+		count++
+		return *limit <= 0 || count < *limit
+	})
+	w.Flush()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "segtool:", err)
+		return 1
+	}
+	return 0
+}
+
+// runPostings prints the live postings of a single term. The term is looked
+// up exactly as stored, without tokenisation.
+func runPostings(args []string) int {
+	flags := flag.NewFlagSet("postings", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, "usage: segtool postings <segment> <term>") }
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+	r, ok := openSegment(flags.Arg(0))
+	if !ok {
+		return 1
+	}
+	defer r.Close()
+
+	postings, err := r.Search(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "segtool:", err)
+		return 1
+	}
+	if len(postings) == 0 {
+		fmt.Fprintf(os.Stderr, "segtool: term %q not found\n", flags.Arg(1))
+		return 1
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DOC ID\tFREQ\tPOSITIONS")
+	for _, p := range postings {
+		positions := make([]string, len(p.Positions))
+		for i, pos := range p.Positions {
+			positions[i] = fmt.Sprint(pos)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\n", p.DocID, p.Frequency, strings.Join(positions, ","))
+	}
+	w.Flush()
+	return 0
+}
+
+// runDocs prints the document table in ordinal order with per-field lengths
+// and deletion state, and optionally the stored fields of each document.
+func runDocs(args []string) int {
+	flags := flag.NewFlagSet("docs", flag.ExitOnError)
+	showStored := flags.Bool("stored", false, "print the stored fields of each document as JSON")
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, "usage: segtool docs [-stored] <segment>") }
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	r, ok := openSegment(flags.Arg(0))
+	if !ok {
+		return 1
+	}
+	defer r.Close()
+
+	live := r.LiveDocs()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "ORD\tDOC ID\tLENGTH\tFIELDS\tSTATUS"
+	if *showStored {
+		header += "\tSTORED"
+	}
+	fmt.Fprintln(w, header)
+	for ord, doc := range r.Docs() {
+		fields := make([]string, 0, len(doc.Fields))
+		for field, n := range doc.Fields {
+			fields = append(fields, fmt.Sprintf("%s=%d", field, n))
+		}
+		sort.Strings(fields)
+		status := "live"
+		if live.IsDeleted(ord) {
+			status = "deleted"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s", ord, doc.DocID, doc.Total(), strings.Join(fields, " "), status)
+		if *showStored {
+			stored, ok, err := r.StoredByOrdinal(uint32(ord))
+			switch {
+			case err != nil:
+				w.Flush()
+				fmt.Fprintf(os.Stderr, "segtool: %v\n", err)
+				return 1
+			case ok:
+				data, _ := json.Marshal(stored)
+				fmt.Fprintf(w, "\t%s", data)
+			default:
+				fmt.Fprint(w, "\t-")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+	return 0
+}
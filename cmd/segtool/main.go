@@ -0,0 +1,88 @@
+// Command segtool inspects and maintains .spdx segment files offline.
+//
+// Subcommands:
+//
+//	info      print a segment's header, counts, section sizes and statistics
+//	terms     dump the term dictionary with document frequencies
+//	postings  print the live postings of a term
+//	docs      print the document table with field lengths, deletions and,
+//	          optionally, stored fields
+//	verify    check segment checksums and structure, optionally quarantining
+//	          corrupt segments
+//	merge     merge segments into a new one
+//	convert   rewrite a segment in another format version
+//
+// Usage:
+//
+//	go run ./cmd/segtool info <segment>
+//	go run ./cmd/segtool terms [-prefix p] [-limit n] <segment>
+//	go run ./cmd/segtool postings <segment> <term>
+//	go run ./cmd/segtool docs [-stored] <segment>
+//	go run ./cmd/segtool verify [-mode full] [-quarantine] <data-dir|segment>...
+//	go run ./cmd/segtool merge -out <dir> [-version n] <segment>...
+//	go run ./cmd/segtool convert -out <dir> -version <n> <segment>
+//
+// merge and convert never modify their inputs. The new segment records the
+// inputs as its sources, so once it is placed in a shard directory the
+// engine drops (and, in the indexer, deletes) the inputs on its next start.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/segment"
+)
+
+// command is a segtool subcommand. run receives the arguments after the
+// subcommand name and returns the process exit code.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands = []command{
+	{"info", "print header, counts, section sizes and statistics", runInfo},
+	{"terms", "dump the term dictionary with document frequencies", runTerms},
+	{"postings", "print the live postings of a term", runPostings},
+	{"docs", "print the document table", runDocs},
+	{"verify", "check segment checksums and structure", runVerify},
+	{"merge", "merge segments into a new segment", runMerge},
+	{"convert", "rewrite a segment in another format version", runConvert},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			os.Exit(cmd.run(os.Args[2:]))
+		}
+	}
+	fmt.Fprintf(os.Stderr, "segtool: unknown command %q\n", os.Args[1])
+	usage()
+	os.Exit(2)
+}
+
+// usage prints the list of subcommands to stderr.
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: segtool <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// openSegment opens a segment for inspection, reporting failures to stderr.
+func openSegment(path string) (*segment.Reader, bool) {
+	r, err := segment.OpenReader(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "segtool: %s: %v\n", path, err)
+		return nil, false
+	}
+	return r, true
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/segment"
+)
+
+// runMerge merges the given segments into a new segment in the output
+// directory. Inputs are merged in file-name order, which is creation order,
+// so the newest copy of a document wins as it does in the engine.
+func runMerge(args []string) int {
+	flags := flag.NewFlagSet("merge", flag.ExitOnError)
+	out := flags.String("out", "", "directory to write the merged segment to")
+	version := flags.Uint("version", uint(segment.FormatVersion), "format version of the merged segment")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: segtool merge -out <dir> [-version n] <segment>...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *out == "" || flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	paths := append([]string(nil), flags.Args()...)
+	sort.Slice(paths, func(i, j int) bool {
+		return filepath.Base(paths[i]) < filepath.Base(paths[j])
+	})
+	return rewrite(paths, *out, uint32(*version))
+}
+
+// runConvert rewrites a segment in another format version. Deleted
+// documents are purged in the process.
+func runConvert(args []string) int {
+	flags := flag.NewFlagSet("convert", flag.ExitOnError)
+	out := flags.String("out", "", "directory to write the converted segment to")
+	version := flags.Uint("version", 0, fmt.Sprintf("target format version (%d-%d)", segment.MinFormatVersion, segment.FormatVersion))
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: segtool convert -out <dir> -version <n> <segment>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *out == "" || *version == 0 || flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	return rewrite(flags.Args(), *out, uint32(*version))
+}
+
+// rewrite merges the segments at paths, oldest first, into a new segment of
+// the given version in dir.
+func rewrite(paths []string, dir string, version uint32) int {
+	w, err := segment.NewVersionedWriter(dir, version)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "segtool:", err)
+		return 2
+	}
+	readers := make([]*segment.Reader, 0, len(paths))
+	defer func() {
+		for _, r := range readers {
+			r.Close()
+		}
+	}()
+	for _, path := range paths {
+		r, ok := openSegment(path)
+		if !ok {
+			return 1
+		}
+		readers = append(readers, r)
+	}
+
+	name, err := segment.Merge(readers, w)
+	if errors.Is(err, segment.ErrEmptyMerge) {
+		fmt.Fprintln(os.Stderr, "segtool: every document in the input is deleted; nothing written")
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "segtool:", err)
+		return 1
+	}
+	r, ok := openSegment(filepath.Join(dir, name))
+	if !ok {
+		return 1
+	}
+	defer r.Close()
+	fmt.Printf("wrote %s: version %d, %d terms, %d docs, %d bytes\n",
+		filepath.Join(dir, name), version, r.Terms(), r.DocCount(), r.Size())
+	return 0
+}
@@ -161,6 +161,21 @@ func (a *Aggregator) Stats() AggregatedStats {
 	return stats
 }
 
+// PopularQueries returns up to n queries ranked by how many of their
+// searches returned results. Queries that only ever returned nothing are
+// left out, so they are not suggested to other users.
+func (a *Aggregator) PopularQueries(n int) []QueryCount {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	counts := make(map[string]int64, len(a.queryCounts))
+	for query, count := range a.queryCounts {
+		if hits := count - a.zeroResultQueries[query]; hits > 0 {
+			counts[query] = hits
+		}
+	}
+	return topN(counts, n)
+}
+
 // percentile returns the pct-th percentile from a pre-sorted int64 slice.
 func percentile(sorted []int64, pct int) int64 {
 	if len(sorted) == 0 {
@@ -54,16 +54,23 @@ func newProxy(target string) *httputil.ReverseProxy {
 
 // ---------- Proxy handlers ----------
 
-// ProxyIngest forwards document ingestion requests to the ingestion service.
+// ProxyIngest forwards document ingestion and deletion requests to the
+// ingestion service.
 func (h *Handler) ProxyIngest(w http.ResponseWriter, r *http.Request) {
 	h.ingestionProxy.ServeHTTP(w, r)
 }
 
-// ProxySearch forwards search queries to the search service.
+// ProxySearch forwards search queries and score explanations to the search
+// service.
 func (h *Handler) ProxySearch(w http.ResponseWriter, r *http.Request) {
 	h.searchProxy.ServeHTTP(w, r)
 }
 
+// ProxySuggest forwards query suggestion requests to the search service.
+func (h *Handler) ProxySuggest(w http.ResponseWriter, r *http.Request) {
+	h.searchProxy.ServeHTTP(w, r)
+}
+
 // ProxyAnalytics forwards analytics requests to the search service.
 func (h *Handler) ProxyAnalytics(w http.ResponseWriter, r *http.Request) {
 	h.searchProxy.ServeHTTP(w, r)
@@ -20,6 +20,8 @@ import (
 //	GET    /api/v1/documents           → list documents   (direct DB)
 //	GET    /api/v1/documents/{id}      → get document     (direct DB)
 //	GET    /api/v1/search              → search service   (proxy)
+//	GET    /api/v1/search/explain      → search service   (proxy)
+//	GET    /api/v1/suggest             → search service   (proxy)
 //	GET    /api/v1/analytics           → search service   (proxy)
 //	GET    /api/v1/cache/stats         → search service   (proxy)
 //	POST   /api/v1/cache/invalidate    → search service   (proxy)
@@ -40,9 +42,12 @@ func New(h *gwhandler.Handler, validator *apikey.Validator, limiter *ratelimit.L
 	mux.HandleFunc("POST /api/v1/documents", h.ProxyIngest)
 	mux.HandleFunc("GET /api/v1/documents", h.ListDocuments)
 	mux.HandleFunc("GET /api/v1/documents/{id}", h.GetDocument)
+	mux.HandleFunc("DELETE /api/v1/documents/{id}", h.ProxyIngest)
 
 	// Search API
 	mux.HandleFunc("GET /api/v1/search", h.ProxySearch)
+	mux.HandleFunc("GET /api/v1/search/explain", h.ProxySearch)
+	mux.HandleFunc("GET /api/v1/suggest", h.ProxySuggest)
 
 	// Analytics API
 	mux.HandleFunc("GET /api/v1/analytics", h.ProxyAnalytics)
@@ -0,0 +1,231 @@
+// Package analysis turns text into the terms stored in and looked up from
+// the inverted index. An Analyzer is built from an optional chain of char
+// filters applied to the raw text, a tokenizer that splits it into terms,
+// and a chain of token filters that normalise, remove or rewrite terms.
+//
+// Analyzers are registered by name so that the indexer and the searcher can
+// select the same one from configuration; documents must be searched with
+// the analyzer they were indexed with. The standard, whitespace and keyword
+// analyzers and the Snowball-stemmed english, german, french and spanish
+// analyzers are built in, and english is the default; Build also assembles
+// analyzers defined in configuration from named components. The standard
+// and language analyzers normalize text to NFKC, split it at Unicode word
+// boundaries and index Chinese and Japanese as character bigrams.
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Token is a single analysed term and its position among the terms produced
+// from the same text.
+type Token struct {
+	Term     string
+	Position int
+}
+
+// Analyzer converts text into tokens. Implementations must be safe for
+// concurrent use.
+type Analyzer interface {
+	Analyze(text string) []Token
+}
+
+// Normalizer is implemented by analyzers that can normalise a single query
+// term, such as the text of a prefix, wildcard or fuzzy query, without
+// tokenizing, removing or stemming it.
+type Normalizer interface {
+	Normalize(term string) string
+}
+
+// Normalize normalises a query term with a, or returns it unchanged if a is
+// not a Normalizer.
+func Normalize(a Analyzer, term string) string {
+	if n, ok := a.(Normalizer); ok {
+		return n.Normalize(term)
+	}
+	return term
+}
+
+// Span is a range of byte offsets in a text.
+type Span struct {
+	Start, End int
+}
+
+// Locator is implemented by analyzers that can find the words of a text
+// their tokens came from, for highlighting.
+type Locator interface {
+	// Locate returns text after the char filters and, for each position
+	// Analyze assigns to a token of text, the span of the word in the
+	// returned text that the token came from.
+	Locate(text string) (string, []Span)
+}
+
+// CharFilter rewrites text before it is tokenized.
+type CharFilter interface {
+	Filter(text string) string
+}
+
+// Tokenizer splits text into terms.
+type Tokenizer interface {
+	Tokenize(text string) []string
+}
+
+// TokenFilter transforms the terms produced by a Tokenizer. It may modify
+// and return the input slice.
+type TokenFilter interface {
+	Filter(terms []string) []string
+}
+
+// CharFilterFunc adapts a function to the CharFilter interface.
+type CharFilterFunc func(text string) string
+
+// Filter calls f(text).
+func (f CharFilterFunc) Filter(text string) string { return f(text) }
+
+// TokenizerFunc adapts a function to the Tokenizer interface.
+type TokenizerFunc func(text string) []string
+
+// Tokenize calls f(text).
+func (f TokenizerFunc) Tokenize(text string) []string { return f(text) }
+
+// TokenFilterFunc adapts a function to the TokenFilter interface.
+type TokenFilterFunc func(terms []string) []string
+
+// Filter calls f(terms).
+func (f TokenFilterFunc) Filter(terms []string) []string { return f(terms) }
+
+// Pipeline is an Analyzer composed of char filters, a tokenizer and token
+// filters, applied in that order. Positions are assigned to the terms that
+// survive the filter chain, so a removed stop word leaves no gap.
+type Pipeline struct {
+	CharFilters []CharFilter
+	Tokenizer   Tokenizer
+	Filters     []TokenFilter
+}
+
+// Analyze runs text through the pipeline.
+func (p *Pipeline) Analyze(text string) []Token {
+	for _, cf := range p.CharFilters {
+		text = cf.Filter(text)
+	}
+	terms := p.Tokenizer.Tokenize(text)
+	for _, f := range p.Filters {
+		if len(terms) == 0 {
+			break
+		}
+		terms = f.Filter(terms)
+	}
+	tokens := make([]Token, len(terms))
+	for i, term := range terms {
+		tokens[i] = Token{Term: term, Position: i}
+	}
+	return tokens
+}
+
+// Locate implements Locator. The token filters are applied to each word on
+// its own, which assigns the same positions as Analyze as long as they
+// rewrite, drop or split every term independently of the others, as all
+// the built-in filters do. A word the tokenizer changed so that it is not
+// found in the text gets an empty span.
+func (p *Pipeline) Locate(text string) (string, []Span) {
+	for _, cf := range p.CharFilters {
+		text = cf.Filter(text)
+	}
+	var spans []Span
+	offset := 0
+	for _, word := range p.Tokenizer.Tokenize(text) {
+		span := Span{Start: offset, End: offset}
+		if i := strings.Index(text[offset:], word); i >= 0 {
+			span = Span{Start: offset + i, End: offset + i + len(word)}
+			offset = span.End
+		}
+		terms := []string{word}
+		for _, f := range p.Filters {
+			if len(terms) == 0 {
+				break
+			}
+			terms = f.Filter(terms)
+		}
+		for range terms {
+			spans = append(spans, span)
+		}
+	}
+	return text, spans
+}
+
+// Normalize applies the char filters and the filters that only rewrite a
+// term's case or accents, such as LowercaseFilter and AccentFoldingFilter,
+// to term.
+func (p *Pipeline) Normalize(term string) string {
+	for _, cf := range p.CharFilters {
+		term = cf.Filter(term)
+	}
+	for _, f := range p.Filters {
+		if _, ok := f.(normalizingFilter); ok {
+			term = f.Filter([]string{term})[0]
+		}
+	}
+	return term
+}
+
+// normalizingFilter is a TokenFilter that rewrites each term on its own,
+// returning one term for each, so that it also applies to single query
+// terms in Pipeline.Normalize.
+type normalizingFilter struct {
+	TokenFilterFunc
+}
+
+// DefaultAnalyzer is the analyzer used when none is configured.
+const DefaultAnalyzer = "english"
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Analyzer)
+)
+
+// Register makes an analyzer available under name, replacing any analyzer
+// already registered with that name.
+func Register(name string, a Analyzer) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = a
+}
+
+// Get returns the analyzer registered under name. The empty name selects
+// DefaultAnalyzer.
+func Get(name string) (Analyzer, error) {
+	if name == "" {
+		name = DefaultAnalyzer
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	a, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown analyzer %q", name)
+	}
+	return a, nil
+}
+
+// Default returns the DefaultAnalyzer.
+func Default() Analyzer {
+	a, err := Get(DefaultAnalyzer)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// Names returns the names of the registered analyzers in sorted order.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
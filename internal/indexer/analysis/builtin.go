@@ -0,0 +1,58 @@
+package analysis
+
+import "github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis/snowball"
+
+func init() {
+	// standard normalizes to NFKC, splits at Unicode word boundaries,
+	// lower-cases and splits Chinese and Japanese into bigrams.
+	Register("standard", &Pipeline{
+		CharFilters: []CharFilter{NFKCFilter},
+		Tokenizer:   UnicodeTokenizer,
+		Filters:     []TokenFilter{LowercaseFilter, CJKBigramFilter},
+	})
+	// whitespace splits on white space and leaves terms untouched.
+	Register("whitespace", &Pipeline{
+		Tokenizer: WhitespaceTokenizer,
+	})
+	// keyword indexes the whole field as one exact term.
+	Register("keyword", &Pipeline{
+		Tokenizer: KeywordTokenizer,
+	})
+	// english, german, french and spanish are standard plus removal of
+	// one-character terms and the language's stop words, Snowball stemming
+	// and accent folding.
+	Register("english", language(EnglishStopWords, snowball.English))
+	Register("german", language(GermanStopWords, snowball.German))
+	Register("french", language(FrenchStopWords, snowball.French, ElisionFilter(FrenchElisions)))
+	Register("spanish", language(SpanishStopWords, snowball.Spanish))
+	// english_legacy is the english analyzer as it was before Unicode
+	// segmentation and Snowball, for indexes that have not been rebuilt.
+	Register("english_legacy", &Pipeline{
+		Tokenizer: LetterDigitTokenizer,
+		Filters: []TokenFilter{
+			LowercaseFilter,
+			MinLengthFilter(2),
+			StopFilter(EnglishStopWords),
+			StemFilter(legacyEnglishStem),
+		},
+	})
+}
+
+// language returns the pipeline of a language analyzer. extra filters run
+// right after lower-casing. Accents are folded after stemming because the
+// stemmers rely on them.
+func language(stopWords []string, stem func(string) string, extra ...TokenFilter) *Pipeline {
+	filters := append([]TokenFilter{LowercaseFilter}, extra...)
+	filters = append(filters,
+		CJKBigramFilter,
+		MinLengthFilter(2),
+		StopFilter(stopWords),
+		StemFilter(stem),
+		AccentFoldingFilter,
+	)
+	return &Pipeline{
+		CharFilters: []CharFilter{NFKCFilter},
+		Tokenizer:   UnicodeTokenizer,
+		Filters:     filters,
+	}
+}
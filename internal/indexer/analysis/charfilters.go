@@ -0,0 +1,8 @@
+package analysis
+
+import "github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis/norm"
+
+// NFKCFilter normalizes text to Unicode NFKC, so that compatibility forms
+// such as ligatures, full-width letters and half-width katakana index as
+// their ordinary equivalents.
+var NFKCFilter CharFilter = CharFilterFunc(norm.NFKC)
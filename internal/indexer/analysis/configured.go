@@ -0,0 +1,143 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis/snowball"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/config"
+)
+
+// stopWords holds the stop-word lists of the <language>_stop filters.
+var stopWords = map[string][]string{
+	"english": EnglishStopWords,
+	"german":  GermanStopWords,
+	"french":  FrenchStopWords,
+	"spanish": SpanishStopWords,
+}
+
+// Build returns the analyzer named name: the one defs defines under that
+// name, or else the registered one. The empty name selects
+// DefaultAnalyzer. Every definition is checked, and may not reuse the name
+// of a registered analyzer.
+func Build(name string, defs map[string]config.AnalyzerConfig) (Analyzer, error) {
+	var selected Analyzer
+	for defName, def := range defs {
+		if _, err := Get(defName); err == nil {
+			return nil, fmt.Errorf("analyzer %q is built in and cannot be redefined", defName)
+		}
+		p, err := NewPipeline(def)
+		if err != nil {
+			return nil, fmt.Errorf("analyzer %q: %w", defName, err)
+		}
+		if defName == name {
+			selected = p
+		}
+	}
+	if selected != nil {
+		return selected, nil
+	}
+	return Get(name)
+}
+
+// NewPipeline returns the pipeline def defines.
+func NewPipeline(def config.AnalyzerConfig) (*Pipeline, error) {
+	p := &Pipeline{}
+	for _, name := range def.CharFilters {
+		switch name {
+		case "nfkc":
+			p.CharFilters = append(p.CharFilters, NFKCFilter)
+		case "mapping":
+			if len(def.Mappings) == 0 {
+				return nil, fmt.Errorf("mapping char filter without mappings")
+			}
+			p.CharFilters = append(p.CharFilters, MappingFilter(def.Mappings))
+		default:
+			return nil, fmt.Errorf("unknown char filter %q", name)
+		}
+	}
+	switch def.Tokenizer {
+	case "standard":
+		p.Tokenizer = UnicodeTokenizer
+	case "letter_digit":
+		p.Tokenizer = LetterDigitTokenizer
+	case "whitespace":
+		p.Tokenizer = WhitespaceTokenizer
+	case "keyword":
+		p.Tokenizer = KeywordTokenizer
+	case "":
+		return nil, fmt.Errorf("no tokenizer")
+	default:
+		return nil, fmt.Errorf("unknown tokenizer %q", def.Tokenizer)
+	}
+	for _, name := range def.Filters {
+		f, err := namedFilter(name, def)
+		if err != nil {
+			return nil, err
+		}
+		p.Filters = append(p.Filters, f)
+	}
+	return p, nil
+}
+
+// namedFilter returns the token filter called name, configured by def.
+func namedFilter(name string, def config.AnalyzerConfig) (TokenFilter, error) {
+	switch name {
+	case "lowercase":
+		return LowercaseFilter, nil
+	case "accent_folding":
+		return AccentFoldingFilter, nil
+	case "cjk_bigram":
+		return CJKBigramFilter, nil
+	case "min_length":
+		n := def.MinLength
+		if n <= 0 {
+			n = 2
+		}
+		return MinLengthFilter(n), nil
+	case "stop":
+		if len(def.StopWords) == 0 {
+			return nil, fmt.Errorf("stop filter without stop words")
+		}
+		return StopFilter(def.StopWords), nil
+	case "english_legacy_stem":
+		return StemFilter(legacyEnglishStem), nil
+	case "french_elision":
+		return ElisionFilter(FrenchElisions), nil
+	}
+	if lang, ok := strings.CutSuffix(name, "_stop"); ok {
+		if words, ok := stopWords[lang]; ok {
+			return StopFilter(words), nil
+		}
+	}
+	if lang, ok := strings.CutSuffix(name, "_stem"); ok {
+		if stem, ok := snowball.Get(lang); ok {
+			return StemFilter(stem), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown token filter %q", name)
+}
+
+// MappingFilter returns a char filter that replaces every occurrence of a
+// key of mappings with its value. Where keys overlap the longest wins, and
+// the ones of the same length are tried in sorted order.
+func MappingFilter(mappings map[string]string) CharFilter {
+	keys := make([]string, 0, len(mappings))
+	for k := range mappings {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	pairs := make([]string, 0, 2*len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k, mappings[k])
+	}
+	return CharFilterFunc(strings.NewReplacer(pairs...).Replace)
+}
@@ -0,0 +1,65 @@
+package analysis
+
+import "strings"
+
+// EnglishStopWords is the stop-word list of the english analyzer.
+var EnglishStopWords = []string{
+	"a", "an", "and", "are", "as", "at",
+	"be", "by", "for", "from", "has", "he",
+	"in", "is", "it", "its", "of", "on",
+	"or", "that", "the", "to", "was", "were",
+	"will", "with", "this", "but", "they",
+	"have", "had", "what", "when", "where",
+	"who", "which", "their", "if", "each",
+	"do", "not", "no", "so", "can",
+}
+
+// legacyEnglishStem is the suffix-stripping stemmer the english analyzer
+// used before it switched to Snowball. It is kept, as english_legacy, for
+// indexes built with it.
+func legacyEnglishStem(word string) string {
+	suffixes := []struct {
+		suffix      string
+		replacement string
+		minLen      int
+	}{
+		{"ational", "ate", 2},
+		{"tional", "tion", 2},
+		{"encies", "ence", 2},
+		{"ances", "ance", 2},
+		{"ments", "ment", 2},
+		{"izing", "ize", 2},
+		{"ating", "ate", 2},
+		{"iness", "y", 2},
+		{"ously", "ous", 2},
+		{"ively", "ive", 2},
+		{"eness", "ene", 2},
+		{"tion", "t", 3},
+		{"sion", "s", 3},
+		{"ying", "y", 2},
+		{"ling", "l", 3},
+		{"ies", "y", 2},
+		{"ing", "", 3},
+		{"ers", "er", 2},
+		{"est", "", 3},
+		{"ful", "", 3},
+		{"ous", "", 3},
+		{"ess", "", 3},
+		{"ble", "", 3},
+		{"ed", "", 3},
+		{"er", "", 3},
+		{"ly", "", 3},
+		{"es", "", 3},
+		{"ss", "ss", 2},
+		{"s", "", 3},
+	}
+	for _, rule := range suffixes {
+		if strings.HasSuffix(word, rule.suffix) {
+			newWord := word[:len(word)-len(rule.suffix)] + rule.replacement
+			if len(newWord) >= rule.minLen {
+				return newWord
+			}
+		}
+	}
+	return word
+}
@@ -0,0 +1,127 @@
+package analysis
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis/norm"
+)
+
+// LowercaseFilter lower-cases every term. It also normalises query terms
+// (see Normalizer).
+var LowercaseFilter TokenFilter = normalizingFilter{func(terms []string) []string {
+	for i, term := range terms {
+		terms[i] = strings.ToLower(term)
+	}
+	return terms
+}}
+
+// MinLengthFilter returns a filter that drops terms shorter than n
+// characters. Han, hiragana and katakana terms are always kept, since a
+// single character is a word in those scripts.
+func MinLengthFilter(n int) TokenFilter {
+	return TokenFilterFunc(func(terms []string) []string {
+		kept := terms[:0]
+		for _, term := range terms {
+			first, _ := utf8.DecodeRuneInString(term)
+			if utf8.RuneCountInString(term) >= n || isCJK(first) {
+				kept = append(kept, term)
+			}
+		}
+		return kept
+	})
+}
+
+// StopFilter returns a filter that drops the given stop words. Terms are
+// compared as they are, so it normally follows LowercaseFilter.
+func StopFilter(words []string) TokenFilter {
+	stop := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		stop[w] = struct{}{}
+	}
+	return TokenFilterFunc(func(terms []string) []string {
+		kept := terms[:0]
+		for _, term := range terms {
+			if _, ok := stop[term]; !ok {
+				kept = append(kept, term)
+			}
+		}
+		return kept
+	})
+}
+
+// StemFilter returns a filter that replaces every term with its stem,
+// dropping terms whose stem is empty.
+func StemFilter(stem func(string) string) TokenFilter {
+	return TokenFilterFunc(func(terms []string) []string {
+		kept := terms[:0]
+		for _, term := range terms {
+			if s := stem(term); s != "" {
+				kept = append(kept, s)
+			}
+		}
+		return kept
+	})
+}
+
+// ElisionFilter returns a filter that removes an elided article, such as
+// the l' of l'homme, from the front of terms. The apostrophe may be ' or ’.
+// Articles are compared as they are, so it normally follows LowercaseFilter.
+func ElisionFilter(articles []string) TokenFilter {
+	elided := make(map[string]struct{}, len(articles))
+	for _, a := range articles {
+		elided[a] = struct{}{}
+	}
+	return TokenFilterFunc(func(terms []string) []string {
+		for i, term := range terms {
+			j := strings.IndexAny(term, "'’")
+			if j <= 0 {
+				continue
+			}
+			if _, ok := elided[term[:j]]; ok {
+				_, size := utf8.DecodeRuneInString(term[j:])
+				terms[i] = term[j+size:]
+			}
+		}
+		return terms
+	})
+}
+
+// AccentFoldingFilter removes diacritics from Latin and Greek terms, so
+// "café" and "cafe" are the same term. It also normalises query terms (see
+// Normalizer).
+var AccentFoldingFilter TokenFilter = normalizingFilter{func(terms []string) []string {
+	for i, term := range terms {
+		terms[i] = norm.FoldAccents(term)
+	}
+	return terms
+}}
+
+// CJKBigramFilter replaces every Han, hiragana or katakana term of more
+// than two characters with its overlapping bigrams: 東京タワー becomes 東京,
+// 京タ, タワ and ワー. Chinese and Japanese are written without spaces, and
+// bigrams make any word of two or more characters findable without a
+// dictionary.
+var CJKBigramFilter TokenFilter = TokenFilterFunc(func(terms []string) []string {
+	var out []string
+	for i, term := range terms {
+		first, _ := utf8.DecodeRuneInString(term)
+		if !isCJK(first) || utf8.RuneCountInString(term) <= 2 {
+			if out != nil {
+				out = append(out, term)
+			}
+			continue
+		}
+		if out == nil {
+			out = append(make([]string, 0, len(terms)+len(term)/3), terms[:i]...)
+		}
+		runes := []rune(term)
+		for j := 0; j+1 < len(runes); j++ {
+			out = append(out, string(runes[j:j+2]))
+		}
+	}
+	if out == nil {
+		return terms
+	}
+	return out
+})
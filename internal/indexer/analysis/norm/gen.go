@@ -0,0 +1,196 @@
+//go:build ignore
+
+// gen generates tables.go from the Unicode Character Database.
+//
+// Usage:
+//
+//	go run gen.go [-version 14.0.0] [-ucd dir] [-output tables.go]
+//
+// Without -ucd the UnicodeData.txt and DerivedNormalizationProps.txt files
+// of the given version are downloaded from unicode.org.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	version = flag.String("version", "14.0.0", "Unicode version")
+	ucdDir  = flag.String("ucd", "", "directory holding the UCD files (downloaded if empty)")
+	output  = flag.String("output", "tables.go", "file to write")
+)
+
+// char is the normalization data of one code point.
+type char struct {
+	ccc       uint8
+	compat    bool
+	decompose []rune
+}
+
+func main() {
+	flag.Parse()
+	chars := make(map[rune]*char)
+	readFile("UnicodeData.txt", func(fields []string) {
+		r := parseRune(fields[0])
+		c := &char{}
+		ccc, err := strconv.Atoi(fields[3])
+		if err != nil {
+			log.Fatalf("combining class of %U: %v", r, err)
+		}
+		c.ccc = uint8(ccc)
+		d := strings.Fields(fields[5])
+		if len(d) > 0 && strings.HasPrefix(d[0], "<") {
+			c.compat = true
+			d = d[1:]
+		}
+		for _, f := range d {
+			c.decompose = append(c.decompose, parseRune(f))
+		}
+		if c.ccc != 0 || len(c.decompose) > 0 {
+			chars[r] = c
+		}
+	})
+	excluded := make(map[rune]bool)
+	readFile("DerivedNormalizationProps.txt", func(fields []string) {
+		if strings.TrimSpace(fields[1]) != "Full_Composition_Exclusion" {
+			return
+		}
+		lo, hi := parseRange(fields[0])
+		for r := lo; r <= hi; r++ {
+			excluded[r] = true
+		}
+	})
+
+	var runes []rune
+	for r := range chars {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gen.go from Unicode %s; DO NOT EDIT.\n\n", *version)
+	fmt.Fprintf(&buf, "package norm\n\n")
+	fmt.Fprintf(&buf, "// UnicodeVersion is the Unicode version the tables are generated from.\n")
+	fmt.Fprintf(&buf, "const UnicodeVersion = %q\n\n", *version)
+
+	fmt.Fprintf(&buf, "// decompositions holds the full compatibility decomposition of every\n")
+	fmt.Fprintf(&buf, "// code point that has one, except the algorithmic Hangul syllables.\n")
+	fmt.Fprintf(&buf, "var decompositions = map[rune]string{\n")
+	for _, r := range runes {
+		if len(chars[r].decompose) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "0x%04X: %+q,\n", r, string(expand(chars, r)))
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+
+	fmt.Fprintf(&buf, "// combiningClasses holds the non-zero canonical combining classes.\n")
+	fmt.Fprintf(&buf, "var combiningClasses = map[rune]uint8{\n")
+	for _, r := range runes {
+		if chars[r].ccc != 0 {
+			fmt.Fprintf(&buf, "0x%04X: %d,\n", r, chars[r].ccc)
+		}
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+
+	fmt.Fprintf(&buf, "// compositions maps a starter and the character that follows it to\n")
+	fmt.Fprintf(&buf, "// their primary composite.\n")
+	fmt.Fprintf(&buf, "var compositions = map[[2]rune]rune{\n")
+	for _, r := range runes {
+		c := chars[r]
+		if c.compat || len(c.decompose) != 2 || excluded[r] {
+			continue
+		}
+		fmt.Fprintf(&buf, "{0x%04X, 0x%04X}: 0x%04X,\n", c.decompose[0], c.decompose[1], r)
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("format: %v", err)
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// expand returns the full compatibility decomposition of r.
+func expand(chars map[rune]*char, r rune) []rune {
+	c, ok := chars[r]
+	if !ok || len(c.decompose) == 0 {
+		return []rune{r}
+	}
+	var out []rune
+	for _, d := range c.decompose {
+		out = append(out, expand(chars, d)...)
+	}
+	return out
+}
+
+// readFile calls fn with the semicolon-separated fields of every data line
+// of the named UCD file.
+func readFile(name string, fn func(fields []string)) {
+	var rd io.Reader
+	if *ucdDir != "" {
+		f, err := os.Open(filepath.Join(*ucdDir, name))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		rd = f
+	} else {
+		url := fmt.Sprintf("https://www.unicode.org/Public/%s/ucd/%s", *version, name)
+		resp, err := http.Get(url)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			log.Fatalf("%s: %s", url, resp.Status)
+		}
+		rd = resp.Body
+	}
+	scanner := bufio.NewScanner(rd)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fn(strings.Split(line, ";"))
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func parseRune(s string) rune {
+	v, err := strconv.ParseUint(strings.TrimSpace(s), 16, 32)
+	if err != nil {
+		log.Fatalf("bad code point %q: %v", s, err)
+	}
+	return rune(v)
+}
+
+func parseRange(s string) (rune, rune) {
+	lo, hi, ok := strings.Cut(strings.TrimSpace(s), "..")
+	if !ok {
+		r := parseRune(lo)
+		return r, r
+	}
+	return parseRune(lo), parseRune(hi)
+}
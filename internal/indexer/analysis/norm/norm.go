@@ -0,0 +1,193 @@
+// Package norm implements Unicode normalization form NFKC and accent
+// folding for text analysis.
+//
+// The decomposition, combining-class and composition tables in tables.go
+// are generated from the Unicode Character Database by gen.go.
+package norm
+
+//go:generate go run gen.go -output tables.go
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Hangul syllables decompose and compose algorithmically.
+const (
+	hangulSBase  = 0xAC00
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11A7
+	hangulLCount = 19
+	hangulVCount = 21
+	hangulTCount = 28
+	hangulNCount = hangulVCount * hangulTCount
+	hangulSCount = hangulLCount * hangulNCount
+)
+
+// NFKC returns s in Unicode normalization form KC: compatibility
+// characters such as ligatures, full-width forms and superscripts are
+// replaced by their plain equivalents, and the result is canonically
+// composed.
+func NFKC(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	buf := make([]rune, 0, len(s))
+	for _, r := range s {
+		buf = decompose(buf, r)
+	}
+	reorder(buf)
+	return string(compose(buf))
+}
+
+// FoldAccents removes diacritics from Latin and Greek letters and replaces
+// the Latin letters that have no decomposition, such as ø and ß, with their
+// closest ASCII spelling. Other scripts are left untouched, since their
+// marks usually make a different letter rather than an accented one, as in
+// Cyrillic й or Japanese が.
+func FoldAccents(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			out = append(out, r)
+			continue
+		}
+		if f, ok := foldings[r]; ok {
+			out = append(out, []rune(f)...)
+			continue
+		}
+		d, ok := decompositions[r]
+		if !ok {
+			if !unicode.Is(unicode.Mn, r) || !foldable(out) {
+				out = append(out, r)
+			}
+			continue
+		}
+		base, _ := utf8.DecodeRuneInString(d)
+		if !foldableScript(base) {
+			out = append(out, r)
+			continue
+		}
+		for _, dr := range d {
+			if !unicode.Is(unicode.Mn, dr) {
+				out = append(out, dr)
+			}
+		}
+	}
+	return string(out)
+}
+
+// foldings are the Latin letters folded by replacement rather than by
+// removing marks.
+var foldings = map[rune]string{
+	'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ø': "o", 'Ø': "O",
+	'ß': "ss", 'ẞ': "SS", 'ð': "d", 'Ð': "D", 'đ': "d", 'Đ': "D",
+	'þ': "th", 'Þ': "TH", 'ł': "l", 'Ł': "L", 'ħ': "h", 'Ħ': "H",
+	'ı': "i",
+}
+
+// foldable reports whether a combining mark following out should be
+// dropped, that is whether the last letter of out is in a folded script.
+func foldable(out []rune) bool {
+	return len(out) > 0 && foldableScript(out[len(out)-1])
+}
+
+func foldableScript(r rune) bool {
+	return unicode.In(r, unicode.Latin, unicode.Greek)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// decompose appends the full compatibility decomposition of r to buf.
+func decompose(buf []rune, r rune) []rune {
+	if r < utf8.RuneSelf {
+		return append(buf, r)
+	}
+	if s := r - hangulSBase; s >= 0 && s < hangulSCount {
+		buf = append(buf, hangulLBase+s/hangulNCount, hangulVBase+(s%hangulNCount)/hangulTCount)
+		if t := s % hangulTCount; t != 0 {
+			buf = append(buf, hangulTBase+t)
+		}
+		return buf
+	}
+	if d, ok := decompositions[r]; ok {
+		for _, dr := range d {
+			buf = append(buf, dr)
+		}
+		return buf
+	}
+	return append(buf, r)
+}
+
+// reorder puts every run of combining marks in canonical order: a stable
+// sort by combining class.
+func reorder(buf []rune) {
+	for i := 1; i < len(buf); i++ {
+		cc := combiningClasses[buf[i]]
+		if cc == 0 {
+			continue
+		}
+		for j := i; j > 0; j-- {
+			prev := combiningClasses[buf[j-1]]
+			if prev <= cc {
+				break
+			}
+			buf[j], buf[j-1] = buf[j-1], buf[j]
+		}
+	}
+}
+
+// compose canonically composes the decomposed, reordered buf in place.
+func compose(buf []rune) []rune {
+	if len(buf) == 0 {
+		return buf
+	}
+	starter := 0
+	lastClass := int(combiningClasses[buf[0]])
+	if lastClass != 0 {
+		// A leading non-starter cannot compose with anything before it.
+		lastClass = 256
+	}
+	n := 1
+	for _, r := range buf[1:] {
+		class := int(combiningClasses[r])
+		if c, ok := composePair(buf[starter], r); ok && (lastClass < class || lastClass == 0) {
+			buf[starter] = c
+			continue
+		}
+		if class == 0 {
+			starter = n
+		}
+		lastClass = class
+		buf[n] = r
+		n++
+	}
+	return buf[:n]
+}
+
+// composePair returns the primary composite of a and b, if there is one.
+func composePair(a, b rune) (rune, bool) {
+	if l := a - hangulLBase; l >= 0 && l < hangulLCount {
+		if v := b - hangulVBase; v >= 0 && v < hangulVCount {
+			return hangulSBase + (l*hangulVCount+v)*hangulTCount, true
+		}
+	}
+	if s := a - hangulSBase; s >= 0 && s < hangulSCount && s%hangulTCount == 0 {
+		if t := b - hangulTBase; t > 0 && t < hangulTCount {
+			return a + t, true
+		}
+	}
+	c, ok := compositions[[2]rune{a, b}]
+	return c, ok
+}
@@ -0,0 +1,272 @@
+package snowball
+
+var (
+	englishVowels = newGrouping("aeiouy")
+	// englishLiEnding are the letters before which a final li is removed.
+	englishLiEnding = newGrouping("cdeghkmnrt")
+)
+
+// englishExceptions are words stemmed to a fixed form (or left alone)
+// before the algorithm runs.
+var englishExceptions = map[string]string{
+	"skis":   "ski",
+	"skies":  "sky",
+	"dying":  "die",
+	"lying":  "lie",
+	"tying":  "tie",
+	"idly":   "idl",
+	"gently": "gentl",
+	"ugly":   "ugli",
+	"early":  "earli",
+	"only":   "onli",
+	"singly": "singl",
+	"sky":    "sky",
+	"news":   "news",
+	"howe":   "howe",
+	"atlas":  "atlas",
+	"cosmos": "cosmos",
+	"bias":   "bias",
+	"andes":  "andes",
+}
+
+// englishInvariants are left unchanged once the plural suffixes have been
+// removed.
+var englishInvariants = []string{
+	"inning", "outing", "canning", "herring", "earring", "proceed", "exceed", "succeed",
+}
+
+// English stems word with the Snowball English (Porter2) algorithm.
+func English(word string) string {
+	if stem, ok := englishExceptions[word]; ok {
+		return stem
+	}
+	var buf [32]rune
+	w := runes(buf[:0], word)
+	if len(w) < 3 {
+		return word
+	}
+
+	// Prelude: drop a leading apostrophe and mark consonant y as Y.
+	if w[0] == '\'' {
+		w = w[1:]
+	}
+	if len(w) > 0 && w[0] == 'y' {
+		w[0] = 'Y'
+	}
+	for i := 1; i < len(w); i++ {
+		if w[i] == 'y' && englishVowels.has(w[i-1]) {
+			w[i] = 'Y'
+		}
+	}
+
+	p1 := englishVowels.region(w, 0)
+	for _, prefix := range []string{"gener", "commun", "arsen"} {
+		if hasPrefix(w, prefix) {
+			p1 = len(prefix)
+			break
+		}
+	}
+	p2 := englishVowels.region(w, p1)
+
+	w = englishStep0(w)
+	w = englishStep1a(w)
+	if !isOneOf(w, englishInvariants) {
+		w = englishStep1b(w, p1)
+		w = englishStep1c(w)
+		w = englishStep2(w, p1)
+		w = englishStep3(w, p1, p2)
+		w = englishStep4(w, p2)
+		w = englishStep5(w, p1, p2)
+	}
+
+	for i, r := range w {
+		if r == 'Y' {
+			w[i] = 'y'
+		}
+	}
+	return string(w)
+}
+
+// englishShortSyllable reports whether w ends in a short syllable: a vowel
+// followed by a non-vowel other than w, x or Y and preceded by a non-vowel,
+// or a vowel at the start of the word followed by a non-vowel.
+func englishShortSyllable(w []rune) bool {
+	n := len(w)
+	if n >= 3 {
+		last := w[n-1]
+		return !englishVowels.has(last) && last != 'w' && last != 'x' && last != 'Y' &&
+			englishVowels.has(w[n-2]) && !englishVowels.has(w[n-3])
+	}
+	return n == 2 && englishVowels.has(w[0]) && !englishVowels.has(w[1])
+}
+
+// englishStep0 removes possessive apostrophes.
+func englishStep0(w []rune) []rune {
+	if s := longestSuffix(w, "'s'", "'s", "'"); s != "" {
+		w = w[:suffixStart(w, s)]
+	}
+	return w
+}
+
+// englishStep1a removes plural suffixes.
+func englishStep1a(w []rune) []rune {
+	switch s := longestSuffix(w, "sses", "ied", "ies", "us", "ss", "s"); s {
+	case "sses":
+		w = replaceSuffix(w, s, "ss")
+	case "ied", "ies":
+		if suffixStart(w, s) > 1 {
+			w = replaceSuffix(w, s, "i")
+		} else {
+			w = replaceSuffix(w, s, "ie")
+		}
+	case "s":
+		// Delete if the word contains a vowel before the letter preceding
+		// the s: gaps -> gap, but gas -> gas.
+		if len(w) > 2 && englishVowels.contains(w[:len(w)-2]) {
+			w = w[:len(w)-1]
+		}
+	}
+	return w
+}
+
+// englishStep1b removes -ed and -ing forms and repairs the remaining stem.
+func englishStep1b(w []rune, p1 int) []rune {
+	switch s := longestSuffix(w, "eed", "eedly", "ed", "edly", "ing", "ingly"); s {
+	case "eed", "eedly":
+		if suffixStart(w, s) >= p1 {
+			w = replaceSuffix(w, s, "ee")
+		}
+	case "ed", "edly", "ing", "ingly":
+		stem := w[:suffixStart(w, s)]
+		if !englishVowels.contains(stem) {
+			break
+		}
+		w = stem
+		switch longestSuffix(w, "at", "bl", "iz", "bb", "dd", "ff", "gg", "mm", "nn", "pp", "rr", "tt") {
+		case "at", "bl", "iz":
+			w = append(w, 'e')
+		case "":
+			if len(w) == p1 && englishShortSyllable(w) {
+				w = append(w, 'e')
+			}
+		default:
+			w = w[:len(w)-1]
+		}
+	}
+	return w
+}
+
+// englishStep1c replaces a final y after a non-vowel with i, unless the
+// non-vowel is the first letter.
+func englishStep1c(w []rune) []rune {
+	n := len(w)
+	if n > 2 && (w[n-1] == 'y' || w[n-1] == 'Y') && !englishVowels.has(w[n-2]) {
+		w[n-1] = 'i'
+	}
+	return w
+}
+
+// englishStep2 maps derivational suffixes in R1 to shorter forms.
+func englishStep2(w []rune, p1 int) []rune {
+	s := longestSuffix(w,
+		"tional", "enci", "anci", "abli", "entli", "izer", "ization",
+		"ational", "ation", "ator", "alism", "aliti", "alli", "fulness",
+		"ousli", "ousness", "iveness", "iviti", "biliti", "bli", "ogi",
+		"fulli", "lessli", "li")
+	if s == "" || suffixStart(w, s) < p1 {
+		return w
+	}
+	switch s {
+	case "tional":
+		return replaceSuffix(w, s, "tion")
+	case "enci":
+		return replaceSuffix(w, s, "ence")
+	case "anci":
+		return replaceSuffix(w, s, "ance")
+	case "abli":
+		return replaceSuffix(w, s, "able")
+	case "entli":
+		return replaceSuffix(w, s, "ent")
+	case "izer", "ization":
+		return replaceSuffix(w, s, "ize")
+	case "ational", "ation", "ator":
+		return replaceSuffix(w, s, "ate")
+	case "alism", "aliti", "alli":
+		return replaceSuffix(w, s, "al")
+	case "fulness", "fulli":
+		return replaceSuffix(w, s, "ful")
+	case "ousli", "ousness":
+		return replaceSuffix(w, s, "ous")
+	case "iveness", "iviti":
+		return replaceSuffix(w, s, "ive")
+	case "biliti", "bli":
+		return replaceSuffix(w, s, "ble")
+	case "ogi":
+		if hasSuffix(w, "logi") {
+			return replaceSuffix(w, s, "og")
+		}
+	case "lessli":
+		return replaceSuffix(w, s, "less")
+	case "li":
+		if i := suffixStart(w, s); i > 0 && englishLiEnding.has(w[i-1]) {
+			return w[:i]
+		}
+	}
+	return w
+}
+
+// englishStep3 removes or shortens further suffixes in R1.
+func englishStep3(w []rune, p1, p2 int) []rune {
+	s := longestSuffix(w, "tional", "ational", "alize", "icate", "iciti", "ical", "ful", "ness", "ative")
+	if s == "" || suffixStart(w, s) < p1 {
+		return w
+	}
+	switch s {
+	case "tional":
+		return replaceSuffix(w, s, "tion")
+	case "ational":
+		return replaceSuffix(w, s, "ate")
+	case "alize":
+		return replaceSuffix(w, s, "al")
+	case "icate", "iciti", "ical":
+		return replaceSuffix(w, s, "ic")
+	case "ful", "ness":
+		return w[:suffixStart(w, s)]
+	case "ative":
+		if suffixStart(w, s) >= p2 {
+			return w[:suffixStart(w, s)]
+		}
+	}
+	return w
+}
+
+// englishStep4 removes suffixes in R2.
+func englishStep4(w []rune, p2 int) []rune {
+	s := longestSuffix(w,
+		"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement",
+		"ment", "ent", "ism", "ate", "iti", "ous", "ive", "ize", "ion")
+	if s == "" || suffixStart(w, s) < p2 {
+		return w
+	}
+	i := suffixStart(w, s)
+	if s == "ion" && (i == 0 || (w[i-1] != 's' && w[i-1] != 't')) {
+		return w
+	}
+	return w[:i]
+}
+
+// englishStep5 removes a final e or the second l of a final ll.
+func englishStep5(w []rune, p1, p2 int) []rune {
+	n := len(w)
+	switch {
+	case hasSuffix(w, "e"):
+		if n-1 >= p2 || (n-1 >= p1 && !englishShortSyllable(w[:n-1])) {
+			return w[:n-1]
+		}
+	case hasSuffix(w, "l"):
+		if n-1 >= p2 && hasSuffix(w, "ll") {
+			return w[:n-1]
+		}
+	}
+	return w
+}
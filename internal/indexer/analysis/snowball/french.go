@@ -0,0 +1,338 @@
+package snowball
+
+var (
+	frenchVowels = newGrouping("aeiouyâàëéèêïîôûù")
+	// frenchKeepWithS are the letters after which a final s is kept.
+	frenchKeepWithS = newGrouping("aiouès")
+)
+
+// French stems word with the Snowball French algorithm.
+func French(word string) string {
+	var buf [32]rune
+	w := runes(buf[:0], word)
+
+	// Prelude: mark u, i and y that act as consonants, and u after q.
+	for i := 0; i+1 < len(w); i++ {
+		switch {
+		case frenchVowels.has(w[i]) && w[i+1] == 'u' && i+2 < len(w) && frenchVowels.has(w[i+2]):
+			w[i+1] = 'U'
+		case frenchVowels.has(w[i]) && w[i+1] == 'i' && i+2 < len(w) && frenchVowels.has(w[i+2]):
+			w[i+1] = 'I'
+		case frenchVowels.has(w[i]) && w[i+1] == 'y':
+			w[i+1] = 'Y'
+		case w[i] == 'y' && frenchVowels.has(w[i+1]):
+			w[i] = 'Y'
+		case w[i] == 'q' && w[i+1] == 'u':
+			w[i+1] = 'U'
+		}
+	}
+
+	pv := frenchRV(w)
+	p1 := frenchVowels.region(w, 0)
+	p2 := frenchVowels.region(w, p1)
+
+	var ok bool
+	if w, ok = frenchStandardSuffix(w, pv, p1, p2); !ok {
+		if w, ok = frenchIVerbSuffix(w, pv); !ok {
+			w, ok = frenchVerbSuffix(w, pv, p2)
+		}
+	}
+	if ok {
+		switch {
+		case hasSuffix(w, "Y"):
+			w[len(w)-1] = 'i'
+		case hasSuffix(w, "ç"):
+			w[len(w)-1] = 'c'
+		}
+	} else {
+		w = frenchResidualSuffix(w, pv, p2)
+	}
+
+	// Undouble: enn, onn, ett, ell and eill lose their last letter.
+	if longestSuffix(w, "enn", "onn", "ett", "ell", "eill") != "" {
+		w = w[:len(w)-1]
+	}
+	// Unaccent: é or è followed only by non-vowels becomes e.
+	i := len(w)
+	for i > 0 && !frenchVowels.has(w[i-1]) {
+		i--
+	}
+	if i < len(w) && i > 0 && (w[i-1] == 'é' || w[i-1] == 'è') {
+		w[i-1] = 'e'
+	}
+
+	for i, r := range w {
+		switch r {
+		case 'I':
+			w[i] = 'i'
+		case 'U':
+			w[i] = 'u'
+		case 'Y':
+			w[i] = 'y'
+		}
+	}
+	return string(w)
+}
+
+// frenchRV returns the start of RV: after the third letter if the word
+// begins with two vowels or with par, col or tap, and otherwise after the
+// first vowel that is not the first letter.
+func frenchRV(w []rune) int {
+	if len(w) > 2 && frenchVowels.has(w[0]) && frenchVowels.has(w[1]) {
+		return 3
+	}
+	if hasPrefix(w, "par") || hasPrefix(w, "col") || hasPrefix(w, "tap") {
+		return 3
+	}
+	for i := 1; i < len(w); i++ {
+		if frenchVowels.has(w[i]) {
+			return i + 1
+		}
+	}
+	return len(w)
+}
+
+// frenchStandardSuffix removes a noun, adjective or adverb suffix. It
+// reports whether the verb suffixes should be skipped; -ment adverbs are
+// shortened but still report false so that the verb ending underneath is
+// removed too.
+func frenchStandardSuffix(w []rune, pv, p1, p2 int) ([]rune, bool) {
+	s := longestSuffix(w,
+		"ance", "iqUe", "isme", "able", "iste", "eux", "ances", "iqUes",
+		"ismes", "ables", "istes", "atrice", "ateur", "ation", "atrices",
+		"ateurs", "ations", "logie", "logies", "usion", "ution", "usions",
+		"utions", "ence", "ences", "ement", "ements", "ité", "ités", "if",
+		"ive", "ifs", "ives", "eaux", "aux", "euse", "euses", "issement",
+		"issements", "amment", "emment", "ment", "ments")
+	if s == "" {
+		return w, false
+	}
+	i := suffixStart(w, s)
+	switch s {
+	case "ance", "iqUe", "isme", "able", "iste", "eux",
+		"ances", "iqUes", "ismes", "ables", "istes":
+		if i < p2 {
+			return w, false
+		}
+		w = w[:i]
+	case "atrice", "ateur", "ation", "atrices", "ateurs", "ations":
+		if i < p2 {
+			return w, false
+		}
+		w = w[:i]
+		if hasSuffix(w, "ic") {
+			if suffixStart(w, "ic") >= p2 {
+				w = w[:len(w)-2]
+			} else {
+				w = replaceSuffix(w, "ic", "iqU")
+			}
+		}
+	case "logie", "logies":
+		if i < p2 {
+			return w, false
+		}
+		w = replaceSuffix(w, s, "log")
+	case "usion", "ution", "usions", "utions":
+		if i < p2 {
+			return w, false
+		}
+		w = replaceSuffix(w, s, "u")
+	case "ence", "ences":
+		if i < p2 {
+			return w, false
+		}
+		w = replaceSuffix(w, s, "ent")
+	case "ement", "ements":
+		if i < pv {
+			return w, false
+		}
+		w = w[:i]
+		switch s := longestSuffix(w, "iv", "eus", "abl", "iqU", "ièr", "Ièr"); s {
+		case "iv":
+			if suffixStart(w, s) >= p2 {
+				w = w[:suffixStart(w, s)]
+				if hasSuffix(w, "at") && suffixStart(w, "at") >= p2 {
+					w = w[:len(w)-2]
+				}
+			}
+		case "eus":
+			if suffixStart(w, s) >= p2 {
+				w = w[:suffixStart(w, s)]
+			} else if suffixStart(w, s) >= p1 {
+				w = replaceSuffix(w, s, "eux")
+			}
+		case "abl", "iqU":
+			if suffixStart(w, s) >= p2 {
+				w = w[:suffixStart(w, s)]
+			}
+		case "ièr", "Ièr":
+			if suffixStart(w, s) >= pv {
+				w = replaceSuffix(w, s, "i")
+			}
+		}
+	case "ité", "ités":
+		if i < p2 {
+			return w, false
+		}
+		w = w[:i]
+		switch s := longestSuffix(w, "abil", "ic", "iv"); s {
+		case "abil":
+			if suffixStart(w, s) >= p2 {
+				w = w[:suffixStart(w, s)]
+			} else {
+				w = replaceSuffix(w, s, "abl")
+			}
+		case "ic":
+			if suffixStart(w, s) >= p2 {
+				w = w[:suffixStart(w, s)]
+			} else {
+				w = replaceSuffix(w, s, "iqU")
+			}
+		case "iv":
+			if suffixStart(w, s) >= p2 {
+				w = w[:suffixStart(w, s)]
+			}
+		}
+	case "if", "ive", "ifs", "ives":
+		if i < p2 {
+			return w, false
+		}
+		w = w[:i]
+		if hasSuffix(w, "at") && suffixStart(w, "at") >= p2 {
+			w = w[:len(w)-2]
+			if hasSuffix(w, "ic") {
+				if suffixStart(w, "ic") >= p2 {
+					w = w[:len(w)-2]
+				} else {
+					w = replaceSuffix(w, "ic", "iqU")
+				}
+			}
+		}
+	case "eaux":
+		w = replaceSuffix(w, s, "eau")
+	case "aux":
+		if i < p1 {
+			return w, false
+		}
+		w = replaceSuffix(w, s, "al")
+	case "euse", "euses":
+		switch {
+		case i >= p2:
+			w = w[:i]
+		case i >= p1:
+			w = replaceSuffix(w, s, "eux")
+		default:
+			return w, false
+		}
+	case "issement", "issements":
+		if i < p1 || frenchVowels.has(w[i-1]) {
+			return w, false
+		}
+		w = w[:i]
+	case "amment":
+		if i >= pv {
+			w = replaceSuffix(w, s, "ant")
+		}
+		return w, false
+	case "emment":
+		if i >= pv {
+			w = replaceSuffix(w, s, "ent")
+		}
+		return w, false
+	case "ment", "ments":
+		if i > 0 && i-1 >= pv && frenchVowels.has(w[i-1]) {
+			w = w[:i]
+		}
+		return w, false
+	}
+	return w, true
+}
+
+// frenchIVerbSuffix removes an -ir verb ending in RV after a non-vowel. It
+// reports whether an ending was removed.
+func frenchIVerbSuffix(w []rune, pv int) ([]rune, bool) {
+	if len(w) < pv {
+		return w, false
+	}
+	s := longestSuffix(w[pv:],
+		"îmes", "ît", "îtes", "i", "ie", "ies", "ir", "ira", "irai",
+		"iraIent", "irais", "irait", "iras", "irent", "irez", "iriez",
+		"irions", "irons", "iront", "is", "issaIent", "issais", "issait",
+		"issant", "issante", "issantes", "issants", "isse", "issent",
+		"isses", "issez", "issiez", "issions", "issons", "it")
+	if s == "" {
+		return w, false
+	}
+	i := suffixStart(w, s)
+	if i-1 < pv || frenchVowels.has(w[i-1]) {
+		return w, false
+	}
+	return w[:i], true
+}
+
+// frenchVerbSuffix removes any other verb ending in RV. It reports whether
+// an ending was removed.
+func frenchVerbSuffix(w []rune, pv, p2 int) ([]rune, bool) {
+	if len(w) < pv {
+		return w, false
+	}
+	s := longestSuffix(w[pv:],
+		"ions",
+		"é", "ée", "ées", "és", "èrent", "er", "era", "erai", "eraIent",
+		"erais", "erait", "eras", "erez", "eriez", "erions", "erons",
+		"eront", "ez", "iez",
+		"âmes", "ât", "âtes", "a", "ai", "aIent", "ais", "ait", "ant",
+		"ante", "antes", "ants", "as", "asse", "assent", "asses", "assiez",
+		"assions")
+	if s == "" {
+		return w, false
+	}
+	i := suffixStart(w, s)
+	switch s {
+	case "ions":
+		if i < p2 {
+			return w, false
+		}
+		w = w[:i]
+	case "âmes", "ât", "âtes", "a", "ai", "aIent", "ais", "ait", "ant",
+		"ante", "antes", "ants", "as", "asse", "assent", "asses", "assiez",
+		"assions":
+		w = w[:i]
+		if hasSuffix(w, "e") && len(w)-1 >= pv {
+			w = w[:len(w)-1]
+		}
+	default:
+		w = w[:i]
+	}
+	return w, true
+}
+
+// frenchResidualSuffix removes a plural s and a few final endings in RV.
+func frenchResidualSuffix(w []rune, pv, p2 int) []rune {
+	if n := len(w); n > 1 && w[n-1] == 's' && !frenchKeepWithS.has(w[n-2]) {
+		w = w[:n-1]
+	}
+	if len(w) < pv {
+		return w
+	}
+	s := longestSuffix(w[pv:], "ion", "ier", "ière", "Ier", "Ière", "e", "ë")
+	if s == "" {
+		return w
+	}
+	i := suffixStart(w, s)
+	switch s {
+	case "ion":
+		if i >= p2 && i-1 >= pv && (w[i-1] == 's' || w[i-1] == 't') {
+			w = w[:i]
+		}
+	case "ier", "ière", "Ier", "Ière":
+		w = replaceSuffix(w, s, "i")
+	case "e":
+		w = w[:i]
+	case "ë":
+		if i-2 >= pv && hasSuffix(w[:i], "gu") {
+			w = w[:i]
+		}
+	}
+	return w
+}
@@ -0,0 +1,136 @@
+package snowball
+
+var (
+	germanVowels = newGrouping("aeiouyäöü")
+	// germanSEnding are the letters after which a final s is removed.
+	germanSEnding = newGrouping("bdfghklmnrt")
+	// germanSTEnding are the letters after which a final st is removed.
+	germanSTEnding = newGrouping("bdfghklmnt")
+)
+
+// German stems word with the Snowball German algorithm. Umlauts are
+// removed from the stem, so "häuser" and "hauser" stem alike.
+func German(word string) string {
+	var buf [32]rune
+	w := buf[:0]
+	for _, r := range word {
+		if r == 'ß' {
+			w = append(w, 's', 's')
+		} else {
+			w = append(w, r)
+		}
+	}
+
+	// Prelude: mark u and y between vowels as consonants.
+	for i := 1; i < len(w)-1; i++ {
+		if germanVowels.has(w[i-1]) && germanVowels.has(w[i+1]) {
+			switch w[i] {
+			case 'u':
+				w[i] = 'U'
+			case 'y':
+				w[i] = 'Y'
+			}
+		}
+	}
+
+	// R1 starts no earlier than the fourth letter; R2 is computed from the
+	// unadjusted R1.
+	r1 := germanVowels.region(w, 0)
+	p2 := germanVowels.region(w, r1)
+	p1 := r1
+	if len(w) >= 3 && p1 < 3 {
+		p1 = 3
+	}
+
+	w = germanStep1(w, p1)
+	w = germanStep2(w, p1)
+	w = germanStep3(w, p1, p2)
+
+	for i, r := range w {
+		switch r {
+		case 'U', 'ü':
+			w[i] = 'u'
+		case 'Y':
+			w[i] = 'y'
+		case 'ä':
+			w[i] = 'a'
+		case 'ö':
+			w[i] = 'o'
+		}
+	}
+	return string(w)
+}
+
+// germanStep1 removes inflectional endings in R1.
+func germanStep1(w []rune, p1 int) []rune {
+	s := longestSuffix(w, "em", "ern", "er", "e", "en", "es", "s")
+	if s == "" || suffixStart(w, s) < p1 {
+		return w
+	}
+	i := suffixStart(w, s)
+	switch s {
+	case "em", "ern", "er":
+		w = w[:i]
+	case "e", "en", "es":
+		w = w[:i]
+		if hasSuffix(w, "niss") {
+			w = w[:len(w)-1]
+		}
+	case "s":
+		if i > 0 && germanSEnding.has(w[i-1]) {
+			w = w[:i]
+		}
+	}
+	return w
+}
+
+// germanStep2 removes comparative and verb endings in R1.
+func germanStep2(w []rune, p1 int) []rune {
+	s := longestSuffix(w, "en", "er", "est", "st")
+	if s == "" || suffixStart(w, s) < p1 {
+		return w
+	}
+	i := suffixStart(w, s)
+	switch s {
+	case "en", "er", "est":
+		w = w[:i]
+	case "st":
+		// The st must follow a valid ending that itself follows at least
+		// three letters.
+		if i > 3 && germanSTEnding.has(w[i-1]) {
+			w = w[:i]
+		}
+	}
+	return w
+}
+
+// germanStep3 removes derivational suffixes in R2.
+func germanStep3(w []rune, p1, p2 int) []rune {
+	s := longestSuffix(w, "end", "ung", "ig", "ik", "isch", "lich", "heit", "keit")
+	if s == "" || suffixStart(w, s) < p2 {
+		return w
+	}
+	i := suffixStart(w, s)
+	switch s {
+	case "end", "ung":
+		w = w[:i]
+		if hasSuffix(w, "ig") && !hasSuffix(w, "eig") && suffixStart(w, "ig") >= p2 {
+			w = w[:len(w)-2]
+		}
+	case "ig", "ik", "isch":
+		if i == 0 || w[i-1] != 'e' {
+			w = w[:i]
+		}
+	case "lich", "heit":
+		w = w[:i]
+		if s := longestSuffix(w, "er", "en"); s != "" && suffixStart(w, s) >= p1 {
+			w = w[:suffixStart(w, s)]
+		}
+	case "keit":
+		w = w[:i]
+		if s := longestSuffix(w, "lich", "ig"); s != "" && suffixStart(w, s) >= p2 {
+			w = w[:suffixStart(w, s)]
+		}
+	}
+	return w
+}
@@ -0,0 +1,180 @@
+// Package snowball implements the Snowball stemming algorithms for English
+// (Porter2), German, French and Spanish, as specified at
+// https://snowballstem.org/algorithms/.
+//
+// Stemmers expect a single lower-case word and return its stem. They never
+// fail: a word the algorithm has no rule for is returned unchanged.
+package snowball
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Stemmer reduces a lower-case word to its stem.
+type Stemmer func(word string) string
+
+var stemmers = map[string]Stemmer{
+	"english": English,
+	"german":  German,
+	"french":  French,
+	"spanish": Spanish,
+}
+
+// Get returns the stemmer for the named language.
+func Get(language string) (Stemmer, bool) {
+	s, ok := stemmers[language]
+	return s, ok
+}
+
+// Languages returns the supported language names in sorted order.
+func Languages() []string {
+	names := make([]string, 0, len(stemmers))
+	for name := range stemmers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// grouping is a set of letters, such as the vowels of a language. ASCII
+// letters are looked up in a table; the few others are searched.
+type grouping struct {
+	ascii [utf8.RuneSelf]bool
+	other string
+}
+
+// newGrouping returns the grouping of the given letters.
+func newGrouping(letters string) *grouping {
+	g := &grouping{}
+	for _, r := range letters {
+		if r < utf8.RuneSelf {
+			g.ascii[r] = true
+		} else {
+			g.other += string(r)
+		}
+	}
+	return g
+}
+
+func (g *grouping) has(r rune) bool {
+	if r < utf8.RuneSelf {
+		return r >= 0 && g.ascii[r]
+	}
+	return strings.ContainsRune(g.other, r)
+}
+
+// region returns the start of the region after the first non-vowel that
+// follows a vowel at or after start, or len(w) if there is none. R1 is
+// region(w, 0) and R2 is region(w, R1).
+func (g *grouping) region(w []rune, start int) int {
+	for i := start; i < len(w); i++ {
+		if !g.has(w[i]) {
+			continue
+		}
+		for j := i + 1; j < len(w); j++ {
+			if !g.has(w[j]) {
+				return j + 1
+			}
+		}
+		break
+	}
+	return len(w)
+}
+
+// contains reports whether any letter of w is in g.
+func (g *grouping) contains(w []rune) bool {
+	for _, r := range w {
+		if g.has(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// runes appends the letters of word to buf, which the stemmers pass a
+// stack array for so that most words are stemmed without allocating.
+func runes(buf []rune, word string) []rune {
+	for _, r := range word {
+		buf = append(buf, r)
+	}
+	return buf
+}
+
+// hasSuffix reports whether w ends with s.
+func hasSuffix(w []rune, s string) bool {
+	_, ok := matchSuffix(w, s)
+	return ok
+}
+
+// matchSuffix reports whether w ends with s and, if it does, the number of
+// letters of s.
+func matchSuffix(w []rune, s string) (int, bool) {
+	i := len(w)
+	for j := len(s); j > 0; {
+		r, size := rune(s[j-1]), 1
+		if r >= utf8.RuneSelf {
+			r, size = utf8.DecodeLastRuneInString(s[:j])
+		}
+		j -= size
+		i--
+		if i < 0 || w[i] != r {
+			return 0, false
+		}
+	}
+	return len(w) - i, true
+}
+
+// isOneOf reports whether w is one of words.
+func isOneOf(w []rune, words []string) bool {
+	for _, word := range words {
+		if n, ok := matchSuffix(w, word); ok && n == len(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPrefix reports whether w starts with s.
+func hasPrefix(w []rune, s string) bool {
+	i := 0
+	for _, r := range s {
+		if i >= len(w) || w[i] != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// longestSuffix returns the longest of suffixes that w ends with, or "" if
+// it ends with none of them.
+func longestSuffix(w []rune, suffixes ...string) string {
+	if len(w) == 0 {
+		return ""
+	}
+	best, bestLen := "", -1
+	last := w[len(w)-1]
+	for _, s := range suffixes {
+		// Most suffixes end in an ASCII letter other than the word's.
+		if c := rune(s[len(s)-1]); c < utf8.RuneSelf && c != last {
+			continue
+		}
+		if n, ok := matchSuffix(w, s); ok && n > bestLen {
+			best, bestLen = s, n
+		}
+	}
+	return best
+}
+
+// suffixStart returns the index in w at which suffix s begins. w must end
+// with s.
+func suffixStart(w []rune, s string) int {
+	return len(w) - utf8.RuneCountInString(s)
+}
+
+// replaceSuffix replaces the suffix s of w with repl.
+func replaceSuffix(w []rune, s, repl string) []rune {
+	return append(w[:suffixStart(w, s)], []rune(repl)...)
+}
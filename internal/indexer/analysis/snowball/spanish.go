@@ -0,0 +1,229 @@
+package snowball
+
+var spanishVowels = newGrouping("aeiouáéíóúü")
+
+// Spanish stems word with the Snowball Spanish algorithm. Acute accents are
+// removed from the stem.
+func Spanish(word string) string {
+	var buf [32]rune
+	w := runes(buf[:0], word)
+	pv := spanishRV(w)
+	p1 := spanishVowels.region(w, 0)
+	p2 := spanishVowels.region(w, p1)
+
+	w = spanishPronoun(w, pv)
+	var ok bool
+	if w, ok = spanishStandardSuffix(w, p1, p2); !ok {
+		if w, ok = spanishYVerbSuffix(w, pv); !ok {
+			w = spanishVerbSuffix(w, pv)
+		}
+	}
+	w = spanishResidualSuffix(w, pv)
+
+	for i, r := range w {
+		switch r {
+		case 'á':
+			w[i] = 'a'
+		case 'é':
+			w[i] = 'e'
+		case 'í':
+			w[i] = 'i'
+		case 'ó':
+			w[i] = 'o'
+		case 'ú':
+			w[i] = 'u'
+		}
+	}
+	return string(w)
+}
+
+// spanishRV returns the start of RV: after the next vowel if the second
+// letter is a consonant, after the next consonant if the first two letters
+// are vowels, and otherwise after the third letter.
+func spanishRV(w []rune) int {
+	if len(w) < 2 {
+		return len(w)
+	}
+	next := func(vowel bool) int {
+		for i := 2; i < len(w); i++ {
+			if spanishVowels.has(w[i]) == vowel {
+				return i + 1
+			}
+		}
+		return len(w)
+	}
+	v0, v1 := spanishVowels.has(w[0]), spanishVowels.has(w[1])
+	switch {
+	case !v1:
+		return next(true)
+	case v0:
+		return next(false)
+	case len(w) > 2:
+		return 3
+	}
+	return len(w)
+}
+
+// spanishPronoun removes an attached pronoun after a gerund or infinitive
+// in RV, removing the accent the pronoun required.
+func spanishPronoun(w []rune, pv int) []rune {
+	pron := longestSuffix(w,
+		"me", "se", "sela", "selo", "selas", "selos", "la", "le", "lo",
+		"las", "les", "los", "nos")
+	if pron == "" {
+		return w
+	}
+	stem := w[:suffixStart(w, pron)]
+	verb := longestSuffix(stem,
+		"iéndo", "ándo", "ár", "ér", "ír", "ando", "iendo", "ar", "er", "ir", "yendo")
+	if verb == "" || suffixStart(stem, verb) < pv {
+		return w
+	}
+	switch verb {
+	case "iéndo":
+		return replaceSuffix(stem, verb, "iendo")
+	case "ándo":
+		return replaceSuffix(stem, verb, "ando")
+	case "ár":
+		return replaceSuffix(stem, verb, "ar")
+	case "ér":
+		return replaceSuffix(stem, verb, "er")
+	case "ír":
+		return replaceSuffix(stem, verb, "ir")
+	case "yendo":
+		if !hasSuffix(stem, "uyendo") {
+			return w
+		}
+	}
+	return stem
+}
+
+// spanishStandardSuffix removes a noun or adjective suffix. It reports
+// whether a suffix was removed.
+func spanishStandardSuffix(w []rune, p1, p2 int) ([]rune, bool) {
+	s := longestSuffix(w,
+		"anza", "anzas", "ico", "ica", "icos", "icas", "ismo", "ismos",
+		"able", "ables", "ible", "ibles", "ista", "istas", "oso", "osa",
+		"osos", "osas", "amiento", "amientos", "imiento", "imientos",
+		"adora", "ador", "ación", "adoras", "adores", "aciones", "ante",
+		"antes", "ancia", "ancias", "logía", "logías", "ución", "uciones",
+		"encia", "encias", "amente", "mente", "idad", "idades", "iva", "ivo",
+		"ivas", "ivos")
+	if s == "" {
+		return w, false
+	}
+	i := suffixStart(w, s)
+	if s == "amente" {
+		if i < p1 {
+			return w, false
+		}
+	} else if i < p2 {
+		return w, false
+	}
+
+	switch s {
+	case "adora", "ador", "ación", "adoras", "adores", "aciones",
+		"ante", "antes", "ancia", "ancias":
+		w = w[:i]
+		if hasSuffix(w, "ic") && suffixStart(w, "ic") >= p2 {
+			w = w[:len(w)-2]
+		}
+	case "logía", "logías":
+		w = replaceSuffix(w, s, "log")
+	case "ución", "uciones":
+		w = replaceSuffix(w, s, "u")
+	case "encia", "encias":
+		w = replaceSuffix(w, s, "ente")
+	case "amente":
+		w = w[:i]
+		if s := longestSuffix(w, "iv", "os", "ic", "ad"); s != "" && suffixStart(w, s) >= p2 {
+			w = w[:suffixStart(w, s)]
+			if s == "iv" && hasSuffix(w, "at") && suffixStart(w, "at") >= p2 {
+				w = w[:len(w)-2]
+			}
+		}
+	case "mente":
+		w = w[:i]
+		if s := longestSuffix(w, "ante", "able", "ible"); s != "" && suffixStart(w, s) >= p2 {
+			w = w[:suffixStart(w, s)]
+		}
+	case "idad", "idades":
+		w = w[:i]
+		if s := longestSuffix(w, "abil", "ic", "iv"); s != "" && suffixStart(w, s) >= p2 {
+			w = w[:suffixStart(w, s)]
+		}
+	case "iva", "ivo", "ivas", "ivos":
+		w = w[:i]
+		if hasSuffix(w, "at") && suffixStart(w, "at") >= p2 {
+			w = w[:len(w)-2]
+		}
+	default:
+		w = w[:i]
+	}
+	return w, true
+}
+
+// spanishYVerbSuffix removes a verb suffix beginning with y in RV when it
+// follows u. It reports whether a suffix was removed.
+func spanishYVerbSuffix(w []rune, pv int) ([]rune, bool) {
+	if len(w) < pv {
+		return w, false
+	}
+	s := longestSuffix(w[pv:],
+		"ya", "ye", "yan", "yen", "yeron", "yendo", "yo", "yó", "yas",
+		"yes", "yais", "yamos")
+	if s == "" {
+		return w, false
+	}
+	i := suffixStart(w, s)
+	if i == 0 || w[i-1] != 'u' {
+		return w, false
+	}
+	return w[:i], true
+}
+
+// spanishVerbSuffix removes any other verb suffix in RV.
+func spanishVerbSuffix(w []rune, pv int) []rune {
+	if len(w) < pv {
+		return w
+	}
+	s := longestSuffix(w[pv:],
+		"en", "es", "éis", "emos",
+		"arían", "arías", "arán", "arás", "aríais", "aría", "aréis",
+		"aríamos", "aremos", "ará", "aré", "erían", "erías", "erán",
+		"erás", "eríais", "ería", "eréis", "eríamos", "eremos", "erá",
+		"eré", "irían", "irías", "irán", "irás", "iríais", "iría", "iréis",
+		"iríamos", "iremos", "irá", "iré", "aba", "ada", "ida", "ía", "ara",
+		"iera", "ad", "ed", "id", "ase", "iese", "aste", "iste", "an",
+		"aban", "ían", "aran", "ieran", "asen", "iesen", "aron", "ieron",
+		"ado", "ido", "ando", "iendo", "ió", "ar", "er", "ir", "as", "abas",
+		"adas", "idas", "ías", "aras", "ieras", "ases", "ieses", "ís", "áis",
+		"abais", "íais", "arais", "ierais", "aseis", "ieseis", "asteis",
+		"isteis", "ados", "idos", "amos", "ábamos", "íamos", "imos",
+		"áramos", "iéramos", "iésemos", "ásemos")
+	if s == "" {
+		return w
+	}
+	i := suffixStart(w, s)
+	switch s {
+	case "en", "es", "éis", "emos":
+		// After gu the u goes too.
+		if i >= 2 && w[i-1] == 'u' && w[i-2] == 'g' {
+			i--
+		}
+	}
+	return w[:i]
+}
+
+// spanishResidualSuffix removes a final vowel or -os in RV.
+func spanishResidualSuffix(w []rune, pv int) []rune {
+	s := longestSuffix(w, "os", "a", "o", "á", "í", "ó", "e", "é")
+	if s == "" || suffixStart(w, s) < pv {
+		return w
+	}
+	w = w[:suffixStart(w, s)]
+	if (s == "e" || s == "é") && hasSuffix(w, "gu") && len(w)-1 >= pv {
+		w = w[:len(w)-1]
+	}
+	return w
+}
@@ -0,0 +1,41 @@
+package analysis
+
+// GermanStopWords is the stop-word list of the german analyzer.
+var GermanStopWords = []string{
+	"aber", "alle", "als", "also", "am", "an", "auch", "auf", "aus",
+	"bei", "bin", "bis", "bist", "da", "damit", "dann", "das", "dass",
+	"dem", "den", "denn", "der", "des", "die", "dies", "doch", "dort",
+	"du", "durch", "ein", "eine", "einem", "einen", "einer", "eines",
+	"er", "es", "für", "hat", "hatte", "ich", "ihr", "im", "in", "ist",
+	"ja", "kein", "mit", "nach", "nicht", "noch", "nun", "nur", "ob",
+	"oder", "sich", "sie", "sind", "so", "um", "und", "uns", "von",
+	"vor", "war", "wie", "wir", "wird", "zu", "zum", "zur", "über",
+}
+
+// FrenchStopWords is the stop-word list of the french analyzer.
+var FrenchStopWords = []string{
+	"au", "aux", "avec", "ce", "ces", "dans", "de", "des", "du", "elle",
+	"en", "est", "et", "été", "être", "eux", "il", "je", "la", "le",
+	"les", "leur", "lui", "ma", "mais", "me", "même", "mes", "moi",
+	"mon", "ne", "nos", "notre", "nous", "on", "ou", "par", "pas",
+	"pour", "qu", "que", "qui", "sa", "se", "ses", "son", "sont", "sur",
+	"ta", "te", "tes", "toi", "ton", "tu", "un", "une", "vos", "votre",
+	"vous",
+}
+
+// FrenchElisions are the elided articles and pronouns removed from the
+// front of French terms by the french analyzer.
+var FrenchElisions = []string{
+	"c", "d", "j", "l", "m", "n", "qu", "s", "t",
+	"jusqu", "lorsqu", "puisqu", "quoiqu",
+}
+
+// SpanishStopWords is the stop-word list of the spanish analyzer.
+var SpanishStopWords = []string{
+	"al", "algo", "como", "con", "de", "del", "el", "ella", "ellos",
+	"en", "entre", "era", "es", "esta", "este", "esto", "está", "fue",
+	"ha", "han", "hay", "la", "las", "le", "les", "lo", "los", "más",
+	"me", "mi", "muy", "no", "nos", "para", "pero", "por", "que", "qué",
+	"se", "sin", "sobre", "su", "sus", "también", "te", "tu", "un",
+	"una", "ya", "yo",
+}
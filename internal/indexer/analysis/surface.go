@@ -0,0 +1,59 @@
+package analysis
+
+// SurfaceForms counts, for each term, the words of texts it was analysed
+// from, normalised as query terms are. It lets a term be shown as a word,
+// such as computer rather than its stem comput.
+type SurfaceForms map[string]map[string]int
+
+// Add counts the word each token a produces from text came from under the
+// token's term. It adds nothing if a is not a Locator.
+func (f SurfaceForms) Add(a Analyzer, text string) {
+	locator, ok := a.(Locator)
+	if !ok {
+		return
+	}
+	located, spans := locator.Locate(text)
+	for _, token := range a.Analyze(text) {
+		if token.Position >= len(spans) {
+			continue
+		}
+		span := spans[token.Position]
+		if span.Start == span.End {
+			continue
+		}
+		word := Normalize(a, located[span.Start:span.End])
+		forms := f[token.Term]
+		if forms == nil {
+			forms = make(map[string]int)
+			f[token.Term] = forms
+		}
+		forms[word]++
+	}
+}
+
+// Merge adds the counts of o to f.
+func (f SurfaceForms) Merge(o SurfaceForms) {
+	for term, words := range o {
+		forms := f[term]
+		if forms == nil {
+			forms = make(map[string]int, len(words))
+			f[term] = forms
+		}
+		for word, n := range words {
+			forms[word] += n
+		}
+	}
+}
+
+// Best returns the word term was most often analysed from, preferring the
+// shorter and then the first in byte order among equally frequent ones, and
+// false if there is none.
+func (f SurfaceForms) Best(term string) (string, bool) {
+	best, bestN := "", 0
+	for word, n := range f[term] {
+		if n > bestN || n == bestN && (len(word) < len(best) || len(word) == len(best) && word < best) {
+			best, bestN = word, n
+		}
+	}
+	return best, bestN > 0
+}
@@ -0,0 +1,62 @@
+package analysis
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis/uax29"
+)
+
+// UnicodeTokenizer splits text at UAX #29 word boundaries and keeps the
+// segments that contain a letter or digit, so "can't", "3.14" and "foo_bar"
+// are single terms. Adjacent Han, hiragana and katakana characters, which
+// UAX #29 separates, are kept together as one term for CJKBigramFilter.
+var UnicodeTokenizer Tokenizer = TokenizerFunc(func(text string) []string {
+	var terms []string
+	inCJK := false
+	for _, seg := range uax29.Segments(text) {
+		if !strings.ContainsFunc(seg, isWordRune) {
+			inCJK = false
+			continue
+		}
+		first, _ := utf8.DecodeRuneInString(seg)
+		cjk := isCJK(first)
+		if cjk && inCJK {
+			terms[len(terms)-1] += seg
+			continue
+		}
+		terms = append(terms, seg)
+		inCJK = cjk
+	}
+	return terms
+})
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+// isCJK reports whether r is written without spaces between words in
+// Chinese or Japanese text.
+func isCJK(r rune) bool {
+	return r >= utf8.RuneSelf && unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// LetterDigitTokenizer splits text into maximal runs of letters and digits.
+var LetterDigitTokenizer Tokenizer = TokenizerFunc(func(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+})
+
+// WhitespaceTokenizer splits text on white space only.
+var WhitespaceTokenizer Tokenizer = TokenizerFunc(strings.Fields)
+
+// KeywordTokenizer emits the whole text as a single term, or nothing for
+// empty text.
+var KeywordTokenizer Tokenizer = TokenizerFunc(func(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return []string{text}
+})
@@ -0,0 +1,380 @@
+// Package uax29 splits text at the word boundaries of Unicode Standard
+// Annex #29, "Unicode Text Segmentation".
+//
+// The Word_Break property is derived from the general categories, scripts
+// and properties in the standard library's unicode package rather than from
+// a generated table, so it follows the library's Unicode version.
+// Extended_Pictographic, which that package does not provide, is
+// approximated by the emoji blocks.
+//
+// One deliberate departure from the annex: letters of the Southeast Asian
+// scripts written without spaces (Thai, Lao, Khmer, Myanmar and Tai scripts)
+// are treated as ALetter, so that a run of them forms one segment instead
+// of one segment per letter. Splitting such runs into words needs a
+// dictionary.
+package uax29
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// property is a Word_Break property value.
+type property uint8
+
+const (
+	other property = iota
+	cr
+	lf
+	newline
+	extend
+	zwj
+	regionalIndicator
+	format
+	katakana
+	hebrewLetter
+	aLetter
+	singleQuote
+	doubleQuote
+	midNumLet
+	midLetter
+	midNum
+	numeric
+	extendNumLet
+	wSegSpace
+)
+
+// ignorable reports whether p is skipped by rule WB4.
+func (p property) ignorable() bool {
+	return p == extend || p == format || p == zwj
+}
+
+func (p property) ahLetter() bool {
+	return p == aLetter || p == hebrewLetter
+}
+
+func (p property) midNumLetQ() bool {
+	return p == midNumLet || p == singleQuote
+}
+
+// southeastAsian are the scripts whose letters have Line_Break=SA.
+var southeastAsian = []*unicode.RangeTable{
+	unicode.Thai, unicode.Lao, unicode.Khmer, unicode.Myanmar,
+	unicode.Tai_Le, unicode.New_Tai_Lue, unicode.Tai_Tham, unicode.Tai_Viet,
+}
+
+// modifierLetters are the symbols and punctuation that are ALetter.
+var modifierLetters = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x02C2, Hi: 0x02C5, Stride: 1},
+		{Lo: 0x02D2, Hi: 0x02D7, Stride: 1},
+		{Lo: 0x02DE, Hi: 0x02DF, Stride: 1},
+		{Lo: 0x02E5, Hi: 0x02EB, Stride: 1},
+		{Lo: 0x02ED, Hi: 0x02EF, Stride: 2},
+		{Lo: 0x02F0, Hi: 0x02FF, Stride: 1},
+		{Lo: 0x055A, Hi: 0x055C, Stride: 1},
+		{Lo: 0x055E, Hi: 0x058A, Stride: 0x2C},
+		{Lo: 0x05F3, Hi: 0x05F3, Stride: 1},
+		{Lo: 0xA708, Hi: 0xA716, Stride: 1},
+		{Lo: 0xA720, Hi: 0xA721, Stride: 1},
+		{Lo: 0xA789, Hi: 0xA78A, Stride: 1},
+		{Lo: 0xAB5B, Hi: 0xAB5B, Stride: 1},
+	},
+}
+
+// pictographic approximates Extended_Pictographic.
+var pictographic = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00A9, Hi: 0x00AE, Stride: 5},
+		{Lo: 0x203C, Hi: 0x2049, Stride: 0x0D},
+		{Lo: 0x2122, Hi: 0x2139, Stride: 0x17},
+		{Lo: 0x2194, Hi: 0x21AA, Stride: 1},
+		{Lo: 0x2300, Hi: 0x23FF, Stride: 1},
+		{Lo: 0x25AA, Hi: 0x25FE, Stride: 1},
+		{Lo: 0x2600, Hi: 0x27BF, Stride: 1},
+		{Lo: 0x2934, Hi: 0x2935, Stride: 1},
+		{Lo: 0x2B05, Hi: 0x2B55, Stride: 1},
+		{Lo: 0x3030, Hi: 0x303D, Stride: 0x0D},
+		{Lo: 0x3297, Hi: 0x3299, Stride: 2},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1F000, Hi: 0x1F1E5, Stride: 1},
+		{Lo: 0x1F200, Hi: 0x1F3FA, Stride: 1},
+		{Lo: 0x1F400, Hi: 0x1FAFF, Stride: 1},
+		{Lo: 0x1FC00, Hi: 0x1FFFD, Stride: 1},
+	},
+}
+
+// wordBreak returns the Word_Break property of r.
+func wordBreak(r rune) property {
+	switch r {
+	case '\r':
+		return cr
+	case '\n':
+		return lf
+	case 0x0B, 0x0C, 0x85, 0x2028, 0x2029:
+		return newline
+	case 0x200D:
+		return zwj
+	case '\'':
+		return singleQuote
+	case '"':
+		return doubleQuote
+	case '.', 0x2018, 0x2019, 0x2024, 0xFE52, 0xFF07, 0xFF0E:
+		return midNumLet
+	case ':', 0x00B7, 0x0387, 0x055F, 0x05F4, 0x2027, 0xFE13, 0xFE55, 0xFF1A:
+		return midLetter
+	case ',', ';', 0x037E, 0x0589, 0x060C, 0x060D, 0x066C, 0x07F8, 0x2044,
+		0xFE10, 0xFE14, 0xFE50, 0xFE54, 0xFF0C, 0xFF1B:
+		return midNum
+	case 0x066B:
+		return numeric
+	case 0x202F:
+		return extendNumLet
+	case 0x3031, 0x3032, 0x3033, 0x3034, 0x3035, 0x309B, 0x309C, 0x30A0, 0x30FC, 0xFF70:
+		return katakana
+	case 0x00A0, 0x2007:
+		return other
+	case 0x200B, 0x200C:
+		// Zero-width space and non-joiner are Other despite being format
+		// characters.
+		return other
+	}
+	if r < utf8.RuneSelf {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+			return aLetter
+		case '0' <= r && r <= '9':
+			return numeric
+		case r == ' ':
+			return wSegSpace
+		case r == '_':
+			return extendNumLet
+		}
+		return other
+	}
+	switch {
+	case unicode.Is(unicode.Regional_Indicator, r):
+		return regionalIndicator
+	case unicode.Is(unicode.Zs, r):
+		return wSegSpace
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc, unicode.Other_Grapheme_Extend),
+		0x1F3FB <= r && r <= 0x1F3FF:
+		return extend
+	case unicode.Is(unicode.Cf, r):
+		if unicode.Is(unicode.Prepended_Concatenation_Mark, r) {
+			return other
+		}
+		return format
+	case unicode.Is(unicode.Katakana, r):
+		return katakana
+	case unicode.Is(unicode.Hebrew, r) && unicode.IsLetter(r):
+		return hebrewLetter
+	case unicode.Is(unicode.Ideographic, r), unicode.Is(unicode.Hiragana, r):
+		return other
+	case unicode.IsOneOf(southeastAsian, r):
+		if unicode.IsLetter(r) {
+			return aLetter
+		}
+		return other
+	case unicode.IsLetter(r), unicode.In(r, unicode.Nl, unicode.Other_Alphabetic, modifierLetters):
+		return aLetter
+	case unicode.Is(unicode.Nd, r):
+		return numeric
+	case unicode.Is(unicode.Pc, r):
+		return extendNumLet
+	}
+	return other
+}
+
+// char is a decoded rune with its position and Word_Break property.
+type char struct {
+	offset int
+	prop   property
+	r      rune
+}
+
+// Segments splits text into the segments between word boundaries. Every
+// byte of text belongs to exactly one segment, so the segments include
+// white space and punctuation as well as words.
+func Segments(text string) []string {
+	if text == "" {
+		return nil
+	}
+	if isASCII(text) {
+		return asciiSegments(text)
+	}
+	chars := make([]char, 0, len(text))
+	for i, r := range text {
+		chars = append(chars, char{offset: i, prop: wordBreak(r), r: r})
+	}
+	var segments []string
+	start := 0
+	for i := 1; i < len(chars); i++ {
+		if isBoundary(chars, i) {
+			segments = append(segments, text[chars[start].offset:chars[i].offset])
+			start = i
+		}
+	}
+	return append(segments, text[chars[start].offset:])
+}
+
+// isBoundary reports whether there is a word boundary before chars[i].
+func isBoundary(chars []char, i int) bool {
+	prev, cur := chars[i-1].prop, chars[i].prop
+	switch {
+	case prev == cr && cur == lf: // WB3
+		return false
+	case prev == cr || prev == lf || prev == newline: // WB3a
+		return true
+	case cur == cr || cur == lf || cur == newline: // WB3b
+		return true
+	case prev == zwj && unicode.Is(pictographic, chars[i].r): // WB3c
+		return false
+	case prev == wSegSpace && cur == wSegSpace: // WB3d
+		return false
+	case cur.ignorable(): // WB4
+		return false
+	}
+
+	// WB4: the rules below see through Extend, Format and ZWJ.
+	p := skipBack(chars, i-1)
+	if p < 0 {
+		return true
+	}
+	prev = chars[p].prop
+	prev2, next := other, other
+	if p2 := skipBack(chars, p-1); p2 >= 0 {
+		prev2 = chars[p2].prop
+	}
+	if n := skipForward(chars, i+1); n < len(chars) {
+		next = chars[n].prop
+	}
+
+	if prev == regionalIndicator && cur == regionalIndicator { // WB15, WB16
+		n := 0
+		for j := p; j >= 0; j = skipBack(chars, j-1) {
+			if chars[j].prop != regionalIndicator {
+				break
+			}
+			n++
+		}
+		return n%2 == 0
+	}
+	return !joins(prev2, prev, cur, next)
+}
+
+// joins reports whether rules WB5 to WB13b keep cur in the segment of prev,
+// given the properties of the characters around them, seen through WB4.
+func joins(prev2, prev, cur, next property) bool {
+	switch {
+	case prev.ahLetter() && cur.ahLetter(): // WB5
+		return true
+	case prev.ahLetter() && (cur == midLetter || cur.midNumLetQ()) && next.ahLetter(): // WB6
+		return true
+	case prev2.ahLetter() && (prev == midLetter || prev.midNumLetQ()) && cur.ahLetter(): // WB7
+		return true
+	case prev == hebrewLetter && cur == singleQuote: // WB7a
+		return true
+	case prev == hebrewLetter && cur == doubleQuote && next == hebrewLetter: // WB7b
+		return true
+	case prev2 == hebrewLetter && prev == doubleQuote && cur == hebrewLetter: // WB7c
+		return true
+	case prev == numeric && cur == numeric, // WB8
+		prev.ahLetter() && cur == numeric, // WB9
+		prev == numeric && cur.ahLetter(): // WB10
+		return true
+	case prev2 == numeric && (prev == midNum || prev.midNumLetQ()) && cur == numeric: // WB11
+		return true
+	case prev == numeric && (cur == midNum || cur.midNumLetQ()) && next == numeric: // WB12
+		return true
+	case prev == katakana && cur == katakana: // WB13
+		return true
+	case (prev.ahLetter() || prev == numeric || prev == katakana || prev == extendNumLet) && cur == extendNumLet: // WB13a
+		return true
+	case prev == extendNumLet && (cur.ahLetter() || cur == numeric || cur == katakana): // WB13b
+		return true
+	}
+	return false // WB999
+}
+
+// skipBack returns the index of the last character at or before i that is
+// not ignorable under WB4, or -1. A run of ignorable characters directly
+// after a line break or at the start of text is not skipped.
+func skipBack(chars []char, i int) int {
+	j := i
+	for j >= 0 && chars[j].prop.ignorable() {
+		j--
+	}
+	if j < 0 || chars[j].prop == cr || chars[j].prop == lf || chars[j].prop == newline {
+		if j == i {
+			return j
+		}
+		return i
+	}
+	return j
+}
+
+// skipForward returns the index of the first character at or after i that
+// is not ignorable under WB4, or len(chars).
+func skipForward(chars []char, i int) int {
+	for i < len(chars) && chars[i].prop.ignorable() {
+		i++
+	}
+	return i
+}
+
+// asciiProperties are the Word_Break properties of the ASCII characters.
+var asciiProperties = func() (props [utf8.RuneSelf]property) {
+	for r := range props {
+		props[r] = wordBreak(rune(r))
+	}
+	return props
+}()
+
+// isASCII reports whether text has no multi-byte characters.
+func isASCII(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// asciiSegments implements Segments for ASCII text, in which no character
+// is ignored by WB4 and the rules for other scripts never apply.
+func asciiSegments(text string) []string {
+	var segments []string
+	start := 0
+	for i := 1; i < len(text); i++ {
+		if asciiBoundary(text, i) {
+			segments = append(segments, text[start:i])
+			start = i
+		}
+	}
+	return append(segments, text[start:])
+}
+
+// asciiBoundary reports whether there is a word boundary before text[i].
+func asciiBoundary(text string, i int) bool {
+	prev, cur := asciiProperties[text[i-1]], asciiProperties[text[i]]
+	switch {
+	case prev == cr && cur == lf: // WB3
+		return false
+	case prev == cr || prev == lf || prev == newline: // WB3a
+		return true
+	case cur == cr || cur == lf || cur == newline: // WB3b
+		return true
+	case prev == wSegSpace && cur == wSegSpace: // WB3d
+		return false
+	}
+	prev2, next := other, other
+	if i >= 2 {
+		prev2 = asciiProperties[text[i-2]]
+	}
+	if i+1 < len(text) {
+		next = asciiProperties[text[i+1]]
+	}
+	return !joins(prev2, prev, cur, next)
+}
@@ -36,7 +36,9 @@ func (ic *IndexConsumer) Start(ctx context.Context) error {
 }
 
 // HandleMessageSharded returns a Kafka MessageHandler that routes each ingest
-// event to the correct shard engine via the Router before indexing.
+// event to the correct shard engine via the Router before indexing. Index
+// events replace any existing copy of the document, so redelivered events
+// are idempotent; delete events tombstone the document.
 // If db is non-nil, the document status is updated from PENDING to INDEXED
 // in PostgreSQL after a successful index operation.
 func HandleMessageSharded(router *shard.Router, db *sql.DB) kafka.MessageHandler {
@@ -57,11 +59,16 @@ func HandleMessageSharded(router *shard.Router, db *sql.DB) kafka.MessageHandler
 		}
 
 		logger.Debug("processing ingest event",
+			"op", event.Op,
 			"doc_id", event.DocumentID,
 			"shard_id", event.ShardID,
 		)
 
-		if err := engine.IndexDocument(event.DocumentID, event.Title, event.Body); err != nil {
+		if event.Op == ingestion.OpDelete {
+			return applyDelete(engine, event, logger)
+		}
+
+		if err := engine.UpdateDocument(event.DocumentID, event.Document()); err != nil {
 			updateDocStatus(ctx, db, event.DocumentID, "FAILED", logger)
 			return fmt.Errorf("indexing document %s in shard %d: %w", event.DocumentID, event.ShardID, err)
 		}
@@ -91,10 +98,14 @@ func HandleMessage(engine *indexer.Engine, db *sql.DB) kafka.MessageHandler {
 			return nil
 		}
 		logger.Debug("processing ingest event",
+			"op", event.Op,
 			"doc_id", event.DocumentID,
 			"shard_id", event.ShardID,
 		)
-		if err := engine.IndexDocument(event.DocumentID, event.Title, event.Body); err != nil {
+		if event.Op == ingestion.OpDelete {
+			return applyDelete(engine, event, logger)
+		}
+		if err := engine.UpdateDocument(event.DocumentID, event.Document()); err != nil {
 			updateDocStatus(ctx, db, event.DocumentID, "FAILED", logger)
 			return fmt.Errorf("indexing document %s: %w", event.DocumentID, err)
 		}
@@ -109,6 +120,21 @@ func HandleMessage(engine *indexer.Engine, db *sql.DB) kafka.MessageHandler {
 	}
 }
 
+// applyDelete tombstones the event's document in the engine. The document
+// status was already set to DELETED by the ingestion service.
+func applyDelete(engine *indexer.Engine, event ingestion.IngestEvent, logger *slog.Logger) error {
+	found, err := engine.DeleteDocument(event.DocumentID)
+	if err != nil {
+		return fmt.Errorf("deleting document %s in shard %d: %w", event.DocumentID, event.ShardID, err)
+	}
+	logger.Info("document deleted",
+		"doc_id", event.DocumentID,
+		"shard_id", event.ShardID,
+		"found", found,
+	)
+	return nil
+}
+
 // updateDocStatus updates the document's status and indexed_at timestamp in PostgreSQL.
 // If db is nil, the update is silently skipped.
 func updateDocStatus(ctx context.Context, db *sql.DB, docID, status string, logger *slog.Logger) {
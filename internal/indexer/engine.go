@@ -1,11 +1,14 @@
 // Package indexer implements the core indexing engine. It maintains an
 // in-memory inverted index backed by on-disk segments that are periodically
-// flushed. Searches fan out across the memory index and all segment readers,
-// and results are deduplicated before being returned.
+// flushed and merged in the background. Mutations are recorded in a
+// write-ahead log first, so unflushed documents survive a crash. Searches fan
+// out across the memory index and all segment readers, and results are
+// deduplicated before being returned.
 package indexer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -15,71 +18,300 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/segment"
-	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/tokenizer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/wal"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/config"
 )
 
+// ErrReadOnly is returned by mutating methods of an engine opened with
+// IndexerConfig.ReadOnly.
+var ErrReadOnly = errors.New("index engine is read-only")
+
+// ErrAnalyzerChanged is returned by NewEngine when the configured analyzer
+// is not the one the index was built with. Segments hold analysed terms, so
+// searching them with another analyzer would silently miss matches: the
+// index must be rebuilt, or its analyzer configured again.
+var ErrAnalyzerChanged = errors.New("configured analyzer differs from the one the index was built with")
+
+// analyzerFile names the file in the data directory recording the analyzer
+// the index is built with.
+const analyzerFile = "analyzer"
+
+// legacyAnalyzer is the analyzer of indexes written before the analyzer was
+// recorded, when text was always analysed as english_legacy does.
+const legacyAnalyzer = "english_legacy"
+
 // Engine is the primary indexing data structure. It buffers documents in a
 // MemoryIndex and flushes them to immutable on-disk segments when the
-// configured size threshold is reached.
+// configured size threshold is reached. Document lengths and corpus
+// statistics are not kept separately: they are read from the memory index
+// and the document tables of the loaded segments, so they are correct after
+// a restart and follow segments hot-loaded by ReloadSegments.
 type Engine struct {
-	memIndex     *index.MemoryIndex
-	writer       *segment.Writer
-	readers      []*segment.Reader
-	readerMu     sync.RWMutex
-	cfg          config.IndexerConfig
-	logger       *slog.Logger
-	docLengths   map[string]int
-	docLengthsMu sync.RWMutex
-	totalDocs    int64
-	totalTokens  int64
-}
-
-// NewEngine creates a new Engine, creating the data directory if necessary
-// and loading any previously-flushed segments from disk.
+	writeMu     sync.Mutex
+	wal         *wal.Log
+	walRecords  int
+	analyzer    analysis.Analyzer
+	schema      index.Schema
+	memIndex    *index.MemoryIndex
+	writer      *segment.Writer
+	readers     []*segment.Reader
+	readerMu    sync.RWMutex
+	mergeMu     sync.Mutex
+	mergePolicy tieredMergePolicy
+	verifyMode  segment.VerifyMode
+	cfg         config.IndexerConfig
+	logger      *slog.Logger
+}
+
+// NewEngine creates a new Engine, creating the data directory if necessary,
+// loading any previously-flushed segments from disk, and replaying the
+// write-ahead log to recover documents that were not flushed before the
+// last shutdown or crash.
 func NewEngine(cfg config.IndexerConfig) (*Engine, error) {
+	verifyMode, err := segment.ParseVerifyMode(cfg.SegmentVerify)
+	if err != nil {
+		return nil, err
+	}
+	analyzer, err := analysis.Build(cfg.Analyzer, cfg.Analyzers)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := index.ParseSchema(cfg.Metadata)
+	if err != nil {
+		return nil, err
+	}
 	if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
 		return nil, fmt.Errorf("creating index data directory: %w", err)
 	}
+	if err := checkAnalyzer(cfg); err != nil {
+		return nil, err
+	}
 	e := &Engine{
-		memIndex:   index.NewMemoryIndex(),
-		writer:     segment.NewWriter(cfg.DataDir),
-		cfg:        cfg,
-		logger:     slog.Default().With("component", "indexer"),
-		docLengths: make(map[string]int),
+		analyzer:    analyzer,
+		schema:      schema,
+		memIndex:    index.NewMemoryIndex(analyzer, schema),
+		writer:      segment.NewWriter(cfg.DataDir, schema),
+		mergePolicy: newTieredMergePolicy(cfg.MaxSegmentsBeforeMerge),
+		verifyMode:  verifyMode,
+		cfg:         cfg,
+		logger:      slog.Default().With("component", "indexer"),
 	}
 	if err := e.loadExistingSegments(); err != nil {
+		e.closeReaders()
 		return nil, fmt.Errorf("loading existing segments: %w", err)
 	}
+	if cfg.ReadOnly {
+		return e, nil
+	}
+	if err := e.recoverWAL(); err != nil {
+		e.closeReaders()
+		return nil, fmt.Errorf("recovering write-ahead log: %w", err)
+	}
 	return e, nil
 }
 
-// IndexDocument tokenises the document and adds it to the memory index.
-// If the memory index exceeds SegmentMaxSize the buffer is flushed to disk.
-func (e *Engine) IndexDocument(docID string, title string, body string) error {
-	fullText := title + " " + body
-	tokens := tokenizer.Tokenize(fullText)
+// checkAnalyzer returns ErrAnalyzerChanged if the index in cfg.DataDir was
+// built with another analyzer than cfg.Analyzer, and otherwise records it
+// there, unless the engine is read-only. An index that has segments but no
+// record was built with legacyAnalyzer.
+func checkAnalyzer(cfg config.IndexerConfig) error {
+	name := cfg.Analyzer
+	if name == "" {
+		name = analysis.DefaultAnalyzer
+	}
+	path := filepath.Join(cfg.DataDir, analyzerFile)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if built := strings.TrimSpace(string(data)); built != name {
+			return fmt.Errorf("%w: %s holds an index built with %q, configured %q", ErrAnalyzerChanged, cfg.DataDir, built, name)
+		}
+		return nil
+	case !os.IsNotExist(err):
+		return fmt.Errorf("reading analyzer record: %w", err)
+	}
+	segs, err := filepath.Glob(filepath.Join(cfg.DataDir, "*.spdx"))
+	if err != nil {
+		return err
+	}
+	if len(segs) > 0 && name != legacyAnalyzer {
+		return fmt.Errorf("%w: %s holds an index built with %q, configured %q", ErrAnalyzerChanged, cfg.DataDir, legacyAnalyzer, name)
+	}
+	if cfg.ReadOnly {
+		return nil
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(name+"\n"), 0644); err != nil {
+		return fmt.Errorf("writing analyzer record: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("renaming analyzer record: %w", err)
+	}
+	return nil
+}
 
-	e.docLengthsMu.Lock()
-	e.docLengths[docID] = len(tokens)
-	e.totalDocs++
-	e.totalTokens += int64(len(tokens))
-	e.docLengthsMu.Unlock()
+// recoverWAL replays the write-ahead log into the memory index and opens a
+// new log file for subsequent writes. Index records are replayed as updates,
+// so records whose documents already reached a segment (a crash between the
+// segment rename and log truncation) do not produce duplicates.
+func (e *Engine) recoverWAL() error {
+	replayed, err := wal.Replay(e.cfg.DataDir, func(rec wal.Record) error {
+		switch rec.Op {
+		case wal.OpIndex:
+			if _, err := e.deleteLocked(rec.DocID); err != nil {
+				return err
+			}
+			e.indexLocked(rec.DocID, rec.Document())
+		case wal.OpDelete:
+			if _, err := e.deleteLocked(rec.DocID); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown wal op %q", rec.Op)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	walLog, err := wal.Open(e.cfg.DataDir, wal.Options{
+		Policy:    wal.SyncPolicy(e.cfg.WALSyncPolicy),
+		BatchSize: e.cfg.WALSyncBatch,
+		Interval:  e.cfg.WALSyncInterval,
+	})
+	if err != nil {
+		return err
+	}
+	if replayed == 0 {
+		// The old files hold no records; left in place, an empty file would
+		// pile up on every start.
+		if err := walLog.TruncateBefore(walLog.Seq()); err != nil {
+			walLog.Close()
+			return err
+		}
+	}
+	e.wal = walLog
+	// Replayed records stay in the old log files until the next flush
+	// covers them.
+	e.walRecords = replayed
+	if replayed > 0 {
+		e.logger.Info("write-ahead log replayed",
+			"records", replayed,
+			"mem_docs", e.memIndex.DocCount(),
+		)
+	}
+	return nil
+}
+
+// IndexDocument tokenises every named text field of the document and adds
+// it, with its stored fields, to the memory index. If the memory index
+// exceeds SegmentMaxSize the buffer is flushed to disk. The caller must
+// ensure docID is not already indexed; use UpdateDocument to replace an
+// existing document. The document must not be modified afterwards.
+func (e *Engine) IndexDocument(docID string, doc index.Document) error {
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+	if err := e.appendWAL(indexRecord(docID, doc)); err != nil {
+		return err
+	}
+	e.indexLocked(docID, doc)
+	return e.maybeFlushLocked()
+}
 
-	e.memIndex.AddDocument(docID, title, body)
+// UpdateDocument replaces any existing copy of the document, in memory or in
+// on-disk segments, with the new content. It is safe to call for documents
+// that have never been indexed, which makes it suitable for redelivered
+// ingest events.
+func (e *Engine) UpdateDocument(docID string, doc index.Document) error {
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+	if err := e.appendWAL(indexRecord(docID, doc)); err != nil {
+		return err
+	}
+	if _, err := e.deleteLocked(docID); err != nil {
+		return fmt.Errorf("deleting previous version: %w", err)
+	}
+	e.indexLocked(docID, doc)
+	return e.maybeFlushLocked()
+}
+
+// DeleteDocument removes the document from the memory index and marks it
+// deleted in the live-docs bitmap of every segment holding it. It returns
+// false if no live copy of the document was found.
+func (e *Engine) DeleteDocument(docID string) (bool, error) {
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+	if err := e.appendWAL(wal.Record{Op: wal.OpDelete, DocID: docID}); err != nil {
+		return false, err
+	}
+	found, err := e.deleteLocked(docID)
+	if err != nil {
+		return found, err
+	}
+	if found {
+		e.logger.Debug("document deleted", "doc_id", docID)
+	}
+	return found, nil
+}
+
+// deleteLocked tombstones docID everywhere it is live. The caller must hold
+// writeMu.
+func (e *Engine) deleteLocked(docID string) (bool, error) {
+	found := e.memIndex.RemoveDocument(docID)
+	readers := e.acquireReaders()
+	defer e.releaseReaders(readers)
+	for _, r := range readers {
+		deleted, err := r.Delete(docID)
+		if err != nil {
+			return found, fmt.Errorf("deleting from segment %s: %w", r.Name(), err)
+		}
+		found = found || deleted
+	}
+	return found, nil
+}
+
+// indexRecord returns the write-ahead log record of indexing doc.
+func indexRecord(docID string, doc index.Document) wal.Record {
+	return wal.Record{Op: wal.OpIndex, DocID: docID, Fields: doc.Fields, Metadata: doc.Metadata}
+}
+
+// appendWAL records a mutation in the write-ahead log before it is applied.
+// The caller must hold writeMu.
+func (e *Engine) appendWAL(rec wal.Record) error {
+	if e.wal == nil {
+		return ErrReadOnly
+	}
+	if err := e.wal.Append(rec); err != nil {
+		return fmt.Errorf("appending to write-ahead log: %w", err)
+	}
+	e.walRecords++
+	return nil
+}
+
+// indexLocked adds the document to the memory index. The caller must hold
+// writeMu.
+func (e *Engine) indexLocked(docID string, doc index.Document) {
+	e.memIndex.AddDocument(docID, doc)
+	tokenCount, _ := e.memIndex.DocLength(docID)
 	e.logger.Debug("document indexed in memory",
 		"doc_id", docID,
-		"token_count", len(tokens),
+		"token_count", tokenCount,
 		"mem_size", e.memIndex.Size(),
 	)
+}
+
+// maybeFlushLocked flushes the memory index if it has reached
+// SegmentMaxSize. The caller must hold writeMu.
+func (e *Engine) maybeFlushLocked() error {
 	if e.memIndex.Size() >= e.cfg.SegmentMaxSize {
 		e.logger.Info("memory index reached max size, flushing to disk",
 			"size", e.memIndex.Size(),
 			"threshold", e.cfg.SegmentMaxSize,
 		)
-		if err := e.Flush(); err != nil {
+		if err := e.flushLocked(); err != nil {
 			return fmt.Errorf("flushing memory index: %w", err)
 		}
 	}
@@ -87,48 +319,88 @@ func (e *Engine) IndexDocument(docID string, title string, body string) error {
 }
 
 // Flush writes the current memory index snapshot to a new on-disk segment
-// and opens a reader for it.
+// and opens a reader for it. Once the segment is durably renamed into place,
+// the write-ahead log files covering it are deleted.
 func (e *Engine) Flush() error {
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+	return e.flushLocked()
+}
+
+// flushLocked implements Flush. The caller must hold writeMu.
+func (e *Engine) flushLocked() error {
+	if e.wal == nil {
+		return nil
+	}
+	if e.walRecords == 0 {
+		return nil
+	}
+	// Every record logged so far is either in the snapshot below or (for
+	// deletes of flushed documents) already persisted in a live-docs file,
+	// so the log can be cut here and truncated once the segment is durable.
+	walSeq, err := e.wal.Rotate()
+	if err != nil {
+		return fmt.Errorf("rotating write-ahead log: %w", err)
+	}
 	snapshot := e.memIndex.Snapshot()
 	if len(snapshot) == 0 {
-		return nil
+		// Nothing is left to persist, but the memory index may still hold
+		// the ordinals of replaced and deleted documents.
+		e.readerMu.Lock()
+		e.memIndex = index.NewMemoryIndex(e.analyzer, e.schema)
+		e.readerMu.Unlock()
+		return e.truncateWAL(walSeq)
 	}
-	segmentName, err := e.writer.Write(snapshot)
+	segmentName, err := e.writer.Write(snapshot, e.memIndex.DocLengths(), e.memIndex.StoredDocuments())
 	if err != nil {
 		return fmt.Errorf("writing segment: %w", err)
 	}
 
-	segPath := filepath.Join(e.cfg.DataDir, segmentName)
-	reader, err := segment.OpenReader(segPath)
+	reader, err := e.openSegment(segmentName)
 	if err != nil {
 		return fmt.Errorf("opening new segment for reading: %w", err)
 	}
+	// The flushed memory index is replaced rather than reset, in the same
+	// critical section that publishes its segment: a View taken before the
+	// flush keeps reading the old memory index and a View taken after it sees
+	// the segment, so no query sees a document twice or not at all.
 	e.readerMu.Lock()
 	e.readers = append(e.readers, reader)
+	e.memIndex = index.NewMemoryIndex(e.analyzer, e.schema)
+	activeSegments := len(e.readers)
 	e.readerMu.Unlock()
-	e.memIndex.Reset()
 	e.logger.Info("segment flushed",
 		"segment", segmentName,
 		"terms", reader.Terms(),
 		"docs", reader.DocCount(),
-		"active_segments", len(e.readers),
+		"active_segments", activeSegments,
 	)
+	return e.truncateWAL(walSeq)
+}
+
+// truncateWAL deletes the log files sealed before walSeq. The caller must
+// hold writeMu.
+func (e *Engine) truncateWAL(walSeq uint64) error {
+	if err := e.wal.TruncateBefore(walSeq); err != nil {
+		return fmt.Errorf("truncating write-ahead log: %w", err)
+	}
+	e.walRecords = 0
 	return nil
 }
 
-// Search tokenises the query term, queries the memory index and all segment
-// readers, and returns deduplicated postings.
-func (e *Engine) Search(term string) (index.PostingList, error) {
-	tokens := tokenizer.Tokenize(term)
+// Search analyses the query term, queries the memory index and all segment
+// readers with its first token in the given field, and returns deduplicated
+// postings.
+func (e *Engine) Search(field string, term string) (index.PostingList, error) {
+	tokens := e.analyzer.Analyze(term)
 	if len(tokens) == 0 {
 		return nil, nil
 	}
-	normalizedTerm := tokens[0].Term
-	allPostings := e.memIndex.Search(normalizedTerm)
-	e.readerMu.RLock()
-	readers := make([]*segment.Reader, len(e.readers))
-	copy(readers, e.readers)
-	e.readerMu.RUnlock()
+	normalizedTerm := index.FieldTerm(field, tokens[0].Term)
+	view := e.AcquireView()
+	defer view.Close()
+	allPostings := view.mem.Search(normalizedTerm)
+	readers := view.readers
 
 	for _, reader := range readers {
 		postings, err := reader.Search(normalizedTerm)
@@ -144,28 +416,75 @@ func (e *Engine) Search(term string) (index.PostingList, error) {
 	return allPostings, nil
 }
 
-// GetDocLength returns the token count for the given document.
+// Analyzer returns the analyzer documents and queries are analysed with.
+func (e *Engine) Analyzer() analysis.Analyzer {
+	return e.analyzer
+}
+
+// Schema returns the types of the metadata keys indexed as doc values.
+func (e *Engine) Schema() index.Schema {
+	return e.schema
+}
+
+// acquireReaders returns a snapshot of the active segment readers with a
+// reference held on each, so that a concurrent merge cannot delete a segment
+// while it is being searched. The snapshot must be released with
+// releaseReaders.
+func (e *Engine) acquireReaders() []*segment.Reader {
+	e.readerMu.RLock()
+	defer e.readerMu.RUnlock()
+	readers := make([]*segment.Reader, len(e.readers))
+	copy(readers, e.readers)
+	for _, r := range readers {
+		r.IncRef()
+	}
+	return readers
+}
+
+// releaseReaders drops the references taken by acquireReaders.
+func (e *Engine) releaseReaders(readers []*segment.Reader) {
+	for _, r := range readers {
+		if err := r.DecRef(); err != nil {
+			e.logger.Error("releasing segment reader", "segment", r.Name(), "error", err)
+		}
+	}
+}
+
+// SegmentCount returns the number of active on-disk segments.
+func (e *Engine) SegmentCount() int {
+	e.readerMu.RLock()
+	defer e.readerMu.RUnlock()
+	return len(e.readers)
+}
+
+// GetDocLength returns the token count for the given document, looking in
+// the memory index first and then in the segments, newest first.
 func (e *Engine) GetDocLength(docID string) int {
-	e.docLengthsMu.RLock()
-	defer e.docLengthsMu.RUnlock()
-	return e.docLengths[docID]
+	view := e.AcquireView()
+	defer view.Close()
+	if length, ok := view.mem.DocLength(docID); ok {
+		return length
+	}
+	for i := len(view.readers) - 1; i >= 0; i-- {
+		if doc, ok := view.readers[i].DocLength(docID); ok {
+			return doc.Total()
+		}
+	}
+	return 0
 }
 
 // GetAvgDocLength returns the average document length across all indexed docs.
 func (e *Engine) GetAvgDocLength() float64 {
-	e.docLengthsMu.RLock()
-	defer e.docLengthsMu.RUnlock()
-	if e.totalDocs == 0 {
-		return 0
-	}
-	return float64(e.totalTokens) / float64(e.totalDocs)
+	view := e.AcquireView()
+	defer view.Close()
+	return view.AvgDocLength()
 }
 
 // GetTotalDocs returns the total number of documents indexed by this engine.
 func (e *Engine) GetTotalDocs() int64 {
-	e.docLengthsMu.RLock()
-	defer e.docLengthsMu.RUnlock()
-	return e.totalDocs
+	view := e.AcquireView()
+	defer view.Close()
+	return view.TotalDocs()
 }
 
 // StartFlushLoop starts a background goroutine that flushes the memory index
@@ -183,21 +502,36 @@ func (e *Engine) StartFlushLoop(ctx context.Context) {
 				}
 				return
 			case <-ticker.C:
-				if e.memIndex.DocCount() > 0 {
-					if err := e.Flush(); err != nil {
-						e.logger.Error("periodic flush failed", "error", err)
-					}
+				if err := e.Flush(); err != nil {
+					e.logger.Error("periodic flush failed", "error", err)
 				}
 			}
 		}
 	}()
 }
 
-// Close flushes any remaining data and closes all segment readers.
+// Close flushes any remaining data, closes the write-ahead log, and closes
+// all segment readers. It waits for an in-progress merge to finish first.
 func (e *Engine) Close() error {
 	if err := e.Flush(); err != nil {
 		e.logger.Error("final flush on close failed", "error", err)
 	}
+	e.writeMu.Lock()
+	if e.wal != nil {
+		if err := e.wal.Close(); err != nil {
+			e.logger.Error("closing write-ahead log", "error", err)
+		}
+		e.wal = nil
+	}
+	e.writeMu.Unlock()
+	e.mergeMu.Lock()
+	defer e.mergeMu.Unlock()
+	e.closeReaders()
+	return nil
+}
+
+// closeReaders releases the engine's reference to every segment reader.
+func (e *Engine) closeReaders() {
 	e.readerMu.Lock()
 	defer e.readerMu.Unlock()
 	for _, reader := range e.readers {
@@ -206,7 +540,6 @@ func (e *Engine) Close() error {
 		}
 	}
 	e.readers = nil
-	return nil
 }
 
 // loadExistingSegments scans the data directory for .spdx segment files and
@@ -228,13 +561,9 @@ func (e *Engine) loadExistingSegments() error {
 	sort.Strings(segFiles)
 
 	for _, name := range segFiles {
-		path := filepath.Join(e.cfg.DataDir, name)
-		reader, err := segment.OpenReader(path)
+		reader, err := e.openSegment(name)
 		if err != nil {
-			e.logger.Error("failed to open segment, skipping",
-				"segment", name,
-				"error", err,
-			)
+			e.handleOpenError(name, err)
 			continue
 		}
 		e.readers = append(e.readers, reader)
@@ -244,13 +573,100 @@ func (e *Engine) loadExistingSegments() error {
 			"docs", reader.DocCount(),
 		)
 	}
+	// A crash between a merge and the deletion of its inputs leaves both on
+	// disk. The merged segment wins, once it has taken over the deletions
+	// recorded in its inputs, which may postdate it; its inputs are dropped
+	// and, unless the engine is read-only, deleted. A read-only engine picks
+	// up the carried-over deletions on reload once the indexer has recovered.
+	if !e.cfg.ReadOnly {
+		byName := make(map[string]*segment.Reader, len(e.readers))
+		for _, r := range e.readers {
+			byName[r.Name()] = r
+		}
+		// Segments are sorted oldest first, so a merged segment that is an
+		// input of a later merge takes over its own inputs' deletions first.
+		for _, r := range e.readers {
+			var inputs []*segment.Reader
+			for _, name := range r.Sources() {
+				if input, ok := byName[name]; ok {
+					inputs = append(inputs, input)
+				}
+			}
+			if err := carryOverDeletions(r, inputs); err != nil {
+				e.logger.Error("carrying over deletions of merged segments", "segment", r.Name(), "error", err)
+			}
+		}
+	}
+	superseded := supersededSegments(e.readers)
+	kept := make([]*segment.Reader, 0, len(e.readers))
+	for _, r := range e.readers {
+		if _, ok := superseded[r.Name()]; !ok {
+			kept = append(kept, r)
+			continue
+		}
+		e.logger.Warn("dropping segment superseded by a merge", "segment", r.Name())
+		if !e.cfg.ReadOnly {
+			r.Retire()
+		}
+		if err := r.Close(); err != nil {
+			e.logger.Error("closing superseded segment", "segment", r.Name(), "error", err)
+		}
+	}
+	e.readers = kept
 	e.logger.Info("segment recovery complete", "segments_loaded", len(e.readers))
 	return nil
 }
 
+// openSegment opens the named segment in the data directory, verifying it
+// according to the configured mode.
+func (e *Engine) openSegment(name string) (*segment.Reader, error) {
+	return segment.OpenVerifiedReader(filepath.Join(e.cfg.DataDir, name), e.verifyMode)
+}
+
+// handleOpenError logs a segment that failed to open. A corrupt segment is
+// moved to the corrupt/ subdirectory, unless the engine is read-only, so it
+// is neither retried on every start nor lost.
+func (e *Engine) handleOpenError(name string, err error) {
+	if !errors.Is(err, segment.ErrCorrupt) || e.cfg.ReadOnly {
+		e.logger.Error("failed to open segment, skipping",
+			"segment", name,
+			"error", err,
+		)
+		return
+	}
+	dest, qerr := segment.Quarantine(filepath.Join(e.cfg.DataDir, name))
+	if qerr != nil {
+		e.logger.Error("failed to quarantine corrupt segment",
+			"segment", name,
+			"error", err,
+			"quarantine_error", qerr,
+		)
+		return
+	}
+	e.logger.Error("quarantined corrupt segment",
+		"segment", name,
+		"error", err,
+		"path", dest,
+	)
+}
+
+// supersededSegments returns the names of the segments that some segment in
+// readers was merged from.
+func supersededSegments(readers []*segment.Reader) map[string]struct{} {
+	superseded := make(map[string]struct{})
+	for _, r := range readers {
+		for _, name := range r.Sources() {
+			superseded[name] = struct{}{}
+		}
+	}
+	return superseded
+}
+
 // ReloadSegments re-scans the data directory for .spdx segment files and opens
 // any that are not already loaded. This allows a searcher process to pick up
 // segments flushed by a separate indexer process sharing the same data volume.
+// Segments whose files have disappeared, or that are superseded by a newly
+// loaded merged segment, are dropped once in-flight searches release them.
 func (e *Engine) ReloadSegments() int {
 	entries, err := os.ReadDir(e.cfg.DataDir)
 	if err != nil {
@@ -258,6 +674,13 @@ func (e *Engine) ReloadSegments() int {
 		return 0
 	}
 
+	onDisk := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".spdx") {
+			onDisk[entry.Name()] = struct{}{}
+		}
+	}
+
 	// Collect names of segments already loaded.
 	e.readerMu.RLock()
 	known := make(map[string]struct{}, len(e.readers))
@@ -268,16 +691,15 @@ func (e *Engine) ReloadSegments() int {
 
 	var newReaders []*segment.Reader
 	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".spdx") {
+		if _, ok := onDisk[entry.Name()]; !ok {
 			continue
 		}
 		if _, ok := known[entry.Name()]; ok {
 			continue
 		}
-		path := filepath.Join(e.cfg.DataDir, entry.Name())
-		reader, err := segment.OpenReader(path)
+		reader, err := e.openSegment(entry.Name())
 		if err != nil {
-			e.logger.Error("reload: failed to open segment", "segment", entry.Name(), "error", err)
+			e.handleOpenError(entry.Name(), err)
 			continue
 		}
 		newReaders = append(newReaders, reader)
@@ -288,10 +710,41 @@ func (e *Engine) ReloadSegments() int {
 		)
 	}
 
-	if len(newReaders) > 0 {
-		e.readerMu.Lock()
-		e.readers = append(e.readers, newReaders...)
-		e.readerMu.Unlock()
+	readers := e.acquireReaders()
+	for _, r := range readers {
+		if _, ok := onDisk[r.Name()]; !ok {
+			continue
+		}
+		if changed, err := r.ReloadLiveDocs(); err != nil {
+			e.logger.Error("reload: failed to read deletions", "segment", r.Name(), "error", err)
+		} else if changed {
+			e.logger.Debug("reloaded segment deletions", "segment", r.Name(), "deleted", r.LiveDocs().DeletedCount())
+		}
+	}
+	e.releaseReaders(readers)
+
+	e.readerMu.Lock()
+	var dropped []*segment.Reader
+	candidates := append(append([]*segment.Reader(nil), e.readers...), newReaders...)
+	superseded := supersededSegments(candidates)
+	kept := make([]*segment.Reader, 0, len(candidates))
+	for _, r := range candidates {
+		_, present := onDisk[r.Name()]
+		_, replaced := superseded[r.Name()]
+		if present && !replaced {
+			kept = append(kept, r)
+		} else {
+			dropped = append(dropped, r)
+		}
+	}
+	e.readers = kept
+	e.readerMu.Unlock()
+
+	for _, r := range dropped {
+		e.logger.Info("unloaded removed segment", "segment", r.Name())
+		if err := r.Close(); err != nil {
+			e.logger.Error("reload: closing removed segment", "segment", r.Name(), "error", err)
+		}
 	}
 	return len(newReaders)
 }
@@ -0,0 +1,34 @@
+package index
+
+// ImpactBlockSize is the number of postings an Impact summarises.
+const ImpactBlockSize = 128
+
+// Impact bounds the postings of one block of a posting list in a field: no
+// posting of the block has a higher frequency than MaxFreq, nor a document
+// whose field is shorter than MinLength. LastDoc is the document of the
+// block's last posting, so a block covers the documents after the previous
+// block's LastDoc up to its own. Scores are monotonic in frequency and
+// length, so an Impact bounds the score of every document of the block.
+type Impact struct {
+	LastDoc   uint32
+	MaxFreq   int
+	MinLength int
+}
+
+// Impacts returns the impacts of postings, in blocks of ImpactBlockSize,
+// reading the length of the field they are in with length.
+func Impacts(postings DocPostingList, length func(doc uint32) int) []Impact {
+	impacts := make([]Impact, 0, (len(postings)+ImpactBlockSize-1)/ImpactBlockSize)
+	for start := 0; start < len(postings); start += ImpactBlockSize {
+		block := postings[start:min(start+ImpactBlockSize, len(postings))]
+		impact := Impact{LastDoc: block[len(block)-1].Doc, MinLength: -1}
+		for _, p := range block {
+			impact.MaxFreq = max(impact.MaxFreq, p.Frequency)
+			if n := length(p.Doc); impact.MinLength < 0 || n < impact.MinLength {
+				impact.MinLength = n
+			}
+		}
+		impacts = append(impacts, impact)
+	}
+	return impacts
+}
@@ -2,64 +2,179 @@ package index
 
 import (
 	"sort"
+	"strings"
 	"sync"
 
-	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/tokenizer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
 )
 
-// MemoryIndex is a concurrency-safe in-memory inverted index. Terms map to
-// per-document Postings, and the entire structure can be snapshotted and
-// reset when flushed to a segment.
+// MemoryIndex is a concurrency-safe in-memory inverted index. Each field of
+// a document is analysed separately and its terms are indexed under
+// field-qualified keys (see FieldTerm) that map to per-document Postings;
+// the entire structure can be snapshotted and reset when flushed to a
+// segment. The stored fields of every document are kept alongside, as are
+// the typed values of the metadata keys of its schema. Every document is
+// also assigned a dense ordinal each time it is inserted so that postings
+// can be served by integer document number; ordinals are never reused
+// before Reset, and the ordinal of a replaced or removed document resolves
+// to no document, so that readers holding ordinals below an earlier
+// OrdinalLimit never see content added after it.
 type MemoryIndex struct {
-	mu       sync.RWMutex
-	index    map[string]map[string]*Posting
-	docCount int
-	size     int64
+	mu          sync.RWMutex
+	analyzer    analysis.Analyzer
+	schema      Schema
+	index       map[string]map[string]*Posting
+	docTerms    map[string][]string
+	docLengths  map[string]map[string]int
+	stored      map[string]Document
+	values      map[string]map[string]Value
+	ords        map[string]uint32
+	ordDocs     []string
+	docCount    int
+	totalTokens int64
+	fieldTokens map[string]int64
+	size        int64
+
+	// forms holds the surface forms of the terms of the documents with
+	// ordinals below formsLimit, counted by AddSurfaceForms.
+	formsMu    sync.Mutex
+	forms      analysis.SurfaceForms
+	formsLimit uint32
 }
 
-// NewMemoryIndex creates an empty MemoryIndex.
-func NewMemoryIndex() *MemoryIndex {
+// NewMemoryIndex creates an empty MemoryIndex that analyses documents with
+// the given analyzer and indexes the metadata keys of schema as typed
+// values.
+func NewMemoryIndex(analyzer analysis.Analyzer, schema Schema) *MemoryIndex {
 	return &MemoryIndex{
-		index: make(map[string]map[string]*Posting),
+		analyzer:    analyzer,
+		schema:      schema,
+		index:       make(map[string]map[string]*Posting),
+		docTerms:    make(map[string][]string),
+		docLengths:  make(map[string]map[string]int),
+		stored:      make(map[string]Document),
+		values:      make(map[string]map[string]Value),
+		ords:        make(map[string]uint32),
+		fieldTokens: make(map[string]int64),
 	}
 }
 
-// AddDocument tokenises the document and upserts term→posting entries into
-// the index.
-func (m *MemoryIndex) AddDocument(docID string, title string, body string) {
-	fullText := title + " " + body
-	tokens := tokenizer.Tokenize(fullText)
-
+// AddDocument analyses every text field of the document, upserts
+// term→posting entries into the index and keeps the document as its stored
+// fields, and its metadata of the schema as typed values. A document that
+// is already present is replaced. A document without any tokens is only
+// removed: segments hold just the documents their postings reference, so
+// it would be dropped at the next flush and must not be counted before it.
+// The document must not be modified afterwards.
+func (m *MemoryIndex) AddDocument(docID string, doc Document) {
+	lengths := make(map[string]int, len(doc.Fields))
 	termData := make(map[string]*Posting)
-
-	for _, token := range tokens {
-		p, exists := termData[token.Term]
-		if !exists {
-			p = &Posting{
-				DocID:     docID,
-				Frequency: 0,
-				Positions: make([]int, 0, 4),
+	for field, text := range doc.Fields {
+		tokens := m.analyzer.Analyze(text)
+		lengths[field] = len(tokens)
+		for _, token := range tokens {
+			key := FieldTerm(field, token.Term)
+			p, exists := termData[key]
+			if !exists {
+				p = &Posting{
+					DocID:     docID,
+					Frequency: 0,
+					Positions: make([]int, 0, 4),
+				}
+				termData[key] = p
 			}
-			termData[token.Term] = p
+			p.Frequency++
+			p.Positions = append(p.Positions, token.Position)
 		}
-		p.Frequency++
-		p.Positions = append(p.Positions, token.Position)
 	}
 
+	values := m.schema.Values(doc.Metadata)
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.removeLocked(docID)
+	if len(termData) == 0 {
+		return
+	}
+	m.ords[docID] = uint32(len(m.ordDocs))
+	m.ordDocs = append(m.ordDocs, docID)
+	terms := make([]string, 0, len(termData))
 	for term, posting := range termData {
 		if _, exists := m.index[term]; !exists {
 			m.index[term] = make(map[string]*Posting)
 		}
 		m.index[term][docID] = posting
-		m.size += int64(len(term) + len(docID) + len(posting.Positions)*8 + 64)
+		m.size += postingSize(term, docID, posting)
+		terms = append(terms, term)
+	}
+	m.docTerms[docID] = terms
+	m.docLengths[docID] = lengths
+	m.stored[docID] = doc
+	m.size += doc.storedSize()
+	if values != nil {
+		m.values[docID] = values
 	}
 	m.docCount++
+	for field, n := range lengths {
+		m.totalTokens += int64(n)
+		m.fieldTokens[field] += int64(n)
+	}
+}
+
+// RemoveDocument deletes every posting for docID from the index. It returns
+// false if the document was not present.
+func (m *MemoryIndex) RemoveDocument(docID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.removeLocked(docID)
+}
+
+// Contains reports whether docID is present in the index.
+func (m *MemoryIndex) Contains(docID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.docTerms[docID]
+	return ok
+}
+
+// removeLocked deletes docID's postings. The caller must hold m.mu.
+func (m *MemoryIndex) removeLocked(docID string) bool {
+	terms, ok := m.docTerms[docID]
+	if !ok {
+		return false
+	}
+	for _, term := range terms {
+		docs := m.index[term]
+		if posting, exists := docs[docID]; exists {
+			m.size -= postingSize(term, docID, posting)
+			delete(docs, docID)
+		}
+		if len(docs) == 0 {
+			delete(m.index, term)
+		}
+	}
+	for field, n := range m.docLengths[docID] {
+		m.totalTokens -= int64(n)
+		m.fieldTokens[field] -= int64(n)
+	}
+	m.size -= m.stored[docID].storedSize()
+	delete(m.docTerms, docID)
+	delete(m.ords, docID)
+	delete(m.docLengths, docID)
+	delete(m.stored, docID)
+	delete(m.values, docID)
+	m.docCount--
+	return true
+}
+
+// postingSize estimates the heap footprint of a single posting.
+func postingSize(term, docID string, posting *Posting) int64 {
+	return int64(len(term) + len(docID) + len(posting.Positions)*8 + 64)
 }
 
-// Search returns the PostingList for the given term, sorted by DocID.
+// Search returns the PostingList for the given field-qualified term, sorted
+// by DocID.
 func (m *MemoryIndex) Search(term string) PostingList {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -77,6 +192,205 @@ func (m *MemoryIndex) Search(term string) PostingList {
 	return result
 }
 
+// SearchOrdinals returns the postings for a field-qualified term addressed
+// by document ordinal, sorted by ordinal.
+func (m *MemoryIndex) SearchOrdinals(term string) DocPostingList {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	docs, exists := m.index[term]
+	if !exists {
+		return nil
+	}
+	result := make(DocPostingList, 0, len(docs))
+	for docID, posting := range docs {
+		result = append(result, DocPosting{
+			Doc:       m.ords[docID],
+			Frequency: posting.Frequency,
+			Positions: posting.Positions,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Doc < result[j].Doc
+	})
+	return result
+}
+
+// TermsWithPrefix calls fn for every field-qualified term starting with
+// prefix and the number of documents containing it, in no particular
+// order, stopping early if fn returns false.
+func (m *MemoryIndex) TermsWithPrefix(prefix string, fn func(term string, docFreq int) bool) {
+	type termFreq struct {
+		term    string
+		docFreq int
+	}
+	m.mu.RLock()
+	var terms []termFreq
+	for term, docs := range m.index {
+		if strings.HasPrefix(term, prefix) {
+			terms = append(terms, termFreq{term, len(docs)})
+		}
+	}
+	m.mu.RUnlock()
+	for _, t := range terms {
+		if !fn(t.term, t.docFreq) {
+			return
+		}
+	}
+}
+
+// AddSurfaceForms adds to dst the words the terms of the stored text fields
+// were analysed from (see analysis.SurfaceForms). The forms of documents are
+// counted once, when first asked for, and kept for later calls; a document
+// replaced after that adds the forms of its new text to those of its
+// earlier text.
+func (m *MemoryIndex) AddSurfaceForms(dst analysis.SurfaceForms) {
+	m.formsMu.Lock()
+	defer m.formsMu.Unlock()
+	if m.forms == nil {
+		m.forms = make(analysis.SurfaceForms)
+	}
+	limit := m.OrdinalLimit()
+	for ord := m.formsLimit; ord < limit; ord++ {
+		doc, ok := m.StoredByOrdinal(ord)
+		if !ok {
+			continue
+		}
+		for _, text := range doc.Fields {
+			m.forms.Add(m.analyzer, text)
+		}
+	}
+	m.formsLimit = limit
+	dst.Merge(m.forms)
+}
+
+// OrdinalLimit returns one more than the highest ordinal assigned so far.
+func (m *MemoryIndex) OrdinalLimit() uint32 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return uint32(len(m.ordDocs))
+}
+
+// OrdinalOf returns the ordinal of docID, and false if it is not present.
+func (m *MemoryIndex) OrdinalOf(docID string) (uint32, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ord, ok := m.ords[docID]
+	return ord, ok
+}
+
+// docAtLocked returns the ID of the document with the given ordinal, and
+// false if it has since been replaced or removed. The caller must hold m.mu.
+func (m *MemoryIndex) docAtLocked(ord uint32) (string, bool) {
+	if int(ord) >= len(m.ordDocs) {
+		return "", false
+	}
+	docID := m.ordDocs[ord]
+	if current, ok := m.ords[docID]; !ok || current != ord {
+		return "", false
+	}
+	return docID, true
+}
+
+// DocIDByOrdinal returns the external ID of the document with the given
+// ordinal.
+func (m *MemoryIndex) DocIDByOrdinal(ord uint32) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if int(ord) >= len(m.ordDocs) {
+		return ""
+	}
+	return m.ordDocs[ord]
+}
+
+// DocLengthByOrdinal returns the total token count of the document with the
+// given ordinal, or 0 if it has been replaced or removed.
+func (m *MemoryIndex) DocLengthByOrdinal(ord uint32) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	docID, ok := m.docAtLocked(ord)
+	if !ok {
+		return 0
+	}
+	return DocLength{Fields: m.docLengths[docID]}.Total()
+}
+
+// FieldLengthsByOrdinal returns the per-field token counts of the document
+// with the given ordinal, or nil if it has been replaced or removed. The
+// returned map must not be modified.
+func (m *MemoryIndex) FieldLengthsByOrdinal(ord uint32) map[string]int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	docID, ok := m.docAtLocked(ord)
+	if !ok {
+		return nil
+	}
+	return m.docLengths[docID]
+}
+
+// StoredByOrdinal returns the stored fields of the document with the given
+// ordinal, and false if it has been replaced or removed. The returned
+// document must not be modified.
+func (m *MemoryIndex) StoredByOrdinal(ord uint32) (Document, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	docID, ok := m.docAtLocked(ord)
+	if !ok {
+		return Document{}, false
+	}
+	return m.stored[docID], true
+}
+
+// ValueByOrdinal returns the typed value of a metadata key of the schema
+// for the document with the given ordinal, and false if it has none or has
+// been replaced or removed.
+func (m *MemoryIndex) ValueByOrdinal(key string, ord uint32) (Value, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	docID, ok := m.docAtLocked(ord)
+	if !ok {
+		return Value{}, false
+	}
+	v, ok := m.values[docID][key]
+	return v, ok
+}
+
+// RangeOrdinals returns the ordinals, sorted, of the documents whose value
+// of a metadata key of the schema lies in r.
+func (m *MemoryIndex) RangeOrdinals(key string, r Range) []uint32 {
+	t, ok := m.schema[key]
+	if !ok {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var ords []uint32
+	for docID, values := range m.values {
+		if v, ok := values[key]; ok && r.Contains(t, v) {
+			ords = append(ords, m.ords[docID])
+		}
+	}
+	sort.Slice(ords, func(i, j int) bool { return ords[i] < ords[j] })
+	return ords
+}
+
+// Schema returns the schema of the metadata keys indexed as typed values.
+func (m *MemoryIndex) Schema() Schema {
+	return m.schema
+}
+
+// StoredDocuments returns the stored fields of every document keyed by
+// DocID, for writing alongside a Snapshot. The documents must not be
+// modified.
+func (m *MemoryIndex) StoredDocuments() map[string]Document {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	docs := make(map[string]Document, len(m.stored))
+	for docID, doc := range m.stored {
+		docs[docID] = doc
+	}
+	return docs
+}
+
 // Snapshot returns a sorted copy of all term entries suitable for flushing
 // to a segment.
 func (m *MemoryIndex) Snapshot() []TermEntry {
@@ -102,6 +416,56 @@ func (m *MemoryIndex) Snapshot() []TermEntry {
 	return entries
 }
 
+// DocLength returns the total token count of docID and whether the document
+// is present in the index.
+func (m *MemoryIndex) DocLength(docID string) (int, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	lengths, ok := m.docLengths[docID]
+	if !ok {
+		return 0, false
+	}
+	return DocLength{Fields: lengths}.Total(), true
+}
+
+// DocLengths returns the per-field lengths of every document, sorted by
+// DocID, for writing alongside a Snapshot.
+func (m *MemoryIndex) DocLengths() []DocLength {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	docs := make([]DocLength, 0, len(m.docLengths))
+	for docID, lengths := range m.docLengths {
+		fields := make(map[string]int, len(lengths))
+		for field, n := range lengths {
+			fields[field] = n
+		}
+		docs = append(docs, DocLength{DocID: docID, Fields: fields})
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].DocID < docs[j].DocID
+	})
+	return docs
+}
+
+// TotalTokens returns the summed length of every document in the index.
+func (m *MemoryIndex) TotalTokens() int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.totalTokens
+}
+
+// FieldTokens returns the summed length of every field across the
+// documents in the index.
+func (m *MemoryIndex) FieldTokens() map[string]int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tokens := make(map[string]int64, len(m.fieldTokens))
+	for field, n := range m.fieldTokens {
+		tokens[field] = n
+	}
+	return tokens
+}
+
 // Size returns the estimated heap size of the index in bytes.
 func (m *MemoryIndex) Size() int64 {
 	m.mu.RLock()
@@ -122,6 +486,14 @@ func (m *MemoryIndex) Reset() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.index = make(map[string]map[string]*Posting)
+	m.docTerms = make(map[string][]string)
+	m.docLengths = make(map[string]map[string]int)
+	m.stored = make(map[string]Document)
+	m.values = make(map[string]map[string]Value)
+	m.ords = make(map[string]uint32)
+	m.ordDocs = nil
 	m.docCount = 0
+	m.totalTokens = 0
+	m.fieldTokens = make(map[string]int64)
 	m.size = 0
 }
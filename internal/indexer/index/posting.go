@@ -3,7 +3,10 @@
 // MemoryIndex that supports add, search, snapshot, and reset operations.
 package index
 
-// Posting records a single document's occurrence data for a term.
+import "strings"
+
+// Posting records a single document's occurrence data for a term in one
+// field. Positions count tokens from the start of the field.
 type Posting struct {
 	DocID     string
 	Frequency int
@@ -13,8 +16,21 @@ type Posting struct {
 // PostingList is a slice of Posting entries for one term.
 type PostingList []Posting
 
-// TermEntry pairs a term string with its PostingList, used when
-// snapshotting the memory index for segment flushing.
+// DocPosting is a Posting addressed by an integer document number (a
+// segment-local ordinal, or a query-wide number derived from one) instead of
+// the external document ID.
+type DocPosting struct {
+	Doc       uint32
+	Frequency int
+	Positions []int
+}
+
+// DocPostingList is a slice of DocPosting entries for one term, sorted by
+// Doc.
+type DocPostingList []DocPosting
+
+// TermEntry pairs a field-qualified term key (see FieldTerm) with its
+// PostingList, used when snapshotting the memory index for segment flushing.
 type TermEntry struct {
 	Term     string
 	Postings PostingList
@@ -26,3 +42,80 @@ type DocStats struct {
 	DocLen   int
 	TermFreq int
 }
+
+// The fields every document of the ingestion API has. Documents may carry
+// other named fields as well.
+const (
+	FieldTitle = "title"
+	FieldBody  = "body"
+)
+
+// maxFieldNameLength bounds the length of a field name.
+const maxFieldNameLength = 64
+
+// ValidFieldName reports whether name can be used as a field name: a lower
+// case ASCII letter followed by at most 63 lower case letters, digits and
+// underscores.
+func ValidFieldName(name string) bool {
+	if name == "" || len(name) > maxFieldNameLength || name[0] < 'a' || name[0] > 'z' {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		c := name[i]
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// FieldTerm returns the key under which term is indexed for field: the
+// field name and the term joined by a colon. Field names cannot contain a
+// colon, so SplitFieldTerm recovers both parts even from terms that do.
+// Keys of one field are contiguous in sorted order.
+func FieldTerm(field, term string) string {
+	return field + ":" + term
+}
+
+// SplitFieldTerm splits a key built by FieldTerm into its field and term.
+func SplitFieldTerm(key string) (field, term string, ok bool) {
+	return strings.Cut(key, ":")
+}
+
+// Document is the content of a document: its named text fields, which are
+// analysed and indexed, and its metadata, which is only stored. Both are
+// kept as the document's stored fields and can be returned with search
+// results.
+type Document struct {
+	Fields   map[string]string `json:"fields,omitempty"`
+	Metadata map[string]any    `json:"metadata,omitempty"`
+}
+
+// storedSize estimates the heap footprint of a document's stored fields.
+func (d Document) storedSize() int64 {
+	var n int64
+	for field, text := range d.Fields {
+		n += int64(len(field) + len(text) + 32)
+	}
+	for key := range d.Metadata {
+		n += int64(len(key) + 48)
+	}
+	return n
+}
+
+// DocLength records how many tokens each field of a document contributed to
+// the index. It is persisted in the document table of every segment so that
+// length normalisation survives restarts.
+type DocLength struct {
+	DocID  string         `json:"id"`
+	Fields map[string]int `json:"fields"`
+}
+
+// Total returns the document's length summed across all fields.
+func (d DocLength) Total() int {
+	total := 0
+	for _, n := range d.Fields {
+		total += n
+	}
+	return total
+}
@@ -0,0 +1,241 @@
+package index
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValueType is the type a metadata key is indexed with. Typed metadata is
+// indexed as doc values, for sorting, and as points, for range filters.
+type ValueType string
+
+const (
+	// TypeKeyword values are strings matched exactly.
+	TypeKeyword ValueType = "keyword"
+	// TypeInteger values are whole numbers of at most 2^53 in magnitude.
+	TypeInteger ValueType = "integer"
+	// TypeFloat values are finite numbers.
+	TypeFloat ValueType = "float"
+	// TypeDate values are RFC 3339 timestamps or dates (2026-01-31), kept
+	// as Unix milliseconds. Dates without a time are midnight UTC.
+	TypeDate ValueType = "date"
+	// TypeBoolean values are true and false, kept as 1 and 0.
+	TypeBoolean ValueType = "boolean"
+)
+
+// maxSafeInteger is the largest magnitude of an integer value, beyond which
+// a float64 no longer holds every integer.
+const maxSafeInteger = 1 << 53
+
+// ParseValueType validates a type name.
+func ParseValueType(s string) (ValueType, error) {
+	switch t := ValueType(s); t {
+	case TypeKeyword, TypeInteger, TypeFloat, TypeDate, TypeBoolean:
+		return t, nil
+	}
+	return "", fmt.Errorf("unknown metadata type %q", s)
+}
+
+// Numeric reports whether values of the type are numbers, as opposed to
+// keywords.
+func (t ValueType) Numeric() bool {
+	return t != TypeKeyword
+}
+
+// Value is a typed metadata value: Str for a keyword, and Num for the
+// other types, dates in Unix milliseconds and booleans as 1 or 0.
+type Value struct {
+	Num float64
+	Str string
+}
+
+// Compare returns -1, 0 or +1 as a is less than, equal to or greater than b
+// as values of type t.
+func (t ValueType) Compare(a, b Value) int {
+	if !t.Numeric() {
+		return strings.Compare(a.Str, b.Str)
+	}
+	switch {
+	case a.Num < b.Num:
+		return -1
+	case a.Num > b.Num:
+		return 1
+	}
+	return 0
+}
+
+// Convert returns the value of a metadata value, as decoded from JSON, as
+// a value of type t. Dates are given as strings, and keywords as strings,
+// numbers or booleans.
+func (t ValueType) Convert(v any) (Value, error) {
+	switch v := v.(type) {
+	case string:
+		return t.Parse(v)
+	case float64:
+		switch t {
+		case TypeKeyword:
+			return Value{Str: strconv.FormatFloat(v, 'f', -1, 64)}, nil
+		case TypeInteger, TypeFloat:
+			return t.number(v)
+		}
+	case bool:
+		switch t {
+		case TypeKeyword:
+			return Value{Str: strconv.FormatBool(v)}, nil
+		case TypeBoolean:
+			return boolValue(v), nil
+		}
+	}
+	return Value{}, fmt.Errorf("%v is not a %s value", v, t)
+}
+
+// Parse parses the text of a value of type t.
+func (t ValueType) Parse(s string) (Value, error) {
+	switch t {
+	case TypeKeyword:
+		return Value{Str: s}, nil
+	case TypeInteger, TypeFloat:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return Value{}, fmt.Errorf("%q is not a %s value", s, t)
+		}
+		return t.number(n)
+	case TypeDate:
+		d, _, err := ParseDate(s)
+		if err != nil {
+			return Value{}, err
+		}
+		return Value{Num: float64(d.UnixMilli())}, nil
+	case TypeBoolean:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return Value{}, fmt.Errorf("%q is not a boolean value", s)
+		}
+		return boolValue(b), nil
+	}
+	return Value{}, fmt.Errorf("unknown metadata type %q", t)
+}
+
+// number validates a number of type t.
+func (t ValueType) number(n float64) (Value, error) {
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return Value{}, fmt.Errorf("%v is not a finite number", n)
+	}
+	if t == TypeInteger && (n != math.Trunc(n) || math.Abs(n) > maxSafeInteger) {
+		return Value{}, fmt.Errorf("%v is not an integer of at most 2^53 in magnitude", n)
+	}
+	return Value{Num: n}, nil
+}
+
+// boolValue returns the value of a boolean.
+func boolValue(b bool) Value {
+	if b {
+		return Value{Num: 1}
+	}
+	return Value{Num: 0}
+}
+
+// ParseDate parses an RFC 3339 timestamp or a date without a time, which
+// is midnight UTC. day is set for a date without a time.
+func ParseDate(s string) (t time.Time, day bool, err error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%q is not a date (2026-01-31) or RFC 3339 timestamp", s)
+	}
+	return t, false, nil
+}
+
+// Format returns the text of a value of type t, which Parse parses back to
+// the same value.
+func (t ValueType) Format(v Value) string {
+	switch t {
+	case TypeKeyword:
+		return v.Str
+	case TypeDate:
+		return time.UnixMilli(int64(v.Num)).UTC().Format(time.RFC3339Nano)
+	case TypeBoolean:
+		return strconv.FormatBool(v.Num != 0)
+	}
+	return strconv.FormatFloat(v.Num, 'f', -1, 64)
+}
+
+// Range is a range of values. A nil bound leaves that end open.
+type Range struct {
+	Lower, Upper               *Value
+	IncludeLower, IncludeUpper bool
+}
+
+// Contains reports whether v lies in the range, compared as values of type
+// t.
+func (r Range) Contains(t ValueType, v Value) bool {
+	if r.Lower != nil {
+		if c := t.Compare(v, *r.Lower); c < 0 || c == 0 && !r.IncludeLower {
+			return false
+		}
+	}
+	if r.Upper != nil {
+		if c := t.Compare(v, *r.Upper); c > 0 || c == 0 && !r.IncludeUpper {
+			return false
+		}
+	}
+	return true
+}
+
+// Schema maps metadata keys to the types they are indexed with. Metadata
+// under other keys is only stored.
+type Schema map[string]ValueType
+
+// ParseSchema validates a schema given as metadata keys and type names.
+func ParseSchema(types map[string]string) (Schema, error) {
+	schema := make(Schema, len(types))
+	for key, name := range types {
+		if !ValidFieldName(key) {
+			return nil, fmt.Errorf("invalid metadata key %q", key)
+		}
+		t, err := ParseValueType(name)
+		if err != nil {
+			return nil, fmt.Errorf("metadata key %q: %w", key, err)
+		}
+		schema[key] = t
+	}
+	return schema, nil
+}
+
+// Values returns the typed values of the metadata keys of the schema in
+// metadata, leaving out values that do not convert to their key's type. It
+// returns nil if there are none.
+func (s Schema) Values(metadata map[string]any) map[string]Value {
+	var values map[string]Value
+	for key, t := range s {
+		raw, ok := metadata[key]
+		if !ok {
+			continue
+		}
+		v, err := t.Convert(raw)
+		if err != nil {
+			continue
+		}
+		if values == nil {
+			values = make(map[string]Value)
+		}
+		values[key] = v
+	}
+	return values
+}
+
+// Keys returns the metadata keys of the schema, sorted.
+func (s Schema) Keys() []string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
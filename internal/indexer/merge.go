@@ -0,0 +1,249 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/segment"
+)
+
+const (
+	// defaultMergeFactor is used when MaxSegmentsBeforeMerge is not set.
+	defaultMergeFactor = 10
+	// minMergeTierSize is the upper bound of the smallest tier. Segments
+	// below this size are all considered equally small.
+	minMergeTierSize int64 = 2 << 20
+)
+
+// tieredMergePolicy groups segments into size tiers, each mergeFactor times
+// larger than the one below it. Once a tier holds mergeFactor segments, its
+// smallest mergeFactor segments are merged into one segment of the next tier,
+// so every document is rewritten O(log n) times over the life of the index.
+type tieredMergePolicy struct {
+	mergeFactor int
+}
+
+// newTieredMergePolicy creates a policy that merges mergeFactor segments at a
+// time, falling back to defaultMergeFactor for values below 2.
+func newTieredMergePolicy(mergeFactor int) tieredMergePolicy {
+	if mergeFactor < 2 {
+		mergeFactor = defaultMergeFactor
+	}
+	return tieredMergePolicy{mergeFactor: mergeFactor}
+}
+
+// tier returns the size tier a segment of the given size belongs to.
+func (p tieredMergePolicy) tier(size int64) int {
+	if size <= minMergeTierSize {
+		return 0
+	}
+	ratio := float64(size) / float64(minMergeTierSize)
+	return int(math.Log(ratio)/math.Log(float64(p.mergeFactor))) + 1
+}
+
+// findMerge returns the indexes (in ascending order) of the segments that
+// should be merged next, or nil if no tier is full.
+func (p tieredMergePolicy) findMerge(sizes []int64) []int {
+	tiers := make(map[int][]int)
+	for i, size := range sizes {
+		t := p.tier(size)
+		tiers[t] = append(tiers[t], i)
+	}
+	levels := make([]int, 0, len(tiers))
+	for t := range tiers {
+		levels = append(levels, t)
+	}
+	sort.Ints(levels)
+	for _, t := range levels {
+		members := tiers[t]
+		if len(members) < p.mergeFactor {
+			continue
+		}
+		sort.SliceStable(members, func(i, j int) bool {
+			return sizes[members[i]] < sizes[members[j]]
+		})
+		picked := members[:p.mergeFactor]
+		sort.Ints(picked)
+		return picked
+	}
+	return nil
+}
+
+// MaybeMerge asks the merge policy for a set of segments to merge and, if
+// one is found, merges them into a new segment. The merged segment replaces
+// its inputs atomically under readerMu; the inputs are retired and their
+// files deleted once in-flight searches release them. Deletions applied to
+// the inputs while the merge was running are carried over to the merged
+// segment before the swap. It returns true if a merge was performed.
+func (e *Engine) MaybeMerge() (bool, error) {
+	e.mergeMu.Lock()
+	defer e.mergeMu.Unlock()
+
+	readers := e.acquireReaders()
+	defer e.releaseReaders(readers)
+
+	sizes := make([]int64, len(readers))
+	for i, r := range readers {
+		sizes[i] = r.Size()
+	}
+	picked := e.mergePolicy.findMerge(sizes)
+	if len(picked) < 2 {
+		return false, nil
+	}
+	inputs := make([]*segment.Reader, len(picked))
+	liveAtStart := make([]*segment.LiveDocs, len(picked))
+	for i, idx := range picked {
+		inputs[i] = readers[idx]
+		liveAtStart[i] = readers[idx].LiveDocs()
+	}
+
+	start := time.Now()
+	var merged *segment.Reader
+	segmentName, err := segment.Merge(inputs, e.writer)
+	switch {
+	case errors.Is(err, segment.ErrEmptyMerge):
+		// Every input document was deleted; the inputs are simply dropped.
+	case err != nil:
+		return false, fmt.Errorf("merging segments: %w", err)
+	default:
+		merged, err = e.openSegment(segmentName)
+		if err != nil {
+			// Left on disk, the segment would supersede its inputs at the
+			// next start.
+			if rmErr := os.Remove(filepath.Join(e.cfg.DataDir, segmentName)); rmErr != nil && !os.IsNotExist(rmErr) {
+				e.logger.Error("removing unopenable merged segment", "segment", segmentName, "error", rmErr)
+			}
+			return false, fmt.Errorf("opening merged segment: %w", err)
+		}
+	}
+
+	// Block deletes while carrying over the ones that raced with the merge
+	// and swapping readers, so no deletion can land on a retired input.
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+	if merged != nil {
+		for i, r := range inputs {
+			for _, docID := range r.DeletedSince(liveAtStart[i]) {
+				if _, err := merged.Delete(docID); err != nil {
+					merged.Retire()
+					merged.Close()
+					return false, fmt.Errorf("carrying over deletion of %s: %w", docID, err)
+				}
+			}
+		}
+	}
+
+	retired := make(map[*segment.Reader]struct{}, len(inputs))
+	for _, r := range inputs {
+		retired[r] = struct{}{}
+	}
+	e.readerMu.Lock()
+	swapped := make([]*segment.Reader, 0, len(e.readers)-len(inputs)+1)
+	inserted := false
+	remaining := len(inputs)
+	for _, r := range e.readers {
+		if _, ok := retired[r]; ok {
+			remaining--
+			if remaining == 0 && !inserted && merged != nil {
+				swapped = append(swapped, merged)
+				inserted = true
+			}
+			continue
+		}
+		swapped = append(swapped, r)
+	}
+	if !inserted && merged != nil {
+		swapped = append(swapped, merged)
+	}
+	e.readers = swapped
+	e.readerMu.Unlock()
+
+	for _, r := range inputs {
+		r.Retire()
+		if err := r.Close(); err != nil {
+			e.logger.Error("releasing merged segment", "segment", r.Name(), "error", err)
+		}
+	}
+	if merged == nil {
+		e.logger.Info("dropped fully deleted segments",
+			"inputs", len(inputs),
+			"active_segments", len(swapped),
+		)
+		return true, nil
+	}
+	e.logger.Info("segments merged",
+		"inputs", len(inputs),
+		"segment", segmentName,
+		"terms", merged.Terms(),
+		"docs", merged.DocCount(),
+		"size_bytes", merged.Size(),
+		"duration_ms", time.Since(start).Milliseconds(),
+		"active_segments", len(swapped),
+	)
+	return true, nil
+}
+
+// carryOverDeletions deletes from merged the documents deleted in the
+// segments it was merged from, except those another of them holds live: a
+// document is merged from its live copy. It is applied at startup, when a
+// crash may have left inputs holding deletions made after their merge.
+func carryOverDeletions(merged *segment.Reader, inputs []*segment.Reader) error {
+	for _, input := range inputs {
+		for _, docID := range input.DeletedDocIDs() {
+			if liveIn(inputs, docID) {
+				continue
+			}
+			if _, err := merged.Delete(docID); err != nil {
+				return fmt.Errorf("carrying over deletion of %s from %s: %w", docID, input.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// liveIn reports whether any of readers holds a live copy of docID.
+func liveIn(readers []*segment.Reader, docID string) bool {
+	for _, r := range readers {
+		if _, ok := r.OrdinalOf(docID); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// StartMergeLoop starts a background goroutine that runs the merge policy at
+// the configured MergeInterval. Each tick keeps merging until the policy
+// finds nothing left to do. The loop exits when ctx is cancelled.
+func (e *Engine) StartMergeLoop(ctx context.Context) {
+	if e.cfg.MergeInterval <= 0 {
+		e.logger.Info("segment merging disabled", "merge_interval", e.cfg.MergeInterval)
+		return
+	}
+	ticker := time.NewTicker(e.cfg.MergeInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for ctx.Err() == nil {
+					merged, err := e.MaybeMerge()
+					if err != nil {
+						e.logger.Error("segment merge failed", "error", err)
+						break
+					}
+					if !merged {
+						break
+					}
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,365 @@
+package segment
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+)
+
+// Version 2 segments store each term's postings as three streams: the gaps
+// between document ordinals, the term frequencies, and the in-document
+// position gaps. Every stream is a sequence of integers compressed in blocks
+// of blockSize values with patched frame-of-reference (PFOR) bit packing;
+// the final partial block is written as uvarints.
+const blockSize = 128
+
+var errCorruptStream = errors.New("corrupt integer stream")
+
+// appendInts compresses values and appends them to dst.
+func appendInts(dst []byte, values []uint32) []byte {
+	for len(values) >= blockSize {
+		dst = appendBlock(dst, values[:blockSize])
+		values = values[blockSize:]
+	}
+	for _, v := range values {
+		dst = binary.AppendUvarint(dst, uint64(v))
+	}
+	return dst
+}
+
+// appendBlock writes one PFOR block: a bit width b, the number of
+// exceptions, every value's low b bits packed LSB first, and then each
+// exception as its index in the block followed by its high bits as a uvarint.
+// b is chosen to minimise the encoded size, so a few outliers do not inflate
+// the width of the whole block.
+func appendBlock(dst []byte, block []uint32) []byte {
+	width := chooseWidth(block)
+	var exceptions []int
+	for i, v := range block {
+		if bits.Len32(v) > width {
+			exceptions = append(exceptions, i)
+		}
+	}
+	dst = append(dst, byte(width), byte(len(exceptions)))
+
+	var acc uint64
+	var accBits int
+	mask := uint64(1)<<uint(width) - 1
+	for _, v := range block {
+		acc |= (uint64(v) & mask) << uint(accBits)
+		accBits += width
+		for accBits >= 8 {
+			dst = append(dst, byte(acc))
+			acc >>= 8
+			accBits -= 8
+		}
+	}
+	if accBits > 0 {
+		dst = append(dst, byte(acc))
+	}
+	for _, i := range exceptions {
+		dst = append(dst, byte(i))
+		dst = binary.AppendUvarint(dst, uint64(block[i])>>uint(width))
+	}
+	return dst
+}
+
+// chooseWidth returns the bit width that gives the smallest encoding of block.
+func chooseWidth(block []uint32) int {
+	best, bestCost := 32, packedSize(32)
+	for width := 0; width < 32; width++ {
+		cost := packedSize(width)
+		exceptions := 0
+		for _, v := range block {
+			if bits.Len32(v) > width {
+				exceptions++
+				cost += 1 + uvarintLen(uint64(v)>>uint(width))
+			}
+		}
+		if exceptions > 255 {
+			continue
+		}
+		if cost < bestCost {
+			best, bestCost = width, cost
+		}
+	}
+	return best
+}
+
+// packedSize returns the number of bytes needed to pack a block at width.
+func packedSize(width int) int {
+	return (blockSize*width + 7) / 8
+}
+
+// uvarintLen returns the encoded length of v as a uvarint.
+func uvarintLen(v uint64) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+	return n
+}
+
+// decodeInts reads n integers written by appendInts from src, appending them
+// to dst. It returns the extended slice and the number of bytes consumed.
+func decodeInts(dst []uint32, src []byte, n int) ([]uint32, int, error) {
+	pos := 0
+	for n >= blockSize {
+		var err error
+		var used int
+		dst, used, err = decodeBlock(dst, src[pos:])
+		if err != nil {
+			return dst, pos, err
+		}
+		pos += used
+		n -= blockSize
+	}
+	for ; n > 0; n-- {
+		v, used := binary.Uvarint(src[pos:])
+		if used <= 0 || v > 1<<32-1 {
+			return dst, pos, errCorruptStream
+		}
+		dst = append(dst, uint32(v))
+		pos += used
+	}
+	return dst, pos, nil
+}
+
+// decodeBlock reads one PFOR block written by appendBlock.
+func decodeBlock(dst []uint32, src []byte) ([]uint32, int, error) {
+	if len(src) < 2 {
+		return dst, 0, errCorruptStream
+	}
+	width, exceptions := int(src[0]), int(src[1])
+	packed := packedSize(width)
+	if width > 32 || len(src) < 2+packed {
+		return dst, 0, errCorruptStream
+	}
+	base := len(dst)
+	var acc uint64
+	var accBits int
+	pos := 2
+	mask := uint64(1)<<uint(width) - 1
+	for i := 0; i < blockSize; i++ {
+		for accBits < width {
+			acc |= uint64(src[pos]) << uint(accBits)
+			pos++
+			accBits += 8
+		}
+		dst = append(dst, uint32(acc&mask))
+		acc >>= uint(width)
+		accBits -= width
+	}
+	pos = 2 + packed
+	for ; exceptions > 0; exceptions-- {
+		if pos >= len(src) {
+			return dst, pos, errCorruptStream
+		}
+		i := int(src[pos])
+		high, used := binary.Uvarint(src[pos+1:])
+		if i >= blockSize || used <= 0 {
+			return dst, pos, errCorruptStream
+		}
+		dst[base+i] |= uint32(high << uint(width))
+		pos += 1 + used
+	}
+	return dst, pos, nil
+}
+
+// postingStreams holds the three encoded streams of one posting list.
+type postingStreams struct {
+	docs      []byte
+	freqs     []byte
+	positions []byte
+}
+
+// encodePostings encodes a posting list sorted by DocID, translating DocIDs
+// to segment ordinals with ords.
+func encodePostings(postings index.PostingList, ords map[string]uint32) (postingStreams, error) {
+	docGaps := make([]uint32, len(postings))
+	freqs := make([]uint32, len(postings))
+	var posGaps []uint32
+	var prev uint32
+	for i, p := range postings {
+		ord, ok := ords[p.DocID]
+		if !ok {
+			return postingStreams{}, fmt.Errorf("document %s missing from document table", p.DocID)
+		}
+		if i > 0 && ord <= prev {
+			return postingStreams{}, fmt.Errorf("postings not sorted by document at %s", p.DocID)
+		}
+		if len(p.Positions) != p.Frequency {
+			return postingStreams{}, fmt.Errorf("document %s has frequency %d but %d positions", p.DocID, p.Frequency, len(p.Positions))
+		}
+		if i == 0 {
+			docGaps[i] = ord
+		} else {
+			docGaps[i] = ord - prev
+		}
+		prev = ord
+		freqs[i] = uint32(p.Frequency)
+		last := 0
+		for _, position := range p.Positions {
+			posGaps = append(posGaps, uint32(position-last))
+			last = position
+		}
+	}
+	return postingStreams{
+		docs:      appendInts(nil, docGaps),
+		freqs:     appendInts(nil, freqs),
+		positions: appendInts(nil, posGaps),
+	}, nil
+}
+
+// decodePostings rebuilds a posting list of docFreq entries from its
+// streams. Documents are identified by ordinal; ordinals at or above numDocs
+// indicate corruption.
+func decodePostings(s postingStreams, docFreq int, numDocs int) (index.DocPostingList, error) {
+	docGaps, _, err := decodeInts(make([]uint32, 0, docFreq), s.docs, docFreq)
+	if err != nil {
+		return nil, fmt.Errorf("decoding document stream: %w", err)
+	}
+	freqs, _, err := decodeInts(make([]uint32, 0, docFreq), s.freqs, docFreq)
+	if err != nil {
+		return nil, fmt.Errorf("decoding frequency stream: %w", err)
+	}
+	totalPositions := 0
+	for _, f := range freqs {
+		totalPositions += int(f)
+	}
+	posGaps, _, err := decodeInts(make([]uint32, 0, totalPositions), s.positions, totalPositions)
+	if err != nil {
+		return nil, fmt.Errorf("decoding position stream: %w", err)
+	}
+	postings := make(index.DocPostingList, docFreq)
+	positions := make([]int, totalPositions)
+	var ord uint32
+	next := 0
+	for i := range postings {
+		if i == 0 {
+			ord = docGaps[0]
+		} else {
+			ord += docGaps[i]
+		}
+		if int(ord) >= numDocs {
+			return nil, fmt.Errorf("document ordinal %d out of range", ord)
+		}
+		freq := int(freqs[i])
+		docPositions := positions[next : next+freq : next+freq]
+		last := 0
+		for j := range docPositions {
+			last += int(posGaps[next+j])
+			docPositions[j] = last
+		}
+		next += freq
+		postings[i] = index.DocPosting{
+			Doc:       ord,
+			Frequency: freq,
+			Positions: docPositions,
+		}
+	}
+	return postings, nil
+}
+
+// encodeDictionary writes the version 2 term dictionary: the entry count,
+// then for each term the length of the prefix it shares with the previous
+// term, the remaining suffix, its document frequency, and the byte lengths of
+// its three posting streams. Stream offsets are not stored; they are the
+// running sums of the lengths.
+func encodeDictionary(dict []DictEntry) []byte {
+	buf := binary.AppendUvarint(nil, uint64(len(dict)))
+	prev := ""
+	for _, de := range dict {
+		shared := sharedPrefix(prev, de.Term)
+		buf = binary.AppendUvarint(buf, uint64(shared))
+		buf = binary.AppendUvarint(buf, uint64(len(de.Term)-shared))
+		buf = append(buf, de.Term[shared:]...)
+		buf = binary.AppendUvarint(buf, uint64(de.DocFreq))
+		buf = binary.AppendUvarint(buf, uint64(de.PostLen))
+		buf = binary.AppendUvarint(buf, uint64(de.FreqLen))
+		buf = binary.AppendUvarint(buf, uint64(de.PosLen))
+		prev = de.Term
+	}
+	return buf
+}
+
+// decodeDictionary parses a dictionary written by encodeDictionary. The
+// three stream regions are laid out back to back in the postings section,
+// so each entry's offsets are derived from the lengths of the entries
+// before it.
+func decodeDictionary(buf []byte) ([]DictEntry, error) {
+	pos := 0
+	next := func() (uint64, error) {
+		v, n := binary.Uvarint(buf[pos:])
+		if n <= 0 {
+			return 0, fmt.Errorf("truncated dictionary at byte %d", pos)
+		}
+		pos += n
+		return v, nil
+	}
+	count, err := next()
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(len(buf)) {
+		return nil, fmt.Errorf("invalid dictionary entry count %d", count)
+	}
+	dict := make([]DictEntry, count)
+	prev := ""
+	for i := range dict {
+		shared, err := next()
+		if err != nil {
+			return nil, err
+		}
+		suffixLen, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if shared > uint64(len(prev)) || suffixLen > uint64(len(buf)-pos) {
+			return nil, fmt.Errorf("invalid term at dictionary entry %d", i)
+		}
+		dict[i].Term = prev[:shared] + string(buf[pos:pos+int(suffixLen)])
+		pos += int(suffixLen)
+		var fields [4]uint64
+		for j := range fields {
+			if fields[j], err = next(); err != nil {
+				return nil, err
+			}
+		}
+		dict[i].DocFreq = int(fields[0])
+		dict[i].PostLen = int(fields[1])
+		dict[i].FreqLen = int(fields[2])
+		dict[i].PosLen = int(fields[3])
+		prev = dict[i].Term
+	}
+	var docsSize, freqsSize int64
+	for i := range dict {
+		dict[i].PostOffset = docsSize
+		docsSize += int64(dict[i].PostLen)
+	}
+	for i := range dict {
+		dict[i].FreqOffset = docsSize + freqsSize
+		freqsSize += int64(dict[i].FreqLen)
+	}
+	posStart := docsSize + freqsSize
+	for i := range dict {
+		dict[i].PosOffset = posStart
+		posStart += int64(dict[i].PosLen)
+	}
+	return dict, nil
+}
+
+// sharedPrefix returns the length of the common prefix of a and b.
+func sharedPrefix(a, b string) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
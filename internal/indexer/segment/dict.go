@@ -0,0 +1,308 @@
+package segment
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// termDict looks up terms in a segment's dictionary.
+type termDict interface {
+	// lookup returns the entry for term.
+	lookup(term string) (DictEntry, bool, error)
+	// scan calls fn for every entry with lo <= term < hi in term order,
+	// stopping early if fn returns false. An empty hi means no upper bound.
+	scan(lo, hi string, fn func(DictEntry) bool) error
+	// size returns the number of terms.
+	size() int
+}
+
+// sliceDict is a fully decoded dictionary, used for version 1 and 2
+// segments.
+type sliceDict []DictEntry
+
+func (d sliceDict) lookup(term string) (DictEntry, bool, error) {
+	idx := sort.Search(len(d), func(i int) bool {
+		return d[i].Term >= term
+	})
+	if idx >= len(d) || d[idx].Term != term {
+		return DictEntry{}, false, nil
+	}
+	return d[idx], true, nil
+}
+
+func (d sliceDict) scan(lo, hi string, fn func(DictEntry) bool) error {
+	idx := sort.Search(len(d), func(i int) bool {
+		return d[i].Term >= lo
+	})
+	for ; idx < len(d); idx++ {
+		if hi != "" && d[idx].Term >= hi {
+			return nil
+		}
+		if !fn(d[idx]) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (d sliceDict) size() int {
+	return len(d)
+}
+
+// From version 3 on, segments store the dictionary in blocks of
+// dictBlockSize terms. Terms are front coded against the previous term of the
+// same block, so a block can be decoded on its own. Only a sparse index holding the first
+// term of every block is loaded into memory; the blocks themselves are read
+// from the memory-mapped file on demand.
+//
+// Block entry:  shared prefix length, suffix length, suffix bytes, document
+// frequency, and the byte lengths of the document, frequency and position
+// streams, all uvarints.
+//
+// Block index entry: first term length, first term, block offset within the
+// dictionary, number of entries, and the offsets of the block's first
+// document, frequency and position streams within the postings section.
+//
+// The dictionary ends with a fixed trailer: term count (uint32), block count
+// (uint32) and block index offset within the dictionary (uint64).
+const (
+	dictBlockSize   = 64
+	dictTrailerSize = 16
+)
+
+// dictBlock is the in-memory index entry for one dictionary block.
+type dictBlock struct {
+	firstTerm  string
+	offset     int
+	count      int
+	firstOrd   int
+	postOffset int64
+	freqOffset int64
+	posOffset  int64
+}
+
+// blockDict reads a version 3 or later dictionary from a mapped byte slice.
+type blockDict struct {
+	data   []byte
+	blocks []dictBlock
+	terms  int
+}
+
+// encodeBlockDictionary writes a version 3 dictionary. Entry stream offsets
+// must already be set.
+func encodeBlockDictionary(dict []DictEntry) []byte {
+	var buf []byte
+	var index []byte
+	blocks := 0
+	for start := 0; start < len(dict); start += dictBlockSize {
+		end := min(start+dictBlockSize, len(dict))
+		first := dict[start]
+		index = binary.AppendUvarint(index, uint64(len(first.Term)))
+		index = append(index, first.Term...)
+		index = binary.AppendUvarint(index, uint64(len(buf)))
+		index = binary.AppendUvarint(index, uint64(end-start))
+		index = binary.AppendUvarint(index, uint64(first.PostOffset))
+		index = binary.AppendUvarint(index, uint64(first.FreqOffset))
+		index = binary.AppendUvarint(index, uint64(first.PosOffset))
+		prev := ""
+		for _, de := range dict[start:end] {
+			shared := sharedPrefix(prev, de.Term)
+			buf = binary.AppendUvarint(buf, uint64(shared))
+			buf = binary.AppendUvarint(buf, uint64(len(de.Term)-shared))
+			buf = append(buf, de.Term[shared:]...)
+			buf = binary.AppendUvarint(buf, uint64(de.DocFreq))
+			buf = binary.AppendUvarint(buf, uint64(de.PostLen))
+			buf = binary.AppendUvarint(buf, uint64(de.FreqLen))
+			buf = binary.AppendUvarint(buf, uint64(de.PosLen))
+			prev = de.Term
+		}
+		blocks++
+	}
+	indexOffset := len(buf)
+	buf = append(buf, index...)
+	var trailer [dictTrailerSize]byte
+	binary.LittleEndian.PutUint32(trailer[0:4], uint32(len(dict)))
+	binary.LittleEndian.PutUint32(trailer[4:8], uint32(blocks))
+	binary.LittleEndian.PutUint64(trailer[8:16], uint64(indexOffset))
+	return append(buf, trailer[:]...)
+}
+
+// openBlockDictionary parses the block index of a version 3 dictionary. The
+// blocks are left in data and decoded lazily.
+func openBlockDictionary(data []byte) (*blockDict, error) {
+	if len(data) < dictTrailerSize {
+		return nil, fmt.Errorf("dictionary too short: %d bytes", len(data))
+	}
+	trailer := data[len(data)-dictTrailerSize:]
+	terms := int(binary.LittleEndian.Uint32(trailer[0:4]))
+	blockCount := int(binary.LittleEndian.Uint32(trailer[4:8]))
+	indexOffset := binary.LittleEndian.Uint64(trailer[8:16])
+	body := data[:len(data)-dictTrailerSize]
+	if indexOffset > uint64(len(body)) || blockCount > len(body) {
+		return nil, fmt.Errorf("invalid dictionary trailer")
+	}
+	d := &blockDict{
+		data:   body[:indexOffset],
+		blocks: make([]dictBlock, blockCount),
+		terms:  terms,
+	}
+	r := uvarintReader{buf: body[indexOffset:]}
+	ord := 0
+	for i := range d.blocks {
+		termLen := r.next()
+		first := r.bytes(termLen)
+		fields := [5]uint64{}
+		for j := range fields {
+			fields[j] = r.next()
+		}
+		if r.err != nil {
+			return nil, fmt.Errorf("invalid dictionary block index entry %d: %w", i, r.err)
+		}
+		if fields[0] > uint64(len(d.data)) {
+			return nil, fmt.Errorf("dictionary block %d offset out of range", i)
+		}
+		d.blocks[i] = dictBlock{
+			firstTerm:  string(first),
+			offset:     int(fields[0]),
+			count:      int(fields[1]),
+			firstOrd:   ord,
+			postOffset: int64(fields[2]),
+			freqOffset: int64(fields[3]),
+			posOffset:  int64(fields[4]),
+		}
+		ord += int(fields[1])
+	}
+	return d, nil
+}
+
+func (d *blockDict) lookup(term string) (DictEntry, bool, error) {
+	var found DictEntry
+	ok := false
+	err := d.scan(term, "", func(de DictEntry) bool {
+		if de.Term == term {
+			found, ok = de, true
+		}
+		return false
+	})
+	return found, ok, err
+}
+
+func (d *blockDict) scan(lo, hi string, fn func(DictEntry) bool) error {
+	// Start at the last block whose first term is <= lo.
+	start := sort.Search(len(d.blocks), func(i int) bool {
+		return d.blocks[i].firstTerm > lo
+	}) - 1
+	if start < 0 {
+		start = 0
+	}
+	for b := start; b < len(d.blocks); b++ {
+		if hi != "" && d.blocks[b].firstTerm >= hi {
+			return nil
+		}
+		more, err := d.scanBlock(b, lo, hi, fn)
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanBlock decodes block b and calls fn for its entries in [lo, hi). It
+// returns false once the scan is complete.
+func (d *blockDict) scanBlock(b int, lo, hi string, fn func(DictEntry) bool) (bool, error) {
+	block := d.blocks[b]
+	r := uvarintReader{buf: d.data[block.offset:]}
+	postOffset, freqOffset, posOffset := block.postOffset, block.freqOffset, block.posOffset
+	term := make([]byte, 0, 32)
+	for i := 0; i < block.count; i++ {
+		shared := r.next()
+		suffixLen := r.next()
+		suffix := r.bytes(suffixLen)
+		var fields [4]uint64
+		for j := range fields {
+			fields[j] = r.next()
+		}
+		if r.err != nil {
+			return false, fmt.Errorf("decoding dictionary block %d: %w", b, r.err)
+		}
+		if shared > uint64(len(term)) {
+			return false, fmt.Errorf("decoding dictionary block %d: invalid shared prefix", b)
+		}
+		term = append(term[:shared], suffix...)
+		de := DictEntry{
+			ord:        block.firstOrd + i,
+			DocFreq:    int(fields[0]),
+			PostOffset: postOffset,
+			PostLen:    int(fields[1]),
+			FreqOffset: freqOffset,
+			FreqLen:    int(fields[2]),
+			PosOffset:  posOffset,
+			PosLen:     int(fields[3]),
+		}
+		postOffset += int64(de.PostLen)
+		freqOffset += int64(de.FreqLen)
+		posOffset += int64(de.PosLen)
+		if string(term) < lo {
+			continue
+		}
+		if hi != "" && string(term) >= hi {
+			return false, nil
+		}
+		de.Term = string(term)
+		if !fn(de) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (d *blockDict) size() int {
+	return d.terms
+}
+
+// uvarintReader decodes a sequence of uvarints, remembering the first error.
+type uvarintReader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (r *uvarintReader) next() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		r.err = fmt.Errorf("truncated varint at byte %d", r.pos)
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *uvarintReader) bytes(n uint64) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > uint64(len(r.buf)-r.pos) {
+		r.err = fmt.Errorf("truncated bytes at byte %d", r.pos)
+		return nil
+	}
+	b := r.buf[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b
+}
+
+// prefixEnd returns the smallest string greater than every string with the
+// given prefix, or "" if there is none (the prefix is all 0xff bytes).
+func prefixEnd(prefix string) string {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1])
+		}
+	}
+	return ""
+}
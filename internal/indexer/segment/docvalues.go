@@ -0,0 +1,303 @@
+package segment
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"sort"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+)
+
+// docValuesVersion is the first format version with a doc-values section,
+// holding the typed metadata values of every document for sorting and
+// range filtering. The section lies between the dictionary and the
+// document table, which records its location and CRC32.
+const docValuesVersion uint32 = 7
+
+// missingOrd marks a document without a value in a keyword column.
+const missingOrd = math.MaxUint32
+
+// sectionRef locates a section of the file recorded in the document table.
+type sectionRef struct {
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+	CRC    uint32 `json:"crc"`
+}
+
+// encodeDocValues encodes the values of the metadata keys of schema in the
+// stored fields of docs, in ordinal order, as the doc-values section. The
+// section starts with a uint32 field count, followed by the fields in name
+// order. Each field is its uvarint-prefixed name and type, a column of
+// values, one per document, and a uint32 point count and the points: the
+// ordinals of the documents with a value, sorted by value and then
+// ordinal, which range filters binary search. A numeric column holds
+// little-endian float64s, NaN for a missing value. A keyword column is
+// preceded by the field's sorted distinct values, as a uvarint count and
+// uvarint-prefixed strings, and holds uint32 indexes into them, missingOrd
+// for a missing value.
+func encodeDocValues(docs []index.DocLength, stored map[string]index.Document, schema index.Schema) []byte {
+	keys := schema.Keys()
+	values := make([]map[string]index.Value, len(docs))
+	for ord, doc := range docs {
+		values[ord] = schema.Values(stored[doc.DocID].Metadata)
+	}
+	section := binary.LittleEndian.AppendUint32(nil, uint32(len(keys)))
+	for _, key := range keys {
+		t := schema[key]
+		section = appendString(section, key)
+		section = appendString(section, string(t))
+		var points []uint32
+		if t.Numeric() {
+			nums := make([]float64, len(docs))
+			for ord := range docs {
+				nums[ord] = math.NaN()
+				if v, ok := values[ord][key]; ok {
+					nums[ord] = v.Num
+					points = append(points, uint32(ord))
+				}
+				section = binary.LittleEndian.AppendUint64(section, math.Float64bits(nums[ord]))
+			}
+			sort.SliceStable(points, func(i, j int) bool { return nums[points[i]] < nums[points[j]] })
+		} else {
+			terms := make(map[string]uint32)
+			for ord := range docs {
+				if v, ok := values[ord][key]; ok {
+					terms[v.Str] = 0
+				}
+			}
+			sorted := make([]string, 0, len(terms))
+			for term := range terms {
+				sorted = append(sorted, term)
+			}
+			sort.Strings(sorted)
+			section = binary.AppendUvarint(section, uint64(len(sorted)))
+			for i, term := range sorted {
+				terms[term] = uint32(i)
+				section = appendString(section, term)
+			}
+			column := make([]uint32, len(docs))
+			for ord := range docs {
+				column[ord] = missingOrd
+				if v, ok := values[ord][key]; ok {
+					column[ord] = terms[v.Str]
+					points = append(points, uint32(ord))
+				}
+				section = binary.LittleEndian.AppendUint32(section, column[ord])
+			}
+			sort.SliceStable(points, func(i, j int) bool { return column[points[i]] < column[points[j]] })
+		}
+		section = binary.LittleEndian.AppendUint32(section, uint32(len(points)))
+		for _, ord := range points {
+			section = binary.LittleEndian.AppendUint32(section, ord)
+		}
+	}
+	return section
+}
+
+// appendString appends s to buf, prefixed with its uvarint length.
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// docValueField provides access to the doc values of one field in place.
+type docValueField struct {
+	typ    index.ValueType
+	terms  []string
+	column []byte
+	points []byte
+}
+
+// docValues provides access to a doc-values section in place, with only the
+// distinct keyword values held on the heap.
+type docValues map[string]*docValueField
+
+// openDocValues parses the field directory of a doc-values section of a
+// segment of docCount documents.
+func openDocValues(data []byte, docCount int) (docValues, error) {
+	d := &uvarintReader{buf: data}
+	fields := readUint32(d)
+	dv := make(docValues)
+	for i := uint32(0); i < fields && d.err == nil; i++ {
+		key := string(d.bytes(d.next()))
+		t, err := index.ParseValueType(string(d.bytes(d.next())))
+		if d.err != nil {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing doc values of %q: %w", key, err)
+		}
+		f := &docValueField{typ: t}
+		width := 8
+		if !t.Numeric() {
+			width = 4
+			n := d.next()
+			if n > uint64(len(data)) {
+				return nil, fmt.Errorf("keyword table of %q holds %d values", key, n)
+			}
+			f.terms = make([]string, 0, n)
+			for j := uint64(0); j < n && d.err == nil; j++ {
+				f.terms = append(f.terms, string(d.bytes(d.next())))
+			}
+		}
+		f.column = d.bytes(uint64(docCount * width))
+		points := readUint32(d)
+		if int64(points) > int64(docCount) {
+			return nil, fmt.Errorf("%d points of %q exceed %d documents", points, key, docCount)
+		}
+		f.points = d.bytes(uint64(points) * 4)
+		for j := 0; j < len(f.points)/4; j++ {
+			if ord := f.point(j); ord >= uint32(docCount) {
+				return nil, fmt.Errorf("point %d of %q is document %d of %d", j, key, ord, docCount)
+			}
+		}
+		dv[key] = f
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("parsing doc values: %w", d.err)
+	}
+	if d.pos != len(data) {
+		return nil, fmt.Errorf("parsing doc values: %d trailing bytes", len(data)-d.pos)
+	}
+	return dv, nil
+}
+
+// readUint32 reads a little-endian uint32.
+func readUint32(d *uvarintReader) uint32 {
+	b := d.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+// value returns the value of the document with the given ordinal.
+func (f *docValueField) value(ord uint32) (index.Value, bool) {
+	if f.typ.Numeric() {
+		n := math.Float64frombits(binary.LittleEndian.Uint64(f.column[ord*8:]))
+		if math.IsNaN(n) {
+			return index.Value{}, false
+		}
+		return index.Value{Num: n}, true
+	}
+	term := binary.LittleEndian.Uint32(f.column[ord*4:])
+	if int(term) >= len(f.terms) {
+		return index.Value{}, false
+	}
+	return index.Value{Str: f.terms[term]}, true
+}
+
+// point returns the ordinal of the i-th point.
+func (f *docValueField) point(i int) uint32 {
+	return binary.LittleEndian.Uint32(f.points[i*4:])
+}
+
+// rangeOrdinals returns the ordinals, sorted, of the documents whose value
+// lies in r.
+func (f *docValueField) rangeOrdinals(r index.Range) []uint32 {
+	n := len(f.points) / 4
+	at := func(i int) index.Value {
+		v, _ := f.value(f.point(i))
+		return v
+	}
+	lo, hi := 0, n
+	if r.Lower != nil {
+		lo = sort.Search(n, func(i int) bool {
+			c := f.typ.Compare(at(i), *r.Lower)
+			return c > 0 || c == 0 && r.IncludeLower
+		})
+	}
+	if r.Upper != nil {
+		hi = sort.Search(n, func(i int) bool {
+			c := f.typ.Compare(at(i), *r.Upper)
+			return c > 0 || c == 0 && !r.IncludeUpper
+		})
+	}
+	if lo >= hi {
+		return nil
+	}
+	ords := make([]uint32, 0, hi-lo)
+	for i := lo; i < hi; i++ {
+		ords = append(ords, f.point(i))
+	}
+	sort.Slice(ords, func(i, j int) bool { return ords[i] < ords[j] })
+	return ords
+}
+
+// loadDocValues opens the doc-values section recorded in the document
+// table, verifying its checksum unless mode is VerifyOff.
+func (r *Reader) loadDocValues(ref *sectionRef, mode VerifyMode) error {
+	if ref == nil {
+		return nil
+	}
+	if ref.Offset < int64(HeaderSize) || ref.Size < 0 || ref.Offset > int64(len(r.data)-FooterSize) || ref.Size > int64(len(r.data)-FooterSize)-ref.Offset {
+		return fmt.Errorf("doc-values region [%d, +%d) outside %d-byte segment", ref.Offset, ref.Size, len(r.data))
+	}
+	data := r.data[ref.Offset : ref.Offset+ref.Size]
+	if mode != VerifyOff && crc32.ChecksumIEEE(data) != ref.CRC {
+		return fmt.Errorf("doc values checksum mismatch")
+	}
+	dv, err := openDocValues(data, len(r.docIDs))
+	if err != nil {
+		return err
+	}
+	r.docValues = dv
+	return nil
+}
+
+// ValueTypes returns the metadata keys the segment holds doc values of and
+// their types, or nil if it predates doc values.
+func (r *Reader) ValueTypes() index.Schema {
+	if r.docValues == nil {
+		return nil
+	}
+	types := make(index.Schema, len(r.docValues))
+	for key, f := range r.docValues {
+		types[key] = f.typ
+	}
+	return types
+}
+
+// ValueType returns the type of the doc values of a metadata key, and
+// false if the segment holds none.
+func (r *Reader) ValueType(key string) (index.ValueType, bool) {
+	f, ok := r.docValues[key]
+	if !ok {
+		return "", false
+	}
+	return f.typ, true
+}
+
+// ValueByOrdinal returns the value of a metadata key for the document with
+// the given ordinal, and false if it has none or the segment holds no doc
+// values of the key.
+func (r *Reader) ValueByOrdinal(key string, ord uint32) (index.Value, bool) {
+	f, ok := r.docValues[key]
+	if !ok {
+		return index.Value{}, false
+	}
+	return f.value(ord)
+}
+
+// RangeOrdinals returns the ordinals, sorted, of the live documents whose
+// value of a metadata key lies in rng.
+func (r *Reader) RangeOrdinals(key string, rng index.Range) []uint32 {
+	f, ok := r.docValues[key]
+	if !ok {
+		return nil
+	}
+	ords := f.rangeOrdinals(rng)
+	live := r.live.Load()
+	if live.DeletedCount() == 0 {
+		return ords
+	}
+	filtered := ords[:0]
+	for _, ord := range ords {
+		if !live.IsDeleted(int(ord)) {
+			filtered = append(filtered, ord)
+		}
+	}
+	return filtered
+}
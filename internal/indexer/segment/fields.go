@@ -0,0 +1,139 @@
+package segment
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+)
+
+// fieldVersion is the first format version whose dictionary holds
+// field-qualified term keys (see index.FieldTerm). Older segments index
+// every field of a document as one stream of unqualified terms; readers
+// present that stream as the body field, and writers of older versions
+// concatenate the fields back into one stream.
+const fieldVersion uint32 = 5
+
+// legacyField is the field the single stream of an older segment is read
+// as.
+const legacyField = index.FieldBody
+
+// legacyDict presents the unqualified dictionary of a segment older than
+// fieldVersion as the terms of legacyField.
+type legacyDict struct {
+	termDict
+}
+
+// legacyPrefix is the key prefix of every term of a legacyDict.
+var legacyPrefix = index.FieldTerm(legacyField, "")
+
+func (d legacyDict) lookup(key string) (DictEntry, bool, error) {
+	term, ok := strings.CutPrefix(key, legacyPrefix)
+	if !ok {
+		return DictEntry{}, false, nil
+	}
+	entry, ok, err := d.termDict.lookup(term)
+	if ok {
+		entry.Term = key
+	}
+	return entry, ok, err
+}
+
+func (d legacyDict) scan(lo, hi string, fn func(DictEntry) bool) error {
+	// Map the key range onto the unqualified terms, all of which sort
+	// between legacyPrefix and prefixEnd(legacyPrefix).
+	switch {
+	case lo <= legacyPrefix:
+		lo = ""
+	case strings.HasPrefix(lo, legacyPrefix):
+		lo = lo[len(legacyPrefix):]
+	default:
+		return nil
+	}
+	switch {
+	case hi == "":
+	case hi <= legacyPrefix:
+		return nil
+	case strings.HasPrefix(hi, legacyPrefix):
+		hi = hi[len(legacyPrefix):]
+	default:
+		hi = ""
+	}
+	return d.termDict.scan(lo, hi, func(de DictEntry) bool {
+		de.Term = legacyPrefix + de.Term
+		return fn(de)
+	})
+}
+
+// collapseFields attributes the whole length of every document to
+// legacyField, matching the postings of a segment older than fieldVersion.
+func collapseFields(docs []index.DocLength) {
+	for i, doc := range docs {
+		docs[i].Fields = map[string]int{legacyField: doc.Total()}
+	}
+}
+
+// unqualifyEntries converts field-qualified term entries into the single
+// stream of a segment older than fieldVersion. A document's fields are
+// concatenated in name order, so the positions of each field are shifted by
+// the lengths of the fields before it.
+func unqualifyEntries(entries []index.TermEntry, docs []index.DocLength) ([]index.TermEntry, error) {
+	offsets := make(map[string]map[string]int, len(docs))
+	for _, doc := range docs {
+		fields := make([]string, 0, len(doc.Fields))
+		for field := range doc.Fields {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		offset := make(map[string]int, len(fields))
+		next := 0
+		for _, field := range fields {
+			offset[field] = next
+			next += doc.Fields[field]
+		}
+		offsets[doc.DocID] = offset
+	}
+
+	merged := make(map[string]map[string]*index.Posting)
+	for _, entry := range entries {
+		field, term, ok := index.SplitFieldTerm(entry.Term)
+		if !ok {
+			return nil, fmt.Errorf("term %q is not qualified by a field", entry.Term)
+		}
+		docs := merged[term]
+		if docs == nil {
+			docs = make(map[string]*index.Posting)
+			merged[term] = docs
+		}
+		for _, p := range entry.Postings {
+			shift := offsets[p.DocID][field]
+			combined := docs[p.DocID]
+			if combined == nil {
+				combined = &index.Posting{DocID: p.DocID}
+				docs[p.DocID] = combined
+			}
+			combined.Frequency += p.Frequency
+			for _, position := range p.Positions {
+				combined.Positions = append(combined.Positions, position+shift)
+			}
+		}
+	}
+
+	result := make([]index.TermEntry, 0, len(merged))
+	for term, docs := range merged {
+		postings := make(index.PostingList, 0, len(docs))
+		for _, p := range docs {
+			sort.Ints(p.Positions)
+			postings = append(postings, *p)
+		}
+		sort.Slice(postings, func(i, j int) bool {
+			return postings[i].DocID < postings[j].DocID
+		})
+		result = append(result, index.TermEntry{Term: term, Postings: postings})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Term < result[j].Term
+	})
+	return result, nil
+}
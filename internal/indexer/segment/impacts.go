@@ -0,0 +1,142 @@
+package segment
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+)
+
+// impactsVersion is the first format version with an impacts section,
+// holding the impacts (see index.Impact) of every posting list so that
+// queries can bound the scores of a block of postings without scoring
+// them. The section follows the doc-values section, and the document table
+// records its location and CRC32.
+const impactsVersion uint32 = 8
+
+// impactTable is the impacts section of a segment.
+type impactTable struct {
+	data    []byte
+	offsets []byte
+	terms   int
+}
+
+// encodeImpacts encodes the impacts of entries, in dictionary order, as the
+// impacts section. The section starts with a uint32 term count and the
+// term count plus one uint32 offsets of each term's impacts in the data
+// that follows. A term has one impact per index.ImpactBlockSize postings,
+// each the uvarint gap between its last document ordinal and that of the
+// previous impact, the maximum frequency and the minimum field length.
+// Ordinals are positions in docs, and field lengths are read from them.
+func encodeImpacts(entries []index.TermEntry, docs []index.DocLength) ([]byte, error) {
+	ords := make(map[string]uint32, len(docs))
+	for i, doc := range docs {
+		ords[doc.DocID] = uint32(i)
+	}
+	var data []byte
+	offsets := binary.LittleEndian.AppendUint32(nil, uint32(len(entries)))
+	for _, entry := range entries {
+		offsets = binary.LittleEndian.AppendUint32(offsets, uint32(len(data)))
+		field, _, ok := index.SplitFieldTerm(entry.Term)
+		if !ok {
+			return nil, fmt.Errorf("term %q is not qualified by a field", entry.Term)
+		}
+		postings := make(index.DocPostingList, len(entry.Postings))
+		for i, p := range entry.Postings {
+			postings[i] = index.DocPosting{Doc: ords[p.DocID], Frequency: p.Frequency}
+		}
+		var last uint32
+		for _, impact := range index.Impacts(postings, func(ord uint32) int { return docs[ord].Fields[field] }) {
+			data = binary.AppendUvarint(data, uint64(impact.LastDoc-last))
+			data = binary.AppendUvarint(data, uint64(impact.MaxFreq))
+			data = binary.AppendUvarint(data, uint64(impact.MinLength))
+			last = impact.LastDoc
+		}
+	}
+	offsets = binary.LittleEndian.AppendUint32(offsets, uint32(len(data)))
+	return append(offsets, data...), nil
+}
+
+// loadImpacts opens the impacts section recorded in the document table,
+// verifying its checksum unless mode is VerifyOff.
+func (r *Reader) loadImpacts(ref *sectionRef, mode VerifyMode) error {
+	if ref.Offset < int64(HeaderSize) || ref.Size < 0 || ref.Offset > int64(len(r.data)-FooterSize) || ref.Size > int64(len(r.data)-FooterSize)-ref.Offset {
+		return fmt.Errorf("impacts region [%d, +%d) outside %d-byte segment", ref.Offset, ref.Size, len(r.data))
+	}
+	data := r.data[ref.Offset : ref.Offset+ref.Size]
+	if mode != VerifyOff && crc32.ChecksumIEEE(data) != ref.CRC {
+		return fmt.Errorf("impacts checksum mismatch")
+	}
+	if len(data) < 4 {
+		return fmt.Errorf("impacts section too short: %d bytes", len(data))
+	}
+	terms := int(binary.LittleEndian.Uint32(data))
+	if terms != r.dict.size() || len(data) < 4+4*(terms+1) {
+		return fmt.Errorf("impacts section holds %d terms, the dictionary %d", terms, r.dict.size())
+	}
+	r.impacts = &impactTable{
+		offsets: data[4 : 4+4*(terms+1)],
+		data:    data[4+4*(terms+1):],
+		terms:   terms,
+	}
+	return nil
+}
+
+// decode returns the impacts of the term with dictionary ordinal ord and
+// docFreq postings.
+func (t *impactTable) decode(ord, docFreq int) ([]index.Impact, error) {
+	if ord < 0 || ord >= t.terms {
+		return nil, fmt.Errorf("term ordinal %d out of range", ord)
+	}
+	start := binary.LittleEndian.Uint32(t.offsets[4*ord:])
+	end := binary.LittleEndian.Uint32(t.offsets[4*ord+4:])
+	if start > end || int(end) > len(t.data) {
+		return nil, fmt.Errorf("impacts of term %d outside the section", ord)
+	}
+	r := uvarintReader{buf: t.data[start:end]}
+	impacts := make([]index.Impact, (docFreq+index.ImpactBlockSize-1)/index.ImpactBlockSize)
+	var last uint32
+	for i := range impacts {
+		last += uint32(r.next())
+		impacts[i] = index.Impact{LastDoc: last, MaxFreq: int(r.next()), MinLength: int(r.next())}
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("decoding impacts of term %d: %w", ord, r.err)
+	}
+	return impacts, nil
+}
+
+// Impacts returns the impacts of the postings of a field-qualified term,
+// addressed by document ordinal like SearchOrdinals, or nil if the segment
+// does not hold the term. Segments that predate impacts compute them from
+// the postings. Impacts may include deleted documents, whose postings only
+// widen their bounds.
+func (r *Reader) Impacts(term string) ([]index.Impact, error) {
+	if r.impacts == nil {
+		postings, err := r.SearchOrdinals(term)
+		if err != nil || postings == nil {
+			return nil, err
+		}
+		field, _, _ := index.SplitFieldTerm(term)
+		return index.Impacts(postings, func(ord uint32) int { return r.docs[ord].Fields[field] }), nil
+	}
+	entry, ok, err := r.dict.lookup(term)
+	if err != nil || !ok {
+		return nil, err
+	}
+	impacts, err := r.impacts.decode(entry.ord, entry.DocFreq)
+	if err != nil {
+		return nil, corruptf("%v", err)
+	}
+	return impacts, nil
+}
+
+// ImpactsSize returns the size in bytes of the impacts section, or 0 if
+// the segment predates impacts.
+func (r *Reader) ImpactsSize() int {
+	if r.impacts == nil {
+		return 0
+	}
+	return 4 + len(r.impacts.offsets) + len(r.impacts.data)
+}
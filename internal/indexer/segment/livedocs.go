@@ -0,0 +1,151 @@
+package segment
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Deletion bitmap (live-docs) file constants. A segment's deletions are
+// stored next to it in a file with the same base name and a .del extension.
+const (
+	LiveDocsMagic   uint32 = 0x53504444
+	LiveDocsVersion uint32 = 1
+	LiveDocsExt            = ".del"
+	liveDocsHeader         = 20
+)
+
+// LiveDocs is an immutable deletion bitmap over a segment's document
+// ordinals. Deleting a document produces a new LiveDocs with a higher
+// generation, so readers can swap bitmaps without locking searches.
+type LiveDocs struct {
+	gen     uint32
+	docs    int
+	deleted []uint64
+	count   int
+}
+
+// newLiveDocs returns an empty bitmap (no deletions) for numDocs documents.
+func newLiveDocs(numDocs int) *LiveDocs {
+	return &LiveDocs{
+		docs:    numDocs,
+		deleted: make([]uint64, (numDocs+63)/64),
+	}
+}
+
+// IsDeleted reports whether the document with the given ordinal is deleted.
+func (l *LiveDocs) IsDeleted(ord int) bool {
+	if l == nil || ord < 0 || ord >= l.docs {
+		return false
+	}
+	return l.deleted[ord/64]&(1<<(uint(ord)%64)) != 0
+}
+
+// DeletedCount returns the number of deleted documents.
+func (l *LiveDocs) DeletedCount() int {
+	if l == nil {
+		return 0
+	}
+	return l.count
+}
+
+// Generation returns the bitmap generation, incremented on every change.
+func (l *LiveDocs) Generation() uint32 {
+	if l == nil {
+		return 0
+	}
+	return l.gen
+}
+
+// withDeleted returns a copy of the bitmap with ord marked deleted.
+func (l *LiveDocs) withDeleted(ord int) *LiveDocs {
+	next := &LiveDocs{
+		gen:     l.gen + 1,
+		docs:    l.docs,
+		deleted: make([]uint64, len(l.deleted)),
+		count:   l.count + 1,
+	}
+	copy(next.deleted, l.deleted)
+	next.deleted[ord/64] |= 1 << (uint(ord) % 64)
+	return next
+}
+
+// liveDocsPath returns the deletion bitmap path for a segment file.
+func liveDocsPath(segmentPath string) string {
+	return strings.TrimSuffix(segmentPath, ".spdx") + LiveDocsExt
+}
+
+// writeLiveDocs atomically persists the bitmap via a temp file and rename.
+func writeLiveDocs(path string, l *LiveDocs) error {
+	buf := make([]byte, liveDocsHeader+len(l.deleted)*8+4)
+	binary.LittleEndian.PutUint32(buf[0:4], LiveDocsMagic)
+	binary.LittleEndian.PutUint32(buf[4:8], LiveDocsVersion)
+	binary.LittleEndian.PutUint32(buf[8:12], l.gen)
+	binary.LittleEndian.PutUint32(buf[12:16], uint32(l.docs))
+	binary.LittleEndian.PutUint32(buf[16:20], uint32(l.count))
+	for i, word := range l.deleted {
+		binary.LittleEndian.PutUint64(buf[liveDocsHeader+i*8:], word)
+	}
+	body := len(buf) - 4
+	binary.LittleEndian.PutUint32(buf[body:], crc32.ChecksumIEEE(buf[:body]))
+
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("creating temp live-docs file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(buf); err != nil {
+		return fmt.Errorf("writing live-docs file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("syncing live-docs file: %w", err)
+	}
+	f.Close()
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("renaming live-docs file: %w", err)
+	}
+	if err := syncDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("syncing live-docs directory: %w", err)
+	}
+	return nil
+}
+
+// readLiveDocs loads a bitmap written by writeLiveDocs. It returns (nil, nil)
+// if the segment has no deletions file.
+func readLiveDocs(path string, numDocs int) (*LiveDocs, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading live-docs file: %w", err)
+	}
+	words := (numDocs + 63) / 64
+	if len(buf) != liveDocsHeader+words*8+4 {
+		return nil, fmt.Errorf("invalid live-docs file: unexpected size %d", len(buf))
+	}
+	if magic := binary.LittleEndian.Uint32(buf[0:4]); magic != LiveDocsMagic {
+		return nil, fmt.Errorf("invalid live-docs file: bad magic bytes %x", magic)
+	}
+	body := len(buf) - 4
+	if crc32.ChecksumIEEE(buf[:body]) != binary.LittleEndian.Uint32(buf[body:]) {
+		return nil, fmt.Errorf("invalid live-docs file: checksum mismatch")
+	}
+	if docs := int(binary.LittleEndian.Uint32(buf[12:16])); docs != numDocs {
+		return nil, fmt.Errorf("invalid live-docs file: covers %d docs, segment has %d", docs, numDocs)
+	}
+	l := &LiveDocs{
+		gen:     binary.LittleEndian.Uint32(buf[8:12]),
+		docs:    numDocs,
+		deleted: make([]uint64, words),
+		count:   int(binary.LittleEndian.Uint32(buf[16:20])),
+	}
+	for i := range l.deleted {
+		l.deleted[i] = binary.LittleEndian.Uint64(buf[liveDocsHeader+i*8:])
+	}
+	return l, nil
+}
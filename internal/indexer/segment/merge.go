@@ -0,0 +1,98 @@
+package segment
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+)
+
+// ErrEmptyMerge is returned by Merge when every document in the inputs has
+// been deleted, leaving nothing to write.
+var ErrEmptyMerge = errors.New("all documents in merged segments are deleted")
+
+// Merge combines the given segments into a single new segment written by w
+// and returns its file name. Postings of documents deleted in an input are
+// dropped for good, and document lengths and stored fields are carried over
+// from the inputs. Readers must be ordered oldest first: when a document
+// appears in more than one input, only the copy held by the newest segment
+// is kept so that re-indexed documents do not resurface. The new segment
+// records the names of its inputs so that a process loading it alongside an
+// input that has not been deleted yet can drop the stale input. Doc values
+// are written for the metadata keys of w's schema and of the inputs, with
+// w's types taking precedence, so that a changed schema applies to merged
+// segments.
+func Merge(readers []*Reader, w *Writer) (string, error) {
+	if len(readers) == 0 {
+		return "", fmt.Errorf("no segments to merge")
+	}
+	owner := make(map[string]int)
+	for i, r := range readers {
+		live := r.LiveDocs()
+		for ord, doc := range r.Docs() {
+			if !live.IsDeleted(ord) {
+				owner[doc.DocID] = i
+			}
+		}
+	}
+
+	merged := make(map[string]index.PostingList)
+	var docs []index.DocLength
+	stored := make(map[string]index.Document)
+	for i, r := range readers {
+		entries, err := r.Entries()
+		if err != nil {
+			return "", fmt.Errorf("reading segment %s: %w", r.Name(), err)
+		}
+		for _, entry := range entries {
+			for _, p := range entry.Postings {
+				if o, ok := owner[p.DocID]; ok && o == i {
+					merged[entry.Term] = append(merged[entry.Term], p)
+				}
+			}
+		}
+		for ord, doc := range r.Docs() {
+			if o, ok := owner[doc.DocID]; !ok || o != i {
+				continue
+			}
+			docs = append(docs, doc)
+			fields, ok, err := r.StoredByOrdinal(uint32(ord))
+			if err != nil {
+				return "", fmt.Errorf("reading stored fields of segment %s: %w", r.Name(), err)
+			}
+			if ok {
+				stored[doc.DocID] = fields
+			}
+		}
+	}
+
+	result := make([]index.TermEntry, 0, len(merged))
+	for term, postings := range merged {
+		sort.Slice(postings, func(i, j int) bool {
+			return postings[i].DocID < postings[j].DocID
+		})
+		result = append(result, index.TermEntry{
+			Term:     term,
+			Postings: postings,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Term < result[j].Term
+	})
+	if len(result) == 0 {
+		return "", ErrEmptyMerge
+	}
+	sources := make([]string, len(readers))
+	schema := make(index.Schema)
+	for i, r := range readers {
+		sources[i] = r.Name()
+		for key, t := range r.ValueTypes() {
+			schema[key] = t
+		}
+	}
+	for key, t := range w.schema {
+		schema[key] = t
+	}
+	return w.write(result, docs, stored, sources, schema)
+}
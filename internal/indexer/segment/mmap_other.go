@@ -0,0 +1,20 @@
+//go:build !unix
+
+package segment
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// mapFile reads the first size bytes of f into memory. Platforms without
+// mmap support (such as Windows development machines) fall back to a heap
+// copy of the segment.
+func mapFile(f *os.File, size int64) ([]byte, func() error, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(io.NewSectionReader(f, 0, size), data); err != nil {
+		return nil, nil, fmt.Errorf("reading segment file: %w", err)
+	}
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package segment
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// mapFile maps the first size bytes of f read-only into memory. The returned
+// function unmaps the region.
+func mapFile(f *os.File, size int64) ([]byte, func() error, error) {
+	if size <= 0 || int64(int(size)) != size {
+		return nil, nil, fmt.Errorf("cannot map segment of %d bytes", size)
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mapping segment file: %w", err)
+	}
+	return data, func() error {
+		if err := syscall.Munmap(data); err != nil {
+			return fmt.Errorf("unmapping segment file: %w", err)
+		}
+		return nil
+	}, nil
+}
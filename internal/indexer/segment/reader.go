@@ -1,8 +1,23 @@
 // Package segment implements a custom binary segment file format (.spdx) for
 // persisting inverted-index data to disk. Each segment has a fixed-size header,
-// a postings region, a JSON dictionary, and a CRC32 footer. The Writer creates
-// new segments atomically, and the Reader provides random-access search over
-// them.
+// a postings region, a term dictionary, a document table carrying per-document
+// field lengths and aggregate statistics, and a footer of CRC32 checksums
+// that OpenVerifiedReader checks according to a VerifyMode. Version 1
+// segments store postings and the dictionary as JSON; version 2 segments
+// store compressed binary posting streams and a front-coded dictionary;
+// version 3 segments keep the binary postings and split the dictionary into
+// independently decodable blocks so it can be searched in place; version 4
+// adds checksums of the header, postings and document table to the footer;
+// version 5 keeps a separate posting list for every field a term occurs in,
+// keyed by field-qualified term; version 6 adds a block-compressed
+// stored-fields section holding the content of every document; version 7
+// adds a doc-values section holding the typed metadata of every document
+// as columns, for sorting, and sorted points, for range filters; version
+// 8 adds an impacts section bounding the frequencies and field lengths of
+// every block of postings, for skipping blocks that cannot score highly.
+// The Writer creates new segments atomically, and the Reader provides
+// random-access search over them. Deletions are recorded in a per-segment
+// bitmap file (.del) alongside the immutable segment.
 package segment
 
 import (
@@ -12,39 +27,103 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"sync"
+	"sync/atomic"
 
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
 )
 
-// Reader provides read-only access to a single .spdx segment file. It
-// memory-maps the dictionary on open and performs random-access reads for
-// posting lists.
+// Reader provides read-only access to a single .spdx segment file. The file
+// is memory-mapped on open: postings and, from version 3 on, the term
+// dictionary are read in place, with only a sparse index of dictionary
+// blocks and the document table held on the heap. Version 1 and 2
+// dictionaries are decoded into memory in full.
+//
+// Readers are reference counted so that a segment retired by a merge can stay
+// open for searches that are still using it. The mapping is released, and a
+// retired segment file removed, only when the last reference is dropped.
 type Reader struct {
-	file     *os.File
+	data     []byte
+	unmap    func() error
 	filePath string
 	header   SegmentHeader
-	dict     []DictEntry
+	dict     termDict
+	docIDs   []string
+	docs     []index.DocLength
+	stored   storedFields
+	sources  []string
+	// docValues is nil before docValuesVersion.
+	docValues docValues
+	// impacts is nil before impactsVersion.
+	impacts  *impactTable
 	postBase int64
+	size     int64
+	live     atomic.Pointer[LiveDocs]
+	stats    atomic.Pointer[Stats]
+	deleteMu sync.Mutex
+	refs     atomic.Int32
+	retired  atomic.Bool
+
+	// forms holds the surface forms of the segment's terms, counted on
+	// first use by AddSurfaceForms.
+	formsOnce sync.Once
+	forms     analysis.SurfaceForms
 }
 
-// OpenReader opens an existing segment file, validates the magic bytes, and
-// loads the term dictionary into memory.
+// OpenReader opens an existing segment file, verifying its header,
+// dictionary and document table against the footer checksums. It is
+// OpenVerifiedReader with VerifyDictionary.
 func OpenReader(path string) (*Reader, error) {
+	return OpenVerifiedReader(path, VerifyDictionary)
+}
+
+// OpenVerifiedReader opens an existing segment file, maps it into memory,
+// verifies it according to mode, and loads the term dictionary index and
+// document table. Errors caused by a damaged file wrap ErrCorrupt.
+func OpenVerifiedReader(path string, mode VerifyMode) (*Reader, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("opening segment file: %w", err)
 	}
-	headerBytes := make([]byte, HeaderSize)
-	if _, err := f.ReadAt(headerBytes, 0); err != nil {
+	info, err := f.Stat()
+	if err != nil {
 		f.Close()
-		return nil, fmt.Errorf("opening segment file: %w", err)
+		return nil, fmt.Errorf("stat segment file: %w", err)
 	}
+	if info.Size() < int64(HeaderSize+FooterSize) {
+		f.Close()
+		return nil, corruptf("file too short (%d bytes)", info.Size())
+	}
+	data, unmap, err := mapFile(f, info.Size())
+	// The mapping stays valid after the descriptor is closed.
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	r := &Reader{
+		data:     data,
+		unmap:    unmap,
+		filePath: path,
+		size:     info.Size(),
+	}
+	if err := r.load(mode); err != nil {
+		unmap()
+		return nil, err
+	}
+	r.refs.Store(1)
+	return r, nil
+}
+
+// load parses the header, verifies the file, and loads the dictionary,
+// document table and deletion bitmap.
+func (r *Reader) load(mode VerifyMode) error {
+	headerBytes := r.data[:HeaderSize]
 	magic := binary.LittleEndian.Uint32(headerBytes[0:4])
 	if magic != MagicBytes {
-		f.Close()
-		return nil, fmt.Errorf("invalid segment file: bad magic bytes %x", magic)
+		return corruptf("bad magic bytes %x", magic)
 	}
-	header := SegmentHeader{
+	r.header = SegmentHeader{
 		Magic:      magic,
 		Version:    binary.LittleEndian.Uint32(headerBytes[4:8]),
 		TermCount:  binary.LittleEndian.Uint32(headerBytes[8:12]),
@@ -53,38 +132,535 @@ func OpenReader(path string) (*Reader, error) {
 		DictSize:   int64(binary.LittleEndian.Uint64(headerBytes[24:32])),
 		PostOffset: int64(binary.LittleEndian.Uint64(headerBytes[32:40])),
 		PostSize:   int64(binary.LittleEndian.Uint64(headerBytes[40:48])),
+		DocsOffset: int64(binary.LittleEndian.Uint64(headerBytes[48:56])),
+		DocsSize:   int64(binary.LittleEndian.Uint64(headerBytes[56:64])),
 	}
-	dictBytes := make([]byte, header.DictSize)
-	if _, err := f.ReadAt(dictBytes, header.DictOffset); err != nil {
-		f.Close()
-		return nil, fmt.Errorf("reading dictionary: %w", err)
+	if r.header.Version < MinFormatVersion || r.header.Version > FormatVersion {
+		return fmt.Errorf("unsupported segment format version %d", r.header.Version)
 	}
-	var dict []DictEntry
-	if err := json.Unmarshal(dictBytes, &dict); err != nil {
-		f.Close()
-		return nil, fmt.Errorf("parsing dictionary: %w", err)
+	// Every section lies between the header and the footer.
+	body := r.data[:len(r.data)-FooterSize]
+	for _, s := range []struct {
+		name           string
+		offset, length int64
+	}{
+		{"postings", r.header.PostOffset, r.header.PostSize},
+		{"dictionary", r.header.DictOffset, r.header.DictSize},
+		{"document table", r.header.DocsOffset, r.header.DocsSize},
+	} {
+		if s.length == 0 {
+			continue
+		}
+		if s.offset < int64(HeaderSize) || s.length < 0 || s.offset > int64(len(body)) || s.length > int64(len(body))-s.offset {
+			return corruptf("%s region [%d, +%d) outside %d-byte segment", s.name, s.offset, s.length, len(r.data))
+		}
 	}
-	return &Reader{
-		file:     f,
-		filePath: path,
-		header:   header,
-		dict:     dict,
-		postBase: header.PostOffset,
-	}, nil
+	if err := r.verifyChecksums(mode); err != nil {
+		return err
+	}
+	r.postBase = r.header.PostOffset
+	dictBytes := r.data[r.header.DictOffset : r.header.DictOffset+r.header.DictSize]
+	var err error
+	switch r.header.Version {
+	case 1:
+		var dict []DictEntry
+		err = json.Unmarshal(dictBytes, &dict)
+		r.dict = sliceDict(dict)
+	case 2:
+		var dict []DictEntry
+		dict, err = decodeDictionary(dictBytes)
+		r.dict = sliceDict(dict)
+	default:
+		r.dict, err = openBlockDictionary(dictBytes)
+	}
+	if err != nil {
+		return corruptf("parsing dictionary: %v", err)
+	}
+	docValuesRef, impactsRef, err := r.loadDocTable()
+	if err != nil {
+		return corruptf("%v", err)
+	}
+	if r.header.Version < fieldVersion {
+		r.dict = legacyDict{r.dict}
+		collapseFields(r.docs)
+	}
+	if r.header.Version >= storedVersion {
+		if r.stored, err = openStored(r.storedSection()); err != nil {
+			return corruptf("%v", err)
+		}
+	}
+	if r.header.Version >= docValuesVersion {
+		if docValuesRef == nil {
+			return corruptf("segment format version %d requires doc values", r.header.Version)
+		}
+		if err := r.loadDocValues(docValuesRef, mode); err != nil {
+			return corruptf("%v", err)
+		}
+	}
+	if r.header.Version >= impactsVersion {
+		if impactsRef == nil {
+			return corruptf("segment format version %d requires impacts", r.header.Version)
+		}
+		if err := r.loadImpacts(impactsRef, mode); err != nil {
+			return corruptf("%v", err)
+		}
+	}
+	if mode == VerifyFull && r.header.Version < checksumVersion {
+		if err := r.verifyPostings(); err != nil {
+			return err
+		}
+	}
+	live, err := readLiveDocs(liveDocsPath(r.filePath), len(r.docIDs))
+	if err != nil {
+		return err
+	}
+	if live == nil {
+		live = newLiveDocs(len(r.docIDs))
+	}
+	r.live.Store(live)
+	r.stats.Store(r.liveStats(live))
+	return nil
+}
+
+// storedSection returns the stored-fields section, which lies between the
+// document table and the footer. It is empty before storedVersion, and nil
+// if the document table is misplaced.
+func (r *Reader) storedSection() []byte {
+	start := r.header.DocsOffset + r.header.DocsSize
+	end := int64(len(r.data) - FooterSize)
+	if r.header.DocsSize == 0 || start < int64(HeaderSize) || start > end {
+		return nil
+	}
+	return r.data[start:end]
+}
+
+// section returns the bytes of the file region [offset, offset+length).
+func (r *Reader) section(offset, length int64) ([]byte, error) {
+	if offset < 0 || length < 0 || offset > int64(len(r.data)) || length > int64(len(r.data))-offset {
+		return nil, corruptf("region [%d, +%d) outside %d-byte segment", offset, length, len(r.data))
+	}
+	return r.data[offset : offset+length], nil
 }
 
-// Search performs a binary search over the term dictionary and reads the
-// matching PostingList from disk.
+// loadDocTable reads the sorted document table and returns the locations
+// of the doc-values and impacts sections it records, if any. Older segments have either no
+// docs section or a bare list of IDs without lengths; for those the table is
+// rebuilt from postings, attributing every token to the body field.
+func (r *Reader) loadDocTable() (*sectionRef, *sectionRef, error) {
+	if r.header.DocsSize > 0 {
+		docsBytes, err := r.section(r.header.DocsOffset, r.header.DocsSize)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading document table: %w", err)
+		}
+		if docsBytes[0] == '{' {
+			var section docsSection
+			if err := json.Unmarshal(docsBytes, &section); err != nil {
+				return nil, nil, fmt.Errorf("parsing document table: %w", err)
+			}
+			r.setDocs(section.Docs)
+			r.sources = section.Sources
+			return section.DocValues, section.Impacts, nil
+		}
+	}
+	if r.header.Version != 1 {
+		return nil, nil, fmt.Errorf("segment format version %d requires a document table", r.header.Version)
+	}
+	lengths := make(map[string]int)
+	for _, de := range r.dict.(sliceDict) {
+		postings, err := r.readPostingsV1(de)
+		if err != nil {
+			return nil, nil, fmt.Errorf("rebuilding document table: term %q: %w", de.Term, err)
+		}
+		for _, p := range postings {
+			lengths[p.DocID] += p.Frequency
+		}
+	}
+	docs := make([]index.DocLength, 0, len(lengths))
+	for docID, n := range lengths {
+		docs = append(docs, index.DocLength{
+			DocID:  docID,
+			Fields: map[string]int{index.FieldBody: n},
+		})
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].DocID < docs[j].DocID
+	})
+	r.setDocs(docs)
+	return nil, nil, nil
+}
+
+// setDocs installs the document table.
+func (r *Reader) setDocs(docs []index.DocLength) {
+	r.docs = docs
+	r.docIDs = make([]string, len(docs))
+	for i, doc := range docs {
+		r.docIDs[i] = doc.DocID
+	}
+}
+
+// liveStats computes the statistics of the documents not deleted in live.
+func (r *Reader) liveStats(live *LiveDocs) *Stats {
+	stats := &Stats{FieldTokens: make(map[string]int64)}
+	for ord, doc := range r.docs {
+		if !live.IsDeleted(ord) {
+			stats.add(doc)
+		}
+	}
+	return stats
+}
+
+// Search performs a binary search over the term dictionary for a
+// field-qualified term and reads the matching PostingList from disk. Postings of deleted documents are dropped.
 func (r *Reader) Search(term string) (index.PostingList, error) {
-	idx := sort.Search(len(r.dict), func(i int) bool {
-		return r.dict[i].Term >= term
+	postings, err := r.SearchOrdinals(term)
+	if err != nil || postings == nil {
+		return nil, err
+	}
+	result := make(index.PostingList, len(postings))
+	for i, p := range postings {
+		result[i] = index.Posting{
+			DocID:     r.docIDs[p.Doc],
+			Frequency: p.Frequency,
+			Positions: p.Positions,
+		}
+	}
+	return result, nil
+}
+
+// SearchOrdinals is like Search but addresses documents by their ordinal in
+// the segment's document table. The postings are sorted by ordinal.
+func (r *Reader) SearchOrdinals(term string) (index.DocPostingList, error) {
+	entry, ok, err := r.dict.lookup(term)
+	if err != nil || !ok {
+		return nil, err
+	}
+	postings, err := r.readPostings(entry)
+	if err != nil {
+		return nil, err
+	}
+	live := r.live.Load()
+	if live.DeletedCount() == 0 {
+		return postings, nil
+	}
+	filtered := postings[:0]
+	for _, p := range postings {
+		if !live.IsDeleted(int(p.Doc)) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered, nil
+}
+
+// DocIDByOrdinal returns the external ID of the document with the given
+// ordinal.
+func (r *Reader) DocIDByOrdinal(ord uint32) string {
+	return r.docIDs[ord]
+}
+
+// DocLengthByOrdinal returns the total token count of the document with the
+// given ordinal.
+func (r *Reader) DocLengthByOrdinal(ord uint32) int {
+	return r.docs[ord].Total()
+}
+
+// FieldLengthsByOrdinal returns the per-field token counts of the document
+// with the given ordinal. The returned map must not be modified.
+func (r *Reader) FieldLengthsByOrdinal(ord uint32) map[string]int {
+	return r.docs[ord].Fields
+}
+
+// StoredByOrdinal returns the stored fields of the document with the given
+// ordinal, and false if the document has none or the segment predates
+// stored fields.
+func (r *Reader) StoredByOrdinal(ord uint32) (index.Document, bool, error) {
+	if r.header.Version < storedVersion {
+		return index.Document{}, false, nil
+	}
+	return r.stored.document(ord)
+}
+
+// AddSurfaceForms adds to dst the words the terms of the segment's stored
+// text fields were analysed from by analyzer, which must be the one the
+// segment was written with (see analysis.SurfaceForms). They are counted
+// over every document, deleted ones included, once, and kept for later
+// calls. A segment that predates stored fields adds none.
+func (r *Reader) AddSurfaceForms(analyzer analysis.Analyzer, dst analysis.SurfaceForms) {
+	r.formsOnce.Do(func() {
+		r.forms = make(analysis.SurfaceForms)
+		for ord := range r.docIDs {
+			doc, ok, err := r.StoredByOrdinal(uint32(ord))
+			if err != nil || !ok {
+				continue
+			}
+			for _, text := range doc.Fields {
+				r.forms.Add(analyzer, text)
+			}
+		}
 	})
-	if idx >= len(r.dict) || r.dict[idx].Term != term {
-		return nil, nil
+	dst.Merge(r.forms)
+}
+
+// StoredSize returns the size in bytes of the stored-fields section, or 0
+// if the segment predates stored fields.
+func (r *Reader) StoredSize() int {
+	return len(r.stored.data)
+}
+
+// docOrd returns the ordinal of docID in the document table, or -1 if the
+// segment does not contain it.
+func (r *Reader) docOrd(docID string) int {
+	idx := sort.SearchStrings(r.docIDs, docID)
+	if idx < len(r.docIDs) && r.docIDs[idx] == docID {
+		return idx
 	}
-	entry := r.dict[idx]
-	postingsBytes := make([]byte, entry.PostLen)
-	if _, err := r.file.ReadAt(postingsBytes, r.postBase+entry.PostOffset); err != nil {
+	return -1
+}
+
+// Contains reports whether the segment holds a live copy of docID.
+func (r *Reader) Contains(docID string) bool {
+	ord := r.docOrd(docID)
+	return ord >= 0 && !r.live.Load().IsDeleted(ord)
+}
+
+// OrdinalOf returns the ordinal of the live copy of docID in the segment,
+// and false if the segment does not hold one.
+func (r *Reader) OrdinalOf(docID string) (uint32, bool) {
+	ord := r.docOrd(docID)
+	if ord < 0 || r.live.Load().IsDeleted(ord) {
+		return 0, false
+	}
+	return uint32(ord), true
+}
+
+// LiveDocs returns the segment's current deletion bitmap.
+func (r *Reader) LiveDocs() *LiveDocs {
+	return r.live.Load()
+}
+
+// Delete marks docID as deleted and persists the updated bitmap. It returns
+// false if the segment holds no live copy of the document.
+func (r *Reader) Delete(docID string) (bool, error) {
+	ord := r.docOrd(docID)
+	if ord < 0 {
+		return false, nil
+	}
+	r.deleteMu.Lock()
+	defer r.deleteMu.Unlock()
+	live := r.live.Load()
+	if live.IsDeleted(ord) {
+		return false, nil
+	}
+	next := live.withDeleted(ord)
+	if err := writeLiveDocs(liveDocsPath(r.filePath), next); err != nil {
+		return false, err
+	}
+	stats := r.copyStats()
+	stats.remove(r.docs[ord])
+	r.live.Store(next)
+	r.stats.Store(stats)
+	return true, nil
+}
+
+// ReloadLiveDocs re-reads the deletion bitmap from disk and swaps it in if
+// it is newer than the one in memory. It lets a searcher process observe
+// deletions made by the indexer process.
+func (r *Reader) ReloadLiveDocs() (bool, error) {
+	r.deleteMu.Lock()
+	defer r.deleteMu.Unlock()
+	live, err := readLiveDocs(liveDocsPath(r.filePath), len(r.docIDs))
+	if err != nil || live == nil {
+		return false, err
+	}
+	if live.Generation() <= r.live.Load().Generation() {
+		return false, nil
+	}
+	r.live.Store(live)
+	r.stats.Store(r.liveStats(live))
+	return true, nil
+}
+
+// DeletedSince returns the IDs of documents deleted after the given bitmap
+// snapshot was taken.
+func (r *Reader) DeletedSince(snapshot *LiveDocs) []string {
+	current := r.live.Load()
+	if current.Generation() == snapshot.Generation() {
+		return nil
+	}
+	var docIDs []string
+	for ord, docID := range r.docIDs {
+		if current.IsDeleted(ord) && !snapshot.IsDeleted(ord) {
+			docIDs = append(docIDs, docID)
+		}
+	}
+	return docIDs
+}
+
+// DeletedDocIDs returns the IDs of the segment's deleted documents.
+func (r *Reader) DeletedDocIDs() []string {
+	live := r.live.Load()
+	var docIDs []string
+	for ord, docID := range r.docIDs {
+		if live.IsDeleted(ord) {
+			docIDs = append(docIDs, docID)
+		}
+	}
+	return docIDs
+}
+
+// LiveDocCount returns the number of documents that are not deleted.
+func (r *Reader) LiveDocCount() int {
+	return len(r.docIDs) - r.live.Load().DeletedCount()
+}
+
+// Stats returns the corpus statistics of the segment's live documents. The
+// returned value must not be modified.
+func (r *Reader) Stats() *Stats {
+	return r.stats.Load()
+}
+
+// copyStats returns a mutable copy of the current statistics. The caller
+// must hold deleteMu.
+func (r *Reader) copyStats() *Stats {
+	current := r.stats.Load()
+	stats := &Stats{
+		Docs:        current.Docs,
+		Tokens:      current.Tokens,
+		FieldTokens: make(map[string]int64, len(current.FieldTokens)),
+	}
+	for field, n := range current.FieldTokens {
+		stats.FieldTokens[field] = n
+	}
+	return stats
+}
+
+// DocLength returns the per-field lengths of docID and whether the segment
+// holds a live copy of it.
+func (r *Reader) DocLength(docID string) (index.DocLength, bool) {
+	ord := r.docOrd(docID)
+	if ord < 0 || r.live.Load().IsDeleted(ord) {
+		return index.DocLength{}, false
+	}
+	return r.docs[ord], true
+}
+
+// Docs returns the segment's document table in ordinal order, including
+// deleted documents. The returned slice must not be modified.
+func (r *Reader) Docs() []index.DocLength {
+	return r.docs
+}
+
+// Entries reads every term and its PostingList from the segment in
+// dictionary order, including postings of deleted documents. It is used
+// when merging segments.
+func (r *Reader) Entries() ([]index.TermEntry, error) {
+	entries := make([]index.TermEntry, 0, r.dict.size())
+	var readErr error
+	err := r.dict.scan("", "", func(de DictEntry) bool {
+		postings, err := r.readPostings(de)
+		if err != nil {
+			readErr = fmt.Errorf("term %q: %w", de.Term, err)
+			return false
+		}
+		converted := make(index.PostingList, len(postings))
+		for i, p := range postings {
+			converted[i] = index.Posting{
+				DocID:     r.docIDs[p.Doc],
+				Frequency: p.Frequency,
+				Positions: p.Positions,
+			}
+		}
+		entries = append(entries, index.TermEntry{
+			Term:     de.Term,
+			Postings: converted,
+		})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return entries, nil
+}
+
+// TermInfo describes a dictionary term.
+type TermInfo struct {
+	Term    string
+	DocFreq int
+}
+
+// TermsInRange calls fn for every term t with lo <= t < hi in sorted order,
+// stopping early if fn returns false. An empty hi means no upper bound.
+// Document frequencies include deleted documents.
+func (r *Reader) TermsInRange(lo, hi string, fn func(TermInfo) bool) error {
+	return r.dict.scan(lo, hi, func(de DictEntry) bool {
+		return fn(TermInfo{Term: de.Term, DocFreq: de.DocFreq})
+	})
+}
+
+// TermsWithPrefix calls fn for every term starting with prefix in sorted
+// order, stopping early if fn returns false.
+func (r *Reader) TermsWithPrefix(prefix string, fn func(TermInfo) bool) error {
+	hi := prefixEnd(prefix)
+	if hi == "" && prefix != "" {
+		// Only reachable for prefixes of 0xff bytes; scan to the end.
+		return r.TermsInRange(prefix, "", func(t TermInfo) bool {
+			return len(t.Term) >= len(prefix) && t.Term[:len(prefix)] == prefix && fn(t)
+		})
+	}
+	return r.TermsInRange(prefix, hi, fn)
+}
+
+// readPostings reads and decodes the postings referenced by entry,
+// addressed by document ordinal.
+func (r *Reader) readPostings(entry DictEntry) (index.DocPostingList, error) {
+	if r.header.Version == 1 {
+		postings, err := r.readPostingsV1(entry)
+		if err != nil {
+			return nil, err
+		}
+		result := make(index.DocPostingList, len(postings))
+		for i, p := range postings {
+			ord := r.docOrd(p.DocID)
+			if ord < 0 {
+				return nil, fmt.Errorf("document %s missing from document table", p.DocID)
+			}
+			result[i] = index.DocPosting{
+				Doc:       uint32(ord),
+				Frequency: p.Frequency,
+				Positions: p.Positions,
+			}
+		}
+		return result, nil
+	}
+	var streams postingStreams
+	for _, s := range []struct {
+		dst    *[]byte
+		offset int64
+		length int
+	}{
+		{&streams.docs, entry.PostOffset, entry.PostLen},
+		{&streams.freqs, entry.FreqOffset, entry.FreqLen},
+		{&streams.positions, entry.PosOffset, entry.PosLen},
+	} {
+		region, err := r.section(r.postBase+s.offset, int64(s.length))
+		if err != nil {
+			return nil, fmt.Errorf("reading postings: %w", err)
+		}
+		*s.dst = region
+	}
+	postings, err := decodePostings(streams, entry.DocFreq, len(r.docIDs))
+	if err != nil {
+		return nil, fmt.Errorf("parsing postings: %w", err)
+	}
+	return postings, nil
+}
+
+// readPostingsV1 reads a version 1 JSON posting list.
+func (r *Reader) readPostingsV1(entry DictEntry) (index.PostingList, error) {
+	postingsBytes, err := r.section(r.postBase+entry.PostOffset, int64(entry.PostLen))
+	if err != nil {
 		return nil, fmt.Errorf("reading postings: %w", err)
 	}
 	var postings index.PostingList
@@ -94,9 +670,14 @@ func (r *Reader) Search(term string) (index.PostingList, error) {
 	return postings, nil
 }
 
+// Header returns the segment's file header.
+func (r *Reader) Header() SegmentHeader {
+	return r.header
+}
+
 // Terms returns the number of unique terms stored in this segment.
 func (r *Reader) Terms() int {
-	return len(r.dict)
+	return r.dict.size()
 }
 
 // DocCount returns the number of unique documents stored in this segment.
@@ -104,9 +685,52 @@ func (r *Reader) DocCount() uint32 {
 	return r.header.DocCount
 }
 
-// Close releases the underlying file handle.
+// Sources returns the names of the segments this segment was merged from, or
+// nil for a flushed segment. A loaded segment supersedes its sources.
+func (r *Reader) Sources() []string {
+	return r.sources
+}
+
+// Size returns the size of the segment file in bytes.
+func (r *Reader) Size() int64 {
+	return r.size
+}
+
+// IncRef acquires an additional reference to the reader. Every call must be
+// paired with a call to DecRef.
+func (r *Reader) IncRef() {
+	r.refs.Add(1)
+}
+
+// DecRef releases a reference. When the last reference is released the file
+// is unmapped and, if the segment has been retired, its file is removed.
+func (r *Reader) DecRef() error {
+	if r.refs.Add(-1) > 0 {
+		return nil
+	}
+	if err := r.unmap(); err != nil {
+		return err
+	}
+	if r.retired.Load() {
+		if err := os.Remove(r.filePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing retired segment: %w", err)
+		}
+		if err := os.Remove(liveDocsPath(r.filePath)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing retired live-docs file: %w", err)
+		}
+	}
+	return nil
+}
+
+// Retire marks the segment as superseded (for example by a merge). Its file
+// is deleted once every outstanding reference has been released.
+func (r *Reader) Retire() {
+	r.retired.Store(true)
+}
+
+// Close releases the reference obtained by OpenReader.
 func (r *Reader) Close() error {
-	return r.file.Close()
+	return r.DecRef()
 }
 
 // Name returns the base file name (e.g. "seg_123456.spdx") of this segment.
@@ -0,0 +1,164 @@
+package segment
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+)
+
+// storedVersion is the first format version with a stored-fields section.
+// The section lies between the document table and the footer, and its
+// CRC32 is kept in the footer.
+const storedVersion uint32 = 6
+
+// storedBlockSize is the uncompressed size at which a block of stored
+// documents is closed. Larger blocks compress better but cost more to
+// decompress for a single document.
+const storedBlockSize = 16 << 10
+
+// storedIndexEntrySize is the size of one entry of the block index: the
+// ordinal of the block's first document and the block's offset from the
+// start of the section, both little-endian uint32s.
+const storedIndexEntrySize = 8
+
+// encodeStored encodes the stored fields of docs, in ordinal order, as the
+// stored-fields section. The section starts with a uint32 block count and
+// the block index, followed by the blocks. Each block is a DEFLATE stream
+// of consecutive documents, each a uvarint length and the JSON encoding of
+// the document; a length of zero marks a document without stored fields.
+func encodeStored(docs []index.DocLength, stored map[string]index.Document) ([]byte, error) {
+	var (
+		blocks     bytes.Buffer
+		blockIndex []byte
+		raw        []byte
+		blockCount uint32
+		firstOrd   uint32
+	)
+	zw, err := flate.NewWriter(&blocks, flate.DefaultCompression)
+	if err != nil {
+		return nil, fmt.Errorf("creating stored-fields compressor: %w", err)
+	}
+	closeBlock := func(nextOrd uint32) error {
+		blockIndex = binary.LittleEndian.AppendUint32(blockIndex, firstOrd)
+		blockIndex = binary.LittleEndian.AppendUint32(blockIndex, uint32(blocks.Len()))
+		zw.Reset(&blocks)
+		if _, err := zw.Write(raw); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		blockCount++
+		firstOrd = nextOrd
+		raw = raw[:0]
+		return nil
+	}
+	for ord, doc := range docs {
+		data := []byte(nil)
+		if d, ok := stored[doc.DocID]; ok {
+			if data, err = json.Marshal(d); err != nil {
+				return nil, fmt.Errorf("marshaling stored fields of %s: %w", doc.DocID, err)
+			}
+		}
+		raw = binary.AppendUvarint(raw, uint64(len(data)))
+		raw = append(raw, data...)
+		if len(raw) >= storedBlockSize {
+			if err := closeBlock(uint32(ord + 1)); err != nil {
+				return nil, fmt.Errorf("compressing stored fields: %w", err)
+			}
+		}
+	}
+	if len(raw) > 0 {
+		if err := closeBlock(uint32(len(docs))); err != nil {
+			return nil, fmt.Errorf("compressing stored fields: %w", err)
+		}
+	}
+
+	headerSize := 4 + len(blockIndex)
+	section := make([]byte, 0, headerSize+blocks.Len())
+	section = binary.LittleEndian.AppendUint32(section, blockCount)
+	for i := 0; i < len(blockIndex); i += storedIndexEntrySize {
+		// Block offsets are relative to the start of the section.
+		offset := binary.LittleEndian.Uint32(blockIndex[i+4:]) + uint32(headerSize)
+		section = append(section, blockIndex[i:i+4]...)
+		section = binary.LittleEndian.AppendUint32(section, offset)
+	}
+	return append(section, blocks.Bytes()...), nil
+}
+
+// storedFields provides access to a stored-fields section in place.
+type storedFields struct {
+	data   []byte
+	blocks int
+}
+
+// openStored validates the block index of a stored-fields section.
+func openStored(data []byte) (storedFields, error) {
+	if len(data) < 4 {
+		return storedFields{}, fmt.Errorf("stored-fields section too short (%d bytes)", len(data))
+	}
+	blocks := int(binary.LittleEndian.Uint32(data))
+	if blocks > (len(data)-4)/storedIndexEntrySize {
+		return storedFields{}, fmt.Errorf("stored-fields index of %d blocks exceeds %d-byte section", blocks, len(data))
+	}
+	return storedFields{data: data, blocks: blocks}, nil
+}
+
+// entry returns the first ordinal and offset of block i.
+func (s storedFields) entry(i int) (uint32, int) {
+	e := s.data[4+i*storedIndexEntrySize:]
+	return binary.LittleEndian.Uint32(e), int(binary.LittleEndian.Uint32(e[4:]))
+}
+
+// document decodes the stored fields of the document with the given
+// ordinal. It returns false if the document has none.
+func (s storedFields) document(ord uint32) (index.Document, bool, error) {
+	i := sort.Search(s.blocks, func(i int) bool {
+		first, _ := s.entry(i)
+		return first > ord
+	}) - 1
+	if i < 0 {
+		return index.Document{}, false, nil
+	}
+	first, start := s.entry(i)
+	end := len(s.data)
+	if i+1 < s.blocks {
+		_, end = s.entry(i + 1)
+	}
+	if start < 4+s.blocks*storedIndexEntrySize || start > end || end > len(s.data) {
+		return index.Document{}, false, corruptf("stored-fields block %d at [%d, %d) outside %d-byte section", i, start, end, len(s.data))
+	}
+	raw, err := io.ReadAll(flate.NewReader(bytes.NewReader(s.data[start:end])))
+	if err != nil {
+		return index.Document{}, false, corruptf("decompressing stored-fields block %d: %v", i, err)
+	}
+	for n := first; ; n++ {
+		length, size := binary.Uvarint(raw)
+		if size <= 0 || length > uint64(len(raw)-size) {
+			// Ordinals beyond the last block's documents are not stored.
+			if len(raw) == 0 {
+				return index.Document{}, false, nil
+			}
+			return index.Document{}, false, corruptf("stored-fields block %d truncated", i)
+		}
+		raw = raw[size:]
+		if n < ord {
+			raw = raw[length:]
+			continue
+		}
+		if length == 0 {
+			return index.Document{}, false, nil
+		}
+		var doc index.Document
+		if err := json.Unmarshal(raw[:length], &doc); err != nil {
+			return index.Document{}, false, corruptf("parsing stored fields of document %d: %v", ord, err)
+		}
+		return doc, true, nil
+	}
+}
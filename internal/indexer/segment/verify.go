@@ -0,0 +1,215 @@
+package segment
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+)
+
+// VerifyMode selects how much of a segment is checked against the checksums
+// in its footer when it is opened.
+type VerifyMode string
+
+const (
+	// VerifyOff skips checksum verification. Section bounds are still
+	// validated.
+	VerifyOff VerifyMode = "off"
+	// VerifyDictionary checks the footer, header, term dictionary and
+	// document table. It is the default: these sections are read in full on
+	// open anyway.
+	VerifyDictionary VerifyMode = "dictionary"
+	// VerifyFull additionally checks the postings and stored fields,
+	// reading the whole file. Segments older than version 4 carry no
+	// postings checksum; every posting list is decoded instead.
+	VerifyFull VerifyMode = "full"
+)
+
+// CorruptDir is the subdirectory of a data directory that corrupt segments
+// are moved to by Quarantine.
+const CorruptDir = "corrupt"
+
+// ErrCorrupt is wrapped by errors reporting a damaged segment file, as
+// opposed to an I/O failure or an unsupported format version.
+var ErrCorrupt = errors.New("corrupt segment")
+
+// ParseVerifyMode parses a verification mode name. The empty string selects
+// VerifyDictionary.
+func ParseVerifyMode(s string) (VerifyMode, error) {
+	switch mode := VerifyMode(s); mode {
+	case "":
+		return VerifyDictionary, nil
+	case VerifyOff, VerifyDictionary, VerifyFull:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown segment verify mode %q", s)
+	}
+}
+
+// corruptf returns an error wrapping ErrCorrupt.
+func corruptf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrCorrupt, fmt.Sprintf(format, args...))
+}
+
+// Footer layout. Every version starts with the CRC32 of the dictionary and
+// the document count. Up to version 3 the rest repeats the dictionary offset
+// and size and the postings size from the header. From version 4 it holds
+// the CRC32s of the header, postings and document table, and the file size;
+// from version 6 also the CRC32 of the stored fields.
+type footer struct {
+	dictCRC  uint32
+	docCount uint32
+
+	// Version 1 to 3.
+	dictOffset int64
+	dictSize   int64
+	postSize   int64
+
+	// Version 4 on.
+	headerCRC uint32
+	postCRC   uint32
+	docsCRC   uint32
+	fileSize  int64
+
+	// Version 6 on.
+	storedCRC uint32
+}
+
+// checksumVersion is the first format version with a full set of checksums.
+const checksumVersion uint32 = 4
+
+// encodeFooter serialises f in the layout of the given format version.
+func encodeFooter(f footer, version uint32) []byte {
+	buf := make([]byte, FooterSize)
+	binary.LittleEndian.PutUint32(buf[0:4], f.dictCRC)
+	binary.LittleEndian.PutUint32(buf[4:8], f.docCount)
+	if version < checksumVersion {
+		binary.LittleEndian.PutUint64(buf[8:16], uint64(f.dictOffset))
+		binary.LittleEndian.PutUint64(buf[16:24], uint64(f.dictSize))
+		binary.LittleEndian.PutUint64(buf[24:32], uint64(f.postSize))
+		return buf
+	}
+	binary.LittleEndian.PutUint32(buf[8:12], f.headerCRC)
+	binary.LittleEndian.PutUint32(buf[12:16], f.postCRC)
+	binary.LittleEndian.PutUint32(buf[16:20], f.docsCRC)
+	if version >= storedVersion {
+		binary.LittleEndian.PutUint32(buf[20:24], f.storedCRC)
+	}
+	binary.LittleEndian.PutUint64(buf[24:32], uint64(f.fileSize))
+	return buf
+}
+
+// decodeFooter parses a footer written by encodeFooter.
+func decodeFooter(buf []byte, version uint32) footer {
+	f := footer{
+		dictCRC:  binary.LittleEndian.Uint32(buf[0:4]),
+		docCount: binary.LittleEndian.Uint32(buf[4:8]),
+	}
+	if version < checksumVersion {
+		f.dictOffset = int64(binary.LittleEndian.Uint64(buf[8:16]))
+		f.dictSize = int64(binary.LittleEndian.Uint64(buf[16:24]))
+		f.postSize = int64(binary.LittleEndian.Uint64(buf[24:32]))
+		return f
+	}
+	f.headerCRC = binary.LittleEndian.Uint32(buf[8:12])
+	f.postCRC = binary.LittleEndian.Uint32(buf[12:16])
+	f.docsCRC = binary.LittleEndian.Uint32(buf[16:20])
+	if version >= storedVersion {
+		f.storedCRC = binary.LittleEndian.Uint32(buf[20:24])
+	}
+	f.fileSize = int64(binary.LittleEndian.Uint64(buf[24:32]))
+	return f
+}
+
+// verifyChecksums checks the header, dictionary and document table, and in
+// VerifyFull mode the postings and stored fields, against the footer.
+// Section bounds must already have been validated.
+func (r *Reader) verifyChecksums(mode VerifyMode) error {
+	if mode == VerifyOff {
+		return nil
+	}
+	h := r.header
+	f := decodeFooter(r.data[len(r.data)-FooterSize:], h.Version)
+	if f.docCount != h.DocCount {
+		return corruptf("footer document count %d does not match header %d", f.docCount, h.DocCount)
+	}
+	if h.Version < checksumVersion {
+		if f.dictOffset != h.DictOffset || f.dictSize != h.DictSize || f.postSize != h.PostSize {
+			return corruptf("footer section layout does not match header")
+		}
+	} else {
+		if f.fileSize != int64(len(r.data)) {
+			return corruptf("file is %d bytes, footer records %d", len(r.data), f.fileSize)
+		}
+		if crc32.ChecksumIEEE(r.data[:HeaderSize]) != f.headerCRC {
+			return corruptf("header checksum mismatch")
+		}
+		docs := r.data[h.DocsOffset : h.DocsOffset+h.DocsSize]
+		if crc32.ChecksumIEEE(docs) != f.docsCRC {
+			return corruptf("document table checksum mismatch")
+		}
+	}
+	if crc32.ChecksumIEEE(r.data[h.DictOffset:h.DictOffset+h.DictSize]) != f.dictCRC {
+		return corruptf("dictionary checksum mismatch")
+	}
+	if mode == VerifyFull && h.Version >= checksumVersion {
+		if crc32.ChecksumIEEE(r.data[h.PostOffset:h.PostOffset+h.PostSize]) != f.postCRC {
+			return corruptf("postings checksum mismatch")
+		}
+	}
+	if mode == VerifyFull && h.Version >= storedVersion {
+		if crc32.ChecksumIEEE(r.storedSection()) != f.storedCRC {
+			return corruptf("stored fields checksum mismatch")
+		}
+	}
+	return nil
+}
+
+// verifyPostings decodes every posting list. It stands in for the postings
+// checksum on segments that predate it.
+func (r *Reader) verifyPostings() error {
+	var readErr error
+	err := r.dict.scan("", "", func(de DictEntry) bool {
+		if _, err := r.readPostings(de); err != nil {
+			readErr = corruptf("postings of term %q: %v", de.Term, err)
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return corruptf("%v", err)
+	}
+	return readErr
+}
+
+// Verify opens the segment at path with the given mode and closes it again,
+// returning the first problem found.
+func Verify(path string, mode VerifyMode) error {
+	r, err := OpenVerifiedReader(path, mode)
+	if err != nil {
+		return err
+	}
+	return r.Close()
+}
+
+// Quarantine moves a segment file and its deletion bitmap, if any, into the
+// CorruptDir subdirectory of the segment's directory so they are no longer
+// loaded but remain available for inspection. It returns the new path of the
+// segment file.
+func Quarantine(path string) (string, error) {
+	dir := filepath.Join(filepath.Dir(path), CorruptDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("creating quarantine directory: %w", err)
+	}
+	dest := filepath.Join(dir, filepath.Base(path))
+	if err := os.Rename(path, dest); err != nil {
+		return "", fmt.Errorf("quarantining segment: %w", err)
+	}
+	del := liveDocsPath(path)
+	if err := os.Rename(del, liveDocsPath(dest)); err != nil && !os.IsNotExist(err) {
+		return dest, fmt.Errorf("quarantining deletion bitmap: %w", err)
+	}
+	return dest, nil
+}
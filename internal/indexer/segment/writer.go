@@ -7,17 +7,21 @@ import (
 	"hash/crc32"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
 )
 
-// MagicBytes identifies a valid .spdx segment file.
+// MagicBytes identifies a valid .spdx segment file. FormatVersion is the
+// version written by NewWriter; MinFormatVersion is the oldest version
+// OpenReader still understands.
 const (
-	MagicBytes    uint32 = 0x53504458
-	FormatVersion uint32 = 1
-	HeaderSize    int    = 64
-	FooterSize    int    = 32
+	MagicBytes       uint32 = 0x53504458
+	FormatVersion    uint32 = 8
+	MinFormatVersion uint32 = 1
+	HeaderSize       int    = 64
+	FooterSize       int    = 32
 )
 
 // SegmentHeader is the 64-byte header written at the start of every segment.
@@ -31,33 +35,188 @@ type SegmentHeader struct {
 	DictSize   int64
 	PostOffset int64
 	PostSize   int64
+	DocsOffset int64
+	DocsSize   int64
 }
 
 // DictEntry maps a term to its postings offset, length, and document frequency
-// in the segment file.
+// in the segment file. In version 1 segments PostOffset and PostLen locate a
+// JSON posting list; from version 2 on they locate the document stream, and
+// the frequency and position streams are located by the remaining fields.
 type DictEntry struct {
 	Term       string `json:"t"`
 	PostOffset int64  `json:"o"`
 	PostLen    int    `json:"l"`
 	DocFreq    int    `json:"d"`
+	FreqOffset int64  `json:"-"`
+	FreqLen    int    `json:"-"`
+	PosOffset  int64  `json:"-"`
+	PosLen     int    `json:"-"`
+
+	// ord is the position of the term in a block dictionary.
+	ord int
+}
+
+// Stats holds the corpus statistics of a segment: the number of documents,
+// their summed length, and the summed length of each field.
+type Stats struct {
+	Docs        int              `json:"docs"`
+	Tokens      int64            `json:"tokens"`
+	FieldTokens map[string]int64 `json:"field_tokens"`
+}
+
+// add accounts for one document in the statistics.
+func (s *Stats) add(doc index.DocLength) {
+	s.Docs++
+	if s.FieldTokens == nil {
+		s.FieldTokens = make(map[string]int64)
+	}
+	for field, n := range doc.Fields {
+		s.Tokens += int64(n)
+		s.FieldTokens[field] += int64(n)
+	}
+}
+
+// remove reverses add for a deleted document.
+func (s *Stats) remove(doc index.DocLength) {
+	s.Docs--
+	for field, n := range doc.Fields {
+		s.Tokens -= int64(n)
+		s.FieldTokens[field] -= int64(n)
+	}
+}
+
+// docsSection is the on-disk layout of the document table. Sources lists
+// the segments a merged segment replaces, and DocValues and Impacts locate
+// the doc-values and impacts sections.
+type docsSection struct {
+	Stats     Stats             `json:"stats"`
+	Docs      []index.DocLength `json:"docs"`
+	Sources   []string          `json:"sources,omitempty"`
+	DocValues *sectionRef       `json:"doc_values,omitempty"`
+	Impacts   *sectionRef       `json:"impacts,omitempty"`
 }
 
 // Writer serialises TermEntry slices into new .spdx segment files.
 type Writer struct {
 	dataDir string
+	version uint32
+	schema  index.Schema
 }
 
-// NewWriter creates a Writer that writes segments into the given directory.
-func NewWriter(dataDir string) *Writer {
-	return &Writer{dataDir: dataDir}
+// NewWriter creates a Writer that writes segments in the current format
+// version into the given directory, with doc values of the metadata keys
+// of schema.
+func NewWriter(dataDir string, schema index.Schema) *Writer {
+	return &Writer{dataDir: dataDir, version: FormatVersion, schema: schema}
+}
+
+// NewVersionedWriter creates a Writer that writes segments in an older
+// format version, for compatibility testing and conversion tools. It writes
+// no doc values of its own; Merge carries over those of its inputs.
+func NewVersionedWriter(dataDir string, version uint32) (*Writer, error) {
+	if version < MinFormatVersion || version > FormatVersion {
+		return nil, fmt.Errorf("unsupported segment format version %d", version)
+	}
+	return &Writer{dataDir: dataDir, version: version}, nil
 }
 
 // Write atomically creates a new segment file containing the given term
-// entries. It writes to a .tmp file first and renames on success.
-func (w *Writer) Write(entries []index.TermEntry) (string, error) {
+// entries, keyed by field-qualified term, and the per-field lengths and
+// stored fields of the documents they reference. Documents missing from
+// stored are written without stored fields. The metadata of the writer's
+// schema in the stored fields is written as doc values. It writes to a
+// .tmp file first and renames on success.
+func (w *Writer) Write(entries []index.TermEntry, docs []index.DocLength, stored map[string]index.Document) (string, error) {
+	return w.write(entries, docs, stored, nil, w.schema)
+}
+
+// write implements Write, recording the names of the segments the new one
+// was merged from and writing doc values of the metadata keys of schema.
+func (w *Writer) write(entries []index.TermEntry, docs []index.DocLength, stored map[string]index.Document, sources []string, schema index.Schema) (string, error) {
 	if len(entries) == 0 {
 		return "", fmt.Errorf("cannot write empty segment")
 	}
+
+	// The document table lists every document in DocID order with its field
+	// lengths, followed by the segment's aggregate statistics. A document's
+	// position in the table is its ordinal within the segment, which the
+	// deletion bitmap and version 2 postings are keyed by.
+	docIDs := make(map[string]struct{})
+	for _, entry := range entries {
+		for _, p := range entry.Postings {
+			docIDs[p.DocID] = struct{}{}
+		}
+	}
+	section := docsSection{
+		Docs:    make([]index.DocLength, 0, len(docIDs)),
+		Sources: sources,
+	}
+	for _, doc := range docs {
+		if _, ok := docIDs[doc.DocID]; ok {
+			section.Docs = append(section.Docs, doc)
+		}
+	}
+	if len(section.Docs) != len(docIDs) {
+		return "", fmt.Errorf("document lengths missing for %d of %d documents", len(docIDs)-len(section.Docs), len(docIDs))
+	}
+	sort.Slice(section.Docs, func(i, j int) bool {
+		return section.Docs[i].DocID < section.Docs[j].DocID
+	})
+	for _, doc := range section.Docs {
+		section.Stats.add(doc)
+	}
+
+	var err error
+	if w.version < fieldVersion {
+		if entries, err = unqualifyEntries(entries, section.Docs); err != nil {
+			return "", err
+		}
+	}
+	var postingsData, dictData []byte
+	if w.version == 1 {
+		postingsData, dictData, err = encodeV1(entries)
+	} else {
+		postingsData, dictData, err = encodeBinary(entries, section.Docs, w.version)
+	}
+	if err != nil {
+		return "", err
+	}
+	var storedData []byte
+	if w.version >= storedVersion {
+		if storedData, err = encodeStored(section.Docs, stored); err != nil {
+			return "", err
+		}
+	}
+	postingsStart := int64(HeaderSize)
+	postingsSize := int64(len(postingsData))
+	dictStart := postingsStart + postingsSize
+	dictSize := int64(len(dictData))
+	var docValuesData []byte
+	if w.version >= docValuesVersion {
+		docValuesData = encodeDocValues(section.Docs, stored, schema)
+		section.DocValues = &sectionRef{
+			Offset: dictStart + dictSize,
+			Size:   int64(len(docValuesData)),
+			CRC:    crc32.ChecksumIEEE(docValuesData),
+		}
+	}
+	var impactsData []byte
+	if w.version >= impactsVersion {
+		if impactsData, err = encodeImpacts(entries, section.Docs); err != nil {
+			return "", err
+		}
+		section.Impacts = &sectionRef{
+			Offset: dictStart + dictSize + int64(len(docValuesData)),
+			Size:   int64(len(impactsData)),
+			CRC:    crc32.ChecksumIEEE(impactsData),
+		}
+	}
+	docsData, err := json.Marshal(section)
+	if err != nil {
+		return "", fmt.Errorf("marshaling document table: %w", err)
+	}
+
 	segmentName := fmt.Sprintf("seg_%d.spdx", time.Now().UnixNano())
 	finalPath := filepath.Join(w.dataDir, segmentName)
 	tmpPath := finalPath + ".tmp"
@@ -70,82 +229,136 @@ func (w *Writer) Write(entries []index.TermEntry) (string, error) {
 		return "", fmt.Errorf("creating temp segment file: %w", err)
 	}
 	defer f.Close()
-	header := SegmentHeader{
-		Magic:     MagicBytes,
-		Version:   FormatVersion,
-		TermCount: uint32(len(entries)),
-		CreatedAt: time.Now().Unix(),
-	}
+
+	docsStart := dictStart + dictSize + int64(len(docValuesData)) + int64(len(impactsData))
+	docsSize := int64(len(docsData))
+	storedStart := docsStart + docsSize
+	storedSize := int64(len(storedData))
+
 	headerBytes := make([]byte, HeaderSize)
-	binary.LittleEndian.PutUint32(headerBytes[0:4], header.Magic)
-	binary.LittleEndian.PutUint32(headerBytes[4:8], header.Version)
-	binary.LittleEndian.PutUint32(headerBytes[8:12], header.TermCount)
+	binary.LittleEndian.PutUint32(headerBytes[0:4], MagicBytes)
+	binary.LittleEndian.PutUint32(headerBytes[4:8], w.version)
+	binary.LittleEndian.PutUint32(headerBytes[8:12], uint32(len(entries)))
+	binary.LittleEndian.PutUint32(headerBytes[12:16], uint32(len(section.Docs)))
+	binary.LittleEndian.PutUint64(headerBytes[16:24], uint64(dictStart))
+	binary.LittleEndian.PutUint64(headerBytes[24:32], uint64(dictSize))
+	binary.LittleEndian.PutUint64(headerBytes[32:40], uint64(postingsStart))
+	binary.LittleEndian.PutUint64(headerBytes[40:48], uint64(postingsSize))
+	binary.LittleEndian.PutUint64(headerBytes[48:56], uint64(docsStart))
+	binary.LittleEndian.PutUint64(headerBytes[56:64], uint64(docsSize))
+
+	footerBytes := encodeFooter(footer{
+		dictCRC:    crc32.ChecksumIEEE(dictData),
+		docCount:   uint32(len(section.Docs)),
+		dictOffset: dictStart,
+		dictSize:   dictSize,
+		postSize:   postingsSize,
+		headerCRC:  crc32.ChecksumIEEE(headerBytes),
+		postCRC:    crc32.ChecksumIEEE(postingsData),
+		docsCRC:    crc32.ChecksumIEEE(docsData),
+		storedCRC:  crc32.ChecksumIEEE(storedData),
+		fileSize:   storedStart + storedSize + int64(FooterSize),
+	}, w.version)
+
+	for _, part := range [][]byte{headerBytes, postingsData, dictData, docValuesData, impactsData, docsData, storedData, footerBytes} {
+		if _, err := f.Write(part); err != nil {
+			return "", fmt.Errorf("writing segment file: %w", err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		return "", fmt.Errorf("syncing segment file: %w", err)
+	}
+	f.Close()
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		return "", fmt.Errorf("renaming segment file: %w", err)
+	}
+	// The rename is only durable once the directory entry is, and the
+	// write-ahead log covering the segment is deleted as soon as this
+	// returns.
+	if err := syncDir(w.dataDir); err != nil {
+		return "", fmt.Errorf("syncing segment directory: %w", err)
+	}
+	return segmentName, nil
+}
 
-	if _, err := f.Write(headerBytes); err != nil {
-		return "", fmt.Errorf("writing header: %w", err)
+// syncDir fsyncs the directory dir so that the files created in and renamed
+// into it survive a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
 	}
+	defer d.Close()
+	return d.Sync()
+}
 
-	postingsStart, _ := f.Seek(0, 1)
+// encodeV1 encodes postings and the dictionary in the version 1 layout: one
+// JSON posting list per term and a JSON dictionary.
+func encodeV1(entries []index.TermEntry) ([]byte, []byte, error) {
+	var postingsData []byte
 	dict := make([]DictEntry, 0, len(entries))
-	docIDs := make(map[string]struct{})
 	for _, entry := range entries {
-		offset, _ := f.Seek(0, 1)
-		relativeOffset := offset - postingsStart
-		postingsData, err := json.Marshal(entry.Postings)
+		data, err := json.Marshal(entry.Postings)
 		if err != nil {
-			return "", fmt.Errorf("marshaling postings for term %q: %w", entry.Term, err)
-		}
-		if _, err := f.Write(postingsData); err != nil {
-			return "", fmt.Errorf("writing postings for term %q: %w", entry.Term, err)
+			return nil, nil, fmt.Errorf("marshaling postings for term %q: %w", entry.Term, err)
 		}
 		dict = append(dict, DictEntry{
 			Term:       entry.Term,
-			PostOffset: relativeOffset,
-			PostLen:    len(postingsData),
+			PostOffset: int64(len(postingsData)),
+			PostLen:    len(data),
 			DocFreq:    len(entry.Postings),
 		})
-		for _, p := range entry.Postings {
-			docIDs[p.DocID] = struct{}{}
-		}
+		postingsData = append(postingsData, data...)
 	}
-
-	postingsEnd, _ := f.Seek(0, 1)
-	postingsSize := postingsEnd - postingsStart
-	dictStart := postingsEnd
 	dictData, err := json.Marshal(dict)
-
 	if err != nil {
-		return "", fmt.Errorf("marshaling dictionary: %w", err)
-	}
-	if _, err := f.Write(dictData); err != nil {
-		return "", fmt.Errorf("writing dictionary: %w", err)
-	}
-	dictEnd, _ := f.Seek(0, 1)
-	dictSize := dictEnd - dictStart
-	checksum := crc32.ChecksumIEEE(dictData)
-	footer := make([]byte, FooterSize)
-	binary.LittleEndian.PutUint32(footer[0:4], checksum)
-	binary.LittleEndian.PutUint32(footer[4:8], uint32(len(docIDs)))
-	binary.LittleEndian.PutUint64(footer[8:16], uint64(dictStart))
-	binary.LittleEndian.PutUint64(footer[16:24], uint64(dictSize))
-	binary.LittleEndian.PutUint64(footer[24:32], uint64(postingsSize))
-	if _, err := f.Write(footer); err != nil {
-		return "", fmt.Errorf("writing footer: %w", err)
-	}
-	binary.LittleEndian.PutUint32(headerBytes[12:16], uint32(len(docIDs)))
-	binary.LittleEndian.PutUint64(headerBytes[16:24], uint64(dictStart))
-	binary.LittleEndian.PutUint64(headerBytes[24:32], uint64(dictSize))
-	binary.LittleEndian.PutUint64(headerBytes[32:40], uint64(postingsStart))
-	binary.LittleEndian.PutUint64(headerBytes[40:48], uint64(postingsSize))
-	if _, err := f.WriteAt(headerBytes, 0); err != nil {
-		return "", fmt.Errorf("updating header: %w", err)
+		return nil, nil, fmt.Errorf("marshaling dictionary: %w", err)
 	}
-	if err := f.Sync(); err != nil {
-		return "", fmt.Errorf("syncing segment file: %w", err)
+	return postingsData, dictData, nil
+}
+
+// encodeBinary encodes postings and the dictionary in the layout of version
+// 2 or later. The postings section holds every term's document stream, then
+// every term's frequency stream, then every term's position stream, each in
+// dictionary order. Version 2 follows it with a front-coded dictionary that
+// readers decode in full; later versions use a block dictionary that readers
+// search in place.
+func encodeBinary(entries []index.TermEntry, docs []index.DocLength, version uint32) ([]byte, []byte, error) {
+	ords := make(map[string]uint32, len(docs))
+	for i, doc := range docs {
+		ords[doc.DocID] = uint32(i)
 	}
-	f.Close()
-	if err := os.Rename(tmpPath, finalPath); err != nil {
-		return "", fmt.Errorf("renaming segment file: %w", err)
+	var docStreams, freqStreams, posStreams []byte
+	dict := make([]DictEntry, 0, len(entries))
+	for _, entry := range entries {
+		streams, err := encodePostings(entry.Postings, ords)
+		if err != nil {
+			return nil, nil, fmt.Errorf("encoding postings for term %q: %w", entry.Term, err)
+		}
+		dict = append(dict, DictEntry{
+			Term:       entry.Term,
+			DocFreq:    len(entry.Postings),
+			PostOffset: int64(len(docStreams)),
+			PostLen:    len(streams.docs),
+			FreqOffset: int64(len(freqStreams)),
+			FreqLen:    len(streams.freqs),
+			PosOffset:  int64(len(posStreams)),
+			PosLen:     len(streams.positions),
+		})
+		docStreams = append(docStreams, streams.docs...)
+		freqStreams = append(freqStreams, streams.freqs...)
+		posStreams = append(posStreams, streams.positions...)
 	}
-	return segmentName, nil
+	for i := range dict {
+		dict[i].FreqOffset += int64(len(docStreams))
+		dict[i].PosOffset += int64(len(docStreams) + len(freqStreams))
+	}
+	postingsData := make([]byte, 0, len(docStreams)+len(freqStreams)+len(posStreams))
+	postingsData = append(postingsData, docStreams...)
+	postingsData = append(postingsData, freqStreams...)
+	postingsData = append(postingsData, posStreams...)
+	if version == 2 {
+		return postingsData, encodeDictionary(dict), nil
+	}
+	return postingsData, encodeBlockDictionary(dict), nil
 }
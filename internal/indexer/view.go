@@ -0,0 +1,323 @@
+package indexer
+
+import (
+	"sort"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/segment"
+)
+
+// View is a point-in-time view of an engine's segments and memory index
+// used to execute a query over integer document numbers. Each segment's
+// ordinals are offset by the number of documents in the segments before it,
+// and the memory index is numbered after the last segment, so postings from
+// every source concatenate into a single list sorted by document number.
+//
+// Documents added after the view is taken are not part of it. A document
+// replaced or removed after it, in a segment or the memory index, drops out
+// of it rather than showing its new content, although the view's document
+// count and lengths still include it.
+//
+// A View pins its segments; it must be released with Close.
+type View struct {
+	engine   *Engine
+	readers  []*segment.Reader
+	bases    []uint32
+	mem      *index.MemoryIndex
+	memBase  uint32
+	memLimit uint32
+	docs     int64
+	tokens   int64
+	fields   map[string]int64
+}
+
+// AcquireView returns a View of the engine's current contents.
+func (e *Engine) AcquireView() *View {
+	e.readerMu.RLock()
+	readers := make([]*segment.Reader, len(e.readers))
+	copy(readers, e.readers)
+	for _, r := range readers {
+		r.IncRef()
+	}
+	mem := e.memIndex
+	e.readerMu.RUnlock()
+
+	v := &View{
+		engine:  e,
+		readers: readers,
+		bases:   make([]uint32, len(readers)),
+		mem:     mem,
+		fields:  make(map[string]int64),
+	}
+	var next uint32
+	for i, r := range readers {
+		v.bases[i] = next
+		next += r.DocCount()
+		stats := r.Stats()
+		v.docs += int64(stats.Docs)
+		v.tokens += stats.Tokens
+		for field, n := range stats.FieldTokens {
+			v.fields[field] += n
+		}
+	}
+	v.memBase = next
+	// Documents added to the memory index after this point are not part of
+	// the view; their ordinals would collide with numbers beyond the view.
+	v.memLimit = mem.OrdinalLimit()
+	v.docs += int64(mem.DocCount())
+	v.tokens += mem.TotalTokens()
+	for field, n := range mem.FieldTokens() {
+		v.fields[field] += n
+	}
+	return v
+}
+
+// Close releases the segments pinned by the view.
+func (v *View) Close() {
+	v.engine.releaseReaders(v.readers)
+	v.readers = nil
+}
+
+// Postings returns the live postings of an analysed, field-qualified term
+// (see index.FieldTerm), addressed by document number.
+func (v *View) Postings(term string) (index.DocPostingList, error) {
+	var result index.DocPostingList
+	for i, r := range v.readers {
+		postings, err := r.SearchOrdinals(term)
+		if err != nil {
+			v.engine.logger.Error("segment search failed",
+				"segment", r.Name(),
+				"error", err,
+			)
+			continue
+		}
+		for _, p := range postings {
+			p.Doc += v.bases[i]
+			result = append(result, p)
+		}
+	}
+	for _, p := range v.mem.SearchOrdinals(term) {
+		if p.Doc >= v.memLimit {
+			continue
+		}
+		p.Doc += v.memBase
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+// Impacts returns the impacts (see index.Impact) of postings, the result
+// of Postings for term, addressed by document number: those stored in each
+// segment, and those of the memory index, computed from its postings.
+// Impacts may cover deleted documents, which only widens their bounds. If
+// a segment's impacts cannot be read, nil is returned, and callers must
+// not bound the postings.
+func (v *View) Impacts(term string, postings index.DocPostingList) []index.Impact {
+	var result []index.Impact
+	for i, r := range v.readers {
+		impacts, err := r.Impacts(term)
+		if err != nil {
+			v.engine.logger.Error("segment impacts failed",
+				"segment", r.Name(),
+				"error", err,
+			)
+			return nil
+		}
+		for _, impact := range impacts {
+			impact.LastDoc += v.bases[i]
+			result = append(result, impact)
+		}
+	}
+	mem := postings[sort.Search(len(postings), func(i int) bool { return postings[i].Doc >= v.memBase }):]
+	field, _, _ := index.SplitFieldTerm(term)
+	return append(result, index.Impacts(mem, func(doc uint32) int {
+		return v.mem.FieldLengthsByOrdinal(doc - v.memBase)[field]
+	})...)
+}
+
+// TermsWithPrefix calls fn for every analysed, field-qualified term
+// starting with prefix in each segment and the memory index, with its
+// document frequency there, stopping early if fn returns false. A term is
+// reported once for each source holding it, in no particular order, and
+// document frequencies may include deleted documents.
+func (v *View) TermsWithPrefix(prefix string, fn func(term string, docFreq int) bool) {
+	stopped := false
+	for _, r := range v.readers {
+		err := r.TermsWithPrefix(prefix, func(t segment.TermInfo) bool {
+			stopped = !fn(t.Term, t.DocFreq)
+			return !stopped
+		})
+		if err != nil {
+			v.engine.logger.Error("segment term scan failed",
+				"segment", r.Name(),
+				"error", err,
+			)
+		}
+		if stopped {
+			return
+		}
+	}
+	v.mem.TermsWithPrefix(prefix, fn)
+}
+
+// AddSurfaceForms adds to dst the words the terms of the stored text fields
+// of every segment and the memory index were analysed from (see
+// analysis.SurfaceForms), including those of deleted documents and of
+// documents added to the memory index since the view was taken.
+func (v *View) AddSurfaceForms(dst analysis.SurfaceForms) {
+	for _, r := range v.readers {
+		r.AddSurfaceForms(v.engine.analyzer, dst)
+	}
+	v.mem.AddSurfaceForms(dst)
+}
+
+// Size returns one more than the highest document number in the view.
+func (v *View) Size() uint32 {
+	return v.memBase + v.memLimit
+}
+
+// DocID resolves a document number to the document's external ID.
+func (v *View) DocID(doc uint32) string {
+	if doc >= v.memBase {
+		return v.mem.DocIDByOrdinal(doc - v.memBase)
+	}
+	i := v.segmentOf(doc)
+	return v.readers[i].DocIDByOrdinal(doc - v.bases[i])
+}
+
+// DocNumber returns the number of the live document with the given
+// external ID, and false if the view does not hold it. The memory index,
+// and then the newest segments, are searched first.
+func (v *View) DocNumber(docID string) (uint32, bool) {
+	if ord, ok := v.mem.OrdinalOf(docID); ok && ord < v.memLimit {
+		return ord + v.memBase, true
+	}
+	for i := len(v.readers) - 1; i >= 0; i-- {
+		if ord, ok := v.readers[i].OrdinalOf(docID); ok {
+			return ord + v.bases[i], true
+		}
+	}
+	return 0, false
+}
+
+// DocLength returns the token count of the document with the given number.
+func (v *View) DocLength(doc uint32) int {
+	if doc >= v.memBase {
+		return v.mem.DocLengthByOrdinal(doc - v.memBase)
+	}
+	i := v.segmentOf(doc)
+	return v.readers[i].DocLengthByOrdinal(doc - v.bases[i])
+}
+
+// FieldLengths returns the per-field token counts of the document with the
+// given number. The returned map must not be modified.
+func (v *View) FieldLengths(doc uint32) map[string]int {
+	if doc >= v.memBase {
+		return v.mem.FieldLengthsByOrdinal(doc - v.memBase)
+	}
+	i := v.segmentOf(doc)
+	return v.readers[i].FieldLengthsByOrdinal(doc - v.bases[i])
+}
+
+// Stored returns the stored fields of the document with the given number,
+// and false if it has none, as for documents of segments written before
+// stored fields existed.
+func (v *View) Stored(doc uint32) (index.Document, bool, error) {
+	if doc >= v.memBase {
+		d, ok := v.mem.StoredByOrdinal(doc - v.memBase)
+		return d, ok, nil
+	}
+	i := v.segmentOf(doc)
+	return v.readers[i].StoredByOrdinal(doc - v.bases[i])
+}
+
+// Value returns the value of a metadata key of the engine's schema for the
+// document with the given number, and false if it has none. Segments
+// written with another type for the key hold no values of it.
+func (v *View) Value(key string, doc uint32) (index.Value, bool) {
+	if doc >= v.memBase {
+		return v.mem.ValueByOrdinal(key, doc-v.memBase)
+	}
+	i := v.segmentOf(doc)
+	if !v.hasValues(i, key) {
+		return index.Value{}, false
+	}
+	return v.readers[i].ValueByOrdinal(key, doc-v.bases[i])
+}
+
+// RangeDocs returns the numbers, sorted, of the live documents whose value
+// of a metadata key of the engine's schema lies in r.
+func (v *View) RangeDocs(key string, r index.Range) []uint32 {
+	var docs []uint32
+	for i, reader := range v.readers {
+		if !v.hasValues(i, key) {
+			continue
+		}
+		for _, ord := range reader.RangeOrdinals(key, r) {
+			docs = append(docs, ord+v.bases[i])
+		}
+	}
+	for _, ord := range v.mem.RangeOrdinals(key, r) {
+		if ord < v.memLimit {
+			docs = append(docs, ord+v.memBase)
+		}
+	}
+	return docs
+}
+
+// hasValues reports whether segment i holds values of key of the type the
+// engine's schema gives it.
+func (v *View) hasValues(i int, key string) bool {
+	t, ok := v.readers[i].ValueType(key)
+	return ok && t == v.engine.schema[key]
+}
+
+// segmentOf returns the index of the segment holding doc, which must be
+// below memBase.
+func (v *View) segmentOf(doc uint32) int {
+	return sort.Search(len(v.bases), func(i int) bool {
+		return v.bases[i] > doc
+	}) - 1
+}
+
+// TotalDocs returns the number of live documents in the view.
+func (v *View) TotalDocs() int64 {
+	return v.docs
+}
+
+// Fields returns the names of the fields that hold at least one token in
+// the view's live documents, sorted.
+func (v *View) Fields() []string {
+	fields := make([]string, 0, len(v.fields))
+	for field, n := range v.fields {
+		if n > 0 {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// FieldTokens returns the summed length of every field across the live
+// documents in the view. The returned map must not be modified.
+func (v *View) FieldTokens() map[string]int64 {
+	return v.fields
+}
+
+// AvgFieldLength returns the average length of field across the live
+// documents in the view, counting documents without the field as empty.
+func (v *View) AvgFieldLength(field string) float64 {
+	if v.docs == 0 {
+		return 0
+	}
+	return float64(v.fields[field]) / float64(v.docs)
+}
+
+// AvgDocLength returns the average length of the live documents in the view.
+func (v *View) AvgDocLength() float64 {
+	if v.docs == 0 {
+		return 0
+	}
+	return float64(v.tokens) / float64(v.docs)
+}
@@ -0,0 +1,426 @@
+// Package wal implements a per-shard, append-only write-ahead log for the
+// indexer. Every document mutation is appended to the log before it is
+// applied to the in-memory index, so documents that have not yet been flushed
+// to a segment can be recovered after a crash by replaying the log.
+//
+// The log is a sequence of files named wal_<seq>.log. Each record is framed
+// as a 4-byte little-endian payload length, a 4-byte CRC32 of the payload,
+// and a JSON-encoded Record. Rotating the log starts a new file so that the
+// files covering a flushed segment can be deleted as a unit.
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+)
+
+// Record operations.
+const (
+	OpIndex  = "index"
+	OpDelete = "delete"
+)
+
+// SyncPolicy controls when appended records are fsynced to disk.
+type SyncPolicy string
+
+const (
+	// SyncAlways fsyncs after every record. A record is durable as soon as
+	// Append returns.
+	SyncAlways SyncPolicy = "always"
+	// SyncBatch fsyncs after every BatchSize records. Up to BatchSize-1
+	// records can be lost on a crash.
+	SyncBatch SyncPolicy = "batch"
+	// SyncInterval fsyncs on a timer every Interval. Records appended within
+	// the last Interval can be lost on a machine crash.
+	SyncInterval SyncPolicy = "interval"
+)
+
+const (
+	filePrefix  = "wal_"
+	fileSuffix  = ".log"
+	frameHeader = 8
+	// maxRecordSize guards replay against reading a garbage length field.
+	maxRecordSize = 64 << 20
+)
+
+// Options configures a Log.
+type Options struct {
+	Policy    SyncPolicy
+	BatchSize int
+	Interval  time.Duration
+}
+
+// Record is a single logged mutation. Index records carry the document's
+// named text fields and metadata; records written before documents had
+// arbitrary fields carry only Title and Body.
+type Record struct {
+	Op       string            `json:"op"`
+	DocID    string            `json:"doc_id"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	Metadata map[string]any    `json:"metadata,omitempty"`
+	Title    string            `json:"title,omitempty"`
+	Body     string            `json:"body,omitempty"`
+}
+
+// Document returns the document of an index record, taking its fields from
+// Title and Body for records that have no Fields.
+func (r Record) Document() index.Document {
+	doc := index.Document{Fields: r.Fields, Metadata: r.Metadata}
+	if doc.Fields == nil {
+		doc.Fields = map[string]string{index.FieldTitle: r.Title, index.FieldBody: r.Body}
+	}
+	return doc
+}
+
+// Log is an append-only write-ahead log stored in a directory.
+type Log struct {
+	mu       sync.Mutex
+	dir      string
+	opts     Options
+	file     *os.File
+	buf      *bufio.Writer
+	seq      uint64
+	unsynced int
+	stop     chan struct{}
+	done     chan struct{}
+	logger   *slog.Logger
+}
+
+// Open starts a new log file in dir, numbered after any existing files.
+// Existing files are left in place for Replay and TruncateBefore.
+func Open(dir string, opts Options) (*Log, error) {
+	if opts.Policy == "" {
+		opts.Policy = SyncAlways
+	}
+	switch opts.Policy {
+	case SyncAlways:
+	case SyncBatch:
+		if opts.BatchSize < 1 {
+			return nil, fmt.Errorf("wal sync policy %q requires a positive batch size", opts.Policy)
+		}
+	case SyncInterval:
+		if opts.Interval <= 0 {
+			return nil, fmt.Errorf("wal sync policy %q requires a positive interval", opts.Policy)
+		}
+	default:
+		return nil, fmt.Errorf("unknown wal sync policy %q", opts.Policy)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating wal directory: %w", err)
+	}
+	seqs, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	var next uint64 = 1
+	if len(seqs) > 0 {
+		next = seqs[len(seqs)-1] + 1
+	}
+	l := &Log{
+		dir:    dir,
+		opts:   opts,
+		logger: slog.Default().With("component", "wal", "dir", dir),
+	}
+	if err := l.openFile(next); err != nil {
+		return nil, err
+	}
+	if opts.Policy == SyncInterval {
+		l.stop = make(chan struct{})
+		l.done = make(chan struct{})
+		go l.syncLoop()
+	}
+	return l, nil
+}
+
+// Append writes rec to the active log file and syncs it according to the
+// configured policy.
+func (l *Log) Append(rec Record) error {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encoding wal record: %w", err)
+	}
+	var header [frameHeader]byte
+	binary.LittleEndian.PutUint32(header[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(header[4:8], crc32.ChecksumIEEE(payload))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return fmt.Errorf("wal is closed")
+	}
+	if _, err := l.buf.Write(header[:]); err != nil {
+		return fmt.Errorf("writing wal record: %w", err)
+	}
+	if _, err := l.buf.Write(payload); err != nil {
+		return fmt.Errorf("writing wal record: %w", err)
+	}
+	l.unsynced++
+	switch l.opts.Policy {
+	case SyncAlways:
+		return l.syncLocked()
+	case SyncBatch:
+		if l.unsynced >= l.opts.BatchSize {
+			return l.syncLocked()
+		}
+		return nil
+	default:
+		// Hand the bytes to the OS so that a process crash (as opposed to a
+		// machine crash) loses nothing; the sync loop makes them durable.
+		if err := l.buf.Flush(); err != nil {
+			return fmt.Errorf("flushing wal buffer: %w", err)
+		}
+		return nil
+	}
+}
+
+// Sync flushes buffered records and fsyncs the active file.
+func (l *Log) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	return l.syncLocked()
+}
+
+// Rotate seals the active file and starts a new one. It returns the sequence
+// number of the new file: every record appended before Rotate lives in a
+// file with a lower sequence number.
+func (l *Log) Rotate() (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return 0, fmt.Errorf("wal is closed")
+	}
+	if err := l.syncLocked(); err != nil {
+		return 0, err
+	}
+	// The new file is opened before the sealed one is closed, so that the
+	// log stays writable if it cannot be.
+	sealed := l.file
+	if err := l.openFile(l.seq + 1); err != nil {
+		return 0, err
+	}
+	if err := sealed.Close(); err != nil {
+		return 0, fmt.Errorf("closing wal file: %w", err)
+	}
+	return l.seq, nil
+}
+
+// Seq returns the sequence number of the active file.
+func (l *Log) Seq() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.seq
+}
+
+// TruncateBefore deletes every log file with a sequence number lower than
+// seq. It is called once the records in those files are covered by a
+// durable segment.
+func (l *Log) TruncateBefore(seq uint64) error {
+	seqs, err := listSegments(l.dir)
+	if err != nil {
+		return err
+	}
+	for _, s := range seqs {
+		if s >= seq {
+			break
+		}
+		if err := os.Remove(segmentPath(l.dir, s)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing wal file: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close syncs and closes the active file and stops the sync loop.
+func (l *Log) Close() error {
+	if l.stop != nil {
+		close(l.stop)
+		<-l.done
+		l.stop = nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	return l.closeFileLocked()
+}
+
+// Replay reads every log file in dir in sequence order and calls fn for each
+// record. A torn or corrupt record at the tail of a file (from a crash in the
+// middle of a write) ends replay of that file; everything before it is
+// delivered.
+func Replay(dir string, fn func(Record) error) (int, error) {
+	seqs, err := listSegments(dir)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, seq := range seqs {
+		n, err := replayFile(segmentPath(dir, seq), fn)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// replayFile replays the records in a single log file.
+func replayFile(path string, fn func(Record) error) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("opening wal file: %w", err)
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	count := 0
+	var header [frameHeader]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return count, nil
+			}
+			slog.Warn("wal: truncated record header, stopping replay of file",
+				"file", filepath.Base(path), "records", count)
+			return count, nil
+		}
+		size := binary.LittleEndian.Uint32(header[0:4])
+		if size > maxRecordSize {
+			slog.Warn("wal: invalid record length, stopping replay of file",
+				"file", filepath.Base(path), "records", count, "length", size)
+			return count, nil
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			slog.Warn("wal: truncated record, stopping replay of file",
+				"file", filepath.Base(path), "records", count)
+			return count, nil
+		}
+		if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:8]) {
+			slog.Warn("wal: checksum mismatch, stopping replay of file",
+				"file", filepath.Base(path), "records", count)
+			return count, nil
+		}
+		var rec Record
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			return count, fmt.Errorf("decoding wal record in %s: %w", filepath.Base(path), err)
+		}
+		if err := fn(rec); err != nil {
+			return count, fmt.Errorf("applying wal record for %s: %w", rec.DocID, err)
+		}
+		count++
+	}
+}
+
+// syncLoop fsyncs the active file every Interval until Close is called.
+func (l *Log) syncLoop() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			if err := l.Sync(); err != nil {
+				l.logger.Error("periodic wal sync failed", "error", err)
+			}
+		}
+	}
+}
+
+// openFile creates the log file with the given sequence number and makes it
+// the active file, leaving the active file in place if it cannot be
+// created. The caller must hold l.mu or have exclusive access.
+func (l *Log) openFile(seq uint64) error {
+	f, err := os.OpenFile(segmentPath(l.dir, seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening wal file: %w", err)
+	}
+	l.file = f
+	l.buf = bufio.NewWriter(f)
+	l.seq = seq
+	l.unsynced = 0
+	return nil
+}
+
+// syncLocked flushes the buffer and fsyncs the active file. The caller must
+// hold l.mu.
+func (l *Log) syncLocked() error {
+	if err := l.buf.Flush(); err != nil {
+		return fmt.Errorf("flushing wal buffer: %w", err)
+	}
+	if l.unsynced == 0 {
+		return nil
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("syncing wal file: %w", err)
+	}
+	l.unsynced = 0
+	return nil
+}
+
+// closeFileLocked syncs and closes the active file. The caller must hold
+// l.mu.
+func (l *Log) closeFileLocked() error {
+	if err := l.syncLocked(); err != nil {
+		l.file.Close()
+		l.file = nil
+		return err
+	}
+	err := l.file.Close()
+	l.file = nil
+	if err != nil {
+		return fmt.Errorf("closing wal file: %w", err)
+	}
+	return nil
+}
+
+// listSegments returns the sequence numbers of the log files in dir in
+// ascending order.
+func listSegments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading wal directory: %w", err)
+	}
+	var seqs []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
+
+// segmentPath returns the path of the log file with the given sequence.
+func segmentPath(dir string, seq uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%s%020d%s", filePrefix, seq, fileSuffix))
+}
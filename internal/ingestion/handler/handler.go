@@ -1,5 +1,5 @@
 // Package handler exposes the HTTP endpoints for the ingestion service,
-// including document ingest and health check.
+// including document ingest, document deletion, and health check.
 package handler
 
 import (
@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/ingestion"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/ingestion/publisher"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/ingestion/validator"
@@ -18,13 +19,16 @@ import (
 // Handler serves HTTP requests for the ingestion API.
 type Handler struct {
 	publisher *publisher.Publisher
+	schema    index.Schema
 	logger    *slog.Logger
 }
 
-// New creates a Handler backed by the given Publisher.
-func New(pub *publisher.Publisher) *Handler {
+// New creates a Handler backed by the given Publisher that validates
+// metadata against the schema the indexers index it with.
+func New(pub *publisher.Publisher, schema index.Schema) *Handler {
 	return &Handler{
 		publisher: pub,
+		schema:    schema,
 		logger:    slog.Default().With("component", "ingestion-handler"),
 	}
 }
@@ -39,7 +43,7 @@ func (h *Handler) Ingest(w http.ResponseWriter, r *http.Request) {
 		h.writeError(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
-	if err := validator.ValidateIngestRequest(&req); err != nil {
+	if err := validator.ValidateIngestRequest(&req, h.schema); err != nil {
 		var validationErr *validator.ValidationError
 		if errors.As(err, &validationErr) {
 			h.writeJSON(w, http.StatusBadRequest, map[string]any{
@@ -69,6 +73,39 @@ func (h *Handler) Ingest(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusAccepted, resp)
 }
 
+// Delete handles DELETE /api/v1/documents/{id}. It marks the document deleted
+// and publishes a delete event so the indexer removes it from the index.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+	docID := r.PathValue("id")
+	if docID == "" {
+		h.writeError(w, http.StatusBadRequest, "document id is required")
+		return
+	}
+
+	resp, err := h.publisher.Delete(ctx, docID)
+	if err != nil {
+		statusCode := apperrors.HTTPStatusCode(err)
+		log.Error("deletion failed",
+			"doc_id", docID,
+			"error", err,
+			"status_code", statusCode,
+		)
+		if statusCode == http.StatusNotFound {
+			h.writeError(w, statusCode, "document not found")
+			return
+		}
+		h.writeError(w, statusCode, "deletion failed")
+		return
+	}
+	log.Info("document deleted",
+		"doc_id", resp.DocumentID,
+		"shard_id", resp.ShardID,
+	)
+	h.writeJSON(w, http.StatusAccepted, resp)
+}
+
 // Health returns a simple health-check response.
 func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
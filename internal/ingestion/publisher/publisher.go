@@ -77,9 +77,12 @@ func (p *Publisher) Ingest(ctx context.Context, req *ingestion.IngestRequest) (*
 	event := kafka.Event{
 		Key: strconv.Itoa(shardID),
 		Value: ingestion.IngestEvent{
+			Op:         ingestion.OpIndex,
 			DocumentID: docID,
 			Title:      req.Title,
 			Body:       req.Body,
+			Fields:     req.Fields,
+			Metadata:   req.Metadata,
 			ShardID:    shardID,
 			IngestedAt: time.Now().UTC(),
 		},
@@ -99,6 +102,54 @@ func (p *Publisher) Ingest(ctx context.Context, req *ingestion.IngestRequest) (*
 	}, nil
 }
 
+// Delete marks the document DELETED in PostgreSQL and publishes a delete
+// event to Kafka on the document's shard so the indexer tombstones it.
+// Deleting an already-deleted document is a no-op that still succeeds.
+func (p *Publisher) Delete(ctx context.Context, docID string) (*ingestion.DeleteResponse, error) {
+	var shardID int
+	var previous string
+	err := p.db.InTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			`SELECT shard_id, status FROM documents WHERE id = $1 FOR UPDATE`, docID,
+		).Scan(&shardID, &previous)
+		if err == sql.ErrNoRows {
+			return apperrors.New(apperrors.ErrDocumentNotFound, 404, "document not found")
+		}
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`UPDATE documents SET status = 'DELETED' WHERE id = $1`, docID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marking document deleted: %w", err)
+	}
+
+	resp := &ingestion.DeleteResponse{
+		DocumentID: docID,
+		Status:     "DELETED",
+		ShardID:    shardID,
+	}
+	if previous == "DELETED" {
+		return resp, nil
+	}
+
+	event := kafka.Event{
+		Key: strconv.Itoa(shardID),
+		Value: ingestion.IngestEvent{
+			Op:         ingestion.OpDelete,
+			DocumentID: docID,
+			ShardID:    shardID,
+			IngestedAt: time.Now().UTC(),
+		},
+	}
+	if err := p.producer.Publish(ctx, event); err != nil {
+		return nil, fmt.Errorf("publishing delete event: %w", err)
+	}
+	return resp, nil
+}
+
 // findByIdempotencyKey checks if a document with the given idempotency key
 // already exists and returns its status.
 func (p *Publisher) findByIdempotencyKey(ctx context.Context, key string) (*ingestion.IngestResponse, error) {
@@ -2,13 +2,22 @@
 // used by the document ingestion pipeline.
 package ingestion
 
-import "time"
+import (
+	"time"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+)
 
 // IngestRequest is the JSON body accepted by the ingestion HTTP endpoint.
+// Fields holds named text fields indexed alongside the title and body;
+// Metadata holds string, number and boolean values that are stored with the
+// document and returned with search results but not indexed.
 type IngestRequest struct {
-	Title          string `json:"title"`
-	Body           string `json:"body"`
-	IdempotencyKey string `json:"idempotency_key"`
+	Title          string            `json:"title"`
+	Body           string            `json:"body"`
+	Fields         map[string]string `json:"fields,omitempty"`
+	Metadata       map[string]any    `json:"metadata,omitempty"`
+	IdempotencyKey string            `json:"idempotency_key"`
 }
 
 // IngestResponse is returned to the caller after a document is accepted.
@@ -18,12 +27,42 @@ type IngestResponse struct {
 	ShardID    int    `json:"shard_id"`
 }
 
+// Event operations carried in IngestEvent.Op.
+const (
+	OpIndex  = "index"
+	OpDelete = "delete"
+)
+
 // IngestEvent is the Kafka message payload produced after a document is
-// persisted and ready for indexing.
+// persisted and ready for indexing, or after it is deleted. An empty Op is
+// treated as OpIndex for events produced before deletes were supported.
 type IngestEvent struct {
-	DocumentID string    `json:"document_id"`
-	Title      string    `json:"title"`
-	Body       string    `json:"body"`
-	ShardID    int       `json:"shard_id"`
-	IngestedAt time.Time `json:"ingested_at"`
+	Op         string            `json:"op,omitempty"`
+	DocumentID string            `json:"document_id"`
+	Title      string            `json:"title,omitempty"`
+	Body       string            `json:"body,omitempty"`
+	Fields     map[string]string `json:"fields,omitempty"`
+	Metadata   map[string]any    `json:"metadata,omitempty"`
+	ShardID    int               `json:"shard_id"`
+	IngestedAt time.Time         `json:"ingested_at"`
+}
+
+// Document returns the event's document: its Fields plus the title and
+// body as named text fields, and its Metadata.
+func (e IngestEvent) Document() index.Document {
+	fields := make(map[string]string, len(e.Fields)+2)
+	for name, text := range e.Fields {
+		fields[name] = text
+	}
+	fields[index.FieldTitle] = e.Title
+	fields[index.FieldBody] = e.Body
+	return index.Document{Fields: fields, Metadata: e.Metadata}
+}
+
+// DeleteResponse is returned to the caller after a document deletion is
+// accepted.
+type DeleteResponse struct {
+	DocumentID string `json:"document_id"`
+	Status     string `json:"status"`
+	ShardID    int    `json:"shard_id"`
 }
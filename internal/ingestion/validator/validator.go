@@ -1,12 +1,13 @@
 // Package validator provides input validation for ingestion requests. It
-// enforces title and body length constraints and returns per-field error
-// details.
+// enforces title, body, named field and metadata constraints and returns
+// per-field error details.
 package validator
 
 import (
 	"fmt"
 	"strings"
 
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/ingestion"
 )
 
@@ -14,8 +15,14 @@ const (
 	maxTitleLength = 1024
 	maxBodyLength  = 1048576
 	minBodyLength  = 1
+	maxFields      = 32
+	maxMetadata    = 32
+	maxMetaLength  = 1024
 )
 
+// invalidNameMessage describes the rules for field and metadata names.
+const invalidNameMessage = "names must start with a lower case letter, contain only lower case letters, digits and underscores, and be at most 64 characters"
+
 // ValidationError holds per-field validation failure messages.
 type ValidationError struct {
 	Fields map[string]string
@@ -29,9 +36,14 @@ func (e *ValidationError) Error() string {
 	return strings.Join(parts, "; ")
 }
 
-// ValidateIngestRequest checks that the title and body of the request meet
-// the required length constraints and returns a ValidationError if not.
-func ValidateIngestRequest(req *ingestion.IngestRequest) error {
+// ValidateIngestRequest checks that the title, body, named fields and
+// metadata of the request meet the required constraints and returns a
+// ValidationError if not. Named fields must have valid names other than
+// title and body, and each is limited to the maximum body length. Metadata
+// keys follow the same naming rules, and values must be strings of at most
+// 1024 characters, numbers or booleans. Values of the metadata keys of
+// schema must also convert to their key's type, so that they are indexed.
+func ValidateIngestRequest(req *ingestion.IngestRequest, schema index.Schema) error {
 	errs := make(map[string]string)
 
 	title := strings.TrimSpace(req.Title)
@@ -46,6 +58,45 @@ func ValidateIngestRequest(req *ingestion.IngestRequest) error {
 	} else if len(body) > maxBodyLength {
 		errs["body"] = fmt.Sprintf("body must be at most %d characters", maxBodyLength)
 	}
+	if len(req.Fields) > maxFields {
+		errs["fields"] = fmt.Sprintf("at most %d fields are allowed", maxFields)
+	}
+	for name, text := range req.Fields {
+		key := "fields." + name
+		switch {
+		case name == index.FieldTitle || name == index.FieldBody:
+			errs[key] = fmt.Sprintf("%s must be given as a top-level property", name)
+		case !index.ValidFieldName(name):
+			errs[key] = "field " + invalidNameMessage
+		case len(text) > maxBodyLength:
+			errs[key] = fmt.Sprintf("field must be at most %d characters", maxBodyLength)
+		}
+	}
+	if len(req.Metadata) > maxMetadata {
+		errs["metadata"] = fmt.Sprintf("at most %d metadata values are allowed", maxMetadata)
+	}
+	for name, value := range req.Metadata {
+		key := "metadata." + name
+		if !index.ValidFieldName(name) {
+			errs[key] = "metadata " + invalidNameMessage
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			if len(v) > maxMetaLength {
+				errs[key] = fmt.Sprintf("metadata value must be at most %d characters", maxMetaLength)
+			}
+		case float64, bool:
+		default:
+			errs[key] = "metadata value must be a string, number or boolean"
+			continue
+		}
+		if t, ok := schema[name]; ok && errs[key] == "" {
+			if _, err := t.Convert(value); err != nil {
+				errs[key] = err.Error()
+			}
+		}
+	}
 	if req.IdempotencyKey != "" && len(req.IdempotencyKey) > 255 {
 		errs["idempotency_key"] = "idempotency key must be at most 255 characters"
 	}
@@ -0,0 +1,242 @@
+// Package aggregation computes facets and statistics over the documents a
+// search matches: counts of the documents by metadata value (terms), by
+// range of values (range) and by date interval (date_histogram), and the
+// min, max, avg, sum and cardinality of a metadata key. Aggregations read
+// the doc values of the metadata schema. Each shard collects a Partial over
+// its own matching documents, and the partials of all shards are merged
+// into the final Result, which is exact.
+package aggregation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+)
+
+// Type is the kind of an aggregation.
+type Type string
+
+const (
+	// Terms counts the documents with each value of a key.
+	Terms Type = "terms"
+	// RangeType counts the documents whose value of a key lies in each of a
+	// list of ranges.
+	RangeType Type = "range"
+	// DateHistogram counts the documents whose date falls in each interval.
+	DateHistogram Type = "date_histogram"
+	// Min is the smallest value of a key.
+	Min Type = "min"
+	// Max is the largest value of a key.
+	Max Type = "max"
+	// Avg is the mean of the values of a key.
+	Avg Type = "avg"
+	// Sum is the sum of the values of a key.
+	Sum Type = "sum"
+	// Cardinality is the number of distinct values of a key.
+	Cardinality Type = "cardinality"
+)
+
+// Limits on an aggregation request.
+const (
+	maxAggregations  = 20
+	defaultTermsSize = 10
+	maxTermsSize     = 1000
+	maxRanges        = 100
+)
+
+// Request is one named aggregation of a search.
+type Request struct {
+	Name  string
+	Type  Type
+	Field string
+	// ValueType is the type of Field in the schema.
+	ValueType index.ValueType
+	// Size is the number of buckets of a terms aggregation.
+	Size int
+	// Ranges are the buckets of a range aggregation.
+	Ranges []Range
+	// Interval is the bucket width of a date histogram: minute, hour,
+	// day, week (starting on Monday), month, quarter or year, in UTC, or
+	// a fixed duration such as 90m.
+	Interval string
+
+	fixed time.Duration
+}
+
+// Range is a bucket of a range aggregation: the values from From,
+// inclusive, to To, exclusive. A nil bound leaves that end open.
+type Range struct {
+	Key      string
+	From, To *index.Value
+}
+
+// String returns the request in a canonical form, such as
+// by_category:terms(category,10), for cache keys.
+func (r Request) String() string {
+	args := []string{r.Field}
+	switch r.Type {
+	case Terms:
+		args = append(args, fmt.Sprint(r.Size))
+	case RangeType:
+		for _, rg := range r.Ranges {
+			args = append(args, fmt.Sprintf("%q=%s", rg.Key, r.rangeKey(rg)))
+		}
+	case DateHistogram:
+		args = append(args, r.Interval)
+	}
+	return fmt.Sprintf("%s:%s(%s)", r.Name, r.Type, strings.Join(args, ","))
+}
+
+// rangeKey returns the default key of a range bucket, from-to with * for an
+// open end.
+func (r Request) rangeKey(rg Range) string {
+	bound := func(v *index.Value) string {
+		if v == nil {
+			return "*"
+		}
+		return r.ValueType.Format(*v)
+	}
+	return bound(rg.From) + "-" + bound(rg.To)
+}
+
+// requestJSON is the encoding of the body of a request, the object under
+// its type.
+type requestJSON struct {
+	Field    string      `json:"field"`
+	Size     *int        `json:"size"`
+	Ranges   []rangeJSON `json:"ranges"`
+	Interval string      `json:"interval"`
+}
+
+// rangeJSON is the encoding of a range bucket.
+type rangeJSON struct {
+	Key  string `json:"key"`
+	From any    `json:"from"`
+	To   any    `json:"to"`
+}
+
+// Parse parses the aggregations of a search, given as a JSON object of
+// names mapped to an object holding one aggregation type and its
+// parameters, over the metadata keys of schema:
+//
+//	{"by_category": {"terms": {"field": "category", "size": 5}},
+//	 "by_price": {"range": {"field": "price", "ranges": [{"to": 10}, {"from": 10}]}},
+//	 "per_month": {"date_histogram": {"field": "created_at", "interval": "month"}},
+//	 "avg_price": {"avg": {"field": "price"}}}
+//
+// Range bounds are values of the field's type: numbers, or strings for
+// dates. The requests are returned sorted by name.
+func Parse(s string, schema index.Schema) ([]Request, error) {
+	var raw map[string]map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(s), &raw); err != nil {
+		return nil, fmt.Errorf("aggs must be a JSON object of named aggregations: %w", err)
+	}
+	if len(raw) > maxAggregations {
+		return nil, fmt.Errorf("at most %d aggregations are allowed", maxAggregations)
+	}
+	reqs := make([]Request, 0, len(raw))
+	for name, body := range raw {
+		if name == "" {
+			return nil, errors.New("aggregation names must not be empty")
+		}
+		if len(body) != 1 {
+			return nil, fmt.Errorf("aggregation %q must have exactly one type", name)
+		}
+		for typ, params := range body {
+			req, err := parseRequest(name, Type(typ), params, schema)
+			if err != nil {
+				return nil, fmt.Errorf("aggregation %q: %w", name, err)
+			}
+			reqs = append(reqs, req)
+		}
+	}
+	sort.Slice(reqs, func(i, j int) bool { return reqs[i].Name < reqs[j].Name })
+	return reqs, nil
+}
+
+// parseRequest parses the parameters of one aggregation.
+func parseRequest(name string, typ Type, params json.RawMessage, schema index.Schema) (Request, error) {
+	var body requestJSON
+	if err := json.Unmarshal(params, &body); err != nil {
+		return Request{}, fmt.Errorf("parsing %s parameters: %w", typ, err)
+	}
+	t, ok := schema[body.Field]
+	if !ok {
+		return Request{}, fmt.Errorf("field %q is not a metadata key of the schema", body.Field)
+	}
+	req := Request{Name: name, Type: typ, Field: body.Field, ValueType: t}
+	switch typ {
+	case Terms:
+		req.Size = defaultTermsSize
+		if body.Size != nil {
+			if *body.Size < 1 || *body.Size > maxTermsSize {
+				return Request{}, fmt.Errorf("size must be between 1 and %d", maxTermsSize)
+			}
+			req.Size = *body.Size
+		}
+	case RangeType:
+		if !t.Numeric() || t == index.TypeBoolean {
+			return Request{}, fmt.Errorf("range needs an integer, float or date field, not %s", t)
+		}
+		if len(body.Ranges) == 0 || len(body.Ranges) > maxRanges {
+			return Request{}, fmt.Errorf("range needs between 1 and %d ranges", maxRanges)
+		}
+		for _, rj := range body.Ranges {
+			rg := Range{Key: rj.Key}
+			var err error
+			if rg.From, err = bound(t, rj.From); err != nil {
+				return Request{}, fmt.Errorf("range from: %w", err)
+			}
+			if rg.To, err = bound(t, rj.To); err != nil {
+				return Request{}, fmt.Errorf("range to: %w", err)
+			}
+			if rg.Key == "" {
+				rg.Key = req.rangeKey(rg)
+			}
+			req.Ranges = append(req.Ranges, rg)
+		}
+	case DateHistogram:
+		if t != index.TypeDate {
+			return Request{}, fmt.Errorf("date_histogram needs a date field, not %s", t)
+		}
+		req.Interval = body.Interval
+		switch body.Interval {
+		case "minute", "hour", "day", "week", "month", "quarter", "year":
+		default:
+			d, err := time.ParseDuration(body.Interval)
+			if err != nil || d < time.Millisecond {
+				return Request{}, fmt.Errorf("interval must be minute, hour, day, week, month, quarter, year or a duration of at least 1ms, not %q", body.Interval)
+			}
+			req.fixed = d
+		}
+	case Min, Max:
+		if !t.Numeric() {
+			return Request{}, fmt.Errorf("%s needs a numeric or date field, not %s", typ, t)
+		}
+	case Avg, Sum:
+		if t != index.TypeInteger && t != index.TypeFloat {
+			return Request{}, fmt.Errorf("%s needs an integer or float field, not %s", typ, t)
+		}
+	case Cardinality:
+	default:
+		return Request{}, fmt.Errorf("unknown aggregation type %q", typ)
+	}
+	return req, nil
+}
+
+// bound converts a bound of a range bucket, nil for an open end.
+func bound(t index.ValueType, v any) (*index.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	value, err := t.Convert(v)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
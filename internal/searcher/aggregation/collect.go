@@ -0,0 +1,268 @@
+package aggregation
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+)
+
+// Values resolves the metadata values of documents.
+type Values interface {
+	Value(key string, doc uint32) (index.Value, bool)
+}
+
+// Partial is the state of one aggregation over the documents of one shard,
+// which merges with the states of the other shards. Documents without a
+// value of the aggregated key are left out.
+type Partial struct {
+	// counts holds the documents per value for terms and cardinality, and
+	// per bucket start for date histograms.
+	counts map[index.Value]int64
+	// buckets holds the documents in each range of a range aggregation.
+	buckets []int64
+	count   int64
+	sum     float64
+	min     float64
+	max     float64
+}
+
+// Collect computes the partial state of each of reqs over docs, whose
+// values are read from values.
+func Collect(reqs []Request, docs []uint32, values Values) []*Partial {
+	partials := make([]*Partial, len(reqs))
+	for i, req := range reqs {
+		p := newPartial(req)
+		for _, doc := range docs {
+			if v, ok := values.Value(req.Field, doc); ok {
+				p.add(req, v)
+			}
+		}
+		partials[i] = p
+	}
+	return partials
+}
+
+// newPartial returns the empty state of req.
+func newPartial(req Request) *Partial {
+	p := &Partial{min: math.Inf(1), max: math.Inf(-1)}
+	switch req.Type {
+	case Terms, Cardinality, DateHistogram:
+		p.counts = make(map[index.Value]int64)
+	case RangeType:
+		p.buckets = make([]int64, len(req.Ranges))
+	}
+	return p
+}
+
+// add adds a document with value v to the state of req.
+func (p *Partial) add(req Request, v index.Value) {
+	p.count++
+	switch req.Type {
+	case Terms, Cardinality:
+		p.counts[v]++
+	case DateHistogram:
+		p.counts[index.Value{Num: req.bucketStart(v.Num)}]++
+	case RangeType:
+		for i, rg := range req.Ranges {
+			r := index.Range{Lower: rg.From, Upper: rg.To, IncludeLower: true}
+			if r.Contains(req.ValueType, v) {
+				p.buckets[i]++
+			}
+		}
+	default:
+		p.sum += v.Num
+		p.min = math.Min(p.min, v.Num)
+		p.max = math.Max(p.max, v.Num)
+	}
+}
+
+// Merge adds the states in from to those in into, both of the same
+// requests, and returns into.
+func Merge(into, from []*Partial) []*Partial {
+	for i, p := range from {
+		q := into[i]
+		q.count += p.count
+		q.sum += p.sum
+		q.min = math.Min(q.min, p.min)
+		q.max = math.Max(q.max, p.max)
+		for v, n := range p.counts {
+			q.counts[v] += n
+		}
+		for j, n := range p.buckets {
+			q.buckets[j] += n
+		}
+	}
+	return into
+}
+
+// bucketStart returns the start, in Unix milliseconds, of the date
+// histogram interval holding the instant ms.
+func (r Request) bucketStart(ms float64) float64 {
+	if r.fixed > 0 {
+		width := float64(r.fixed.Milliseconds())
+		return math.Floor(ms/width) * width
+	}
+	t := time.UnixMilli(int64(ms)).UTC()
+	y, m, d := t.Date()
+	switch r.Interval {
+	case "minute":
+		t = t.Truncate(time.Minute)
+	case "hour":
+		t = t.Truncate(time.Hour)
+	case "day":
+		t = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	case "week":
+		t = time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+	case "month":
+		t = time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case "quarter":
+		t = time.Date(y, (m-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+	case "year":
+		t = time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return float64(t.UnixMilli())
+}
+
+// Result is the outcome of one aggregation: Buckets for terms, range and
+// date histogram aggregations, and Value for metrics, which is nil for min,
+// max and avg over no values.
+type Result struct {
+	Buckets []Bucket `json:"buckets,omitempty"`
+	// SumOtherDocCount counts the documents of the terms beyond the
+	// requested size.
+	SumOtherDocCount int64    `json:"sum_other_doc_count,omitempty"`
+	Value            *float64 `json:"value,omitempty"`
+	// ValueAsString is the text of a date Value.
+	ValueAsString string `json:"value_as_string,omitempty"`
+}
+
+// MarshalJSON encodes a bucket aggregation with its buckets, even if there
+// are none, and a metric with its value, even if it is null.
+func (r Result) MarshalJSON() ([]byte, error) {
+	if r.Buckets != nil {
+		return json.Marshal(struct {
+			Buckets          []Bucket `json:"buckets"`
+			SumOtherDocCount int64    `json:"sum_other_doc_count,omitempty"`
+		}{r.Buckets, r.SumOtherDocCount})
+	}
+	return json.Marshal(struct {
+		Value         *float64 `json:"value"`
+		ValueAsString string   `json:"value_as_string,omitempty"`
+	}{r.Value, r.ValueAsString})
+}
+
+// Bucket is a group of the matching documents. Key is a string for a
+// keyword or range and a number otherwise, with KeyAsString giving the
+// text of a date or boolean. From and To are the bounds of a range.
+type Bucket struct {
+	Key         any      `json:"key"`
+	KeyAsString string   `json:"key_as_string,omitempty"`
+	From        *float64 `json:"from,omitempty"`
+	To          *float64 `json:"to,omitempty"`
+	DocCount    int64    `json:"doc_count"`
+}
+
+// Results returns the result of each of reqs, by name, from their merged
+// partial states. Terms are ordered by descending document count and then
+// by value, ranges as requested, and date histogram buckets by date;
+// intervals without documents are left out.
+func Results(reqs []Request, partials []*Partial) map[string]Result {
+	results := make(map[string]Result, len(reqs))
+	for i, req := range reqs {
+		results[req.Name] = req.result(partials[i])
+	}
+	return results
+}
+
+// result returns the result of req from its state p.
+func (r Request) result(p *Partial) Result {
+	switch r.Type {
+	case Terms:
+		values := r.sortedValues(p.counts, func(a, b index.Value) bool {
+			if p.counts[a] != p.counts[b] {
+				return p.counts[a] > p.counts[b]
+			}
+			return r.ValueType.Compare(a, b) < 0
+		})
+		res := Result{Buckets: make([]Bucket, 0, min(len(values), r.Size))}
+		for j, v := range values {
+			if j >= r.Size {
+				res.SumOtherDocCount += p.counts[v]
+				continue
+			}
+			res.Buckets = append(res.Buckets, r.bucket(v, p.counts[v]))
+		}
+		return res
+	case DateHistogram:
+		values := r.sortedValues(p.counts, func(a, b index.Value) bool { return a.Num < b.Num })
+		res := Result{Buckets: make([]Bucket, len(values))}
+		for j, v := range values {
+			res.Buckets[j] = r.bucket(v, p.counts[v])
+		}
+		return res
+	case RangeType:
+		res := Result{Buckets: make([]Bucket, len(r.Ranges))}
+		for j, rg := range r.Ranges {
+			b := Bucket{Key: rg.Key, DocCount: p.buckets[j]}
+			if rg.From != nil {
+				b.From = &rg.From.Num
+			}
+			if rg.To != nil {
+				b.To = &rg.To.Num
+			}
+			res.Buckets[j] = b
+		}
+		return res
+	case Cardinality:
+		n := float64(len(p.counts))
+		return Result{Value: &n}
+	case Sum:
+		return Result{Value: &p.sum}
+	}
+	if p.count == 0 {
+		return Result{}
+	}
+	var v float64
+	switch r.Type {
+	case Min:
+		v = p.min
+	case Max:
+		v = p.max
+	case Avg:
+		v = p.sum / float64(p.count)
+	}
+	res := Result{Value: &v}
+	if r.ValueType == index.TypeDate {
+		res.ValueAsString = r.ValueType.Format(index.Value{Num: v})
+	}
+	return res
+}
+
+// sortedValues returns the values of counts ordered by less.
+func (r Request) sortedValues(counts map[index.Value]int64, less func(a, b index.Value) bool) []index.Value {
+	values := make([]index.Value, 0, len(counts))
+	for v := range counts {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool { return less(values[i], values[j]) })
+	return values
+}
+
+// bucket returns the bucket of the documents with value v, or in the date
+// histogram interval starting at v.
+func (r Request) bucket(v index.Value, count int64) Bucket {
+	b := Bucket{DocCount: count}
+	switch r.ValueType {
+	case index.TypeKeyword:
+		b.Key = v.Str
+	case index.TypeDate, index.TypeBoolean:
+		b.Key = v.Num
+		b.KeyAsString = r.ValueType.Format(v)
+	default:
+		b.Key = v.Num
+	}
+	return b
+}
@@ -0,0 +1,114 @@
+// Package automaton builds deterministic finite automata that match index
+// terms, for expanding wildcard and fuzzy queries against a term
+// dictionary. Automata are built eagerly, are immutable and are safe for
+// concurrent use.
+package automaton
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxStates bounds the number of states of an automaton; patterns that
+// need more are rejected with ErrTooComplex.
+const MaxStates = 10000
+
+// ErrTooComplex is returned for a pattern whose automaton would exceed
+// MaxStates.
+var ErrTooComplex = errors.New("automaton too complex")
+
+// DFA is a deterministic finite automaton over the runes of a term. Runes
+// are mapped to classes, one for each rune the automaton distinguishes and
+// one shared by every other rune, and each accepting state carries a value,
+// such as an edit distance.
+type DFA struct {
+	classes  map[rune]int
+	nclasses int
+	// delta holds the next state for every state and class, at
+	// state*nclasses+class, or -1 for a state that cannot accept.
+	delta []int32
+	// accept holds the value of each accepting state, or -1.
+	accept []int
+}
+
+// otherRune stands for the runes an automaton does not distinguish while
+// it is built.
+const otherRune rune = -1
+
+// build constructs a DFA by exploring every state reachable from start.
+// States are identified by key; step returns the key of the state reached
+// on r, which is otherRune for the class of undistinguished runes, or ""
+// for one that cannot accept. value returns the value of an accepting
+// state, or -1.
+func build(alphabet []rune, start string, step func(state string, r rune) string, value func(state string) int) (*DFA, error) {
+	d := &DFA{classes: make(map[rune]int)}
+	runes := make([]rune, 0, len(alphabet)+1)
+	for _, r := range alphabet {
+		if _, ok := d.classes[r]; !ok {
+			d.classes[r] = len(runes)
+			runes = append(runes, r)
+		}
+	}
+	runes = append(runes, otherRune)
+	d.nclasses = len(runes)
+
+	ids := map[string]int32{start: 0}
+	keys := []string{start}
+	for i := 0; i < len(keys); i++ {
+		key := keys[i]
+		d.accept = append(d.accept, value(key))
+		for _, r := range runes {
+			next := step(key, r)
+			if next == "" {
+				d.delta = append(d.delta, -1)
+				continue
+			}
+			id, ok := ids[next]
+			if !ok {
+				if len(keys) == MaxStates {
+					return nil, fmt.Errorf("%w: more than %d states", ErrTooComplex, MaxStates)
+				}
+				id = int32(len(keys))
+				ids[next] = id
+				keys = append(keys, next)
+			}
+			d.delta = append(d.delta, id)
+		}
+	}
+	return d, nil
+}
+
+// Match runs the automaton over term. It returns the value of the state it
+// ends in and whether that state accepts.
+func (d *DFA) Match(term string) (int, bool) {
+	state := 0
+	for _, r := range term {
+		if state = d.Step(state, r); state < 0 {
+			return 0, false
+		}
+	}
+	return d.Accept(state)
+}
+
+// Step returns the state reached from state on r, or -1 if no term
+// continuing that way is accepted. The start state is 0. Step lets a
+// caller run the automaton along the paths of a trie, abandoning those
+// that cannot match.
+func (d *DFA) Step(state int, r rune) int {
+	class, ok := d.classes[r]
+	if !ok {
+		class = d.nclasses - 1
+	}
+	return int(d.delta[state*d.nclasses+class])
+}
+
+// Accept returns the value of state and whether it accepts.
+func (d *DFA) Accept(state int) (int, bool) {
+	v := d.accept[state]
+	return v, v >= 0
+}
+
+// States returns the number of states of the automaton.
+func (d *DFA) States() int {
+	return len(d.accept)
+}
@@ -0,0 +1,50 @@
+package automaton
+
+// MaxEdits is the largest edit distance a Levenshtein automaton accepts.
+const MaxEdits = 2
+
+// Levenshtein returns an automaton accepting the terms within maxEdits
+// insertions, deletions or substitutions of term, whose values are their
+// edit distances to term. maxEdits is clamped to [0, MaxEdits].
+//
+// A state is the row of edit distances between the input read so far and
+// every prefix of term, the row of the classic dynamic program, with
+// distances above maxEdits merged. Only finitely many such rows are
+// reachable, so they form a DFA; a row whose every entry exceeds maxEdits
+// can no longer accept.
+func Levenshtein(term string, maxEdits int) (*DFA, error) {
+	maxEdits = min(max(maxEdits, 0), MaxEdits)
+	target := []rune(term)
+	limit := byte(maxEdits + 1)
+
+	start := make([]byte, len(target)+1)
+	for i := range start {
+		start[i] = min(byte(i), limit)
+	}
+	step := func(state string, r rune) string {
+		row := make([]byte, len(state))
+		row[0] = min(state[0]+1, limit)
+		alive := row[0] < limit
+		for i := 1; i < len(row); i++ {
+			cost := state[i-1]
+			if target[i-1] != r {
+				cost++
+			}
+			row[i] = min(cost, state[i]+1, row[i-1]+1, limit)
+			if row[i] < limit {
+				alive = true
+			}
+		}
+		if !alive {
+			return ""
+		}
+		return string(row)
+	}
+	value := func(state string) int {
+		if d := state[len(state)-1]; d < limit {
+			return int(d)
+		}
+		return -1
+	}
+	return build(target, string(start), step, value)
+}
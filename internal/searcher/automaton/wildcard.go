@@ -0,0 +1,113 @@
+package automaton
+
+// Wildcard returns an automaton accepting the terms matched by a wildcard
+// pattern, with a value of 0. In the pattern, ? matches any one rune, *
+// matches any run of runes, including none, and a backslash makes the rune
+// after it literal.
+//
+// A state is the set of pattern positions the input read so far can have
+// reached, the subset construction of the pattern's NFA.
+func Wildcard(pattern string) (*DFA, error) {
+	elems := parseWildcard(pattern)
+	var alphabet []rune
+	for _, e := range elems {
+		if e.kind == literal {
+			alphabet = append(alphabet, e.r)
+		}
+	}
+
+	// closure adds the positions reachable by letting each * match nothing.
+	closure := func(set []byte) {
+		for i, e := range elems {
+			if set[i] == 1 && e.kind == anyRun {
+				set[i+1] = 1
+			}
+		}
+	}
+	start := make([]byte, len(elems)+1)
+	start[0] = 1
+	closure(start)
+	step := func(state string, r rune) string {
+		set := make([]byte, len(state))
+		alive := false
+		for i, e := range elems {
+			if state[i] == 0 {
+				continue
+			}
+			switch {
+			case e.kind == anyRun:
+				set[i] = 1
+			case e.kind == anyRune, e.kind == literal && e.r == r:
+				set[i+1] = 1
+			default:
+				continue
+			}
+			alive = true
+		}
+		if !alive {
+			return ""
+		}
+		closure(set)
+		return string(set)
+	}
+	value := func(state string) int {
+		if state[len(elems)] == 1 {
+			return 0
+		}
+		return -1
+	}
+	return build(alphabet, string(start), step, value)
+}
+
+// wildcardKind is the kind of an element of a wildcard pattern.
+type wildcardKind int
+
+const (
+	literal wildcardKind = iota
+	anyRune
+	anyRun
+)
+
+type wildcardElem struct {
+	kind wildcardKind
+	r    rune
+}
+
+// parseWildcard splits a pattern into its elements.
+func parseWildcard(pattern string) []wildcardElem {
+	runes := []rune(pattern)
+	var elems []wildcardElem
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			elems = append(elems, wildcardElem{kind: literal, r: runes[i]})
+		case r == '?':
+			elems = append(elems, wildcardElem{kind: anyRune})
+		case r == '*':
+			// Consecutive stars match the same as one.
+			if len(elems) > 0 && elems[len(elems)-1].kind == anyRun {
+				continue
+			}
+			elems = append(elems, wildcardElem{kind: anyRun})
+		default:
+			elems = append(elems, wildcardElem{kind: literal, r: r})
+		}
+	}
+	return elems
+}
+
+// LiteralPrefix returns the runes of a wildcard pattern before its first
+// wildcard, unescaped, and whether the rest of the pattern is a single *,
+// making it a plain prefix pattern.
+func LiteralPrefix(pattern string) (string, bool) {
+	elems := parseWildcard(pattern)
+	var prefix []rune
+	for i, e := range elems {
+		if e.kind != literal {
+			return string(prefix), e.kind == anyRun && i == len(elems)-1
+		}
+		prefix = append(prefix, e.r)
+	}
+	return string(prefix), false
+}
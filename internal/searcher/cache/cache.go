@@ -1,6 +1,6 @@
 // Package cache provides a Redis-backed query cache with singleflight
-// deduplication. Queries are normalised and hashed so that semantically
-// identical searches share the same cache entry.
+// deduplication. Parsed queries are normalised and hashed so that
+// semantically identical searches share the same cache entry.
 package cache
 
 import (
@@ -14,6 +14,7 @@ import (
 	"sync/atomic"
 
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/executor"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/config"
 	pkgredis "github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/redis"
 	"golang.org/x/sync/singleflight"
@@ -42,8 +43,8 @@ func New(client *pkgredis.Client, cfg config.RedisConfig) *QueryCache {
 }
 
 // Get reads a cached search result. Returns (nil, false) on miss or error.
-func (c *QueryCache) Get(ctx context.Context, query string, limit int) (*executor.SearchResult, bool) {
-	key := c.buildKey(query, limit)
+func (c *QueryCache) Get(ctx context.Context, plan *parser.QueryPlan, opts executor.Options) (*executor.SearchResult, bool) {
+	key := c.buildKey(plan, opts)
 	data, err := c.client.Get(ctx, key)
 	if err != nil {
 		if pkgredis.IsNilError(err) {
@@ -61,13 +62,13 @@ func (c *QueryCache) Get(ctx context.Context, query string, limit int) (*executo
 		return nil, false
 	}
 	c.hits.Add(1)
-	c.logger.Debug("cache hit", "query", query, "key", key)
+	c.logger.Debug("cache hit", "query", plan.RawQuery, "key", key)
 	return &result, true
 }
 
 // Set stores a search result in the cache with the configured TTL.
-func (c *QueryCache) Set(ctx context.Context, query string, limit int, result *executor.SearchResult) {
-	key := c.buildKey(query, limit)
+func (c *QueryCache) Set(ctx context.Context, plan *parser.QueryPlan, opts executor.Options, result *executor.SearchResult) {
+	key := c.buildKey(plan, opts)
 	data, err := json.Marshal(result)
 	if err != nil {
 		c.logger.Error("cache marshal failed", "key", key, "error", err)
@@ -83,23 +84,23 @@ func (c *QueryCache) Set(ctx context.Context, query string, limit int, result *e
 // prevents thundering-herd cache-miss storms.
 func (c *QueryCache) GetOrCompute(
 	ctx context.Context,
-	query string,
-	limit int,
+	plan *parser.QueryPlan,
+	opts executor.Options,
 	computeFn func() (*executor.SearchResult, error),
 ) (*executor.SearchResult, bool, error) {
-	if result, ok := c.Get(ctx, query, limit); ok {
+	if result, ok := c.Get(ctx, plan, opts); ok {
 		return result, true, nil
 	}
-	key := c.buildKey(query, limit)
+	key := c.buildKey(plan, opts)
 	val, err, _ := c.group.Do(key, func() (interface{}, error) {
-		if result, ok := c.Get(ctx, query, limit); ok {
+		if result, ok := c.Get(ctx, plan, opts); ok {
 			return result, nil
 		}
 		result, err := computeFn()
 		if err != nil {
 			return nil, err
 		}
-		c.Set(ctx, query, limit, result)
+		c.Set(ctx, plan, opts, result)
 		return result, nil
 	})
 	if err != nil {
@@ -125,46 +126,69 @@ func (c *QueryCache) Stats() (hits, misses int64) {
 }
 
 // buildKey produces a deterministic SHA-256 cache key for the normalised
-// query and limit.
-func (c *QueryCache) buildKey(query string, limit int) string {
-	normalized := normalizeQuery(query)
-	raw := fmt.Sprintf("%s:limit=%d", normalized, limit)
+// query, the sort, the page of results (limit, offset and search_after
+// cursor), the requested stored fields, the highlighting settings, the
+// aggregations, whether the total must be exact, the similarity and
+// whether scores are explained.
+// Filters are clauses of the query and range queries render canonically,
+// so equal filters share entries however they were written.
+func (c *QueryCache) buildKey(plan *parser.QueryPlan, opts executor.Options) string {
+	normalized := normalizeQuery(plan.Root)
+	raw := fmt.Sprintf("%s:limit=%d", normalized, opts.Limit)
+	if len(opts.Sort) > 0 {
+		fields := make([]string, len(opts.Sort))
+		for i, f := range opts.Sort {
+			fields[i] = f.String()
+		}
+		raw += ":sort=" + strings.Join(fields, ",")
+	}
+	if opts.Offset > 0 {
+		raw += fmt.Sprintf(":offset=%d", opts.Offset)
+	}
+	if opts.SearchAfter != nil {
+		raw += ":after=" + opts.SearchAfter.String()
+	}
+	if len(opts.Fields) > 0 {
+		fields := append([]string(nil), opts.Fields...)
+		sort.Strings(fields)
+		raw += ":fields=" + strings.Join(fields, ",")
+	}
+	if h := opts.Highlight; h != nil {
+		raw += fmt.Sprintf(":highlight=%q,%q,%d,%d,%s", h.PreTag, h.PostTag, h.FragmentSize, h.NumFragments, h.Encoder)
+	}
+	if len(opts.Aggs) > 0 {
+		aggs := make([]string, len(opts.Aggs))
+		for i, a := range opts.Aggs {
+			aggs[i] = a.String()
+		}
+		raw += ":aggs=" + strings.Join(aggs, ";")
+	}
+	if opts.ExactTotal {
+		raw += ":exact_total"
+	}
+	if opts.Similarity != "" {
+		raw += ":similarity=" + string(opts.Similarity)
+	}
+	if opts.Explain {
+		raw += ":explain"
+	}
 	hash := sha256.Sum256([]byte(raw))
 	return fmt.Sprintf("%s%x", keyPrefix, hash[:16])
 }
 
-// normalizeQuery canonicalises a query string by lower-casing, extracting
-// terms and excludes, sorting them, and joining with a separator.
-func normalizeQuery(query string) string {
-	words := strings.Fields(strings.ToLower(query))
-	terms := make([]string, 0)
-	excludes := make([]string, 0)
-	queryType := "AND"
-	excludeNext := false
-	for _, w := range words {
-		upper := strings.ToUpper(w)
-		switch upper {
-		case "AND":
-			queryType = "AND"
-		case "OR":
-			queryType = "OR"
-		case "NOT":
-			excludeNext = true
-		default:
-			if excludeNext {
-				excludes = append(excludes, w)
-				excludeNext = false
-			} else {
-				terms = append(terms, w)
-			}
-		}
+// normalizeQuery canonicalises a parsed query by rendering it in query
+// syntax with the clauses of every Boolean query sorted, since their order
+// does not affect the result. Terms are already analysed, so differences in
+// case or word form that analyse the same are gone.
+func normalizeQuery(q parser.Query) string {
+	bq, ok := q.(*parser.BooleanQuery)
+	if !ok {
+		return q.String()
 	}
-
-	sort.Strings(terms)
-	sort.Strings(excludes)
-	parts := []string{queryType, strings.Join(terms, ",")}
-	if len(excludes) > 0 {
-		parts = append(parts, "NOT:"+strings.Join(excludes, ","))
+	parts := make([]string, len(bq.Clauses))
+	for i, c := range bq.Clauses {
+		parts[i] = fmt.Sprintf("%d(%s)", c.Occur, normalizeQuery(c.Query))
 	}
-	return strings.Join(parts, "|")
+	sort.Strings(parts)
+	return fmt.Sprintf("(%s)@%d^%g", strings.Join(parts, " "), bq.MinimumShouldMatch, bq.Boost)
 }
@@ -0,0 +1,268 @@
+package executor
+
+import (
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+)
+
+// evaluate matches a query tree against postings addressed by document
+// number, keyed by the String of each term of the tree and of the terms its
+// prefix, wildcard and fuzzy queries were expanded to, and the sorted
+// documents matching each range query, and ranks the matching documents,
+// resolving the document IDs that break ties and the metadata values of
+// sorts with docs. It returns the requested page of the ranked documents,
+// whose DocIDs are left for the caller to resolve, the sorted matching
+// documents, which must not be modified, and the number of documents matching each term, phrase,
+// expanded query and range.
+func evaluate(
+	root parser.Query,
+	postings map[string]ranker.FieldPostings,
+	expanded map[parser.Query][]expandedTerm,
+	ranges map[*parser.RangeQuery][]uint32,
+	rewrite Rewrite,
+	params ranker.RankParams,
+	getDocInfo func(doc uint32) ranker.DocInfo,
+	docs ranker.Docs,
+	page ranker.Page,
+) ([]ranker.ScoredDoc, []uint32, map[string]int) {
+	ev := newEvaluator(postings, expanded, ranges, rewrite, params, getDocInfo)
+	candidates := ev.match(root)
+	scores := ev.score(root, candidates)
+	for _, doc := range candidates {
+		if _, ok := scores[doc]; !ok {
+			scores[doc] = 0
+		}
+	}
+	return ranker.TopK(scores, docs, page), candidates, ev.termStats(root)
+}
+
+// evaluator evaluates one query tree. The documents matching each node are
+// computed once and kept for scoring.
+type evaluator struct {
+	postings   map[string]ranker.FieldPostings
+	expanded   map[parser.Query][]expandedTerm
+	ranges     map[*parser.RangeQuery][]uint32
+	rewrite    Rewrite
+	params     ranker.RankParams
+	getDocInfo func(doc uint32) ranker.DocInfo
+	matches    map[parser.Query][]uint32
+	phrases    map[*parser.PhraseQuery]phraseMatch
+}
+
+// newEvaluator returns an evaluator over the given postings, expansions
+// and range matches.
+func newEvaluator(
+	postings map[string]ranker.FieldPostings,
+	expanded map[parser.Query][]expandedTerm,
+	ranges map[*parser.RangeQuery][]uint32,
+	rewrite Rewrite,
+	params ranker.RankParams,
+	getDocInfo func(doc uint32) ranker.DocInfo,
+) *evaluator {
+	return &evaluator{
+		postings:   postings,
+		expanded:   expanded,
+		ranges:     ranges,
+		rewrite:    rewrite,
+		params:     params,
+		getDocInfo: getDocInfo,
+		matches:    make(map[parser.Query][]uint32),
+		phrases:    make(map[*parser.PhraseQuery]phraseMatch),
+	}
+}
+
+// termStats returns the number of documents matching each term, phrase,
+// expanded query and range of the tree rooted at root.
+func (ev *evaluator) termStats(root parser.Query) map[string]int {
+	termStats := make(map[string]int)
+	parser.Walk(root, func(q parser.Query, _ parser.Occur) bool {
+		switch q := q.(type) {
+		case *parser.TermQuery:
+			termStats[q.Term.String()] = len(ev.match(q))
+		case *parser.PhraseQuery:
+			termStats[q.Phrase.String()] = len(ev.match(q))
+		case parser.MultiTermQuery:
+			termStats[q.TermPattern()] = len(ev.match(q))
+		case *parser.RangeQuery:
+			termStats[q.String()] = len(ev.match(q))
+		}
+		return true
+	})
+	return termStats
+}
+
+// match returns the sorted documents matching q. The result must not be
+// modified.
+func (ev *evaluator) match(q parser.Query) []uint32 {
+	if docs, ok := ev.matches[q]; ok {
+		return docs
+	}
+	var docs []uint32
+	switch q := q.(type) {
+	case *parser.TermQuery:
+		docs = matchingDocs(ev.postings[q.Term.String()])
+	case *parser.PhraseQuery:
+		terms := q.Phrase.FieldTerms()
+		postings := make([]ranker.FieldPostings, len(terms))
+		for i, term := range terms {
+			postings[i] = ev.postings[term.String()]
+		}
+		m := matchPhrase(q.Phrase, postings)
+		ev.phrases[q] = m
+		docs = m.docs
+	case *parser.BooleanQuery:
+		docs = ev.matchBoolean(q)
+	case parser.MultiTermQuery:
+		for _, et := range ev.expanded[q] {
+			docs = mergeSorted(docs, matchingDocs(ev.postings[et.term.String()]))
+		}
+	case *parser.RangeQuery:
+		docs = ev.ranges[q]
+	}
+	ev.matches[q] = docs
+	return docs
+}
+
+// matchBoolean returns the documents matching every Must and Filter clause
+// of q and none of its MustNot clauses. Should clauses are optional if
+// there are Must or Filter clauses and MinimumShouldMatch is zero;
+// otherwise at least MinimumShouldMatch of them, and at least one, must
+// match.
+func (ev *evaluator) matchBoolean(q *parser.BooleanQuery) []uint32 {
+	var required, optional, prohibited [][]uint32
+	for _, c := range q.Clauses {
+		docs := ev.match(c.Query)
+		switch c.Occur {
+		case parser.Must, parser.Filter:
+			required = append(required, docs)
+		case parser.Should:
+			optional = append(optional, docs)
+		case parser.MustNot:
+			prohibited = append(prohibited, docs)
+		}
+	}
+	var docs []uint32
+	switch {
+	case len(required) > 0:
+		docs = intersectDocs(required)
+		if q.MinimumShouldMatch > 0 {
+			docs = intersectDocs([][]uint32{docs, atLeast(optional, q.MinimumShouldMatch)})
+		}
+	case len(optional) > 0:
+		docs = atLeast(optional, max(q.MinimumShouldMatch, 1))
+	}
+	for _, excluded := range prohibited {
+		docs = subtractSorted(docs, excluded)
+	}
+	return docs
+}
+
+// score returns the score of q in each document of docs, which must be
+// sorted, that q matches. A Boolean query scores the sum of its matching
+// Must and Should clauses, a prefix, wildcard or fuzzy query scores as its
+// Rewrite says, and a range query scores nothing; every score is multiplied
+// by its query's boost.
+func (ev *evaluator) score(q parser.Query, docs []uint32) map[uint32]float64 {
+	var scores map[uint32]float64
+	var boost float64
+	switch q := q.(type) {
+	case *parser.TermQuery:
+		scores = ev.scoreTerm(q.Term, docs)
+		boost = q.Boost
+	case *parser.PhraseQuery:
+		ev.match(q)
+		m := ev.phrases[q].matches
+		filtered := ranker.PhraseMatches{
+			Fields: make(map[string]map[uint32]float64, len(m.Fields)),
+			Terms:  m.Terms,
+		}
+		for field, freqs := range m.Fields {
+			kept := make(map[uint32]float64)
+			for doc, freq := range freqs {
+				if containsSorted(docs, doc) {
+					kept[doc] = freq
+				}
+			}
+			filtered.Fields[field] = kept
+		}
+		scores = ranker.ScorePhrase(filtered, ev.params, ev.getDocInfo)
+		boost = q.Boost
+	case *parser.BooleanQuery:
+		matched := intersectDocs([][]uint32{ev.match(q), docs})
+		scores = make(map[uint32]float64, len(matched))
+		for _, doc := range matched {
+			scores[doc] = 0
+		}
+		for _, c := range q.Clauses {
+			if c.Occur != parser.Must && c.Occur != parser.Should {
+				continue
+			}
+			for doc, score := range ev.score(c.Query, matched) {
+				scores[doc] += score
+			}
+		}
+		boost = q.Boost
+	case parser.MultiTermQuery:
+		scores = ev.scoreExpanded(q, docs)
+		boost = multiTermBoost(q)
+	case *parser.RangeQuery:
+		matched := intersectDocs([][]uint32{ev.match(q), docs})
+		scores = make(map[uint32]float64, len(matched))
+		for _, doc := range matched {
+			scores[doc] = 0
+		}
+		boost = 1
+	}
+	if boost != 1 {
+		for doc := range scores {
+			scores[doc] *= boost
+		}
+	}
+	return scores
+}
+
+// scoreTerm returns the score of term in each document of docs it occurs
+// in.
+func (ev *evaluator) scoreTerm(term parser.Term, docs []uint32) map[uint32]float64 {
+	fields := ev.postings[term.String()]
+	filtered := make(ranker.FieldPostings)
+	for field, postings := range fields {
+		if kept := filterPostings(postings, docs); len(kept) > 0 {
+			filtered[field] = kept
+		}
+	}
+	return ranker.ScoreTerm(filtered, termStatsOf(fields), ev.params, ev.getDocInfo)
+}
+
+// scoreExpanded returns the unboosted score of a prefix, wildcard or fuzzy
+// query in each document of docs it matches.
+func (ev *evaluator) scoreExpanded(q parser.MultiTermQuery, docs []uint32) map[uint32]float64 {
+	if ev.rewrite != ScoringBoolean {
+		matched := intersectDocs([][]uint32{ev.match(q), docs})
+		scores := make(map[uint32]float64, len(matched))
+		for _, doc := range matched {
+			scores[doc] = 1
+		}
+		return scores
+	}
+	scores := make(map[uint32]float64)
+	for _, et := range ev.expanded[q] {
+		for doc, score := range ev.scoreTerm(et.term, docs) {
+			scores[doc] += score * et.weight
+		}
+	}
+	return scores
+}
+
+// multiTermBoost returns the boost of a prefix, wildcard or fuzzy query.
+func multiTermBoost(q parser.MultiTermQuery) float64 {
+	switch q := q.(type) {
+	case *parser.PrefixQuery:
+		return q.Boost
+	case *parser.WildcardQuery:
+		return q.Boost
+	case *parser.FuzzyQuery:
+		return q.Boost
+	}
+	return 1
+}
@@ -1,162 +1,521 @@
 // Package executor runs parsed query plans against one or more indexer
-// engines, applying Boolean filtering (AND/OR/NOT) and BM25 ranking.
+// engines, evaluating their Boolean query trees, matching phrases on term
+// positions, expanding prefix, wildcard and fuzzy queries into the terms
+// of the index, ranking with the configured similarity, highlighting the matches in
+// the stored text of the results, explaining their scores, and aggregating
+// the metadata of all the matching documents. Top-k searches by score are
+// evaluated document at a time with block-max WAND, which skips the
+// documents whose score bounds cannot reach the requested page.
 package executor
 
 import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sort"
 
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/aggregation"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/highlight"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
 )
 
+// TotalHitsRelation says how SearchResult.TotalHits relates to the number
+// of documents a query matches.
+type TotalHitsRelation string
+
+const (
+	// TotalHitsEqual means TotalHits is the number of matching documents.
+	TotalHitsEqual TotalHitsRelation = "eq"
+	// TotalHitsLowerBound means the search skipped documents that could
+	// not reach the requested page, some of which may match, so there are
+	// at least TotalHits matching documents.
+	TotalHitsLowerBound TotalHitsRelation = "gte"
+)
+
 // SearchResult holds the final output of a query execution.
 type SearchResult struct {
-	Query     string             `json:"query"`
-	TotalHits int                `json:"total_hits"`
-	Results   []ranker.ScoredDoc `json:"results"`
-	TermStats map[string]int     `json:"term_stats"`
+	Query     string `json:"query"`
+	TotalHits int    `json:"total_hits"`
+	// TotalHitsRelation says whether TotalHits is exact or a lower bound.
+	TotalHitsRelation TotalHitsRelation  `json:"total_hits_relation,omitempty"`
+	Results           []ranker.ScoredDoc `json:"results"`
+	TermStats         map[string]int     `json:"term_stats"`
+	// Aggregations holds the result of each requested aggregation by name.
+	Aggregations map[string]aggregation.Result `json:"aggregations,omitempty"`
+}
+
+// EmptyResult returns the result of a query that matches nothing, with the
+// aggregations opts requests computed over no documents.
+func EmptyResult(query string, opts Options) *SearchResult {
+	result := &SearchResult{
+		Query:             query,
+		TotalHitsRelation: TotalHitsEqual,
+		Results:           []ranker.ScoredDoc{},
+	}
+	if len(opts.Aggs) > 0 {
+		result.Aggregations = aggregation.Results(opts.Aggs, aggregation.Collect(opts.Aggs, nil, nil))
+	}
+	return result
+}
+
+// AllFields selects every stored field in Options.Fields.
+const AllFields = "*"
+
+// Options holds the settings of a search request besides the query. Limit
+// results are returned, after skipping Offset of those ranked after
+// SearchAfter, or of all of them if it is nil. Fields names the stored
+// fields returned with each result: text fields and metadata values of
+// those names, or all of them for "*". Highlight, if set, requests
+// fragments of the stored text fields with the words that matched marked
+// up. Sort orders the results by metadata values and score instead of by
+// descending score; SearchAfter must have been taken under the same sort.
+// Aggs are computed over all the matching documents, not just the page.
+// Searches for the top results by score skip the documents that cannot
+// reach the page, and count the matching documents only as a lower bound,
+// unless ExactTotal is set. Similarity, if set, ranks with that similarity
+// instead of the configured one. Explain attaches the explanation of its
+// score to every result.
+type Options struct {
+	Limit       int
+	Offset      int
+	SearchAfter *ranker.Cursor
+	Sort        []ranker.SortField
+	Fields      []string
+	Highlight   *highlight.Config
+	Aggs        []aggregation.Request
+	ExactTotal  bool
+	Similarity  ranker.Model
+	Explain     bool
+}
+
+// ranking returns the scoring configuration c with the options'
+// similarity.
+func (o Options) ranking(c ranker.Config) ranker.Config {
+	if o.Similarity != "" {
+		c.Similarity = o.Similarity
+	}
+	return c
+}
+
+// page returns the page of the ranked results the options select.
+func (o Options) page() ranker.Page {
+	return ranker.Page{Offset: o.Offset, Limit: o.Limit, After: o.SearchAfter, Sort: o.Sort}
 }
 
 // Executor runs queries against a single indexer.Engine instance.
 type Executor struct {
-	engine *indexer.Engine
-	logger *slog.Logger
+	engine    *indexer.Engine
+	ranking   ranker.Config
+	expansion ExpansionConfig
+	logger    *slog.Logger
 }
 
-// New creates an Executor backed by the given engine.
-func New(engine *indexer.Engine) *Executor {
+// New creates an Executor backed by the given engine that ranks with the
+// given scoring configuration and expands prefix, wildcard and fuzzy
+// queries as configured.
+func New(engine *indexer.Engine, ranking ranker.Config, expansion ExpansionConfig) *Executor {
 	return &Executor{
-		engine: engine,
-		logger: slog.Default().With("component", "query-executor"),
+		engine:    engine,
+		ranking:   ranking,
+		expansion: expansion.withDefaults(),
+		logger:    slog.Default().With("component", "query-executor"),
 	}
 }
 
-// Execute runs the query plan: collects postings per term, applies
-// AND/OR/NOT logic, ranks with BM25, and returns the top-limit results.
-func (e *Executor) Execute(ctx context.Context, plan *parser.QueryPlan, limit int) (*SearchResult, error) {
-	if len(plan.Terms) == 0 {
-		return &SearchResult{
-			Query:   plan.RawQuery,
-			Results: []ranker.ScoredDoc{},
-		}, nil
+// Execute runs the query plan: expands prefix, wildcard and fuzzy queries
+// into the matching terms of the index, collects postings per term and
+// field and the documents in each range, evaluates the query tree, matching phrases against term positions, ranks
+// the matching documents, and returns the page of results opts selects with the
+// requested stored fields and highlighted fragments and the aggregations
+// of all the matching documents. Postings are processed by integer document number;
+// external IDs and stored fields are resolved only for the returned
+// documents. Queries for the top results by score whose scoring clauses
+// are terms are evaluated document at a time, skipping the documents that
+// cannot reach the page (see evaluateTopK).
+func (e *Executor) Execute(ctx context.Context, plan *parser.QueryPlan, opts Options) (*SearchResult, error) {
+	if plan.Empty() {
+		return EmptyResult(plan.RawQuery, opts), nil
+	}
+
+	view := e.engine.AcquireView()
+	defer view.Close()
+	q, err := e.prepare(view, plan, opts)
+	if err != nil {
+		return nil, err
+	}
+	var ranked []ranker.ScoredDoc
+	var candidates []uint32
+	var termStats map[string]int
+	total, relation := 0, TotalHitsEqual
+	if topK, ok := planTopK(plan.Root, opts); ok {
+		impacts := make(map[string]FieldImpacts)
+		for _, term := range topK.terms() {
+			impacts[term.String()] = termImpacts(view, term, q.postings[term.String()])
+		}
+		ranked, total, relation, termStats = evaluateTopK(plan.Root, topK, q.postings, impacts, q.expanded, q.ranges, e.expansion.Rewrite, q.params, q.docInfo, view, opts.page())
+	} else {
+		ranked, candidates, termStats = evaluate(plan.Root, q.postings, q.expanded, q.ranges, e.expansion.Rewrite, q.params, q.docInfo, view, opts.page())
+		total = len(candidates)
+	}
+	if opts.Explain {
+		explainResults(ranked, q.evaluator(e.expansion.Rewrite), plan.Root, explainCorpus(q.params, nil))
+	}
+	var highlighted []string
+	if opts.Highlight != nil {
+		highlighted = highlightTerms(plan.Root, q.expanded)
+	}
+	for i := range ranked {
+		ranked[i].DocID = view.DocID(ranked[i].Doc)
+		if len(opts.Fields) > 0 || opts.Highlight != nil {
+			positions := matchPositions(q.postings, highlighted, ranked[i].Doc)
+			fetchStored(e.logger, &ranked[i], view, ranked[i].Doc, opts, e.engine.Analyzer(), positions)
+		}
+	}
+	e.logger.Info("query executed",
+		"query", plan.RawQuery,
+		"terms", plan.Clauses(),
+		"candidates", total,
+		"total_relation", relation,
+		"results", len(ranked),
+	)
+	result := &SearchResult{
+		Query:             plan.RawQuery,
+		TotalHits:         total,
+		TotalHitsRelation: relation,
+		Results:           ranked,
+		TermStats:         termStats,
+	}
+	if len(opts.Aggs) > 0 {
+		result.Aggregations = aggregation.Results(opts.Aggs, aggregation.Collect(opts.Aggs, candidates, view))
 	}
+	return result, nil
+}
+
+// preparedQuery holds what a query plan is evaluated with: the postings of
+// its terms and of the terms its prefix, wildcard and fuzzy queries were
+// expanded to, the documents matching each range query, and the
+// statistics and configuration its matches are ranked with.
+type preparedQuery struct {
+	postings map[string]ranker.FieldPostings
+	expanded map[parser.Query][]expandedTerm
+	ranges   map[*parser.RangeQuery][]uint32
+	params   ranker.RankParams
+	docInfo  func(doc uint32) ranker.DocInfo
+}
+
+// evaluator returns an evaluator of the prepared query.
+func (q *preparedQuery) evaluator(rewrite Rewrite) *evaluator {
+	return newEvaluator(q.postings, q.expanded, q.ranges, rewrite, q.params, q.docInfo)
+}
 
-	postingsPerTerm := make(map[string]index.PostingList)
-	termStats := make(map[string]int)
-	for _, term := range plan.Terms {
-		postings, err := e.engine.Search(term)
+// prepare expands the prefix, wildcard and fuzzy queries of plan into the
+// matching terms of view, collects the postings of every term and the
+// documents in each range, and the statistics the matches are ranked with.
+func (e *Executor) prepare(view *indexer.View, plan *parser.QueryPlan, opts Options) (*preparedQuery, error) {
+	fields := view.Fields()
+	matched := make(map[parser.MultiTermQuery]map[string]termCandidate)
+	collectCandidates(view, plan.Root, fields, matched)
+	expanded := selectExpansions(matched, e.expansion.MaxExpansions)
+	postings := make(map[string]ranker.FieldPostings)
+	for _, term := range append(plan.Terms(), expandedTerms(expanded)...) {
+		if _, ok := postings[term.String()]; ok {
+			continue
+		}
+		p, err := termPostings(view, term, fields)
 		if err != nil {
 			return nil, fmt.Errorf("searching term %q: %w", term, err)
 		}
-		if len(postings) > 0 {
-			postingsPerTerm[term] = postings
-			termStats[term] = len(postings)
+		postings[term.String()] = p
+	}
+	avgFieldLengths := make(map[string]float64, len(fields))
+	for _, field := range fields {
+		avgFieldLengths[field] = view.AvgFieldLength(field)
+	}
+	params := ranker.RankParams{
+		TotalDocs:       view.TotalDocs(),
+		AvgDocLength:    view.AvgDocLength(),
+		AvgFieldLengths: avgFieldLengths,
+		Config:          opts.ranking(e.ranking),
+	}
+	docInfo := func(doc uint32) ranker.DocInfo {
+		return ranker.DocInfo{DocLength: view.DocLength(doc), FieldLengths: view.FieldLengths(doc)}
+	}
+	return &preparedQuery{
+		postings: postings,
+		expanded: expanded,
+		ranges:   rangeDocs(view, plan.Root),
+		params:   params,
+		docInfo:  docInfo,
+	}, nil
+}
+
+// fetchStored fills in the stored fields named by opts.Fields, and the
+// highlighted fragments if opts.Highlight is set, for a result with the
+// given document number in view. positions holds the positions of the
+// terms it matched in each field, and analyzer is the one it was indexed
+// with. Failures are logged and leave the result without stored fields.
+func fetchStored(logger *slog.Logger, result *ranker.ScoredDoc, view *indexer.View, doc uint32, opts Options, analyzer analysis.Analyzer, positions map[string][]int) {
+	stored, ok, err := view.Stored(doc)
+	if err != nil {
+		logger.Error("reading stored fields failed", "doc_id", result.DocID, "error", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	if len(opts.Fields) > 0 {
+		result.Fields, result.Metadata = selectStored(stored, opts.Fields)
+	}
+	if opts.Highlight != nil {
+		result.Highlight = highlightFields(stored, analyzer, positions, *opts.Highlight)
+	}
+}
+
+// selectStored returns the text fields and metadata values of doc named by
+// fields, or all of them if fields contains "*". Nil maps are returned when
+// nothing is selected.
+func selectStored(doc index.Document, fields []string) (map[string]string, map[string]any) {
+	var text map[string]string
+	var metadata map[string]any
+	for _, name := range fields {
+		if name == AllFields {
+			return doc.Fields, doc.Metadata
+		}
+		if v, ok := doc.Fields[name]; ok {
+			if text == nil {
+				text = make(map[string]string)
+			}
+			text[name] = v
+		}
+		if v, ok := doc.Metadata[name]; ok {
+			if metadata == nil {
+				metadata = make(map[string]any)
+			}
+			metadata[name] = v
 		}
 	}
-	excludeDocIDs := make(map[string]struct{})
-	for _, term := range plan.ExcludeTerms {
-		postings, err := e.engine.Search(term)
+	return text, metadata
+}
+
+// termPostings returns the postings of term in its field, or in each of
+// fields for a term that is not restricted to one. Fields in which the term
+// does not occur are omitted.
+func termPostings(view *indexer.View, term parser.Term, fields []string) (ranker.FieldPostings, error) {
+	if term.Field != "" {
+		fields = []string{term.Field}
+	}
+	result := make(ranker.FieldPostings)
+	for _, field := range fields {
+		postings, err := view.Postings(index.FieldTerm(field, term.Text))
 		if err != nil {
-			e.logger.Error("searching exclude term failed", "term", term, "error", err)
-			continue
+			return nil, err
+		}
+		if len(postings) > 0 {
+			result[field] = postings
+		}
+	}
+	return result, nil
+}
+
+// rangeDocs returns the sorted documents of view matching each range query
+// of the tree rooted at root.
+func rangeDocs(view *indexer.View, root parser.Query) map[*parser.RangeQuery][]uint32 {
+	ranges := make(map[*parser.RangeQuery][]uint32)
+	parser.Walk(root, func(q parser.Query, _ parser.Occur) bool {
+		if rq, ok := q.(*parser.RangeQuery); ok {
+			ranges[rq] = view.RangeDocs(rq.Field, rq.Range)
 		}
+		return true
+	})
+	return ranges
+}
+
+// termStatsOf returns the statistics of a term from its postings in each
+// field it is searched in.
+func termStatsOf(fields ranker.FieldPostings) ranker.TermStats {
+	return ranker.TermStats{
+		DocFreq:       int64(len(matchingDocs(fields))),
+		TotalTermFreq: totalTermFreq(fields),
+	}
+}
+
+// totalTermFreq returns the number of occurrences of a term in its
+// postings.
+func totalTermFreq(fields ranker.FieldPostings) int64 {
+	var n int64
+	for _, postings := range fields {
 		for _, p := range postings {
-			excludeDocIDs[p.DocID] = struct{}{}
+			n += int64(p.Frequency)
 		}
 	}
-	var candidateDocIDs map[string]struct{}
-	switch plan.Type {
-	case parser.QueryAND:
-		candidateDocIDs = intersectPostings(postingsPerTerm)
-	case parser.QueryOR:
-		candidateDocIDs = unionPostings(postingsPerTerm)
+	return n
+}
+
+// matchingDocs returns the sorted document numbers that occur in any of a
+// term's per-field postings.
+func matchingDocs(fields ranker.FieldPostings) []uint32 {
+	var docs []uint32
+	for _, postings := range fields {
+		list := make([]uint32, len(postings))
+		for i, p := range postings {
+			list[i] = p.Doc
+		}
+		docs = mergeSorted(docs, list)
 	}
-	for docID := range excludeDocIDs {
-		delete(candidateDocIDs, docID)
+	return docs
+}
+
+// intersectDocs returns the sorted document numbers present in every list
+// (AND semantics). Lists are intersected shortest first.
+func intersectDocs(lists [][]uint32) []uint32 {
+	if len(lists) == 0 {
+		return nil
 	}
-	filteredPostings := make(map[string]index.PostingList)
-	for term, postings := range postingsPerTerm {
-		filtered := make(index.PostingList, 0)
-		for _, p := range postings {
-			if _, ok := candidateDocIDs[p.DocID]; ok {
-				filtered = append(filtered, p)
+	sort.Slice(lists, func(i, j int) bool {
+		return len(lists[i]) < len(lists[j])
+	})
+	candidates := append([]uint32(nil), lists[0]...)
+	for _, docs := range lists[1:] {
+		kept := candidates[:0]
+		j := 0
+		for _, doc := range candidates {
+			j = advance(docs, j, doc)
+			if j == len(docs) {
+				break
+			}
+			if docs[j] == doc {
+				kept = append(kept, doc)
 			}
 		}
-		if len(filtered) > 0 {
-			filteredPostings[term] = filtered
+		candidates = kept
+		if len(candidates) == 0 {
+			break
 		}
 	}
-	params := ranker.RankParams{
-		TotalDocs:    e.engine.GetTotalDocs(),
-		AvgDocLength: e.engine.GetAvgDocLength(),
+	return candidates
+}
+
+// advance returns the index of the first element of docs at or after from
+// that is at least doc. It gallops ahead before binary searching, so
+// skipping a long run of documents costs O(log distance).
+func advance(docs []uint32, from int, doc uint32) int {
+	lo, hi, step := from, from, 1
+	for hi < len(docs) && docs[hi] < doc {
+		lo = hi + 1
+		hi += step
+		step *= 2
 	}
-	getDocInfo := func(docID string) ranker.DocInfo {
-		return ranker.DocInfo{
-			DocLength: e.engine.GetDocLength(docID),
-		}
+	if hi > len(docs) {
+		hi = len(docs)
 	}
-	ranked := ranker.Rank(filteredPostings, params, getDocInfo, limit)
-	e.logger.Info("query executed",
-		"query", plan.RawQuery,
-		"terms", plan.Terms,
-		"candidates", len(candidateDocIDs),
-		"results", len(ranked),
-	)
-	return &SearchResult{
-		Query:     plan.RawQuery,
-		TotalHits: len(candidateDocIDs),
-		Results:   ranked,
-		TermStats: termStats,
-	}, nil
+	return lo + sort.Search(hi-lo, func(i int) bool {
+		return docs[lo+i] >= doc
+	})
 }
 
-// intersectPostings returns the set of DocIDs present in every term's
-// PostingList (AND semantics).
-func intersectPostings(postingsPerTerm map[string]index.PostingList) map[string]struct{} {
-	if len(postingsPerTerm) == 0 {
-		return make(map[string]struct{})
+// mergeSorted returns the sorted union of two sorted, duplicate-free lists
+// (OR semantics).
+func mergeSorted(a, b []uint32) []uint32 {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
 	}
-	var shortestTerm string
-	shortestLen := int(^uint(0) >> 1)
-	for term, postings := range postingsPerTerm {
-		if len(postings) < shortestLen {
-			shortestLen = len(postings)
-			shortestTerm = term
+	merged := make([]uint32, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			merged = append(merged, a[i])
+			i++
+		case a[i] > b[j]:
+			merged = append(merged, b[j])
+			j++
+		default:
+			merged = append(merged, a[i])
+			i++
+			j++
 		}
 	}
-	candidates := make(map[string]struct{})
-	for _, p := range postingsPerTerm[shortestTerm] {
-		candidates[p.DocID] = struct{}{}
+	merged = append(merged, a[i:]...)
+	return append(merged, b[j:]...)
+}
+
+// subtractSorted returns the elements of docs not in excluded. Both slices
+// must be sorted.
+func subtractSorted(docs []uint32, excluded []uint32) []uint32 {
+	if len(excluded) == 0 {
+		return docs
 	}
-	for term, postings := range postingsPerTerm {
-		if term == shortestTerm {
+	result := docs[:0]
+	j := 0
+	for _, doc := range docs {
+		for j < len(excluded) && excluded[j] < doc {
+			j++
+		}
+		if j < len(excluded) && excluded[j] == doc {
 			continue
 		}
-		docSet := make(map[string]struct{}, len(postings))
-		for _, p := range postings {
-			docSet[p.DocID] = struct{}{}
+		result = append(result, doc)
+	}
+	return result
+}
+
+// atLeast returns the sorted documents present in at least k of lists,
+// which must be sorted. The result never aliases an input list.
+func atLeast(lists [][]uint32, k int) []uint32 {
+	if k <= 1 {
+		var docs []uint32
+		for _, list := range lists {
+			docs = mergeSorted(docs, list)
 		}
-		for docID := range candidates {
-			if _, exists := docSet[docID]; !exists {
-				delete(candidates, docID)
-			}
+		return append([]uint32(nil), docs...)
+	}
+	if k > len(lists) {
+		return nil
+	}
+	counts := make(map[uint32]int)
+	for _, list := range lists {
+		for _, doc := range list {
+			counts[doc]++
 		}
 	}
-	return candidates
+	var docs []uint32
+	for doc, n := range counts {
+		if n >= k {
+			docs = append(docs, doc)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i] < docs[j] })
+	return docs
 }
 
-// unionPostings returns the set of DocIDs present in any term's PostingList
-// (OR semantics).
-func unionPostings(postingsPerTerm map[string]index.PostingList) map[string]struct{} {
-	result := make(map[string]struct{})
-	for _, postings := range postingsPerTerm {
-		for _, p := range postings {
-			result[p.DocID] = struct{}{}
+// containsSorted reports whether doc is in docs, which must be sorted.
+func containsSorted(docs []uint32, doc uint32) bool {
+	i := sort.Search(len(docs), func(i int) bool { return docs[i] >= doc })
+	return i < len(docs) && docs[i] == doc
+}
+
+// filterPostings returns the postings whose document is in candidates,
+// which must be sorted.
+func filterPostings(postings index.DocPostingList, candidates []uint32) index.DocPostingList {
+	filtered := make(index.DocPostingList, 0)
+	j := 0
+	for _, p := range postings {
+		for j < len(candidates) && candidates[j] < p.Doc {
+			j++
+		}
+		if j == len(candidates) {
+			break
+		}
+		if candidates[j] == p.Doc {
+			filtered = append(filtered, p)
 		}
 	}
-	return result
+	return filtered
 }
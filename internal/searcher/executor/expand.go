@@ -0,0 +1,174 @@
+package executor
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+)
+
+// Rewrite selects how the terms a prefix, wildcard or fuzzy query expands
+// to are scored.
+type Rewrite string
+
+const (
+	// ConstantScore scores every document matching any of the terms with
+	// the query's boost, regardless of how often or which terms it
+	// contains.
+	ConstantScore Rewrite = "constant_score"
+	// ScoringBoolean scores the terms as the optional clauses of a Boolean
+	// query, each with the similarity. A fuzzy term's score is weighted by
+	// 1/(1+edits), so closer terms rank higher.
+	ScoringBoolean Rewrite = "scoring_boolean"
+)
+
+// DefaultMaxExpansions is the number of terms a prefix, wildcard or fuzzy
+// query expands to at most unless configured otherwise.
+const DefaultMaxExpansions = 64
+
+// ExpansionConfig controls the expansion of prefix, wildcard and fuzzy
+// queries into the terms of the index they match. A query matching more
+// than MaxExpansions terms keeps the ones with the fewest edits for a fuzzy
+// query and then the most documents. Zero values select
+// DefaultMaxExpansions and ConstantScore.
+type ExpansionConfig struct {
+	MaxExpansions int
+	Rewrite       Rewrite
+}
+
+// ParseRewrite validates a rewrite name. The empty string selects
+// ConstantScore.
+func ParseRewrite(s string) (Rewrite, error) {
+	switch Rewrite(s) {
+	case "", ConstantScore:
+		return ConstantScore, nil
+	case ScoringBoolean:
+		return ScoringBoolean, nil
+	}
+	return "", fmt.Errorf("unknown term expansion rewrite %q", s)
+}
+
+// withDefaults returns the configuration with its zero values replaced.
+func (c ExpansionConfig) withDefaults() ExpansionConfig {
+	if c.MaxExpansions <= 0 {
+		c.MaxExpansions = DefaultMaxExpansions
+	}
+	if c.Rewrite == "" {
+		c.Rewrite = ConstantScore
+	}
+	return c
+}
+
+// termCandidate is a term of the index a multi-term query matches.
+type termCandidate struct {
+	docFreq  int
+	distance int
+}
+
+// expandedTerm is a term a multi-term query was expanded to and the weight
+// of its score.
+type expandedTerm struct {
+	term   parser.Term
+	weight float64
+}
+
+// collectCandidates adds the terms of view that each prefix, wildcard and
+// fuzzy query of the tree matches to candidates, keyed by query and term
+// text. Document frequencies are summed over the fields and sources
+// holding a term.
+func collectCandidates(view *indexer.View, root parser.Query, fields []string, candidates map[parser.MultiTermQuery]map[string]termCandidate) {
+	parser.Walk(root, func(q parser.Query, _ parser.Occur) bool {
+		mq, ok := q.(parser.MultiTermQuery)
+		if !ok {
+			return true
+		}
+		terms := candidates[mq]
+		if terms == nil {
+			terms = make(map[string]termCandidate)
+			candidates[mq] = terms
+		}
+		searched := fields
+		if field := mq.TermField(); field != "" {
+			searched = []string{field}
+		}
+		for _, field := range searched {
+			prefix := index.FieldTerm(field, "")
+			view.TermsWithPrefix(prefix+mq.TermPrefix(), func(term string, docFreq int) bool {
+				text := term[len(prefix):]
+				if distance, ok := mq.MatchTerm(text); ok {
+					c := terms[text]
+					c.docFreq += docFreq
+					c.distance = distance
+					terms[text] = c
+				}
+				return true
+			})
+		}
+		return true
+	})
+}
+
+// mergeCandidates adds the candidates of one shard to those of others.
+func mergeCandidates(dst, src map[parser.MultiTermQuery]map[string]termCandidate) {
+	for q, terms := range src {
+		merged := dst[q]
+		if merged == nil {
+			merged = make(map[string]termCandidate, len(terms))
+			dst[q] = merged
+		}
+		for text, c := range terms {
+			m := merged[text]
+			m.docFreq += c.docFreq
+			m.distance = c.distance
+			merged[text] = m
+		}
+	}
+}
+
+// selectExpansions chooses the terms each multi-term query expands to
+// among its candidates: at most maxExpansions, fewest edits first, then
+// most documents, then in term order.
+func selectExpansions(candidates map[parser.MultiTermQuery]map[string]termCandidate, maxExpansions int) map[parser.Query][]expandedTerm {
+	expanded := make(map[parser.Query][]expandedTerm, len(candidates))
+	for q, terms := range candidates {
+		texts := make([]string, 0, len(terms))
+		for text := range terms {
+			texts = append(texts, text)
+		}
+		sort.Slice(texts, func(i, j int) bool {
+			a, b := terms[texts[i]], terms[texts[j]]
+			if a.distance != b.distance {
+				return a.distance < b.distance
+			}
+			if a.docFreq != b.docFreq {
+				return a.docFreq > b.docFreq
+			}
+			return texts[i] < texts[j]
+		})
+		if len(texts) > maxExpansions {
+			texts = texts[:maxExpansions]
+		}
+		list := make([]expandedTerm, len(texts))
+		for i, text := range texts {
+			list[i] = expandedTerm{
+				term:   parser.Term{Field: q.TermField(), Text: text},
+				weight: 1 / float64(1+terms[text].distance),
+			}
+		}
+		expanded[q] = list
+	}
+	return expanded
+}
+
+// expandedTerms returns the terms of every expansion.
+func expandedTerms(expanded map[parser.Query][]expandedTerm) []parser.Term {
+	var terms []parser.Term
+	for _, list := range expanded {
+		for _, et := range list {
+			terms = append(terms, et.term)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,239 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+)
+
+// ErrDocNotFound is returned by Explain when no live document has the
+// requested ID.
+var ErrDocNotFound = errors.New("document not found")
+
+// ExplainResult holds the explanation of the score of one document for a
+// query. Score is rounded as that of a search result; Explanation holds
+// the unrounded values it was computed from.
+type ExplainResult struct {
+	Query       string             `json:"query"`
+	DocID       string             `json:"doc_id"`
+	Matched     bool               `json:"matched"`
+	Score       float64            `json:"score"`
+	Explanation ranker.Explanation `json:"explanation"`
+}
+
+// Explain explains the score of the document with the given ID for the
+// query plan, computed as Execute ranks it, including when the query does
+// not match it. It returns ErrDocNotFound if the engine holds no live
+// document with that ID.
+func (e *Executor) Explain(ctx context.Context, plan *parser.QueryPlan, docID string, opts Options) (*ExplainResult, error) {
+	view := e.engine.AcquireView()
+	defer view.Close()
+	doc, ok := view.DocNumber(docID)
+	if !ok {
+		return nil, fmt.Errorf("explaining %q: %w", docID, ErrDocNotFound)
+	}
+	if plan.Empty() {
+		return explainEmpty(plan, docID), nil
+	}
+	q, err := e.prepare(view, plan, opts)
+	if err != nil {
+		return nil, err
+	}
+	return explainDoc(plan, docID, doc, q.evaluator(e.expansion.Rewrite), explainCorpus(q.params, nil)), nil
+}
+
+// Explain explains the score of the document with the given ID for the
+// query plan, computed as Execute ranks it with statistics over all
+// shards, including when the query does not match it. It returns
+// ErrDocNotFound if no shard holds a live document with that ID.
+func (se *ShardedExecutor) Explain(ctx context.Context, plan *parser.QueryPlan, docID string, opts Options) (*ExplainResult, error) {
+	if plan.Empty() {
+		for _, engine := range se.engines {
+			view := engine.AcquireView()
+			_, ok := view.DocNumber(docID)
+			view.Close()
+			if ok {
+				return explainEmpty(plan, docID), nil
+			}
+		}
+		return nil, fmt.Errorf("explaining %q: %w", docID, ErrDocNotFound)
+	}
+	q, err := se.prepare(ctx, plan, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer q.close()
+	for i, sr := range q.shards {
+		if doc, ok := sr.View.DocNumber(docID); ok {
+			ev := q.evaluator(se.expansion.Rewrite)
+			return explainDoc(plan, docID, doc+q.offsets[i], ev, explainCorpus(q.params, q.shards)), nil
+		}
+	}
+	return nil, fmt.Errorf("explaining %q: %w", docID, ErrDocNotFound)
+}
+
+// explainEmpty returns the explanation of a document for a plan without
+// clauses, which matches nothing.
+func explainEmpty(plan *parser.QueryPlan, docID string) *ExplainResult {
+	return &ExplainResult{
+		Query:       plan.RawQuery,
+		DocID:       docID,
+		Explanation: ranker.Explanation{Description: "empty query, no match"},
+	}
+}
+
+// explainDoc explains the score of document doc for plan with ev, with
+// corpus explaining the statistics it was ranked with.
+func explainDoc(plan *parser.QueryPlan, docID string, doc uint32, ev *evaluator, corpus ranker.Explanation) *ExplainResult {
+	result := &ExplainResult{
+		Query:   plan.RawQuery,
+		DocID:   docID,
+		Matched: containsSorted(ev.match(plan.Root), doc),
+	}
+	e := ev.explain(plan.Root, doc)
+	if result.Matched {
+		result.Score = ranker.RoundScore(e.Value)
+	}
+	result.Explanation = ranker.Explanation{
+		Value:       e.Value,
+		Description: "score, from:",
+		Details:     []ranker.Explanation{e, corpus},
+	}
+	return result
+}
+
+// explainResults attaches to each of ranked the explanation of its score
+// for the query tree rooted at root, with corpus explaining the statistics
+// it was ranked with.
+func explainResults(ranked []ranker.ScoredDoc, ev *evaluator, root parser.Query, corpus ranker.Explanation) {
+	for i := range ranked {
+		e := ev.explain(root, ranked[i].Doc)
+		ranked[i].Explanation = &ranker.Explanation{
+			Value:       e.Value,
+			Description: "score, from:",
+			Details:     []ranker.Explanation{e, corpus},
+		}
+	}
+}
+
+// explainCorpus explains the statistics of params: the number of
+// documents and the average length of documents and of each field. If the
+// statistics were merged from shards, each shard's own are explained too.
+func explainCorpus(params ranker.RankParams, shards []ShardResult) ranker.Explanation {
+	e := ranker.Explanation{
+		Value:       float64(params.TotalDocs),
+		Description: "docs, number of documents searched, with:",
+		Details:     explainLengths(params.AvgDocLength, params.AvgFieldLengths),
+	}
+	for _, sr := range shards {
+		avgFieldLengths := make(map[string]float64, len(sr.FieldTokens))
+		if sr.TotalDocs > 0 {
+			for field, n := range sr.FieldTokens {
+				avgFieldLengths[field] = float64(n) / float64(sr.TotalDocs)
+			}
+		}
+		e.Details = append(e.Details, ranker.Explanation{
+			Value:       float64(sr.TotalDocs),
+			Description: fmt.Sprintf("docs, of shard %d, with:", sr.ShardID),
+			Details:     explainLengths(sr.AvgDocLen, avgFieldLengths),
+		})
+	}
+	return e
+}
+
+// explainLengths explains the average length of documents and of each
+// field, sorted by field.
+func explainLengths(avgDocLength float64, avgFieldLengths map[string]float64) []ranker.Explanation {
+	fields := make([]string, 0, len(avgFieldLengths))
+	for field := range avgFieldLengths {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	details := []ranker.Explanation{{Value: avgDocLength, Description: "avgLength, average length of documents"}}
+	for _, field := range fields {
+		details = append(details, ranker.Explanation{
+			Value:       avgFieldLengths[field],
+			Description: fmt.Sprintf("avgLength, average length of field %s", field),
+		})
+	}
+	return details
+}
+
+// explain explains the score of q in doc, computed as by score, or 0 if q
+// does not match doc.
+func (ev *evaluator) explain(q parser.Query, doc uint32) ranker.Explanation {
+	if !containsSorted(ev.match(q), doc) {
+		return ranker.Explanation{Description: fmt.Sprintf("%s, no match", q)}
+	}
+	var e ranker.Explanation
+	var boost float64
+	switch q := q.(type) {
+	case *parser.TermQuery:
+		e = ev.explainTerm(q.Term, doc)
+		boost = q.Boost
+	case *parser.PhraseQuery:
+		e = ranker.ExplainPhrase(q.Phrase.String(), ev.phrases[q].matches, ev.params, ev.getDocInfo, doc)
+		boost = q.Boost
+	case *parser.BooleanQuery:
+		e = ranker.Explanation{Description: fmt.Sprintf("%s, sum of:", q)}
+		for _, c := range q.Clauses {
+			switch c.Occur {
+			case parser.Must, parser.Should:
+				d := ev.explain(c.Query, doc)
+				e.Value += d.Value
+				e.Details = append(e.Details, d)
+			case parser.Filter:
+				if containsSorted(ev.match(c.Query), doc) {
+					e.Details = append(e.Details, ranker.Explanation{Description: fmt.Sprintf("%s, filter, not scored", c.Query)})
+				}
+			}
+		}
+		boost = q.Boost
+	case parser.MultiTermQuery:
+		e = ev.explainExpanded(q, doc)
+		boost = multiTermBoost(q)
+	case *parser.RangeQuery:
+		e = ranker.Explanation{Description: fmt.Sprintf("%s, not scored", q)}
+		boost = 1
+	}
+	if boost != 1 {
+		e = ranker.Explanation{
+			Value:       e.Value * boost,
+			Description: "boost * score, from:",
+			Details:     []ranker.Explanation{{Value: boost, Description: "boost"}, e},
+		}
+	}
+	return e
+}
+
+// explainTerm explains the score of term in doc.
+func (ev *evaluator) explainTerm(term parser.Term, doc uint32) ranker.Explanation {
+	fields := ev.postings[term.String()]
+	return ranker.ExplainTerm(term.String(), fields, termStatsOf(fields), ev.params, ev.getDocInfo, doc)
+}
+
+// explainExpanded explains the unboosted score of a prefix, wildcard or
+// fuzzy query in doc, which it matches, computed as by scoreExpanded.
+func (ev *evaluator) explainExpanded(q parser.MultiTermQuery, doc uint32) ranker.Explanation {
+	if ev.rewrite != ScoringBoolean {
+		return ranker.Explanation{Value: 1, Description: fmt.Sprintf("%s, constant score", q)}
+	}
+	e := ranker.Explanation{Description: fmt.Sprintf("%s, sum of its expansions:", q)}
+	for _, et := range ev.expanded[q] {
+		if !containsSorted(matchingDocs(ev.postings[et.term.String()]), doc) {
+			continue
+		}
+		d := ev.explainTerm(et.term, doc)
+		e.Value += d.Value * et.weight
+		e.Details = append(e.Details, ranker.Explanation{
+			Value:       d.Value * et.weight,
+			Description: "weight * score, from:",
+			Details:     []ranker.Explanation{{Value: et.weight, Description: "weight, of the expansion"}, d},
+		})
+	}
+	return e
+}
@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/highlight"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+)
+
+// highlightTerms returns the postings keys of the terms whose matches are
+// highlighted: the terms and phrase terms of every clause that is not
+// excluded, and the terms prefix, wildcard and fuzzy queries expanded to.
+func highlightTerms(root parser.Query, expanded map[parser.Query][]expandedTerm) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	add := func(t parser.Term) {
+		if key := t.String(); !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	parser.Walk(root, func(q parser.Query, occur parser.Occur) bool {
+		if occur == parser.MustNot {
+			return false
+		}
+		switch q := q.(type) {
+		case *parser.TermQuery:
+			add(q.Term)
+		case *parser.PhraseQuery:
+			for _, t := range q.Phrase.FieldTerms() {
+				add(t)
+			}
+		case parser.MultiTermQuery:
+			for _, et := range expanded[q] {
+				add(et.term)
+			}
+		}
+		return true
+	})
+	return keys
+}
+
+// matchPositions returns the positions at which the terms with the given
+// postings keys occur in doc, by field.
+func matchPositions(postings map[string]ranker.FieldPostings, keys []string, doc uint32) map[string][]int {
+	var positions map[string][]int
+	for _, key := range keys {
+		for field, list := range postings[key] {
+			i := seekPosting(list, 0, doc)
+			if i == len(list) || list[i].Doc != doc {
+				continue
+			}
+			if positions == nil {
+				positions = make(map[string][]int)
+			}
+			positions[field] = append(positions[field], list[i].Positions...)
+		}
+	}
+	return positions
+}
+
+// highlightFields returns the highlighted fragments of every stored text
+// field of doc holding one of the given match positions, or nil if there
+// are none or the analyzer cannot locate its tokens.
+func highlightFields(doc index.Document, analyzer analysis.Analyzer, positions map[string][]int, cfg highlight.Config) map[string][]string {
+	locator, ok := analyzer.(analysis.Locator)
+	if !ok {
+		return nil
+	}
+	var fields map[string][]string
+	for field, pos := range positions {
+		text, ok := doc.Fields[field]
+		if !ok {
+			continue
+		}
+		located, spans := locator.Locate(text)
+		if fragments := highlight.Fragments(located, spans, pos, cfg); len(fragments) > 0 {
+			if fields == nil {
+				fields = make(map[string][]string)
+			}
+			fields[field] = fragments
+		}
+	}
+	return fields
+}
@@ -0,0 +1,158 @@
+package executor
+
+import (
+	"sort"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+)
+
+// phraseMatch holds the documents a phrase matches, sorted, and its matches
+// for ranking.
+type phraseMatch struct {
+	docs    []uint32
+	matches ranker.PhraseMatches
+}
+
+// matchPhrase finds the documents in which phrase occurs, given the
+// postings of its terms in order. A document matches if the phrase occurs
+// within one field; every field is checked for an unrestricted phrase.
+func matchPhrase(phrase parser.Phrase, postings []ranker.FieldPostings) phraseMatch {
+	m := phraseMatch{
+		matches: ranker.PhraseMatches{
+			Fields: make(map[string]map[uint32]float64),
+			Terms:  make([]ranker.TermStats, len(postings)),
+		},
+	}
+	for i, fields := range postings {
+		m.matches.Terms[i] = termStatsOf(fields)
+	}
+	if len(postings) == 0 {
+		return m
+	}
+	lists := make([]index.DocPostingList, len(postings))
+	positions := make([][]int, len(postings))
+	for field, first := range postings[0] {
+		complete := true
+		for i, fields := range postings {
+			if lists[i] = fields[field]; len(lists[i]) == 0 {
+				complete = false
+				break
+			}
+		}
+		if !complete {
+			continue
+		}
+		next := make([]int, len(lists))
+		var docs map[uint32]float64
+	candidates:
+		for _, p := range first {
+			positions[0] = p.Positions
+			for i := 1; i < len(lists); i++ {
+				next[i] = seekPosting(lists[i], next[i], p.Doc)
+				if next[i] == len(lists[i]) {
+					break candidates
+				}
+				if lists[i][next[i]].Doc != p.Doc {
+					continue candidates
+				}
+				positions[i] = lists[i][next[i]].Positions
+			}
+			if freq := phraseFreq(positions, phrase.Slop); freq > 0 {
+				if docs == nil {
+					docs = make(map[uint32]float64)
+				}
+				docs[p.Doc] = freq
+			}
+		}
+		if docs == nil {
+			continue
+		}
+		m.matches.Fields[field] = docs
+		list := make([]uint32, 0, len(docs))
+		for doc := range docs {
+			list = append(list, doc)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+		m.docs = mergeSorted(m.docs, list)
+	}
+	return m
+}
+
+// seekPosting returns the index of the first posting at or after from whose
+// document is at least doc.
+func seekPosting(postings index.DocPostingList, from int, doc uint32) int {
+	return from + sort.Search(len(postings)-from, func(i int) bool {
+		return postings[from+i].Doc >= doc
+	})
+}
+
+// phraseFreq returns the match frequency of a phrase in one field, given
+// the sorted positions of each of its terms there. Each occurrence is
+// measured by how far its terms lie from consecutive positions: the spread
+// of position minus index in the phrase over its terms. Exact occurrences
+// count 1 and those within slop count 1/(1+spread).
+func phraseFreq(positions [][]int, slop int) float64 {
+	if slop == 0 {
+		var freq float64
+		next := make([]int, len(positions))
+	exact:
+		for _, start := range positions[0] {
+			for i := 1; i < len(positions); i++ {
+				want := start + i
+				for next[i] < len(positions[i]) && positions[i][next[i]] < want {
+					next[i]++
+				}
+				if next[i] == len(positions[i]) {
+					break exact
+				}
+				if positions[i][next[i]] != want {
+					continue exact
+				}
+			}
+			freq++
+		}
+		return freq
+	}
+
+	// Sweep the terms' positions, shifted by their index in the phrase, in
+	// ascending order. Every step measures the window spanned by the current
+	// position of each term and then moves past the lowest one, so each
+	// position starts at most one counted occurrence.
+	var freq float64
+	next := make([]int, len(positions))
+	for {
+		lo, hi, lowest := 0, 0, 0
+		for i, list := range positions {
+			if next[i] == len(list) {
+				return freq
+			}
+			shifted := list[next[i]] - i
+			if i == 0 || shifted < lo {
+				lo, lowest = shifted, i
+			}
+			if i == 0 || shifted > hi {
+				hi = shifted
+			}
+		}
+		if hi-lo <= slop && distinctPositions(positions, next) {
+			freq += 1 / float64(1+hi-lo)
+		}
+		next[lowest]++
+	}
+}
+
+// distinctPositions reports whether the current positions of the terms
+// are all different, so that a term repeated in a phrase is not matched
+// twice by the same occurrence.
+func distinctPositions(positions [][]int, next []int) bool {
+	for i := range positions {
+		for j := i + 1; j < len(positions); j++ {
+			if positions[i][next[i]] == positions[j][next[j]] {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -2,148 +2,325 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
 	"sync"
 
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/aggregation"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
 )
 
 // ShardResult holds the raw postings and metadata returned by a single shard.
+// Postings, and the Impacts of the postings of top-k searches' terms, are
+// keyed by query term and field and, like the documents matching each
+// range query in Ranges, addressed by the document numbers of View, which
+// stays pinned until the query finishes.
 type ShardResult struct {
-	ShardID   int
-	Postings  map[string]index.PostingList
-	TotalDocs int64
-	AvgDocLen float64
-	Engine    *indexer.Engine
+	ShardID     int
+	Postings    map[string]ranker.FieldPostings
+	Impacts     map[string]FieldImpacts
+	Ranges      map[*parser.RangeQuery][]uint32
+	TotalDocs   int64
+	AvgDocLen   float64
+	FieldTokens map[string]int64
+	View        *indexer.View
+
+	// candidates holds the terms of the shard each prefix, wildcard and
+	// fuzzy query of the plan matches.
+	candidates map[parser.MultiTermQuery]map[string]termCandidate
 }
 
 // ShardedExecutor fans out a query across multiple shard engines in parallel
 // and merges the results.
 type ShardedExecutor struct {
-	engines map[int]*indexer.Engine
-	logger  *slog.Logger
+	engines   map[int]*indexer.Engine
+	ranking   ranker.Config
+	expansion ExpansionConfig
+	logger    *slog.Logger
 }
 
-// NewSharded creates a ShardedExecutor over the given set of shard engines.
-func NewSharded(engines map[int]*indexer.Engine) *ShardedExecutor {
+// NewSharded creates a ShardedExecutor over the given set of shard engines
+// that ranks with the given scoring configuration and expands prefix,
+// wildcard and fuzzy queries as configured.
+func NewSharded(engines map[int]*indexer.Engine, ranking ranker.Config, expansion ExpansionConfig) *ShardedExecutor {
 	return &ShardedExecutor{
-		engines: engines,
-		logger:  slog.Default().With("component", "sharded-executor"),
+		engines:   engines,
+		ranking:   ranking,
+		expansion: expansion.withDefaults(),
+		logger:    slog.Default().With("component", "sharded-executor"),
 	}
 }
 
-// Execute fans out the query to every shard, merges postings, applies Boolean
-// filtering and BM25 ranking, and returns the top-limit results.
-func (se *ShardedExecutor) Execute(ctx context.Context, plan *parser.QueryPlan, limit int) (*SearchResult, error) {
-	if len(plan.Terms) == 0 {
-		return &SearchResult{
-			Query:   plan.RawQuery,
-			Results: []ranker.ScoredDoc{},
-		}, nil
+// Execute fans out the query to every shard, expands prefix, wildcard and
+// fuzzy queries into the terms matched on any shard, merges postings,
+// evaluates the query tree over them, and returns the page of results opts selects with the
+// requested stored fields and highlighted fragments. Each shard's document numbers are offset by the
+// sizes of the shards before it so that merged postings stay sorted by a
+// query-wide document number, and impacts are offset alike for top-k
+// searches evaluated document at a time. Aggregations are collected on each shard over
+// its matching documents and merged. Field length averages are computed over all
+// shards. Results are ordered by score, or opts.Sort, and then document ID,
+// so a SearchAfter cursor is independent of how documents are sharded.
+func (se *ShardedExecutor) Execute(ctx context.Context, plan *parser.QueryPlan, opts Options) (*SearchResult, error) {
+	if plan.Empty() {
+		return EmptyResult(plan.RawQuery, opts), nil
+	}
+	topK, early := planTopK(plan.Root, opts)
+	var impactTerms []parser.Term
+	if early {
+		impactTerms = topK.terms()
+	}
+	q, err := se.prepare(ctx, plan, opts, impactTerms)
+	if err != nil {
+		return nil, err
+	}
+	defer q.close()
+	var ranked []ranker.ScoredDoc
+	var candidates []uint32
+	var termStats map[string]int
+	total, relation := 0, TotalHitsEqual
+	if early {
+		ranked, total, relation, termStats = evaluateTopK(plan.Root, topK, q.postings, q.impacts, q.expanded, q.ranges, se.expansion.Rewrite, q.params, q.docInfo, q.docs, opts.page())
+	} else {
+		ranked, candidates, termStats = evaluate(plan.Root, q.postings, q.expanded, q.ranges, se.expansion.Rewrite, q.params, q.docInfo, q.docs, opts.page())
+		total = len(candidates)
+	}
+	if opts.Explain {
+		explainResults(ranked, q.evaluator(se.expansion.Rewrite), plan.Root, explainCorpus(q.params, q.shards))
 	}
-	shardResults, err := se.fanOut(ctx, plan)
+	var highlighted []string
+	if opts.Highlight != nil {
+		highlighted = highlightTerms(plan.Root, q.expanded)
+	}
+	for i := range ranked {
+		shard := q.docs.shardOf(ranked[i].Doc)
+		sr := q.shards[shard]
+		doc := ranked[i].Doc - q.offsets[shard]
+		ranked[i].DocID = sr.View.DocID(doc)
+		if len(opts.Fields) > 0 || opts.Highlight != nil {
+			positions := matchPositions(q.postings, highlighted, ranked[i].Doc)
+			fetchStored(se.logger, &ranked[i], sr.View, doc, opts, se.engines[sr.ShardID].Analyzer(), positions)
+		}
+	}
+	se.logger.Info("sharded query executed",
+		"query", plan.RawQuery,
+		"shards_queried", len(q.shards),
+		"global_candidates", total,
+		"total_relation", relation,
+		"results", len(ranked),
+	)
+	result := &SearchResult{
+		Query:             plan.RawQuery,
+		TotalHits:         total,
+		TotalHitsRelation: relation,
+		Results:           ranked,
+		TermStats:         termStats,
+	}
+	if len(opts.Aggs) > 0 {
+		result.Aggregations = aggregation.Results(opts.Aggs, collectAggregations(q.shards, q.offsets, candidates, opts.Aggs))
+	}
+	return result, nil
+}
+
+// shardedQuery holds a query plan prepared over every shard: the results
+// of each shard, whose views stay pinned until close, and what the plan is
+// evaluated with, addressed by query-wide document numbers.
+type shardedQuery struct {
+	preparedQuery
+	shards  []ShardResult
+	impacts map[string]FieldImpacts
+	offsets []uint32
+	docs    *shardDocs
+}
+
+// close releases the views of the shards.
+func (q *shardedQuery) close() {
+	for _, sr := range q.shards {
+		sr.View.Close()
+	}
+}
+
+// prepare fans the plan out to every shard, with the impacts of the
+// postings of impactTerms, expands prefix, wildcard and fuzzy queries into
+// the terms matched on any shard, and merges the shards' postings, impacts
+// and ranges and their statistics. The result must be closed.
+func (se *ShardedExecutor) prepare(ctx context.Context, plan *parser.QueryPlan, opts Options, impactTerms []parser.Term) (*shardedQuery, error) {
+	shardResults, err := se.fanOut(ctx, plan, impactTerms)
 	if err != nil {
 		return nil, fmt.Errorf("shard fan-out: %w", err)
 	}
-	mergedPostings := make(map[string]index.PostingList)
-	termStats := make(map[string]int)
+	q := &shardedQuery{shards: shardResults}
+	sort.Slice(shardResults, func(i, j int) bool {
+		return shardResults[i].ShardID < shardResults[j].ShardID
+	})
+	matched := make(map[parser.MultiTermQuery]map[string]termCandidate)
+	for _, sr := range shardResults {
+		mergeCandidates(matched, sr.candidates)
+	}
+	expanded := selectExpansions(matched, se.expansion.MaxExpansions)
+	if err := fetchExpanded(shardResults, expandedTerms(expanded)); err != nil {
+		q.close()
+		return nil, fmt.Errorf("expanding terms: %w", err)
+	}
+
+	mergedPostings := make(map[string]ranker.FieldPostings)
+	mergedImpacts := make(map[string]FieldImpacts)
+	mergedRanges := make(map[*parser.RangeQuery][]uint32)
+	offsets := make([]uint32, len(shardResults))
+	var next uint32
 	var globalTotalDocs int64
 	var globalTotalTokens float64
-	engineLookup := make(map[string]*indexer.Engine)
-	for _, sr := range shardResults {
+	globalFieldTokens := make(map[string]int64)
+	for i, sr := range shardResults {
+		offsets[i] = next
+		next += sr.View.Size()
 		globalTotalDocs += sr.TotalDocs
 		globalTotalTokens += sr.AvgDocLen * float64(sr.TotalDocs)
-		for term, postings := range sr.Postings {
-			mergedPostings[term] = append(mergedPostings[term], postings...)
-			termStats[term] += len(postings)
+		for field, n := range sr.FieldTokens {
+			globalFieldTokens[field] += n
 		}
-		for term := range sr.Postings {
-			for _, p := range sr.Postings[term] {
-				engineLookup[p.DocID] = sr.Engine
+		for term, fields := range sr.Postings {
+			merged := mergedPostings[term]
+			if merged == nil {
+				merged = make(ranker.FieldPostings)
+				mergedPostings[term] = merged
+			}
+			for field, postings := range fields {
+				for _, p := range postings {
+					p.Doc += offsets[i]
+					merged[field] = append(merged[field], p)
+				}
+			}
+		}
+		for term, fields := range sr.Impacts {
+			merged := mergedImpacts[term]
+			if merged == nil {
+				merged = make(FieldImpacts)
+				mergedImpacts[term] = merged
+			}
+			for field, impacts := range fields {
+				for _, impact := range impacts {
+					impact.LastDoc += offsets[i]
+					merged[field] = append(merged[field], impact)
+				}
+			}
+		}
+		for q, docs := range sr.Ranges {
+			for _, doc := range docs {
+				mergedRanges[q] = append(mergedRanges[q], doc+offsets[i])
 			}
 		}
 	}
 	var globalAvgDocLen float64
+	avgFieldLengths := make(map[string]float64, len(globalFieldTokens))
 	if globalTotalDocs > 0 {
 		globalAvgDocLen = globalTotalTokens / float64(globalTotalDocs)
-	}
-	excludeDocIDs := make(map[string]struct{})
-	for _, sr := range shardResults {
-		for _, term := range plan.ExcludeTerms {
-			for _, p := range sr.Postings[term] {
-				excludeDocIDs[p.DocID] = struct{}{}
-			}
-		}
-	}
-	searchPostings := make(map[string]index.PostingList)
-	for _, term := range plan.Terms {
-		if postings, ok := mergedPostings[term]; ok {
-			searchPostings[term] = postings
+		for field, n := range globalFieldTokens {
+			avgFieldLengths[field] = float64(n) / float64(globalTotalDocs)
 		}
 	}
-
-	var candidateDocIDs map[string]struct{}
-	switch plan.Type {
-	case parser.QueryAND:
-		candidateDocIDs = intersectPostings(searchPostings)
-	case parser.QueryOR:
-		candidateDocIDs = unionPostings(searchPostings)
+	params := ranker.RankParams{
+		TotalDocs:       globalTotalDocs,
+		AvgDocLength:    globalAvgDocLen,
+		AvgFieldLengths: avgFieldLengths,
+		Config:          opts.ranking(se.ranking),
 	}
 
-	for docID := range excludeDocIDs {
-		delete(candidateDocIDs, docID)
+	docs := &shardDocs{results: shardResults, offsets: offsets}
+	q.preparedQuery = preparedQuery{
+		postings: mergedPostings,
+		expanded: expanded,
+		ranges:   mergedRanges,
+		params:   params,
 	}
-	filteredPostings := make(map[string]index.PostingList)
-	for term, postings := range searchPostings {
-		filtered := make(index.PostingList, 0)
-		for _, p := range postings {
-			if _, ok := candidateDocIDs[p.DocID]; ok {
-				filtered = append(filtered, p)
-			}
-		}
-		if len(filtered) > 0 {
-			filteredPostings[term] = filtered
+	q.impacts = mergedImpacts
+	q.offsets = offsets
+	q.docs = docs
+	q.docInfo = func(doc uint32) ranker.DocInfo {
+		view, doc := docs.locate(doc)
+		return ranker.DocInfo{
+			DocLength:    view.DocLength(doc),
+			FieldLengths: view.FieldLengths(doc),
 		}
 	}
-	params := ranker.RankParams{
-		TotalDocs:    globalTotalDocs,
-		AvgDocLength: globalAvgDocLen,
-	}
+	return q, nil
+}
 
-	getDocInfo := func(docID string) ranker.DocInfo {
-		if engine, ok := engineLookup[docID]; ok {
-			return ranker.DocInfo{
-				DocLength: engine.GetDocLength(docID),
-			}
+// collectAggregations collects the partial state of reqs over the matching
+// documents of each shard, given by their sorted query-wide numbers in
+// candidates, concurrently with the shard's view, and merges them.
+func collectAggregations(shardResults []ShardResult, offsets []uint32, candidates []uint32, reqs []aggregation.Request) []*aggregation.Partial {
+	partials := make([][]*aggregation.Partial, len(shardResults))
+	var wg sync.WaitGroup
+	start := 0
+	for i, sr := range shardResults {
+		end := start + sort.Search(len(candidates)-start, func(j int) bool {
+			return candidates[start+j] >= offsets[i]+sr.View.Size()
+		})
+		local := make([]uint32, end-start)
+		for j, doc := range candidates[start:end] {
+			local[j] = doc - offsets[i]
 		}
-		return ranker.DocInfo{DocLength: 0}
+		start = end
+		wg.Add(1)
+		go func(i int, view *indexer.View) {
+			defer wg.Done()
+			partials[i] = aggregation.Collect(reqs, local, view)
+		}(i, sr.View)
 	}
-	ranked := ranker.Rank(filteredPostings, params, getDocInfo, limit)
-	se.logger.Info("sharded query executed",
-		"query", plan.RawQuery,
-		"shards_queried", len(shardResults),
-		"global_candidates", len(candidateDocIDs),
-		"results", len(ranked),
-	)
-	return &SearchResult{
-		Query:     plan.RawQuery,
-		TotalHits: len(candidateDocIDs),
-		Results:   ranked,
-		TermStats: termStats,
-	}, nil
+	wg.Wait()
+	merged := aggregation.Collect(reqs, nil, nil) // the empty states
+	for _, p := range partials {
+		merged = aggregation.Merge(merged, p)
+	}
+	return merged
+}
+
+// shardDocs resolves the query-wide document numbers of merged shard
+// results, each shard's numbered from its offset.
+type shardDocs struct {
+	results []ShardResult
+	offsets []uint32
+}
+
+// shardOf returns the index of the shard holding doc.
+func (d *shardDocs) shardOf(doc uint32) int {
+	return sort.Search(len(d.offsets), func(i int) bool {
+		return d.offsets[i] > doc
+	}) - 1
+}
+
+// locate returns the view of the shard holding doc and its number there.
+func (d *shardDocs) locate(doc uint32) (*indexer.View, uint32) {
+	i := d.shardOf(doc)
+	return d.results[i].View, doc - d.offsets[i]
 }
 
-// fanOut queries all shards concurrently and collects their results.
-func (se *ShardedExecutor) fanOut(ctx context.Context, plan *parser.QueryPlan) ([]ShardResult, error) {
+// DocID implements ranker.Docs.
+func (d *shardDocs) DocID(doc uint32) string {
+	view, doc := d.locate(doc)
+	return view.DocID(doc)
+}
+
+// Value implements ranker.Docs.
+func (d *shardDocs) Value(key string, doc uint32) (index.Value, bool) {
+	view, doc := d.locate(doc)
+	return view.Value(key, doc)
+}
+
+// fanOut queries all shards concurrently and collects their results, with
+// the impacts of the postings of impactTerms.
+func (se *ShardedExecutor) fanOut(ctx context.Context, plan *parser.QueryPlan, impactTerms []parser.Term) ([]ShardResult, error) {
 	type result struct {
 		sr  ShardResult
 		err error
 	}
-	allTerms := append(plan.Terms, plan.ExcludeTerms...)
+	allTerms := plan.Terms()
 	results := make([]result, len(se.engines))
 	var wg sync.WaitGroup
 	i := 0
@@ -151,23 +328,34 @@ func (se *ShardedExecutor) fanOut(ctx context.Context, plan *parser.QueryPlan) (
 		wg.Add(1)
 		go func(idx int, sid int, eng *indexer.Engine) {
 			defer wg.Done()
+			view := eng.AcquireView()
 			sr := ShardResult{
-				ShardID:   sid,
-				Postings:  make(map[string]index.PostingList),
-				TotalDocs: eng.GetTotalDocs(),
-				AvgDocLen: eng.GetAvgDocLength(),
-				Engine:    eng,
+				ShardID:     sid,
+				Postings:    make(map[string]ranker.FieldPostings),
+				Impacts:     make(map[string]FieldImpacts),
+				TotalDocs:   view.TotalDocs(),
+				AvgDocLen:   view.AvgDocLength(),
+				FieldTokens: view.FieldTokens(),
+				View:        view,
+				candidates:  make(map[parser.MultiTermQuery]map[string]termCandidate),
 			}
+			fields := view.Fields()
+			collectCandidates(view, plan.Root, fields, sr.candidates)
+			sr.Ranges = rangeDocs(view, plan.Root)
 			for _, term := range allTerms {
-				postings, err := eng.Search(term)
+				postings, err := termPostings(view, term, fields)
 				if err != nil {
+					view.Close()
 					results[idx] = result{err: fmt.Errorf("shard %d, term %q: %w", sid, term, err)}
 					return
 				}
 				if len(postings) > 0 {
-					sr.Postings[term] = postings
+					sr.Postings[term.String()] = postings
 				}
 			}
+			for _, term := range impactTerms {
+				sr.Impacts[term.String()] = termImpacts(view, term, sr.Postings[term.String()])
+			}
 			results[idx] = result{sr: sr}
 		}(i, shardID, engine)
 		i++
@@ -186,3 +374,36 @@ func (se *ShardedExecutor) fanOut(ctx context.Context, plan *parser.QueryPlan) (
 	}
 	return shardResults, nil
 }
+
+// fetchExpanded adds the postings of the terms queries were expanded to,
+// which are not known before every shard has been searched, to each
+// shard's results.
+func fetchExpanded(shardResults []ShardResult, terms []parser.Term) error {
+	if len(terms) == 0 {
+		return nil
+	}
+	errs := make([]error, len(shardResults))
+	var wg sync.WaitGroup
+	for i := range shardResults {
+		wg.Add(1)
+		go func(sr *ShardResult) {
+			defer wg.Done()
+			fields := sr.View.Fields()
+			for _, term := range terms {
+				if _, ok := sr.Postings[term.String()]; ok {
+					continue
+				}
+				postings, err := termPostings(sr.View, term, fields)
+				if err != nil {
+					errs[i] = fmt.Errorf("shard %d, term %q: %w", sr.ShardID, term, err)
+					return
+				}
+				if len(postings) > 0 {
+					sr.Postings[term.String()] = postings
+				}
+			}
+		}(&shardResults[i])
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
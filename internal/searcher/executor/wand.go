@@ -0,0 +1,563 @@
+package executor
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+)
+
+// noDoc is the document of an exhausted cursor, after every real one.
+const noDoc = math.MaxUint32
+
+// FieldImpacts holds the impacts (see index.Impact) of the postings of one
+// query term in each field it is searched in.
+type FieldImpacts map[string][]index.Impact
+
+// weightedTerm is a scoring term of a flattened query with the product of
+// its boost and those of the queries it is nested in.
+type weightedTerm struct {
+	query *parser.TermQuery
+	boost float64
+}
+
+// topKQuery is a query tree flattened for document-at-a-time evaluation.
+// A document matches if it contains every required term and at least
+// minShould, zero or one, of the optional terms, matches every filter and
+// none of the excluded queries. It scores the boosted sum of the scores of
+// the terms it contains, as the tree would.
+type topKQuery struct {
+	required  []weightedTerm
+	optional  []weightedTerm
+	minShould int
+	filters   []parser.Query
+	excluded  []parser.Query
+}
+
+// terms returns the scoring terms of q.
+func (q topKQuery) terms() []parser.Term {
+	terms := make([]parser.Term, 0, len(q.required)+len(q.optional))
+	for _, wt := range append(append([]weightedTerm(nil), q.required...), q.optional...) {
+		terms = append(terms, wt.query.Term)
+	}
+	return terms
+}
+
+// planTopK returns root flattened for document-at-a-time evaluation, and
+// false if the page opts selects needs every match scored: under an
+// explicit sort, after a cursor, with aggregations or an exact total, for
+// all the results, or if root does not flatten.
+func planTopK(root parser.Query, opts Options) (topKQuery, bool) {
+	if opts.Limit <= 0 || opts.ExactTotal || len(opts.Sort) > 0 || opts.SearchAfter != nil || len(opts.Aggs) > 0 {
+		return topKQuery{}, false
+	}
+	q, ok := flattenTopK(root, 1)
+	if !ok || len(q.required)+len(q.optional) == 0 || len(q.required) == 0 && q.minShould == 0 {
+		return topKQuery{}, false
+	}
+	return q, true
+}
+
+// flattenTopK flattens q, nested in queries with the given combined boost.
+// Trees scoring phrases or expanded queries, with a MinimumShouldMatch
+// above one or with optional terms in more than one Boolean query do not
+// flatten.
+func flattenTopK(q parser.Query, boost float64) (topKQuery, bool) {
+	switch q := q.(type) {
+	case *parser.TermQuery:
+		if boost*q.Boost < 0 {
+			return topKQuery{}, false
+		}
+		return topKQuery{required: []weightedTerm{{query: q, boost: boost * q.Boost}}}, true
+	case *parser.BooleanQuery:
+		return flattenBoolean(q, boost*q.Boost)
+	}
+	return topKQuery{}, false
+}
+
+// flattenBoolean flattens a Boolean query with the given combined boost.
+// Its Filter clauses, and Must range queries, which score nothing, become
+// filters; Must clauses that flatten add their terms, filters and
+// exclusions to it.
+func flattenBoolean(q *parser.BooleanQuery, boost float64) (topKQuery, bool) {
+	if q.MinimumShouldMatch > 1 || boost < 0 {
+		return topKQuery{}, false
+	}
+	var flat topKQuery
+	var nested []weightedTerm
+	nestedMin := 0
+	hasRequired, hasShould := false, false
+	for _, c := range q.Clauses {
+		switch c.Occur {
+		case parser.Filter:
+			hasRequired = true
+			flat.filters = append(flat.filters, c.Query)
+		case parser.MustNot:
+			flat.excluded = append(flat.excluded, c.Query)
+		case parser.Should:
+			tq, ok := c.Query.(*parser.TermQuery)
+			if !ok || tq.Boost < 0 {
+				return topKQuery{}, false
+			}
+			hasShould = true
+			flat.optional = append(flat.optional, weightedTerm{query: tq, boost: boost * tq.Boost})
+		case parser.Must:
+			hasRequired = true
+			if rq, ok := c.Query.(*parser.RangeQuery); ok {
+				flat.filters = append(flat.filters, rq)
+				continue
+			}
+			inner, ok := flattenTopK(c.Query, boost)
+			if !ok {
+				return topKQuery{}, false
+			}
+			flat.required = append(flat.required, inner.required...)
+			flat.filters = append(flat.filters, inner.filters...)
+			flat.excluded = append(flat.excluded, inner.excluded...)
+			if len(inner.optional) > 0 {
+				if nested != nil {
+					return topKQuery{}, false
+				}
+				nested, nestedMin = inner.optional, inner.minShould
+			}
+		}
+	}
+	switch {
+	case !hasRequired && !hasShould:
+		return topKQuery{}, false
+	case hasShould && nested != nil:
+		return topKQuery{}, false
+	case hasShould:
+		flat.minShould = q.MinimumShouldMatch
+		if !hasRequired {
+			flat.minShould = 1
+		}
+	case q.MinimumShouldMatch > 0:
+		return topKQuery{}, false
+	default:
+		flat.optional, flat.minShould = nested, nestedMin
+	}
+	return flat, true
+}
+
+// termImpacts returns the impacts of a term's postings in each field, as
+// termPostings returns them. Impacts that cannot be read are computed from
+// the postings.
+func termImpacts(view *indexer.View, term parser.Term, postings ranker.FieldPostings) FieldImpacts {
+	result := make(FieldImpacts, len(postings))
+	for field, fieldPostings := range postings {
+		impacts := view.Impacts(index.FieldTerm(field, term.Text), fieldPostings)
+		if impacts == nil {
+			impacts = index.Impacts(fieldPostings, func(doc uint32) int { return view.FieldLengths(doc)[field] })
+		}
+		result[field] = impacts
+	}
+	return result
+}
+
+// evaluateTopK evaluates a flattened query tree document at a time,
+// skipping the documents that cannot reach the requested page, over
+// postings and their impacts, both keyed by the String of each term, and
+// ranks the documents it scores like evaluate. The terms required of every
+// match are intersected; documents are scored only where the bounds of the
+// blocks of postings they are in could reach the page, and the optional
+// terms alone are evaluated with block-max WAND. It returns the requested
+// page, the number of matching documents found, which is a lower bound if
+// documents were skipped, its relation to the number of matches, and the
+// number of documents matching each term and range of the tree.
+func evaluateTopK(
+	root parser.Query,
+	q topKQuery,
+	postings map[string]ranker.FieldPostings,
+	impacts map[string]FieldImpacts,
+	expanded map[parser.Query][]expandedTerm,
+	ranges map[*parser.RangeQuery][]uint32,
+	rewrite Rewrite,
+	params ranker.RankParams,
+	getDocInfo func(doc uint32) ranker.DocInfo,
+	docs ranker.Docs,
+	page ranker.Page,
+) ([]ranker.ScoredDoc, int, TotalHitsRelation, map[string]int) {
+	ev := newEvaluator(postings, expanded, ranges, rewrite, params, getDocInfo)
+	cursors := func(terms []weightedTerm) []*termCursor {
+		result := make([]*termCursor, len(terms))
+		for i, wt := range terms {
+			key := wt.query.Term.String()
+			stats := ranker.TermStats{
+				DocFreq:       int64(len(ev.match(wt.query))),
+				TotalTermFreq: totalTermFreq(postings[key]),
+			}
+			result[i] = newTermCursor(wt.boost, postings[key], impacts[key], stats, params, getDocInfo)
+		}
+		return result
+	}
+	var filter docFilter
+	if len(q.filters) > 0 {
+		lists := make([][]uint32, len(q.filters))
+		for i, f := range q.filters {
+			lists[i] = ev.match(f)
+		}
+		filter.allowed, filter.filtered = intersectDocs(lists), true
+	}
+	for _, e := range q.excluded {
+		filter.excluded = mergeSorted(filter.excluded, ev.match(e))
+	}
+	c := newTopKCollector(page.Offset + page.Limit)
+	if len(q.required) > 0 {
+		c.conjunction(cursors(q.required), cursors(q.optional), q.minShould, filter)
+	} else {
+		c.disjunction(cursors(q.optional), filter)
+	}
+	relation := TotalHitsEqual
+	if c.skipped {
+		relation = TotalHitsLowerBound
+	}
+	return ranker.TopK(c.scores, docs, page), c.hits, relation, ev.termStats(root)
+}
+
+// docFilter holds the documents the filters of a flattened query allow, if
+// it has any, and those it excludes, both sorted.
+type docFilter struct {
+	allowed  []uint32
+	filtered bool
+	excluded []uint32
+}
+
+// next returns the first allowed document at or after doc, or noDoc.
+func (f docFilter) next(doc uint32) uint32 {
+	if !f.filtered || doc == noDoc {
+		return doc
+	}
+	i := sort.Search(len(f.allowed), func(i int) bool { return f.allowed[i] >= doc })
+	if i == len(f.allowed) {
+		return noDoc
+	}
+	return f.allowed[i]
+}
+
+// fieldCursor iterates the postings of a term in one field and the impacts
+// of their blocks.
+type fieldCursor struct {
+	postings index.DocPostingList
+	impacts  []index.Impact
+	pos      int
+	block    int
+}
+
+// termCursor iterates the documents containing a term in any of its
+// fields in document order, and bounds the term's score in them.
+type termCursor struct {
+	scorer *ranker.TermScorer
+	boost  float64
+	fields []fieldCursor
+	// doc is the current document, or noDoc once the postings are
+	// exhausted.
+	doc uint32
+	// maxScore bounds the term's score in every document.
+	maxScore   float64
+	freqs      []int
+	maxFreqs   []int
+	minLengths []int
+}
+
+// newTermCursor returns a cursor on the first document of the postings of
+// a term with the given boost and statistics. Fields without
+// impacts have them computed from their postings.
+func newTermCursor(boost float64, postings ranker.FieldPostings, impacts FieldImpacts, stats ranker.TermStats, params ranker.RankParams, getDocInfo func(doc uint32) ranker.DocInfo) *termCursor {
+	names := make([]string, 0, len(postings))
+	for field := range postings {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+	c := &termCursor{
+		scorer:     ranker.NewTermScorer(names, stats, params, getDocInfo),
+		boost:      boost,
+		fields:     make([]fieldCursor, len(names)),
+		freqs:      make([]int, len(names)),
+		maxFreqs:   make([]int, len(names)),
+		minLengths: make([]int, len(names)),
+	}
+	for i, field := range names {
+		fieldImpacts := impacts[field]
+		if fieldImpacts == nil {
+			fieldImpacts = index.Impacts(postings[field], func(doc uint32) int {
+				return getDocInfo(doc).FieldLengths[field]
+			})
+		}
+		c.fields[i] = fieldCursor{postings: postings[field], impacts: fieldImpacts}
+		c.minLengths[i] = -1
+		for _, impact := range fieldImpacts {
+			c.maxFreqs[i] = max(c.maxFreqs[i], impact.MaxFreq)
+			if c.minLengths[i] < 0 || impact.MinLength < c.minLengths[i] {
+				c.minLengths[i] = impact.MinLength
+			}
+		}
+		c.minLengths[i] = max(c.minLengths[i], 0)
+	}
+	c.maxScore = c.boost * c.scorer.Bound(c.maxFreqs, c.minLengths)
+	c.seek(0)
+	return c
+}
+
+// seek moves the cursor to the first document at or after target.
+func (c *termCursor) seek(target uint32) {
+	c.doc = noDoc
+	for i := range c.fields {
+		f := &c.fields[i]
+		if f.pos < len(f.postings) && f.postings[f.pos].Doc < target {
+			f.pos = seekPosting(f.postings, f.pos, target)
+		}
+		if f.pos < len(f.postings) {
+			c.doc = min(c.doc, f.postings[f.pos].Doc)
+		}
+	}
+}
+
+// score returns the boosted score of the term in the current document.
+func (c *termCursor) score() float64 {
+	for i, f := range c.fields {
+		c.freqs[i] = 0
+		if f.pos < len(f.postings) && f.postings[f.pos].Doc == c.doc {
+			c.freqs[i] = f.postings[f.pos].Frequency
+		}
+	}
+	return c.boost * c.scorer.Score(c.doc, c.freqs)
+}
+
+// blockBound bounds the boosted score of the term in target and the
+// documents after it up to the returned one, the last of the blocks of
+// postings holding target. target must not precede the targets of earlier
+// calls.
+func (c *termCursor) blockBound(target uint32) (float64, uint32) {
+	end := uint32(noDoc)
+	for i := range c.fields {
+		f := &c.fields[i]
+		for f.block < len(f.impacts) && f.impacts[f.block].LastDoc < target {
+			f.block++
+		}
+		if f.block == len(f.impacts) {
+			if f.pos < len(f.postings) {
+				// Postings past the impacts cannot be bounded.
+				return math.Inf(1), noDoc
+			}
+			c.maxFreqs[i] = 0
+			continue
+		}
+		impact := f.impacts[f.block]
+		c.maxFreqs[i], c.minLengths[i] = impact.MaxFreq, impact.MinLength
+		end = min(end, impact.LastDoc)
+	}
+	return c.boost * c.scorer.Bound(c.maxFreqs, c.minLengths), end
+}
+
+// after returns the document after doc, or noDoc for noDoc.
+func after(doc uint32) uint32 {
+	if doc == noDoc {
+		return noDoc
+	}
+	return doc + 1
+}
+
+// topKCollector keeps the scores of the documents that can still reach the
+// top k. Scores are compared rounded, as TopK orders them, so documents
+// tied with the k-th best are kept for TopK to break the ties.
+type topKCollector struct {
+	k      int
+	best   scoreHeap
+	scores map[uint32]float64
+	hits   int
+	// skipped is set once a document that may match is skipped.
+	skipped bool
+}
+
+// newTopKCollector returns a collector of the top k documents.
+func newTopKCollector(k int) *topKCollector {
+	return &topKCollector{k: k, scores: make(map[uint32]float64)}
+}
+
+// threshold returns the rounded score of the k-th best document, or
+// negative infinity while there are fewer.
+func (c *topKCollector) threshold() float64 {
+	if len(c.best) < c.k {
+		return math.Inf(-1)
+	}
+	return c.best[0]
+}
+
+// competitive reports whether a document scoring up to bound could reach
+// the top k. The bound is widened slightly against rounding errors.
+func (c *topKCollector) competitive(bound float64) bool {
+	return ranker.RoundScore(bound+math.Abs(bound)*1e-9+1e-9) >= c.threshold()
+}
+
+// collect counts a matching document and keeps its score if it reaches
+// the top k.
+func (c *topKCollector) collect(doc uint32, score float64) {
+	c.hits++
+	rounded := ranker.RoundScore(score)
+	if rounded < c.threshold() {
+		return
+	}
+	c.scores[doc] = score
+	if len(c.best) < c.k {
+		heap.Push(&c.best, rounded)
+	} else if rounded > c.best[0] {
+		c.best[0] = rounded
+		heap.Fix(&c.best, 0)
+	}
+}
+
+// conjunction collects the documents containing every required term,
+// which must not be empty, and at least minShould of the optional ones.
+// Documents whose block bounds cannot reach the top k are skipped a block
+// at a time.
+func (c *topKCollector) conjunction(required, optional []*termCursor, minShould int, filter docFilter) {
+	lead := required[0]
+	for {
+		doc := lead.doc
+		for aligned := false; !aligned; {
+			doc = filter.next(doc)
+			aligned = true
+			for _, t := range required {
+				t.seek(doc)
+				if t.doc != doc {
+					doc, aligned = t.doc, false
+					break
+				}
+			}
+		}
+		if doc == noDoc {
+			return
+		}
+		if containsSorted(filter.excluded, doc) {
+			lead.seek(doc + 1)
+			continue
+		}
+		var bound float64
+		end := uint32(noDoc)
+		for _, group := range [][]*termCursor{required, optional} {
+			for _, t := range group {
+				b, e := t.blockBound(doc)
+				bound += b
+				end = min(end, e)
+			}
+		}
+		if !c.competitive(bound) {
+			c.skipped = true
+			lead.seek(after(end))
+			continue
+		}
+		var score float64
+		for _, t := range required {
+			score += t.score()
+		}
+		matched := 0
+		for _, t := range optional {
+			t.seek(doc)
+			if t.doc == doc {
+				score += t.score()
+				matched++
+			}
+		}
+		if matched >= minShould {
+			c.collect(doc, score)
+		}
+		lead.seek(doc + 1)
+	}
+}
+
+// disjunction collects the documents containing any of cursors with
+// block-max WAND: the cursors are kept sorted by document, and the pivot
+// is the first document where the score bounds of the cursors up to it
+// could reach the top k. Documents before the pivot cannot, and the pivot
+// is scored only if the bounds of the blocks holding it also could;
+// otherwise the cursors skip to the end of the shortest of those blocks.
+func (c *topKCollector) disjunction(cursors []*termCursor, filter docFilter) {
+	for {
+		sort.Slice(cursors, func(i, j int) bool { return cursors[i].doc < cursors[j].doc })
+		var acc float64
+		p := -1
+		for i, t := range cursors {
+			if t.doc == noDoc {
+				break
+			}
+			acc += t.maxScore
+			if c.competitive(acc) {
+				p = i
+				break
+			}
+		}
+		if p < 0 {
+			if len(cursors) > 0 && cursors[0].doc != noDoc {
+				c.skipped = true
+			}
+			return
+		}
+		pivot := cursors[p].doc
+		if next := filter.next(pivot); next != pivot {
+			if cursors[0].doc < pivot {
+				c.skipped = true
+			}
+			for _, t := range cursors {
+				t.seek(next)
+			}
+			continue
+		}
+		for p+1 < len(cursors) && cursors[p+1].doc == pivot {
+			p++
+		}
+		var bound float64
+		end := uint32(noDoc)
+		for _, t := range cursors[:p+1] {
+			b, e := t.blockBound(pivot)
+			bound += b
+			end = min(end, e)
+		}
+		if !c.competitive(bound) {
+			c.skipped = true
+			next := after(end)
+			if p+1 < len(cursors) {
+				next = min(next, cursors[p+1].doc)
+			}
+			for _, t := range cursors[:p+1] {
+				t.seek(next)
+			}
+			continue
+		}
+		if cursors[0].doc != pivot {
+			c.skipped = true
+			for _, t := range cursors[:p] {
+				t.seek(pivot)
+			}
+			continue
+		}
+		if !containsSorted(filter.excluded, pivot) {
+			var score float64
+			for _, t := range cursors[:p+1] {
+				score += t.score()
+			}
+			c.collect(pivot, score)
+		}
+		for _, t := range cursors[:p+1] {
+			t.seek(pivot + 1)
+		}
+	}
+}
+
+// scoreHeap is a min-heap of rounded scores.
+type scoreHeap []float64
+
+func (h scoreHeap) Len() int           { return len(h) }
+func (h scoreHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h scoreHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *scoreHeap) Push(x any)        { *h = append(*h, x.(float64)) }
+func (h *scoreHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -1,60 +1,104 @@
 // Package handler exposes the search service HTTP endpoints including query
-// execution, cache management, and health checks.
+// execution, query suggestions, cache management, and health checks.
 package handler
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/analytics"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/aggregation"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/cache"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/executor"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/highlight"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/suggest"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/logger"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/metrics"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/middleware"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/proto"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/tracing"
 )
 
 // SearchExecutor abstracts single-shard and sharded query execution.
 type SearchExecutor interface {
-	Execute(ctx context.Context, plan *parser.QueryPlan, limit int) (*executor.SearchResult, error)
+	Execute(ctx context.Context, plan *parser.QueryPlan, opts executor.Options) (*executor.SearchResult, error)
+	Explain(ctx context.Context, plan *parser.QueryPlan, docID string, opts executor.Options) (*executor.ExplainResult, error)
 }
 
 // Handler serves the search service HTTP API.
 type Handler struct {
 	executor     SearchExecutor
+	analyzer     analysis.Analyzer
+	schema       index.Schema
 	cache        *cache.QueryCache
+	suggester    *suggest.Suggester
 	collector    *analytics.Collector
 	metrics      *metrics.Metrics
+	highlight    highlight.Config
 	defaultLimit int
 	maxResults   int
+	maxOffset    int
 	logger       *slog.Logger
 }
 
-// New creates a Handler with the given executor, cache, analytics collector,
-// metrics recorder, and result-limit settings.
-func New(exec SearchExecutor, queryCache *cache.QueryCache, collector *analytics.Collector, m *metrics.Metrics, defaultLimit, maxResults int) *Handler {
+// New creates a Handler with the given executor, query analyzer, metadata
+// schema, cache, suggester, analytics collector, metrics recorder,
+// highlighting settings and result-limit settings. maxOffset caps the
+// offset of a page, or leaves it uncapped if it is not positive. The
+// analyzer and schema must be the ones the index was built with.
+func New(exec SearchExecutor, analyzer analysis.Analyzer, schema index.Schema, queryCache *cache.QueryCache, suggester *suggest.Suggester, collector *analytics.Collector, m *metrics.Metrics, hl highlight.Config, defaultLimit, maxResults, maxOffset int) *Handler {
 	return &Handler{
 		executor:     exec,
+		analyzer:     analyzer,
+		schema:       schema,
 		cache:        queryCache,
+		suggester:    suggester,
 		collector:    collector,
 		metrics:      m,
+		highlight:    hl.WithDefaults(),
 		defaultLimit: defaultLimit,
 		maxResults:   maxResults,
+		maxOffset:    maxOffset,
 		logger:       slog.Default().With("component", "search-handler"),
 	}
 }
 
-// Search handles GET /api/v1/search?q=&limit=. It parses the query,
-// optionally checks the cache, executes the plan, records metrics and
-// analytics, and writes the JSON result.
+// Search handles GET /api/v1/search?q=&filter=&sort=&limit=&offset=&search_after=&fields=&highlight=&aggs=&track_total_hits=&similarity=&explain=. It parses the
+// query, rejecting malformed ones with 400, optionally checks the cache, executes the plan, records metrics and
+// analytics, and writes the JSON result. Each filter, such as
+// created_at:[2026-01-01 TO *], is a query the results must also match
+// without it adding to their score; q may be left out if a filter is
+// given. sort is a comma-separated list of metadata keys and _score, each
+// with an optional :asc or :desc, to order the results by instead of by
+// descending score. offset (or from) skips results,
+// up to the configured maximum, and search_after starts the page after the
+// result a next_search_after cursor of an earlier page, under the same
+// sort, points at, which
+// pages on without limit and stays in place as the index changes. fields is a comma-separated list of
+// stored fields to return with each result, or "*" for all of them, and
+// highlight=true adds fragments of the text fields with the matched words
+// marked up. aggs is a JSON object of named aggregations, such as
+// {"by_category":{"terms":{"field":"category"}}}, computed over all the
+// matching documents and returned under aggregations. Searches for the top
+// results by score skip the documents that cannot reach the page, so total
+// may only be a lower bound, as total_relation says ("eq" or "gte");
+// track_total_hits=true counts every match. similarity ranks with another
+// similarity than the configured one: bm25, bm25plus, tfidf, dfr or
+// lm_dirichlet. explain=true adds to each result the explanation of its
+// score (see Explain). A query without hits carries a
+// did_you_mean spelling correction if one is found.
 func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	ctx := r.Context()
@@ -68,8 +112,9 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	query := r.URL.Query().Get("q")
-	if query == "" {
-		h.writeError(w, http.StatusBadRequest, "query parameter 'q' is required")
+	filterStrs := r.URL.Query()["filter"]
+	if query == "" && len(filterStrs) == 0 {
+		h.writeError(w, http.StatusBadRequest, "query parameter 'q' or 'filter' is required")
 		return
 	}
 
@@ -85,37 +130,139 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 		}
 		limit = parsed
 	}
+	opts := executor.Options{Limit: limit}
+	offsetStr := r.URL.Query().Get("offset")
+	if offsetStr == "" {
+		offsetStr = r.URL.Query().Get("from")
+	}
+	if offsetStr != "" {
+		parsed, err := strconv.Atoi(offsetStr)
+		if err != nil || parsed < 0 {
+			h.writeError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+			return
+		}
+		if h.maxOffset > 0 && parsed > h.maxOffset {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("offset must not exceed %d; use search_after to page further", h.maxOffset))
+			return
+		}
+		opts.Offset = parsed
+	}
+	if afterStr := r.URL.Query().Get("search_after"); afterStr != "" {
+		cursor, err := ranker.ParseCursor(afterStr)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "search_after must be a next_search_after cursor")
+			return
+		}
+		opts.SearchAfter = &cursor
+	}
+	if sortStr := r.URL.Query().Get("sort"); sortStr != "" {
+		fields, err := ranker.ParseSort(sortStr, h.schema)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts.Sort = fields
+	}
+	if opts.SearchAfter != nil {
+		if err := opts.SearchAfter.Check(opts.Sort); err != nil {
+			h.writeError(w, http.StatusBadRequest, "search_after cursor does not match the sort: "+err.Error())
+			return
+		}
+	}
+	if fieldsStr := r.URL.Query().Get("fields"); fieldsStr != "" {
+		fields, ok := parseFields(fieldsStr)
+		if !ok {
+			h.writeError(w, http.StatusBadRequest, "fields must be a comma-separated list of field names, or *")
+			return
+		}
+		opts.Fields = fields
+	}
+	if highlightStr := r.URL.Query().Get("highlight"); highlightStr != "" {
+		enabled, err := strconv.ParseBool(highlightStr)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "highlight must be true or false")
+			return
+		}
+		if enabled {
+			opts.Highlight = &h.highlight
+		}
+	}
+	if aggsStr := r.URL.Query().Get("aggs"); aggsStr != "" {
+		aggs, err := aggregation.Parse(aggsStr, h.schema)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts.Aggs = aggs
+	}
+	if trackStr := r.URL.Query().Get("track_total_hits"); trackStr != "" {
+		exact, err := strconv.ParseBool(trackStr)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "track_total_hits must be true or false")
+			return
+		}
+		opts.ExactTotal = exact
+	}
+	if simStr := r.URL.Query().Get("similarity"); simStr != "" {
+		similarity, err := ranker.ParseModel(simStr)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts.Similarity = similarity
+	}
+	if explainStr := r.URL.Query().Get("explain"); explainStr != "" {
+		explain, err := strconv.ParseBool(explainStr)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "explain must be true or false")
+			return
+		}
+		opts.Explain = explain
+	}
 
 	_, parseSpan := tracing.StartChildSpan(ctx, "parse_query")
-	plan := parser.Parse(query)
-	parseSpan.SetAttr("terms", len(plan.Terms))
-	parseSpan.SetAttr("exclude_terms", len(plan.ExcludeTerms))
+	plan, err := parser.Parse(query, h.analyzer, h.schema)
+	if err != nil {
+		parseSpan.End()
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// The filters are added to a copy of the plan, so that spelling
+	// corrections and analytics see the query alone.
+	searchPlan := *plan
+	for _, f := range filterStrs {
+		filter, err := parser.Parse(f, h.analyzer, h.schema)
+		if err != nil {
+			parseSpan.End()
+			h.writeError(w, http.StatusBadRequest, "filter: "+err.Error())
+			return
+		}
+		searchPlan.AddFilters(filter)
+	}
+	parseSpan.SetAttr("terms", len(searchPlan.Terms()))
+	parseSpan.SetAttr("clauses", len(searchPlan.Clauses()))
 	parseSpan.End()
 
-	if len(plan.Terms) == 0 {
-		h.writeJSON(w, http.StatusOK, &executor.SearchResult{
-			Query:   query,
-			Results: []ranker.ScoredDoc{},
-		})
+	if searchPlan.Empty() {
+		h.writeJSON(w, http.StatusOK, executor.EmptyResult(query, opts))
 		return
 	}
 
 	var result *executor.SearchResult
-	var err error
 	cacheHit := false
 
 	if h.cache != nil {
 		_, cacheSpan := tracing.StartChildSpan(ctx, "cache_lookup")
-		result, cacheHit, err = h.cache.GetOrCompute(ctx, query, limit, func() (*executor.SearchResult, error) {
+		result, cacheHit, err = h.cache.GetOrCompute(ctx, &searchPlan, opts, func() (*executor.SearchResult, error) {
 			_, execSpan := tracing.StartChildSpan(ctx, "execute_query")
 			defer execSpan.End()
-			return h.executor.Execute(ctx, plan, limit)
+			return h.executor.Execute(ctx, &searchPlan, opts)
 		})
 		cacheSpan.SetAttr("hit", cacheHit)
 		cacheSpan.End()
 	} else {
 		_, execSpan := tracing.StartChildSpan(ctx, "execute_query")
-		result, err = h.executor.Execute(ctx, plan, limit)
+		result, err = h.executor.Execute(ctx, &searchPlan, opts)
 		execSpan.End()
 	}
 
@@ -159,7 +306,7 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 		h.collector.Track(analytics.SearchEvent{
 			Type:      eventType,
 			Query:     query,
-			Terms:     plan.Terms,
+			Terms:     plan.Clauses(),
 			TotalHits: result.TotalHits,
 			Returned:  len(result.Results),
 			LatencyMs: latencyMs,
@@ -169,13 +316,129 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	h.writeJSON(w, http.StatusOK, map[string]any{
-		"query":     result.Query,
-		"total":     result.TotalHits,
-		"results":   result.Results,
-		"took_ms":   float64(latencyMs),
-		"cache_hit": cacheHit,
-	})
+	relation := result.TotalHitsRelation
+	if relation == "" {
+		relation = executor.TotalHitsEqual
+	}
+	response := map[string]any{
+		"query":          result.Query,
+		"total":          result.TotalHits,
+		"total_relation": relation,
+		"results":        result.Results,
+		"took_ms":        float64(latencyMs),
+		"cache_hit":      cacheHit,
+	}
+	if result.Aggregations != nil {
+		response["aggregations"] = result.Aggregations
+	}
+	if n := len(result.Results); n > 0 && n == opts.Limit {
+		response["next_search_after"] = ranker.CursorOf(result.Results[n-1]).String()
+	}
+	if result.TotalHits == 0 && h.suggester != nil {
+		if correction, ok := h.suggester.DidYouMean(plan); ok {
+			response["did_you_mean"] = correction
+		}
+	}
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// Explain handles GET /api/v1/search/explain?q=&doc_id=&filter=&similarity=.
+// It explains the score the query, with any filters, gives the document
+// with ID doc_id, computed as a search ranks it: the IDF, normalised
+// frequency and length of every term in each field, the field boosts, and
+// the corpus and shard statistics used. The explanation is returned even
+// if the query does not match the document, with matched false. A missing
+// document is answered with 404.
+func (h *Handler) Explain(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query().Get("q")
+	docID := r.URL.Query().Get("doc_id")
+	if query == "" || docID == "" {
+		h.writeError(w, http.StatusBadRequest, "query parameters 'q' and 'doc_id' are required")
+		return
+	}
+	var opts executor.Options
+	if simStr := r.URL.Query().Get("similarity"); simStr != "" {
+		similarity, err := ranker.ParseModel(simStr)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts.Similarity = similarity
+	}
+	plan, err := parser.Parse(query, h.analyzer, h.schema)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	for _, f := range r.URL.Query()["filter"] {
+		filter, err := parser.Parse(f, h.analyzer, h.schema)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "filter: "+err.Error())
+			return
+		}
+		plan.AddFilters(filter)
+	}
+
+	result, err := h.executor.Explain(ctx, plan, docID, opts)
+	if errors.Is(err, executor.ErrDocNotFound) {
+		h.writeError(w, http.StatusNotFound, "document not found")
+		return
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("explain failed", "query", query, "doc_id", docID, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "explain failed")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, result)
+}
+
+// Suggest handles GET /api/v1/suggest?prefix=&limit=. It returns up to
+// limit completions of prefix, drawn from the indexed terms and popular
+// queries and ranked by frequency.
+func (h *Handler) Suggest(w http.ResponseWriter, r *http.Request) {
+	if h.suggester == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "suggestions are disabled")
+		return
+	}
+	prefix := r.URL.Query().Get("prefix")
+	if strings.TrimSpace(prefix) == "" {
+		h.writeError(w, http.StatusBadRequest, "query parameter 'prefix' is required")
+		return
+	}
+	req := &proto.SuggestRequest{Prefix: prefix}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 {
+			h.writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		req.MaxItems = int32(min(parsed, math.MaxInt32))
+	}
+	resp, err := h.suggester.Suggest(r.Context(), req)
+	if err != nil {
+		h.logger.Error("suggest failed", "prefix", prefix, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "suggest failed")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// parseFields parses the fields parameter of a search. It returns false if
+// a name is neither a valid field name nor executor.AllFields.
+func parseFields(s string) ([]string, bool) {
+	var fields []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if name != executor.AllFields && !index.ValidFieldName(name) {
+			return nil, false
+		}
+		fields = append(fields, name)
+	}
+	return fields, true
 }
 
 // recordSearchMetrics updates Prometheus counters and histograms for the
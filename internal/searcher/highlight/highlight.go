@@ -0,0 +1,218 @@
+// Package highlight builds snippets of stored text in which the words that
+// matched a query are marked up. Matches are located from the positions of
+// the matched terms in the postings, mapped back to the text by the
+// analyzer it was indexed with, and the fragments holding the most
+// matches are chosen.
+package highlight
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+)
+
+// Encoder selects how the text of a fragment is encoded around the tags.
+type Encoder string
+
+const (
+	// HTMLEncoder escapes the text for HTML, so that only the tags are
+	// markup. It is the default.
+	HTMLEncoder Encoder = "html"
+	// NoEncoder leaves the text as it is.
+	NoEncoder Encoder = "none"
+)
+
+// ParseEncoder validates an encoder name. The empty string selects
+// HTMLEncoder.
+func ParseEncoder(s string) (Encoder, error) {
+	switch Encoder(s) {
+	case "", HTMLEncoder:
+		return HTMLEncoder, nil
+	case NoEncoder:
+		return NoEncoder, nil
+	}
+	return "", fmt.Errorf("unknown highlight encoder %q", s)
+}
+
+// Defaults of Config.
+const (
+	DefaultPreTag       = "<em>"
+	DefaultPostTag      = "</em>"
+	DefaultFragmentSize = 100
+	DefaultNumFragments = 3
+)
+
+// Config controls how fragments are built. Zero values select the
+// defaults.
+type Config struct {
+	// PreTag and PostTag are put around every matched word.
+	PreTag, PostTag string
+	// FragmentSize is the length of a fragment in characters. A fragment
+	// ends at a word boundary, so it may be a little shorter, and is longer
+	// only if a single match is.
+	FragmentSize int
+	// NumFragments is the most fragments returned for a field.
+	NumFragments int
+	// Encoder encodes the text of fragments.
+	Encoder Encoder
+}
+
+// WithDefaults returns the configuration with its zero values replaced.
+func (c Config) WithDefaults() Config {
+	if c.PreTag == "" && c.PostTag == "" {
+		c.PreTag, c.PostTag = DefaultPreTag, DefaultPostTag
+	}
+	if c.FragmentSize <= 0 {
+		c.FragmentSize = DefaultFragmentSize
+	}
+	if c.NumFragments <= 0 {
+		c.NumFragments = DefaultNumFragments
+	}
+	if c.Encoder == "" {
+		c.Encoder = HTMLEncoder
+	}
+	return c
+}
+
+// Fragments returns up to cfg.NumFragments fragments of text, those with
+// the most matches first, in which the words the tokens at positions came
+// from are wrapped in the tags. spans locates the word of every position in
+// text, as returned by analysis.Locator. It returns nil if no position is
+// located.
+func Fragments(text string, spans []analysis.Span, positions []int, cfg Config) []string {
+	cfg = cfg.WithDefaults()
+	matches := matchedSpans(spans, positions)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	type fragment struct {
+		start, end int
+		first, n   int
+	}
+	candidates := make([]fragment, 0, len(matches))
+	for i, m := range matches {
+		start, end := window(text, m, cfg.FragmentSize)
+		f := fragment{start: start, end: end, first: i}
+		for f.first > 0 && matches[f.first-1].Start >= start {
+			f.first--
+		}
+		for j := f.first; j < len(matches) && matches[j].End <= end; j++ {
+			f.n++
+		}
+		candidates = append(candidates, f)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].n > candidates[j].n
+	})
+
+	var chosen []fragment
+	for _, c := range candidates {
+		overlaps := false
+		for _, o := range chosen {
+			if c.start < o.end && o.start < c.end {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			continue
+		}
+		chosen = append(chosen, c)
+		if len(chosen) == cfg.NumFragments {
+			break
+		}
+	}
+
+	fragments := make([]string, len(chosen))
+	for i, f := range chosen {
+		var sb strings.Builder
+		last := f.start
+		for _, m := range matches[f.first : f.first+f.n] {
+			sb.WriteString(encode(text[last:m.Start], cfg.Encoder))
+			sb.WriteString(cfg.PreTag)
+			sb.WriteString(encode(text[m.Start:m.End], cfg.Encoder))
+			sb.WriteString(cfg.PostTag)
+			last = m.End
+		}
+		sb.WriteString(encode(text[last:f.end], cfg.Encoder))
+		fragments[i] = strings.TrimSpace(sb.String())
+	}
+	return fragments
+}
+
+// matchedSpans returns the non-empty spans of positions, sorted and with
+// overlapping spans merged.
+func matchedSpans(spans []analysis.Span, positions []int) []analysis.Span {
+	var matches []analysis.Span
+	for _, pos := range positions {
+		if pos >= 0 && pos < len(spans) && spans[pos].End > spans[pos].Start {
+			matches = append(matches, spans[pos])
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+	merged := matches[:0]
+	for _, m := range matches {
+		if n := len(merged); n > 0 && m.Start < merged[n-1].End {
+			merged[n-1].End = max(merged[n-1].End, m.End)
+			continue
+		}
+		merged = append(merged, m)
+	}
+	return merged
+}
+
+// window returns the byte range of a fragment of about size characters
+// around match m, the context split evenly before and after it where the
+// text allows, and trimmed so that it neither starts nor ends within a
+// word.
+func window(text string, m analysis.Span, size int) (int, int) {
+	context := max(size-utf8.RuneCountInString(text[m.Start:m.End]), 0)
+	before := context / 2
+	if after := utf8.RuneCountInString(text[m.End:]); after < context-before {
+		before = context - after
+	}
+	start, taken := m.Start, 0
+	for ; taken < before && start > 0; taken++ {
+		_, n := utf8.DecodeLastRuneInString(text[:start])
+		start -= n
+	}
+	end := m.End
+	for left := context - taken; left > 0 && end < len(text); left-- {
+		_, n := utf8.DecodeRuneInString(text[end:])
+		end += n
+	}
+
+	// Move the edges to the nearest word boundary inside the window.
+	for start < m.Start && start > 0 && !boundaryBefore(text, start) {
+		_, n := utf8.DecodeRuneInString(text[start:])
+		start += n
+	}
+	for end > m.End && end < len(text) && !boundaryBefore(text, end) {
+		_, n := utf8.DecodeLastRuneInString(text[:end])
+		end -= n
+	}
+	return start, end
+}
+
+// boundaryBefore reports whether offset i of text, which is neither 0 nor
+// len(text), lies between a space and another character or the other way
+// round.
+func boundaryBefore(text string, i int) bool {
+	prev, _ := utf8.DecodeLastRuneInString(text[:i])
+	next, _ := utf8.DecodeRuneInString(text[i:])
+	return unicode.IsSpace(prev) != unicode.IsSpace(next)
+}
+
+// encode encodes text with encoder.
+func encode(text string, encoder Encoder) string {
+	if encoder == HTMLEncoder {
+		return html.EscapeString(text)
+	}
+	return text
+}
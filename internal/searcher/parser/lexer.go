@@ -0,0 +1,408 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/automaton"
+)
+
+// tokenKind identifies a lexical token of the query syntax.
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokWildcard
+	tokFuzzy
+	tokPhrase
+	tokField
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+	tokPlus
+	tokMinus
+	tokHash
+	tokBoost
+	tokMinMatch
+	tokRange
+)
+
+// token is a lexical token. text holds the text of a word, fuzzy word or
+// phrase, the pattern of a wildcard word or the name of a field, slop the
+// slop of a phrase or the edit distance of a fuzzy word, and num the value
+// of a boost or minimum_should_match. bounds holds the bounds of a range.
+// col is the 1-based column, in characters, at which the token starts, and
+// end the 0-based offset after a word.
+type token struct {
+	kind   tokenKind
+	text   string
+	slop   int
+	num    float64
+	bounds *bounds
+	col    int
+	end    int
+}
+
+// bounds are the bounds of a range as written: the text of each and
+// whether it is given, as opposed to *, and inclusive.
+type bounds struct {
+	lower, upper               string
+	hasLower, hasUpper         bool
+	includeLower, includeUpper bool
+}
+
+// describe returns the token as it is named in error messages.
+func (t token) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokWord, tokWildcard, tokFuzzy:
+		return fmt.Sprintf("%q", t.text)
+	case tokPhrase:
+		return "phrase"
+	case tokField:
+		return fmt.Sprintf("field %q", t.text)
+	case tokLParen:
+		return "'('"
+	case tokRParen:
+		return "')'"
+	case tokAnd:
+		return "AND"
+	case tokOr:
+		return "OR"
+	case tokNot:
+		return "NOT"
+	case tokPlus:
+		return "'+'"
+	case tokMinus:
+		return "'-'"
+	case tokHash:
+		return "'#'"
+	case tokBoost:
+		return "'^'"
+	case tokMinMatch:
+		return "'@'"
+	case tokRange:
+		return "range"
+	}
+	return "token"
+}
+
+// SyntaxError reports a malformed query. Column is the 1-based position, in
+// characters, at which the problem was found.
+type SyntaxError struct {
+	Column int
+	Msg    string
+}
+
+// Error implements the error interface.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("query syntax error at column %d: %s", e.Column, e.Msg)
+}
+
+// lexer splits a query into tokens.
+type lexer struct {
+	runes []rune
+	pos   int
+}
+
+// tokenize returns the tokens of query, ending with a tokEOF token.
+func tokenize(query string) ([]token, error) {
+	l := &lexer{runes: []rune(query)}
+	var tokens []token
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		if tok.kind == tokEOF {
+			return tokens, nil
+		}
+	}
+}
+
+// errorf returns a SyntaxError at the 0-based rune offset pos.
+func errorf(pos int, format string, args ...any) error {
+	return &SyntaxError{Column: pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+// peek returns the rune at offset pos, or 0 past the end of the query.
+func (l *lexer) peek(pos int) rune {
+	if pos < len(l.runes) {
+		return l.runes[pos]
+	}
+	return 0
+}
+
+// clauseFollows reports whether a clause can start at offset pos: it is not
+// the end of the query, white space or a closing parenthesis.
+func (l *lexer) clauseFollows(pos int) bool {
+	r := l.peek(pos)
+	return r != 0 && r != ')' && !unicode.IsSpace(r)
+}
+
+// next returns the next token.
+func (l *lexer) next() (token, error) {
+	l.skipSpace()
+	start := l.pos
+	tok := token{col: start + 1}
+	if start == len(l.runes) {
+		tok.kind = tokEOF
+		return tok, nil
+	}
+	switch r := l.runes[start]; r {
+	case '(':
+		l.pos++
+		tok.kind = tokLParen
+		return tok, nil
+	case ')':
+		l.pos++
+		tok.kind = tokRParen
+		return tok, nil
+	case '^':
+		l.pos++
+		boost, err := l.number(start, "boost")
+		if err != nil {
+			return token{}, err
+		}
+		tok.kind, tok.num = tokBoost, boost
+		return tok, nil
+	case '@':
+		if start == 0 || l.runes[start-1] != ')' {
+			break
+		}
+		l.pos++
+		n, err := l.number(start, "minimum_should_match")
+		if err != nil {
+			return token{}, err
+		}
+		if n != math.Trunc(n) {
+			return token{}, errorf(start, "minimum_should_match must be a whole number")
+		}
+		tok.kind, tok.num = tokMinMatch, n
+		return tok, nil
+	case '+', '-', '#':
+		if !l.clauseFollows(start + 1) {
+			return token{}, errorf(start, "expected a clause after '%c'", r)
+		}
+		l.pos++
+		switch r {
+		case '+':
+			tok.kind = tokPlus
+		case '-':
+			tok.kind = tokMinus
+		default:
+			tok.kind = tokHash
+		}
+		return tok, nil
+	case '"':
+		return l.phrase()
+	case '[', '{':
+		if start == 0 || l.runes[start-1] != ':' {
+			break
+		}
+		return l.rangeBounds()
+	}
+	return l.word()
+}
+
+// number reads the number following a ^ or @ at offset at.
+func (l *lexer) number(at int, what string) (float64, error) {
+	start := l.pos
+	for l.pos < len(l.runes) && (unicode.IsDigit(l.runes[l.pos]) || l.runes[l.pos] == '.') {
+		l.pos++
+	}
+	if start == l.pos {
+		return 0, errorf(at, "expected a number after '%c' for %s", l.runes[at], what)
+	}
+	n, err := strconv.ParseFloat(string(l.runes[start:l.pos]), 64)
+	if err != nil || math.IsInf(n, 0) {
+		return 0, errorf(start, "invalid %s %q", what, string(l.runes[start:l.pos]))
+	}
+	return n, nil
+}
+
+// phrase reads a quoted phrase and its optional ~slop.
+func (l *lexer) phrase() (token, error) {
+	start := l.pos
+	end := start + 1
+	for end < len(l.runes) && l.runes[end] != '"' {
+		end++
+	}
+	if end == len(l.runes) {
+		return token{}, errorf(start, "unterminated phrase")
+	}
+	tok := token{kind: tokPhrase, text: string(l.runes[start+1 : end]), col: start + 1}
+	l.pos = end + 1
+	if l.peek(l.pos) == '~' {
+		at := l.pos
+		l.pos++
+		slop, err := l.number(at, "slop")
+		if err != nil {
+			return token{}, err
+		}
+		if slop != math.Trunc(slop) {
+			return token{}, errorf(at, "slop must be a whole number")
+		}
+		tok.slop = int(slop)
+	}
+	return tok, nil
+}
+
+// rangeBounds reads a range, [a TO b], where [ and ] include the bound
+// next to them and { and } exclude it. A bound is a word, a quoted string
+// or *, which leaves that end open.
+func (l *lexer) rangeBounds() (token, error) {
+	start := l.pos
+	b := &bounds{includeLower: l.runes[start] == '['}
+	l.pos++
+	var err error
+	if b.lower, b.hasLower, err = l.bound(); err != nil {
+		return token{}, err
+	}
+	l.skipSpace()
+	if l.pos+2 > len(l.runes) || string(l.runes[l.pos:l.pos+2]) != "TO" || l.clauseFollows(l.pos+2) && !strings.ContainsRune("]}", l.peek(l.pos+2)) {
+		return token{}, errorf(l.pos, "expected TO in range")
+	}
+	l.pos += 2
+	if b.upper, b.hasUpper, err = l.bound(); err != nil {
+		return token{}, err
+	}
+	l.skipSpace()
+	switch l.peek(l.pos) {
+	case ']':
+		b.includeUpper = true
+	case '}':
+	default:
+		return token{}, errorf(l.pos, "expected ']' or '}' to close the range opened at column %d", start+1)
+	}
+	l.pos++
+	return token{kind: tokRange, bounds: b, col: start + 1}, nil
+}
+
+// bound reads a bound of a range, and whether it is given, as opposed to *.
+func (l *lexer) bound() (string, bool, error) {
+	l.skipSpace()
+	start := l.pos
+	if l.peek(l.pos) == '"' {
+		end := start + 1
+		for end < len(l.runes) && l.runes[end] != '"' {
+			end++
+		}
+		if end == len(l.runes) {
+			return "", false, errorf(start, "unterminated range bound")
+		}
+		l.pos = end + 1
+		return string(l.runes[start+1 : end]), true, nil
+	}
+	for l.pos < len(l.runes) && !unicode.IsSpace(l.runes[l.pos]) && !strings.ContainsRune("]}", l.runes[l.pos]) {
+		l.pos++
+	}
+	text := string(l.runes[start:l.pos])
+	switch text {
+	case "":
+		return "", false, errorf(start, "expected a range bound")
+	case "*":
+		return "", false, nil
+	}
+	return text, true, nil
+}
+
+// skipSpace advances past white space.
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.runes) && unicode.IsSpace(l.runes[l.pos]) {
+		l.pos++
+	}
+}
+
+// word reads a bare word, an operator, or the field: prefix of a word. A
+// backslash makes the character after it part of the word. A word with an
+// unescaped * or ? is a wildcard pattern, in which the backslashes before
+// *, ? and \ are kept, and a word followed by ~ and an optional edit
+// distance is a fuzzy word.
+func (l *lexer) word() (token, error) {
+	start := l.pos
+	var sb, pattern strings.Builder
+	escaped, wildcard := false, false
+	for l.pos < len(l.runes) {
+		r := l.runes[l.pos]
+		if unicode.IsSpace(r) || r == '(' || r == ')' || r == '^' {
+			break
+		}
+		if r == '\\' && l.pos+1 < len(l.runes) {
+			next := l.runes[l.pos+1]
+			sb.WriteRune(next)
+			if next == '*' || next == '?' || next == '\\' {
+				pattern.WriteRune('\\')
+			}
+			pattern.WriteRune(next)
+			l.pos += 2
+			escaped = true
+			continue
+		}
+		if r == ':' && !escaped && !wildcard && l.clauseFollows(l.pos+1) {
+			if name := strings.ToLower(sb.String()); index.ValidFieldName(name) {
+				l.pos++
+				return token{kind: tokField, text: name, col: start + 1}, nil
+			}
+		}
+		if r == '~' && sb.Len() > 0 {
+			return l.fuzzy(start, sb.String(), wildcard)
+		}
+		if r == '*' || r == '?' {
+			wildcard = true
+		}
+		sb.WriteRune(r)
+		pattern.WriteRune(r)
+		l.pos++
+	}
+	if wildcard {
+		return token{kind: tokWildcard, text: pattern.String(), col: start + 1}, nil
+	}
+	tok := token{kind: tokWord, text: sb.String(), col: start + 1, end: l.pos}
+	if escaped {
+		return tok, nil
+	}
+	switch strings.ToUpper(tok.text) {
+	case "AND":
+		tok.kind = tokAnd
+	case "OR":
+		tok.kind = tokOr
+	case "NOT":
+		tok.kind = tokNot
+	}
+	return tok, nil
+}
+
+// fuzzy reads the ~ and optional edit distance after the text of a fuzzy
+// word that started at offset start. The distance defaults to
+// automaton.MaxEdits.
+func (l *lexer) fuzzy(start int, text string, wildcard bool) (token, error) {
+	at := l.pos
+	if wildcard {
+		return token{}, errorf(at, "a fuzzy term cannot contain wildcards")
+	}
+	l.pos++
+	edits := float64(automaton.MaxEdits)
+	if unicode.IsDigit(l.peek(l.pos)) {
+		var err error
+		if edits, err = l.number(at, "fuzzy edit distance"); err != nil {
+			return token{}, err
+		}
+		if edits != math.Trunc(edits) || edits > automaton.MaxEdits {
+			return token{}, errorf(at, "fuzzy edit distance must be 0, 1 or %d", automaton.MaxEdits)
+		}
+	}
+	if r := l.peek(l.pos); r != '^' && l.clauseFollows(l.pos) {
+		return token{}, errorf(l.pos, "unexpected %q after fuzzy term", r)
+	}
+	return token{kind: tokFuzzy, text: text, slop: int(edits), col: start + 1}, nil
+}
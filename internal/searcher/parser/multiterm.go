@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/automaton"
+)
+
+// MultiTermQuery is a query that matches the documents containing any term
+// of the index that satisfies a condition: a *PrefixQuery, *WildcardQuery
+// or *FuzzyQuery. It is expanded to the matching terms of the index when
+// it is executed.
+type MultiTermQuery interface {
+	Query
+	// TermField returns the field whose terms are matched, or "" for every
+	// field.
+	TermField() string
+	// TermPrefix returns a prefix that every matching term starts with,
+	// possibly empty.
+	TermPrefix() string
+	// MatchTerm reports whether the query matches an analysed term, without
+	// its field, and the term's edit distance from the query's term for a
+	// fuzzy query, or 0.
+	MatchTerm(term string) (int, bool)
+	// TermPattern returns the query in query syntax without its boost.
+	TermPattern() string
+}
+
+// PrefixQuery matches the terms starting with Prefix, e.g. data*.
+type PrefixQuery struct {
+	Field  string
+	Prefix string
+	Boost  float64
+}
+
+// WildcardQuery matches the terms matching a wildcard Pattern, in which ?
+// stands for any one character, * for any run of characters and a
+// backslash makes the character after it literal, e.g. d?ta*.
+type WildcardQuery struct {
+	Field   string
+	Pattern string
+	Boost   float64
+	prefix  string
+	dfa     *automaton.DFA
+}
+
+// FuzzyQuery matches the terms within MaxEdits insertions, deletions or
+// substitutions of Text, e.g. databse~1.
+type FuzzyQuery struct {
+	Field    string
+	Text     string
+	MaxEdits int
+	Boost    float64
+	dfa      *automaton.DFA
+}
+
+// NewWildcardQuery returns a WildcardQuery for a pattern, which must not be
+// a plain prefix pattern such as data*.
+func NewWildcardQuery(field, pattern string) (*WildcardQuery, error) {
+	dfa, err := automaton.Wildcard(pattern)
+	if err != nil {
+		return nil, err
+	}
+	prefix, _ := automaton.LiteralPrefix(pattern)
+	return &WildcardQuery{Field: field, Pattern: pattern, Boost: 1, prefix: prefix, dfa: dfa}, nil
+}
+
+// NewFuzzyQuery returns a FuzzyQuery for text and an edit distance of at
+// most automaton.MaxEdits.
+func NewFuzzyQuery(field, text string, maxEdits int) (*FuzzyQuery, error) {
+	dfa, err := automaton.Levenshtein(text, maxEdits)
+	if err != nil {
+		return nil, err
+	}
+	return &FuzzyQuery{Field: field, Text: text, MaxEdits: maxEdits, Boost: 1, dfa: dfa}, nil
+}
+
+func (*PrefixQuery) isQuery()   {}
+func (*WildcardQuery) isQuery() {}
+func (*FuzzyQuery) isQuery()    {}
+
+// TermField implements MultiTermQuery.
+func (q *PrefixQuery) TermField() string { return q.Field }
+
+// TermField implements MultiTermQuery.
+func (q *WildcardQuery) TermField() string { return q.Field }
+
+// TermField implements MultiTermQuery.
+func (q *FuzzyQuery) TermField() string { return q.Field }
+
+// TermPrefix implements MultiTermQuery.
+func (q *PrefixQuery) TermPrefix() string { return q.Prefix }
+
+// TermPrefix implements MultiTermQuery.
+func (q *WildcardQuery) TermPrefix() string { return q.prefix }
+
+// TermPrefix implements MultiTermQuery. A fuzzy term may differ from its
+// first character on.
+func (q *FuzzyQuery) TermPrefix() string { return "" }
+
+// MatchTerm implements MultiTermQuery.
+func (q *PrefixQuery) MatchTerm(term string) (int, bool) {
+	return 0, strings.HasPrefix(term, q.Prefix)
+}
+
+// MatchTerm implements MultiTermQuery.
+func (q *WildcardQuery) MatchTerm(term string) (int, bool) {
+	return q.dfa.Match(term)
+}
+
+// MatchTerm implements MultiTermQuery.
+func (q *FuzzyQuery) MatchTerm(term string) (int, bool) {
+	return q.dfa.Match(term)
+}
+
+// TermPattern implements MultiTermQuery.
+func (q *PrefixQuery) TermPattern() string {
+	return fieldPrefix(q.Field) + escapeWord(q.Prefix) + "*"
+}
+
+// TermPattern implements MultiTermQuery.
+func (q *WildcardQuery) TermPattern() string {
+	return fieldPrefix(q.Field) + escapePattern(q.Pattern)
+}
+
+// TermPattern implements MultiTermQuery.
+func (q *FuzzyQuery) TermPattern() string {
+	return fieldPrefix(q.Field) + escapeWord(q.Text) + "~" + strconv.Itoa(q.MaxEdits)
+}
+
+// String returns the prefix query in query syntax, e.g. title:data*^2.
+func (q *PrefixQuery) String() string {
+	return q.TermPattern() + boostSuffix(q.Boost)
+}
+
+// String returns the wildcard query in query syntax, e.g. d?ta*.
+func (q *WildcardQuery) String() string {
+	return q.TermPattern() + boostSuffix(q.Boost)
+}
+
+// String returns the fuzzy query in query syntax, e.g. databse~1.
+func (q *FuzzyQuery) String() string {
+	return q.TermPattern() + boostSuffix(q.Boost)
+}
+
+// fieldPrefix returns the field: prefix of a query restricted to field, or
+// "" for one searched in every field.
+func fieldPrefix(field string) string {
+	if field == "" {
+		return ""
+	}
+	return field + ":"
+}
@@ -1,70 +1,608 @@
 // Package parser converts raw search query strings into structured QueryPlan
-// objects, recognising AND, OR, and NOT operators and delegating token
-// normalisation to the indexer tokenizer.
+// objects holding a query tree of terms, phrases and Boolean clauses. It
+// recognises AND, OR, and NOT operators, +, - and # clause prefixes,
+// parenthesised groups with an optional minimum_should_match, field
+// restrictions such as title:foo, quoted phrases such as "foo bar"~2,
+// prefix, wildcard and fuzzy terms such as foo*, f?o and foo~1, boosts
+// such as foo^2, and values and ranges of typed metadata such as
+// category:news and created_at:[2026-01-01 TO *], and delegates term
+// normalisation to the analyzer the index was built with.
 package parser
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
-	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/tokenizer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/automaton"
 )
 
-// QueryType indicates the Boolean combination mode for query terms.
-type QueryType int
+// Term is an analysed query term. A term with an empty Field is searched in
+// every field of the index.
+type Term struct {
+	Field string
+	Text  string
+}
 
-const (
-	QueryAND QueryType = iota
-	QueryOR
-)
+// String returns the term in query syntax: "field:text", or the bare text
+// for a term searched in every field.
+func (t Term) String() string {
+	if t.Field == "" {
+		return t.Text
+	}
+	return index.FieldTerm(t.Field, t.Text)
+}
+
+// TermStrings returns the String form of every term.
+func TermStrings(terms []Term) []string {
+	strs := make([]string, len(terms))
+	for i, t := range terms {
+		strs[i] = t.String()
+	}
+	return strs
+}
+
+// Phrase is a sequence of analysed terms that must occur in one field at
+// consecutive positions, in order. With a Slop above zero the terms may
+// also match up to Slop position moves away from that: "a b"~1 matches
+// "a x b", and "a b"~2 also matches "b a". A phrase with an empty Field is
+// matched in every field of the index.
+type Phrase struct {
+	Field string
+	Terms []string
+	Slop  int
+}
+
+// String returns the phrase in query syntax, e.g. title:"quick fox"~2.
+func (p Phrase) String() string {
+	var sb strings.Builder
+	if p.Field != "" {
+		sb.WriteString(p.Field)
+		sb.WriteByte(':')
+	}
+	sb.WriteByte('"')
+	sb.WriteString(strings.Join(p.Terms, " "))
+	sb.WriteByte('"')
+	if p.Slop > 0 {
+		sb.WriteByte('~')
+		sb.WriteString(strconv.Itoa(p.Slop))
+	}
+	return sb.String()
+}
+
+// FieldTerms returns the phrase's terms restricted to its field, in order.
+func (p Phrase) FieldTerms() []Term {
+	terms := make([]Term, len(p.Terms))
+	for i, text := range p.Terms {
+		terms[i] = Term{Field: p.Field, Text: text}
+	}
+	return terms
+}
 
-// QueryPlan is the parsed representation of a search query, containing the
-// include terms, exclude terms, Boolean type, and the original query string.
+// QueryPlan is the parsed representation of a search query: the root of
+// its query tree, nil if the query has nothing to match, and the original
+// query string.
 type QueryPlan struct {
-	Terms        []string
-	Type         QueryType
-	ExcludeTerms []string
-	RawQuery     string
-}
-
-// Parse tokenises the query string and produces a QueryPlan. Operators AND,
-// OR, and NOT are recognised case-insensitively.
-func Parse(query string) *QueryPlan {
-	plan := &QueryPlan{
-		Terms:        make([]string, 0),
-		ExcludeTerms: make([]string, 0),
-		Type:         QueryAND,
-		RawQuery:     query,
-	}
-	if strings.TrimSpace(query) == "" {
-		return plan
-	}
-	words := strings.Fields(query)
-	excludeNext := false
-	for i := 0; i < len(words); i++ {
-		upper := strings.ToUpper(words[i])
-		switch upper {
-		case "AND":
-			plan.Type = QueryAND
-			continue
-		case "OR":
-			plan.Type = QueryOR
-			continue
-		case "NOT":
-			excludeNext = true
-			continue
+	Root     Query
+	RawQuery string
+}
+
+// Empty reports whether the plan has nothing to match.
+func (p *QueryPlan) Empty() bool {
+	return p.Root == nil
+}
+
+// Terms returns every distinct term whose postings are needed to evaluate
+// the plan, including the terms of phrases. The terms prefix, wildcard and
+// fuzzy queries expand to depend on the index and are not included.
+func (p *QueryPlan) Terms() []Term {
+	var terms []Term
+	seen := make(map[Term]bool)
+	add := func(t Term) {
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	if p.Root == nil {
+		return terms
+	}
+	Walk(p.Root, func(q Query, _ Occur) bool {
+		switch q := q.(type) {
+		case *TermQuery:
+			add(q.Term)
+		case *PhraseQuery:
+			for _, t := range q.Phrase.FieldTerms() {
+				add(t)
+			}
+		}
+		return true
+	})
+	return terms
+}
+
+// Clauses returns the String form of every term, phrase, prefix, wildcard
+// or fuzzy term and range the plan searches for, leaving out excluded ones.
+func (p *QueryPlan) Clauses() []string {
+	clauses := make([]string, 0)
+	if p.Root == nil {
+		return clauses
+	}
+	Walk(p.Root, func(q Query, occur Occur) bool {
+		if occur == MustNot {
+			return false
+		}
+		switch q := q.(type) {
+		case *TermQuery:
+			clauses = append(clauses, q.Term.String())
+		case *PhraseQuery:
+			clauses = append(clauses, q.Phrase.String())
+		case MultiTermQuery:
+			clauses = append(clauses, q.TermPattern())
+		}
+		return true
+	})
+	return clauses
+}
+
+// AddFilters requires the documents to match the queries of filters as well,
+// without adding to their score. Filters with nothing to match are ignored;
+// a plan with nothing to match but filters matches the documents matching
+// all of them.
+func (p *QueryPlan) AddFilters(filters ...*QueryPlan) {
+	var clauses []Clause
+	if p.Root != nil {
+		clauses = append(clauses, Clause{Occur: Must, Query: p.Root})
+	}
+	for _, f := range filters {
+		if f.Root != nil {
+			clauses = append(clauses, Clause{Occur: Filter, Query: f.Root})
+		}
+	}
+	if len(clauses) == 0 || len(clauses) == 1 && p.Root != nil {
+		return
+	}
+	p.Root = &BooleanQuery{Clauses: clauses, Boost: 1}
+}
+
+// Correct returns the query with the words of its term queries replaced as
+// fn chooses, and whether fn replaced any. fn is called with every word that
+// analysed to a single term, outside excluded clauses, unescaped, and with
+// its term, and returns the text to put in its place, which is escaped as
+// needed.
+func (p *QueryPlan) Correct(fn func(word string, term Term) (string, bool)) (string, bool) {
+	if p.Root == nil {
+		return p.RawQuery, false
+	}
+	type replacement struct {
+		span span
+		text string
+	}
+	var replacements []replacement
+	Walk(p.Root, func(q Query, occur Occur) bool {
+		if occur == MustNot {
+			return false
 		}
-		tokens := tokenizer.Tokenize(words[i])
-		if len(tokens) == 0 {
+		if tq, ok := q.(*TermQuery); ok && tq.span.end > 0 {
+			if text, ok := fn(tq.word, tq.Term); ok {
+				replacements = append(replacements, replacement{tq.span, escapeWord(text)})
+			}
+		}
+		return true
+	})
+	if len(replacements) == 0 {
+		return p.RawQuery, false
+	}
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].span.start < replacements[j].span.start
+	})
+	runes := []rune(p.RawQuery)
+	var sb strings.Builder
+	last := 0
+	for _, r := range replacements {
+		sb.WriteString(string(runes[last:r.span.start]))
+		sb.WriteString(r.text)
+		last = r.span.end
+	}
+	sb.WriteString(string(runes[last:]))
+	return sb.String(), true
+}
+
+// Parse parses a query string into a QueryPlan, running every word and
+// phrase through analyzer. The syntax, loosest binding first:
+//
+//	a OR b       either clause; AND binds tighter, so a b OR c is (a b) OR c
+//	a AND b, a b both clauses
+//	NOT a, -a    excludes documents matching a
+//	+a           a must match
+//	#a           a must match but does not add to the score
+//	(a b)        a group; (a OR b OR c)@2 requires 2 of the 3 clauses
+//	title:a      a in the title field only; title:(a OR b) and title:"a b"
+//	             restrict a whole group or phrase
+//	"a b"~N      a phrase, optionally with slop N
+//	a*           the terms starting with a
+//	a?c*         the terms matching a wildcard pattern: ? is any one
+//	             character and * any run of characters
+//	abc~N        the terms within N edits of abc, N being 0 to 2 (default 2)
+//	a^2          doubles the score contributed by a clause or group
+//	key:v        documents whose value of metadata key key is v; quoted
+//	             values may hold spaces
+//	key:[a TO b] documents whose value of key lies between a and b, [ and ]
+//	             including the bound next to them and { and } excluding it;
+//	             * leaves an end open
+//
+// Operators are recognised case-insensitively, as are field names, and a
+// backslash makes the following character part of the word. A +, - or #
+// prefixed clause joined to others by OR keeps its occurrence: +a OR b
+// requires a and scores b as optional. A word that analyses to several
+// terms requires all of them, and a word or phrase that analyses to none,
+// such as a stop word, is dropped. Prefix and wildcard patterns are not
+// analysed but normalised (see analysis.Normalize) and matched against the
+// terms as indexed, after stemming; a fuzzy word is analysed like any other
+// and its edits counted against the indexed stems.
+//
+// A field named by schema is a typed metadata key, not a text field: its
+// values are not analysed but parsed as its type, and match without adding
+// to the score. A date without a time, such as 2026-01-31, stands for the
+// whole day. Prefix, wildcard and fuzzy terms do not apply to metadata keys,
+// nor ranges to text. Malformed queries return a *SyntaxError.
+func Parse(query string, analyzer analysis.Analyzer, schema index.Schema) (*QueryPlan, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, analyzer: analyzer, schema: schema}
+	plan := &QueryPlan{RawQuery: query}
+	if p.peek().kind == tokEOF {
+		return plan, nil
+	}
+	clauses, err := p.parseOr("")
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, errorf(tok.col-1, "unexpected %s", tok.describe())
+	}
+	plan.Root = toQuery(clauses)
+	return plan, nil
+}
+
+// parser is a recursive-descent parser over the tokens of a query.
+type parser struct {
+	tokens   []token
+	pos      int
+	analyzer analysis.Analyzer
+	schema   index.Schema
+}
+
+// clause is a parsed clause. explicit is set if its occurrence was given by
+// a prefix or NOT rather than implied.
+type clause struct {
+	Clause
+	explicit bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// startsPrimary reports whether tok can start a term, phrase or group.
+func startsPrimary(tok token) bool {
+	switch tok.kind {
+	case tokWord, tokWildcard, tokFuzzy, tokPhrase, tokField, tokLParen, tokRange:
+		return true
+	}
+	return false
+}
+
+// startsClause reports whether tok can start a clause.
+func startsClause(tok token) bool {
+	switch tok.kind {
+	case tokNot, tokPlus, tokMinus, tokHash:
+		return true
+	}
+	return startsPrimary(tok)
+}
+
+// parseOr parses clauses joined by OR. Operands that are single explicit
+// clauses keep their occurrence; the others become Should clauses.
+func (p *parser) parseOr(field string) ([]clause, error) {
+	first, err := p.parseAnd(field)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokOr {
+		return first, nil
+	}
+	operands := [][]clause{first}
+	for p.peek().kind == tokOr {
+		p.next()
+		if tok := p.peek(); !startsClause(tok) {
+			return nil, errorf(tok.col-1, "expected a clause after OR, found %s", tok.describe())
+		}
+		operand, err := p.parseAnd(field)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+	var clauses []clause
+	for _, operand := range operands {
+		switch {
+		case len(operand) == 0:
+		case len(operand) == 1 && operand[0].explicit:
+			clauses = append(clauses, operand[0])
+		default:
+			clauses = append(clauses, clause{Clause: Clause{Occur: Should, Query: toQuery(operand)}})
+		}
+	}
+	return clauses, nil
+}
+
+// parseAnd parses a sequence of clauses, joined by AND or juxtaposed.
+func (p *parser) parseAnd(field string) ([]clause, error) {
+	var clauses []clause
+	parsed := 0
+	for {
+		tok := p.peek()
+		switch tok.kind {
+		case tokEOF, tokRParen, tokOr:
+			if parsed == 0 {
+				return nil, errorf(tok.col-1, "expected a clause, found %s", tok.describe())
+			}
+			return clauses, nil
+		case tokAnd:
+			if parsed == 0 {
+				return nil, errorf(tok.col-1, "expected a clause before AND")
+			}
+			p.next()
+			if next := p.peek(); !startsClause(next) {
+				return nil, errorf(next.col-1, "expected a clause after AND, found %s", next.describe())
+			}
 			continue
 		}
-		term := tokens[0].Term
-		if excludeNext {
-			plan.ExcludeTerms = append(plan.ExcludeTerms, term)
-			excludeNext = false
-		} else {
-			plan.Terms = append(plan.Terms, term)
+		c, err := p.parseUnary(field)
+		if err != nil {
+			return nil, err
+		}
+		parsed++
+		if c != nil {
+			clauses = append(clauses, *c)
+		}
+	}
+}
+
+// parseUnary parses a clause with its optional NOT or prefix. It returns
+// nil for a clause that analyses to nothing.
+func (p *parser) parseUnary(field string) (*clause, error) {
+	tok := p.peek()
+	c := &clause{Clause: Clause{Occur: Must}}
+	switch tok.kind {
+	case tokNot, tokMinus:
+		c.Occur, c.explicit = MustNot, true
+	case tokPlus:
+		c.Occur, c.explicit = Must, true
+	case tokHash:
+		c.Occur, c.explicit = Filter, true
+	}
+	if c.explicit {
+		p.next()
+		if next := p.peek(); !startsPrimary(next) {
+			return nil, errorf(next.col-1, "expected a term, phrase or group after %s, found %s", tok.describe(), next.describe())
 		}
 	}
-	return plan
+	q, err := p.parsePrimary(field)
+	if err != nil || q == nil {
+		return nil, err
+	}
+	c.Query = q
+	return c, nil
+}
 
+// parsePrimary parses a term, phrase or group, restricted to field if it is
+// not empty, and its optional boost. It returns nil for one that analyses
+// to nothing.
+func (p *parser) parsePrimary(field string) (Query, error) {
+	tok := p.next()
+	if _, ok := p.schema[field]; ok && tok.kind != tokField && tok.kind != tokLParen {
+		return p.parseValue(field, tok)
+	}
+	var q Query
+	switch tok.kind {
+	case tokField:
+		if next := p.peek(); !startsPrimary(next) || next.kind == tokField {
+			return nil, errorf(next.col-1, "expected a term, phrase or group after %s:, found %s", tok.text, next.describe())
+		}
+		return p.parsePrimary(tok.text)
+	case tokLParen:
+		if p.peek().kind == tokRParen {
+			return nil, errorf(tok.col-1, "empty group")
+		}
+		clauses, err := p.parseOr(field)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, errorf(closing.col-1, "expected ')' to close the group opened at column %d, found %s", tok.col, closing.describe())
+		}
+		q = toQuery(clauses)
+		if p.peek().kind == tokMinMatch {
+			mm := p.next()
+			if err := setMinimumShouldMatch(q, int(mm.num)); err != nil {
+				return nil, errorf(mm.col-1, "%v", err)
+			}
+		}
+	case tokPhrase:
+		q = p.phraseQuery(field, tok)
+	case tokWord:
+		q = p.termQuery(field, tok.text)
+		if tq, ok := q.(*TermQuery); ok {
+			tq.word, tq.span = tok.text, span{start: tok.col - 1, end: tok.end}
+		}
+	case tokWildcard:
+		var err error
+		if q, err = p.wildcardQuery(field, tok.text); err != nil {
+			return nil, errorf(tok.col-1, "wildcard pattern %q: %v", tok.text, err)
+		}
+	case tokFuzzy:
+		fq, err := NewFuzzyQuery(field, p.fuzzyText(tok.text), tok.slop)
+		if err != nil {
+			return nil, errorf(tok.col-1, "fuzzy term %q: %v", tok.text, err)
+		}
+		q = fq
+	case tokRange:
+		return nil, errorf(tok.col-1, "%s is not a metadata key of the schema, so it has no ranges", field)
+	default:
+		return nil, errorf(tok.col-1, "expected a term, phrase or group, found %s", tok.describe())
+	}
+	if p.peek().kind == tokBoost {
+		boost := p.next().num
+		switch q := q.(type) {
+		case *TermQuery:
+			q.Boost *= boost
+		case *PhraseQuery:
+			q.Boost *= boost
+		case *BooleanQuery:
+			q.Boost *= boost
+		case *PrefixQuery:
+			q.Boost *= boost
+		case *WildcardQuery:
+			q.Boost *= boost
+		case *FuzzyQuery:
+			q.Boost *= boost
+		}
+	}
+	return q, nil
+}
+
+// parseValue parses a value or range of a metadata key of the schema,
+// skipping any boost, which a RangeQuery does not take.
+func (p *parser) parseValue(field string, tok token) (Query, error) {
+	var q Query
+	var err error
+	switch tok.kind {
+	case tokWord, tokPhrase:
+		q, err = p.valueQuery(field, tok.text, tok)
+	case tokRange:
+		q, err = p.rangeQuery(field, tok)
+	case tokWildcard, tokFuzzy:
+		err = errorf(tok.col-1, "%s is a %s metadata key; match it with a value or range", field, p.schema[field])
+	default:
+		err = errorf(tok.col-1, "expected a value, range or group after %s:, found %s", field, tok.describe())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind == tokBoost {
+		p.next()
+	}
+	return q, nil
+}
+
+// termQuery analyses a word. A word with several terms requires all of
+// them.
+func (p *parser) termQuery(field, text string) Query {
+	tokens := p.analyzer.Analyze(text)
+	switch len(tokens) {
+	case 0:
+		return nil
+	case 1:
+		return &TermQuery{Term: Term{Field: field, Text: tokens[0].Term}, Boost: 1}
+	}
+	bq := &BooleanQuery{Boost: 1}
+	for _, token := range tokens {
+		bq.Clauses = append(bq.Clauses, Clause{
+			Occur: Must,
+			Query: &TermQuery{Term: Term{Field: field, Text: token.Term}, Boost: 1},
+		})
+	}
+	return bq
+}
+
+// wildcardQuery normalises a wildcard pattern and returns a PrefixQuery for
+// a pattern ending in its only *, and a WildcardQuery otherwise.
+func (p *parser) wildcardQuery(field, pattern string) (Query, error) {
+	pattern = analysis.Normalize(p.analyzer, pattern)
+	if prefix, ok := automaton.LiteralPrefix(pattern); ok {
+		return &PrefixQuery{Field: field, Prefix: prefix, Boost: 1}, nil
+	}
+	return NewWildcardQuery(field, pattern)
+}
+
+// fuzzyText returns the text a fuzzy term is matched with: the term it
+// analyses to, so that edits are counted between stems, or the normalised
+// text if it analyses to none or several.
+func (p *parser) fuzzyText(text string) string {
+	if tokens := p.analyzer.Analyze(text); len(tokens) == 1 {
+		return tokens[0].Term
+	}
+	return analysis.Normalize(p.analyzer, text)
+}
+
+// phraseQuery analyses a phrase. A phrase of a single term is a term query.
+func (p *parser) phraseQuery(field string, tok token) Query {
+	tokens := p.analyzer.Analyze(tok.text)
+	switch len(tokens) {
+	case 0:
+		return nil
+	case 1:
+		return &TermQuery{Term: Term{Field: field, Text: tokens[0].Term}, Boost: 1}
+	}
+	phrase := Phrase{Field: field, Terms: make([]string, len(tokens)), Slop: tok.slop}
+	for i, token := range tokens {
+		phrase.Terms[i] = token.Term
+	}
+	return &PhraseQuery{Phrase: phrase, Boost: 1}
+}
+
+// toQuery returns the query of a sequence of clauses: nil if there are
+// none, the clause's query for a single Must or Should clause, and a
+// BooleanQuery otherwise.
+func toQuery(clauses []clause) Query {
+	switch {
+	case len(clauses) == 0:
+		return nil
+	case len(clauses) == 1 && (clauses[0].Occur == Must || clauses[0].Occur == Should):
+		return clauses[0].Query
+	}
+	bq := &BooleanQuery{Clauses: make([]Clause, len(clauses)), Boost: 1}
+	for i, c := range clauses {
+		bq.Clauses[i] = c.Clause
+	}
+	return bq
+}
+
+// setMinimumShouldMatch sets the minimum_should_match of a group, which
+// must not exceed its number of Should clauses.
+func setMinimumShouldMatch(q Query, n int) error {
+	if q == nil {
+		return nil
+	}
+	bq, ok := q.(*BooleanQuery)
+	optional := 0
+	if ok {
+		for _, c := range bq.Clauses {
+			if c.Occur == Should {
+				optional++
+			}
+		}
+	}
+	if n > optional {
+		return fmt.Errorf("minimum_should_match %d exceeds the %d optional clauses of the group", n, optional)
+	}
+	if ok {
+		bq.MinimumShouldMatch = n
+	}
+	return nil
 }
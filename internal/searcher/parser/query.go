@@ -0,0 +1,207 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Occur says how a clause of a BooleanQuery takes part in matching and
+// scoring.
+type Occur int
+
+const (
+	// Must clauses have to match and add to the score.
+	Must Occur = iota
+	// Should clauses add to the score of the documents they match. At least
+	// MinimumShouldMatch of them have to match, or one if the query has no
+	// Must or Filter clauses.
+	Should
+	// MustNot clauses exclude the documents they match.
+	MustNot
+	// Filter clauses have to match but do not add to the score.
+	Filter
+)
+
+// prefix returns the query syntax prefix of a clause with this occurrence.
+func (o Occur) prefix() string {
+	switch o {
+	case Must:
+		return "+"
+	case MustNot:
+		return "-"
+	case Filter:
+		return "#"
+	}
+	return ""
+}
+
+// Query is a node of a parsed query: a *TermQuery, *PhraseQuery,
+// *BooleanQuery, *RangeQuery or MultiTermQuery. Every node but a
+// RangeQuery has a Boost that multiplies its score.
+type Query interface {
+	// String returns the query in query syntax.
+	String() string
+	isQuery()
+}
+
+// TermQuery matches the documents containing an analysed term.
+type TermQuery struct {
+	Term  Term
+	Boost float64
+
+	// word is the unescaped word the term was analysed from, and span
+	// locates it in the query, if it was the only term of a word.
+	word string
+	span span
+}
+
+// span is a range of rune offsets in a query string.
+type span struct {
+	start, end int
+}
+
+// PhraseQuery matches the documents containing a phrase.
+type PhraseQuery struct {
+	Phrase Phrase
+	Boost  float64
+}
+
+// Clause is a subquery of a BooleanQuery and how it occurs there.
+type Clause struct {
+	Occur Occur
+	Query Query
+}
+
+// BooleanQuery combines clauses. A BooleanQuery without Must, Filter or
+// Should clauses matches nothing.
+type BooleanQuery struct {
+	Clauses            []Clause
+	MinimumShouldMatch int
+	Boost              float64
+}
+
+func (*TermQuery) isQuery()    {}
+func (*PhraseQuery) isQuery()  {}
+func (*BooleanQuery) isQuery() {}
+
+// String returns the term in query syntax, e.g. title:kafka^2. Characters
+// of the term text that the syntax treats specially are escaped.
+func (q *TermQuery) String() string {
+	s := escapeWord(q.Term.Text)
+	if q.Term.Field != "" {
+		s = q.Term.Field + ":" + s
+	}
+	return s + boostSuffix(q.Boost)
+}
+
+// String returns the phrase in query syntax, e.g. "stream processing"~2.
+func (q *PhraseQuery) String() string {
+	return q.Phrase.String() + boostSuffix(q.Boost)
+}
+
+// String returns the query in query syntax, every clause carrying its
+// occurrence prefix. Clauses are joined with OR if any of them is a Should
+// clause, so that the result parses back to the same query.
+func (q *BooleanQuery) String() string {
+	sep := " "
+	for _, c := range q.Clauses {
+		if c.Occur == Should {
+			sep = " OR "
+			break
+		}
+	}
+	parts := make([]string, len(q.Clauses))
+	for i, c := range q.Clauses {
+		s := c.Query.String()
+		if bq, ok := c.Query.(*BooleanQuery); ok && !bq.grouped() {
+			s = "(" + s + ")"
+		}
+		parts[i] = c.Occur.prefix() + s
+	}
+	s := strings.Join(parts, sep)
+	if q.grouped() {
+		s = "(" + s + ")"
+		if q.MinimumShouldMatch > 0 {
+			s += "@" + strconv.Itoa(q.MinimumShouldMatch)
+		}
+	}
+	return s + boostSuffix(q.Boost)
+}
+
+// grouped reports whether String puts q in parentheses itself, to attach
+// its minimum_should_match or boost.
+func (q *BooleanQuery) grouped() bool {
+	return q.MinimumShouldMatch > 0 || q.Boost != 1
+}
+
+// escapeWord escapes the characters of a word that would otherwise end it,
+// make it an operator, a field prefix, a wildcard pattern or a fuzzy term,
+// or start a phrase.
+func escapeWord(word string) string {
+	switch strings.ToUpper(word) {
+	case "AND", "OR", "NOT":
+		return `\` + word
+	}
+	var sb strings.Builder
+	for i, r := range word {
+		if r == '*' || r == '?' || needsEscape(r, i == 0) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// escapePattern escapes the characters of a wildcard pattern, whose *, ?
+// and backslash escapes are already in query syntax, as escapeWord does.
+func escapePattern(pattern string) string {
+	var sb strings.Builder
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\\' && i+1 < len(runes) {
+			sb.WriteRune(r)
+			i++
+			sb.WriteRune(runes[i])
+			continue
+		}
+		if r != '\\' && needsEscape(r, i == 0) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// needsEscape reports whether r must be escaped in a word, other than as a
+// wildcard; first is set for the first character of the word.
+func needsEscape(r rune, first bool) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(`\()^:"~`, r) || first && strings.ContainsRune("+-#", r)
+}
+
+// boostSuffix returns the ^boost suffix of a query, or "" for a boost of 1.
+func boostSuffix(boost float64) string {
+	if boost == 1 {
+		return ""
+	}
+	return "^" + strconv.FormatFloat(boost, 'g', -1, 64)
+}
+
+// Walk calls fn for q and, for as long as fn returns true, for each of its
+// descendants in depth-first order, passing the occurrence of each node in
+// its parent; the root is passed as Must.
+func Walk(q Query, fn func(q Query, occur Occur) bool) {
+	walk(q, Must, fn)
+}
+
+func walk(q Query, occur Occur, fn func(Query, Occur) bool) {
+	if !fn(q, occur) {
+		return
+	}
+	if bq, ok := q.(*BooleanQuery); ok {
+		for _, c := range bq.Clauses {
+			walk(c.Query, c.Occur, fn)
+		}
+	}
+}
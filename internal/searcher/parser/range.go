@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+)
+
+// RangeQuery matches the documents whose value of a metadata key of the
+// schema lies in Range, e.g. created_at:[2026-01-01 TO *] or category:news,
+// which is the range of that one value. It filters without adding to the
+// score, so it takes no boost.
+type RangeQuery struct {
+	Field string
+	Type  index.ValueType
+	Range index.Range
+}
+
+func (*RangeQuery) isQuery() {}
+
+// String returns the range in query syntax, a single value as field:value.
+func (q *RangeQuery) String() string {
+	r := q.Range
+	if r.Lower != nil && r.Upper != nil && r.IncludeLower && r.IncludeUpper && q.Type.Compare(*r.Lower, *r.Upper) == 0 {
+		return q.Field + ":" + escapeWord(q.Type.Format(*r.Lower))
+	}
+	var sb strings.Builder
+	sb.WriteString(q.Field)
+	sb.WriteByte(':')
+	if r.IncludeLower {
+		sb.WriteByte('[')
+	} else {
+		sb.WriteByte('{')
+	}
+	sb.WriteString(q.bound(r.Lower))
+	sb.WriteString(" TO ")
+	sb.WriteString(q.bound(r.Upper))
+	if r.IncludeUpper {
+		sb.WriteByte(']')
+	} else {
+		sb.WriteByte('}')
+	}
+	return sb.String()
+}
+
+// bound returns a bound of the range in query syntax: * for an open end,
+// and quoted if it could not be read back as a bare word.
+func (q *RangeQuery) bound(v *index.Value) string {
+	if v == nil {
+		return "*"
+	}
+	s := q.Type.Format(*v)
+	if s == "" || s == "*" || s == "TO" || strings.ContainsAny(s, "]} \t\n\"") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// day is the span of a date without a time, which matches the whole day.
+const day = 24 * time.Hour
+
+// valueQuery returns the query matching one value of a metadata key of the
+// schema: the value itself, or every instant of the day for a date without
+// a time.
+func (p *parser) valueQuery(field, text string, tok token) (Query, error) {
+	t := p.schema[field]
+	q := &RangeQuery{Field: field, Type: t, Range: index.Range{IncludeLower: true, IncludeUpper: true}}
+	if t == index.TypeDate {
+		d, isDay, err := index.ParseDate(text)
+		if err != nil {
+			return nil, errorf(tok.col-1, "%s: %v", field, err)
+		}
+		lower := index.Value{Num: float64(d.UnixMilli())}
+		upper := lower
+		if isDay {
+			upper.Num = float64(d.Add(day).UnixMilli())
+			q.Range.IncludeUpper = false
+		}
+		q.Range.Lower, q.Range.Upper = &lower, &upper
+		return q, nil
+	}
+	v, err := t.Parse(text)
+	if err != nil {
+		return nil, errorf(tok.col-1, "%s: %v", field, err)
+	}
+	q.Range.Lower, q.Range.Upper = &v, &v
+	return q, nil
+}
+
+// rangeQuery returns the query of a range of values of a metadata key of
+// the schema. A date without a time stands for the whole day, so that
+// [2026-01-01 TO 2026-01-31] includes the 31st and {2026-01-01 TO *}
+// starts on the 2nd.
+func (p *parser) rangeQuery(field string, tok token) (Query, error) {
+	t := p.schema[field]
+	b := tok.bounds
+	q := &RangeQuery{Field: field, Type: t, Range: index.Range{IncludeLower: b.includeLower, IncludeUpper: b.includeUpper}}
+	bound := func(text string, upper bool) (*index.Value, error) {
+		if t != index.TypeDate {
+			v, err := t.Parse(text)
+			return &v, err
+		}
+		d, isDay, err := index.ParseDate(text)
+		if err != nil {
+			return nil, err
+		}
+		// An inclusive upper or exclusive lower bound on a day becomes an
+		// exclusive upper or inclusive lower one at the end of the day.
+		switch {
+		case isDay && upper && q.Range.IncludeUpper:
+			d, q.Range.IncludeUpper = d.Add(day), false
+		case isDay && !upper && !q.Range.IncludeLower:
+			d, q.Range.IncludeLower = d.Add(day), true
+		}
+		return &index.Value{Num: float64(d.UnixMilli())}, nil
+	}
+	var err error
+	if b.hasLower {
+		if q.Range.Lower, err = bound(b.lower, false); err != nil {
+			return nil, errorf(tok.col-1, "%s: %v", field, err)
+		}
+	}
+	if b.hasUpper {
+		if q.Range.Upper, err = bound(b.upper, true); err != nil {
+			return nil, errorf(tok.col-1, "%s: %v", field, err)
+		}
+	}
+	return q, nil
+}
@@ -0,0 +1,78 @@
+package ranker
+
+// TermScorer scores one term a document at a time, for query evaluation
+// that skips the documents that cannot reach the top results, and bounds
+// its score over blocks of documents. It computes the same scores as
+// ScoreTerm.
+type TermScorer struct {
+	s     *scorer
+	norms []fieldNorm
+}
+
+// NewTermScorer returns a scorer of a term with the given statistics
+// searched in fields.
+func NewTermScorer(fields []string, term TermStats, params RankParams, getDocInfo func(doc uint32) DocInfo) *TermScorer {
+	t := &TermScorer{
+		s:     newScorer(params, []TermStats{term}, getDocInfo),
+		norms: make([]fieldNorm, len(fields)),
+	}
+	for i, field := range fields {
+		t.norms[i] = t.s.fieldNorm(field)
+	}
+	return t
+}
+
+// Score returns the score of the term in doc, where freqs holds its
+// frequency in each field, 0 in those it does not occur in.
+func (t *TermScorer) Score(doc uint32, freqs []int) float64 {
+	info := t.s.getDocInfo(doc)
+	var acc float64
+	matched := false
+	for i, freq := range freqs {
+		if freq == 0 {
+			continue
+		}
+		norm := t.norms[i]
+		v, ok := t.s.accumulate(norm, float64(freq), float64(info.FieldLengths[norm.field]))
+		if !ok {
+			continue
+		}
+		acc += v
+		matched = true
+	}
+	if !matched {
+		return 0
+	}
+	return t.s.finish(acc, float64(info.DocLength))
+}
+
+// Bound returns an upper bound of the score of the term in the documents
+// whose frequency in each field is at most maxFreqs, 0 where it does not
+// occur, and whose length of that field is at least minLengths. Scores
+// grow with frequency and shrink with length, so the bound is the score of
+// the highest frequencies at the shortest lengths, leaving out what fields
+// of negative boost subtract.
+func (t *TermScorer) Bound(maxFreqs, minLengths []int) float64 {
+	var acc float64
+	matched := false
+	docLength := -1
+	for i, freq := range maxFreqs {
+		if freq == 0 {
+			continue
+		}
+		if t.s.mode == ModeBM25 && (docLength < 0 || minLengths[i] < docLength) {
+			// A document is at least as long as any of its fields.
+			docLength = minLengths[i]
+		}
+		v, ok := t.s.accumulate(t.norms[i], float64(freq), float64(minLengths[i]))
+		if !ok {
+			continue
+		}
+		acc += max(v, 0)
+		matched = true
+	}
+	if !matched {
+		return 0
+	}
+	return max(t.s.finish(acc, float64(max(docLength, 0))), 0)
+}
@@ -0,0 +1,77 @@
+package ranker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Cursor marks a result's place in the ranking order, by its sort values
+// and then ascending document ID, so that the next page of a search starts
+// after it. Unlike an offset, it stays in place when documents are added
+// or removed ahead of it, and it does not depend on document numbers, so it
+// is valid on any shard layout. Values holds the result's score under the
+// default order, and its sort values under an explicit sort.
+type Cursor struct {
+	Values []any
+	DocID  string
+}
+
+// CursorOf returns the cursor of a ranked result.
+func CursorOf(d ScoredDoc) Cursor {
+	if d.Sort != nil {
+		return Cursor{Values: d.Sort, DocID: d.DocID}
+	}
+	return Cursor{Values: []any{d.Score}, DocID: d.DocID}
+}
+
+// cursorJSON is the encoding of a Cursor.
+type cursorJSON struct {
+	Values []any  `json:"v"`
+	DocID  string `json:"id"`
+}
+
+// String encodes the cursor as an opaque, URL-safe token.
+func (c Cursor) String() string {
+	raw, _ := json.Marshal(cursorJSON{Values: c.Values, DocID: c.DocID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// ParseCursor decodes a token returned by Cursor.String.
+func ParseCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("decoding cursor: %w", err)
+	}
+	var c cursorJSON
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return Cursor{}, fmt.Errorf("parsing cursor: %w", err)
+	}
+	if c.DocID == "" || len(c.Values) == 0 {
+		return Cursor{}, errors.New("malformed cursor")
+	}
+	return Cursor{Values: c.Values, DocID: c.DocID}, nil
+}
+
+// Check reports whether the cursor was taken under sort, the sort fields
+// of a search or nil for the default order.
+func (c Cursor) Check(sort []SortField) error {
+	if len(sort) == 0 {
+		sort = scoreSort
+	}
+	_, err := cursorKeys(sort, c.Values)
+	return err
+}
+
+// Page selects a page of the ranked results: the first Limit of those after
+// the cursor After, if set, once Offset of them are skipped. A Limit that
+// is not positive selects all of them. Sort orders the results, by
+// descending score if it is empty; After must have been taken under the
+// same order.
+type Page struct {
+	Offset int
+	Limit  int
+	After  *Cursor
+	Sort   []SortField
+}
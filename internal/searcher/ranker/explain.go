@@ -0,0 +1,137 @@
+package ranker
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Explanation describes how a score, or a value it was computed from, came
+// about: the value, what it is, and the values it was computed from.
+type Explanation struct {
+	Value       float64       `json:"value"`
+	Description string        `json:"description"`
+	Details     []Explanation `json:"details,omitempty"`
+}
+
+// explain returns the explanation of value.
+func explain(value float64, description string, details ...Explanation) Explanation {
+	return Explanation{Value: value, Description: description, Details: details}
+}
+
+// explainLength explains the length of field in a document, or of the
+// document if field is empty.
+func explainLength(field string, length float64) Explanation {
+	if field == "" {
+		return explain(length, "length, of the document")
+	}
+	return explain(length, fmt.Sprintf("length, of field %s", field))
+}
+
+// explainAvgLength explains the average length of field, or of documents
+// if field is empty.
+func explainAvgLength(field string, avgLength float64) Explanation {
+	if field == "" {
+		return explain(avgLength, "avgLength, average length of documents")
+	}
+	return explain(avgLength, fmt.Sprintf("avgLength, average length of field %s", field))
+}
+
+// ExplainTerm explains the score ScoreTerm computes of a term, described
+// by name, with the given statistics in doc, or 0 if doc does not occur in
+// its postings.
+func ExplainTerm(name string, fields FieldPostings, term TermStats, params RankParams, getDocInfo func(doc uint32) DocInfo, doc uint32) Explanation {
+	s := newScorer(params, []TermStats{term}, getDocInfo)
+	freqs := make(map[string]float64, len(fields))
+	for field, postings := range fields {
+		i := sort.Search(len(postings), func(i int) bool { return postings[i].Doc >= doc })
+		if i < len(postings) && postings[i].Doc == doc {
+			freqs[field] = float64(postings[i].Frequency)
+		}
+	}
+	return s.explain(fmt.Sprintf("term %s", name), doc, freqs)
+}
+
+// ExplainPhrase explains the score ScorePhrase computes of a phrase,
+// described by name, in doc, or 0 if it does not match doc.
+func ExplainPhrase(name string, phrase PhraseMatches, params RankParams, getDocInfo func(doc uint32) DocInfo, doc uint32) Explanation {
+	s := newScorer(params, phrase.Terms, getDocInfo)
+	freqs := make(map[string]float64, len(phrase.Fields))
+	for field, docs := range phrase.Fields {
+		if freq, ok := docs[doc]; ok {
+			freqs[field] = freq
+		}
+	}
+	return s.explain(fmt.Sprintf("phrase %s", name), doc, freqs)
+}
+
+// explain explains the score of a term or phrase, described by what, in
+// doc, where freqs holds its frequency in each field it occurs in. Its
+// values are computed as by addFreq and score.
+func (s *scorer) explain(what string, doc uint32, freqs map[string]float64) Explanation {
+	fields := make([]string, 0, len(freqs))
+	for field := range freqs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	info := s.getDocInfo(doc)
+	weights := make([]Explanation, len(s.weights))
+	for i, term := range s.terms {
+		weights[i] = s.sim.ExplainWeight(s.corpus, term)
+	}
+
+	var acc float64
+	var details []Explanation
+	matched := false
+	for _, field := range fields {
+		norm := s.fieldNorm(field)
+		freq := freqs[field]
+		length := float64(info.FieldLengths[field])
+		v, ok := s.accumulate(norm, freq, length)
+		if !ok {
+			continue
+		}
+		acc += v
+		matched = true
+		switch {
+		case s.mode == ModeBM25:
+			details = append(details, explain(v, fmt.Sprintf("freq, in field %s", field)))
+		case s.combiner != nil:
+			details = append(details, explain(v, fmt.Sprintf("boost * normalised freq, of field %s, from:", field),
+				explain(norm.boost, "boost"),
+				s.combiner.ExplainNormalize(field, freq, length, norm.avgLength),
+			))
+		default:
+			e := explain(v, fmt.Sprintf("boost * score, of field %s, from:", field), explain(norm.boost, "boost"))
+			for _, w := range weights {
+				e.Details = append(e.Details, s.sim.ExplainScore(field, w, freq, length, norm.avgLength))
+			}
+			details = append(details, e)
+		}
+	}
+	if !matched {
+		return explain(0, fmt.Sprintf("%s, no match", what))
+	}
+
+	switch {
+	case s.mode == ModeBM25:
+		score := s.finish(acc, float64(info.DocLength))
+		tf := explain(acc, "freq, summed over the fields:", details...)
+		if s.params.AvgDocLength == 0 {
+			return explain(score, fmt.Sprintf("%s, with fields as one stream, of an empty corpus", what), tf)
+		}
+		e := explain(score, fmt.Sprintf("%s, with fields as one stream, from:", what))
+		for _, w := range weights {
+			e.Details = append(e.Details, s.sim.ExplainScore("", w, acc, float64(info.DocLength), s.params.AvgDocLength))
+		}
+		e.Details = append(e.Details, tf)
+		return e
+	case s.combiner != nil:
+		tf := explain(acc, "tf, combined over the fields:", details...)
+		e := explain(s.finish(acc, 0), fmt.Sprintf("%s, with fields combined, from:", what))
+		for _, w := range weights {
+			e.Details = append(e.Details, s.combiner.ExplainSaturate(w, tf))
+		}
+		return e
+	}
+	return explain(s.finish(acc, 0), fmt.Sprintf("%s, the sum of its field scores:", what), details...)
+}
@@ -1,93 +1,445 @@
-// Package ranker implements BM25 relevance scoring for search results. It
-// takes per-term posting lists, global corpus statistics, and per-document
-// length information to produce a ranked list of ScoredDoc entries.
+// Package ranker implements relevance scoring for search results with
+// pluggable similarities: BM25, BM25+, TF-IDF, DFR and LM Dirichlet, whose
+// scores of each field are combined as BM25F does, summed, or computed as
+// if the fields were one stream. It takes per-term, per-field posting
+// lists, phrase matches, global corpus statistics, and per-document length
+// information to produce a ranked list of ScoredDoc entries. A TermScorer scores a term one
+// document at a time and bounds its score over blocks of postings, for
+// evaluation that skips the documents that cannot reach the top results.
 package ranker
 
 import (
+	"fmt"
 	"math"
 	"sort"
 
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/config"
 )
 
-// BM25 tuning parameters.
+// Mode selects how Rank combines the fields of a document.
+type Mode string
+
 const (
-	k1 = 1.2
-	b  = 0.75
+	// ModeBM25F scores each field with its own boost and length
+	// normalisation and, for similarities that are FieldCombiners (BM25
+	// and BM25+), combines the weighted term frequencies before
+	// saturation, so repeating a term across fields counts less than it
+	// would in separate documents. Other similarities score as in
+	// ModeFields.
+	ModeBM25F Mode = "bm25f"
+	// ModeFields scores each field separately and sums the boosted field
+	// scores.
+	ModeFields Mode = "fields"
+	// ModeBM25 scores a document as if all its fields were one stream.
+	ModeBM25 Mode = "bm25"
 )
 
-// ScoredDoc pairs a document ID with its BM25 relevance score.
+// Config tunes Rank. Similarity selects the scoring function, tuned by
+// Params, and Mode how it combines fields. FieldBoosts weight each field;
+// fields not listed have a boost of 1. Boosts are ignored in ModeBM25. An
+// empty Mode is ModeBM25F and an empty Similarity ModelBM25.
+type Config struct {
+	Mode        Mode
+	Similarity  Model
+	Params      SimilarityParams
+	FieldBoosts map[string]float64
+}
+
+// ParseMode validates a scoring mode name. The empty string selects
+// ModeBM25F.
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case "", ModeBM25F:
+		return ModeBM25F, nil
+	case ModeFields, ModeBM25:
+		return Mode(s), nil
+	}
+	return "", fmt.Errorf("unknown ranking mode %q", s)
+}
+
+// ParseConfig validates the ranking configuration and returns the Config
+// it selects.
+func ParseConfig(cfg config.RankingConfig) (Config, error) {
+	mode, err := ParseMode(cfg.Mode)
+	if err != nil {
+		return Config{}, err
+	}
+	similarity, err := ParseModel(cfg.Similarity)
+	if err != nil {
+		return Config{}, err
+	}
+	b := cfg.B
+	return Config{
+		Mode:       mode,
+		Similarity: similarity,
+		Params: SimilarityParams{
+			K1:      cfg.K1,
+			B:       &b,
+			FieldK1: cfg.FieldK1,
+			FieldB:  cfg.FieldLengthNorm,
+			Delta:   cfg.Delta,
+			C:       cfg.DFRC,
+			Mu:      cfg.Mu,
+		},
+		FieldBoosts: cfg.FieldBoosts,
+	}, nil
+}
+
+// boost returns the configured boost of field.
+func (c Config) boost(field string) float64 {
+	if w, ok := c.FieldBoosts[field]; ok {
+		return w
+	}
+	return 1
+}
+
+// similarity returns the configured similarity.
+func (c Config) similarity() Similarity {
+	return c.Params.Similarity(c.Similarity)
+}
+
+// ScoredDoc pairs a document ID with its relevance score. Rank fills in
+// the integer document number; the executor resolves DocID, and any stored
+// fields requested, for the documents it returns.
 type ScoredDoc struct {
-	DocID string  `json:"doc_id"`
-	Score float64 `json:"score"`
+	DocID     string              `json:"doc_id"`
+	Score     float64             `json:"score"`
+	Fields    map[string]string   `json:"fields,omitempty"`
+	Metadata  map[string]any      `json:"metadata,omitempty"`
+	Highlight map[string][]string `json:"highlight,omitempty"`
+	// Sort holds the sort values of the result under an explicit sort.
+	Sort []any `json:"sort,omitempty"`
+	// Explanation, if requested, explains the result's score.
+	Explanation *Explanation `json:"explanation,omitempty"`
+	Doc         uint32       `json:"-"`
+}
+
+// Docs resolves the document numbers of ranked documents to their IDs and
+// metadata values.
+type Docs interface {
+	DocID(doc uint32) string
+	Value(key string, doc uint32) (index.Value, bool)
 }
 
-// RankParams holds the global corpus statistics needed by BM25.
+// FieldPostings holds the postings of one query term in each field it is
+// searched in.
+type FieldPostings map[string]index.DocPostingList
+
+// RankParams holds the global corpus statistics needed for scoring and the
+// scoring configuration. AvgFieldLengths holds the average length of every
+// field, counting documents without the field as empty.
 type RankParams struct {
-	TotalDocs    int64
-	AvgDocLength float64
+	TotalDocs       int64
+	AvgDocLength    float64
+	AvgFieldLengths map[string]float64
+	Config          Config
 }
 
-// DocInfo holds per-document metadata required for BM25 normalisation.
+// DocInfo holds per-document metadata required for length normalisation.
 type DocInfo struct {
-	DocLength int
+	DocLength    int
+	FieldLengths map[string]int
 }
 
-// Rank scores every candidate document using BM25 and returns the top-limit
-// results sorted by descending score.
+// PhraseMatches holds the matches of one phrase: the match frequency in
+// each field and document it occurs in, where an exact occurrence counts 1
+// and a sloppy one 1/(1+d) for an occurrence d position moves from exact,
+// and the statistics of its terms. A phrase scores the sum of the scores
+// of its terms with the phrase's frequency, so close matches score higher
+// than distant ones.
+type PhraseMatches struct {
+	Fields map[string]map[uint32]float64
+	Terms  []TermStats
+}
+
+// Rank scores every candidate document and returns the top-limit results
+// sorted by descending score, ties broken by document number. A term's
+// statistics are counted across the fields it is searched in. Each phrase adds to the score of the documents
+// it matches like a further term.
 func Rank(
-	postingsPerTerm map[string]index.PostingList,
+	postingsPerTerm map[string]FieldPostings,
+	phrases []PhraseMatches,
 	params RankParams,
-	getDocInfo func(docID string) DocInfo,
+	getDocInfo func(doc uint32) DocInfo,
 	limit int,
 ) []ScoredDoc {
-	scores := make(map[string]float64)
-	for term, postings := range postingsPerTerm {
-		docFreq := len(postings)
-		idf := computeIDF(params.TotalDocs, int64(docFreq))
+	scores := make(map[uint32]float64)
+	for _, fields := range postingsPerTerm {
+		for doc, score := range ScoreTerm(fields, statsOf(fields), params, getDocInfo) {
+			scores[doc] += score
+		}
+	}
+	for _, phrase := range phrases {
+		for doc, score := range ScorePhrase(phrase, params, getDocInfo) {
+			scores[doc] += score
+		}
+	}
+	return TopK(scores, nil, Page{Limit: limit})
+}
+
+// ScoreTerm returns the score of a term with the given statistics in every
+// document of its postings.
+func ScoreTerm(fields FieldPostings, term TermStats, params RankParams, getDocInfo func(doc uint32) DocInfo) map[uint32]float64 {
+	s := newScorer(params, []TermStats{term}, getDocInfo)
+	// acc holds the document's accumulated frequency or score (see
+	// scorer.accumulate).
+	acc := make(map[uint32]float64)
+	for field, postings := range fields {
+		norm := s.fieldNorm(field)
 		for _, posting := range postings {
-			info := getDocInfo(posting.DocID)
-			tfNorm := computeTFNorm(
-				float64(posting.Frequency),
-				float64(info.DocLength),
-				params.AvgDocLength,
-			)
-			scores[posting.DocID] += idf * tfNorm
-			_ = term
-		}
-	}
-	result := make([]ScoredDoc, 0, len(scores))
-	for docID, score := range scores {
-		result = append(result, ScoredDoc{
-			DocID: docID,
-			Score: math.Round(score*10000) / 10000,
-		})
+			s.addFreq(acc, norm, posting.Doc, float64(posting.Frequency))
+		}
 	}
-	sort.Slice(result, func(i, j int) bool {
-		if result[i].Score != result[j].Score {
-			return result[i].Score > result[j].Score
+	return s.score(acc)
+}
+
+// ScorePhrase returns the score of a phrase in every document it matches.
+func ScorePhrase(phrase PhraseMatches, params RankParams, getDocInfo func(doc uint32) DocInfo) map[uint32]float64 {
+	s := newScorer(params, phrase.Terms, getDocInfo)
+	acc := make(map[uint32]float64)
+	for field, docs := range phrase.Fields {
+		norm := s.fieldNorm(field)
+		for doc, freq := range docs {
+			s.addFreq(acc, norm, doc, freq)
+		}
+	}
+	return s.score(acc)
+}
+
+// statsOf returns the statistics of a term counted from its postings.
+func statsOf(fields FieldPostings) TermStats {
+	var term TermStats
+	docs := make(map[uint32]struct{})
+	for _, postings := range fields {
+		for _, p := range postings {
+			docs[p.Doc] = struct{}{}
+			term.TotalTermFreq += int64(p.Frequency)
+		}
+	}
+	term.DocFreq = int64(len(docs))
+	return term
+}
+
+// TopK returns the page of the documents of scores in the order of
+// page.Sort, by descending score if it is empty, ties broken by the
+// document ID docs resolves a document number to, and then by document
+// number. Without docs, ties are broken by document number alone, and
+// page.After must not be set nor page.Sort name a metadata key. Document
+// IDs are resolved only to break ties, and left in the results that needed
+// them. Under an explicit sort, every result carries its sort values.
+func TopK(scores map[uint32]float64, docs Docs, page Page) []ScoredDoc {
+	fields := page.Sort
+	if len(fields) == 0 {
+		fields = scoreSort
+	}
+	type ranked struct {
+		ScoredDoc
+		keys []sortKey
+	}
+	resolve := func(d *ranked) string {
+		if d.DocID == "" {
+			d.DocID = docs.DocID(d.Doc)
+		}
+		return d.DocID
+	}
+	less := func(a, b *ranked) bool {
+		if c := compareKeys(fields, a.keys, b.keys); c != 0 {
+			return c < 0
 		}
-		return result[i].DocID < result[j].DocID
+		if docs != nil {
+			if idA, idB := resolve(a), resolve(b); idA != idB {
+				return idA < idB
+			}
+		}
+		return a.Doc < b.Doc
+	}
+
+	var after []sortKey
+	if page.After != nil {
+		// Handlers check cursors against the sort; an invalid one selects
+		// nothing.
+		var err error
+		if after, err = cursorKeys(fields, page.After.Values); err != nil {
+			return []ScoredDoc{}
+		}
+	}
+	result := make([]ranked, 0, len(scores))
+	keys := make([]sortKey, len(scores)*len(fields))
+	for doc, score := range scores {
+		d := ranked{ScoredDoc: ScoredDoc{
+			Doc:   doc,
+			Score: RoundScore(score),
+		}}
+		d.keys, keys = keys[:len(fields):len(fields)], keys[len(fields):]
+		for i, f := range fields {
+			if f.Field == ScoreField {
+				d.keys[i].value.Num = d.Score
+				continue
+			}
+			v, ok := docs.Value(f.Field, doc)
+			d.keys[i] = sortKey{value: v, missing: !ok}
+		}
+		if after != nil {
+			if c := compareKeys(fields, d.keys, after); c < 0 || c == 0 && resolve(&d) <= page.After.DocID {
+				continue
+			}
+		}
+		result = append(result, d)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return less(&result[i], &result[j])
 	})
-	if limit > 0 && len(result) > limit {
-		result = result[:limit]
+	result = result[min(page.Offset, len(result)):]
+	if page.Limit > 0 && len(result) > page.Limit {
+		result = result[:page.Limit]
+	}
+	top := make([]ScoredDoc, len(result))
+	for i, d := range result {
+		top[i] = d.ScoredDoc
+		if len(page.Sort) > 0 {
+			top[i].Sort = sortValues(fields, d.keys)
+		}
 	}
-	return result
+	return top
 }
 
-// computeIDF calculates the BM25 inverse document frequency for a term.
-func computeIDF(totalDocs int64, docFreq int64) float64 {
-	numerator := float64(totalDocs) - float64(docFreq)
-	denominator := float64(docFreq) + 0.5
-	return math.Log(numerator/denominator + 1)
+// RoundScore rounds a score to the four decimals results carry, which TopK
+// orders them by.
+func RoundScore(score float64) float64 {
+	return math.Round(score*10000) / 10000
 }
 
-// computeTFNorm calculates the BM25 normalised term frequency.
-func computeTFNorm(termFreq float64, docLength float64, avgDocLength float64) float64 {
-	if avgDocLength == 0 {
-		return 0
+// scorer computes term and phrase scores with one configuration.
+type scorer struct {
+	params RankParams
+	mode   Mode
+	sim    Similarity
+	// combiner is sim in ModeBM25F, which is ModeFields for similarities
+	// that do not combine fields.
+	combiner FieldCombiner
+	// weights holds the similarity's weight of the term, or of each term
+	// of a phrase, with the statistics it was computed from.
+	weights    []float64
+	terms      []TermStats
+	corpus     CorpusStats
+	getDocInfo func(doc uint32) DocInfo
+}
+
+// newScorer returns a scorer for params of a term, or a phrase of terms,
+// with the given statistics.
+func newScorer(params RankParams, terms []TermStats, getDocInfo func(doc uint32) DocInfo) *scorer {
+	s := &scorer{
+		params:  params,
+		mode:    params.Config.Mode,
+		sim:     params.Config.similarity(),
+		weights: make([]float64, len(terms)),
+		terms:   terms,
+		corpus: CorpusStats{
+			Docs:   params.TotalDocs,
+			Tokens: params.AvgDocLength * float64(params.TotalDocs),
+		},
+		getDocInfo: getDocInfo,
+	}
+	if s.mode == "" {
+		s.mode = ModeBM25F
+	}
+	if s.mode == ModeBM25F {
+		if c, ok := s.sim.(FieldCombiner); ok {
+			s.combiner = c
+		} else {
+			s.mode = ModeFields
+		}
+	}
+	for i, term := range terms {
+		s.weights[i] = s.sim.Weight(s.corpus, term)
+	}
+	return s
+}
+
+// fieldNorm holds the weighting of one field.
+type fieldNorm struct {
+	field     string
+	boost     float64
+	avgLength float64
+}
+
+// fieldNorm returns the weighting of field.
+func (s *scorer) fieldNorm(field string) fieldNorm {
+	return fieldNorm{
+		field:     field,
+		boost:     s.params.Config.boost(field),
+		avgLength: s.params.AvgFieldLengths[field],
+	}
+}
+
+// addFreq adds a document's frequency of a term or phrase in a field to
+// its accumulated frequency or score in acc.
+func (s *scorer) addFreq(acc map[uint32]float64, norm fieldNorm, doc uint32, freq float64) {
+	var length float64
+	if s.mode != ModeBM25 {
+		length = float64(s.getDocInfo(doc).FieldLengths[norm.field])
+	}
+	if v, ok := s.accumulate(norm, freq, length); ok {
+		acc[doc] += v
+	}
+}
+
+// accumulate returns what a term or phrase occurring freq times in a field
+// of the given length adds to a document's accumulated value: the raw
+// frequency in ModeBM25, the boosted, length-normalised frequency when
+// combining fields, and the boosted field score in ModeFields. It returns
+// false for fields no document holds, which are skipped.
+func (s *scorer) accumulate(norm fieldNorm, freq, length float64) (float64, bool) {
+	switch {
+	case s.mode == ModeBM25:
+		return freq, true
+	case norm.avgLength == 0:
+		return 0, false
+	case s.combiner != nil:
+		return norm.boost * s.combiner.Normalize(norm.field, freq, length, norm.avgLength), true
+	}
+	return norm.boost * s.fieldScore(norm.field, freq, length, norm.avgLength), true
+}
+
+// finish returns the score of a document from its accumulated value and,
+// in ModeBM25, its length.
+func (s *scorer) finish(acc, docLength float64) float64 {
+	switch {
+	case s.mode == ModeBM25:
+		if s.params.AvgDocLength == 0 {
+			return 0
+		}
+		return s.fieldScore("", acc, docLength, s.params.AvgDocLength)
+	case s.combiner != nil:
+		var score float64
+		for _, w := range s.weights {
+			score += s.combiner.Saturate(w, acc)
+		}
+		return score
+	}
+	return acc
+}
+
+// fieldScore returns the summed similarity score of the scorer's terms
+// occurring freq times in a field.
+func (s *scorer) fieldScore(field string, freq, length, avgLength float64) float64 {
+	var score float64
+	for _, w := range s.weights {
+		score += s.sim.Score(field, w, freq, length, avgLength)
+	}
+	return score
+}
+
+// score replaces the accumulated values in acc by the scores of a term or
+// phrase and returns acc.
+func (s *scorer) score(acc map[uint32]float64) map[uint32]float64 {
+	for doc, v := range acc {
+		var length float64
+		if s.mode == ModeBM25 {
+			length = float64(s.getDocInfo(doc).DocLength)
+		}
+		acc[doc] = s.finish(v, length)
 	}
-	lengthRatio := docLength / avgDocLength
-	denominator := termFreq + k1*(1-b+b*lengthRatio)
-	return (termFreq * (k1 + 1)) / denominator
+	return acc
 }
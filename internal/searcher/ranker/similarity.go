@@ -0,0 +1,414 @@
+package ranker
+
+import (
+	"fmt"
+	"math"
+)
+
+// Model names a similarity, the function scoring a term by its frequency
+// in a text, the text's length and the term's statistics in the corpus.
+type Model string
+
+const (
+	// ModelBM25 is Okapi BM25, with k1 and b configurable per field.
+	ModelBM25 Model = "bm25"
+	// ModelBM25Plus is BM25+, which adds delta to BM25's normalised term
+	// frequency so that a match in a very long text still outscores no
+	// match at all.
+	ModelBM25Plus Model = "bm25plus"
+	// ModelTFIDF is classic TF-IDF: the square root of the frequency times
+	// the squared IDF, divided by the square root of the length.
+	ModelTFIDF Model = "tfidf"
+	// ModelDFR is divergence from randomness with the I(n) basic model,
+	// Laplace after-effect and H2 length normalisation (InL2).
+	ModelDFR Model = "dfr"
+	// ModelLMDirichlet is a query likelihood language model with Dirichlet
+	// smoothing, clamped so that no match scores below zero.
+	ModelLMDirichlet Model = "lm_dirichlet"
+)
+
+// ParseModel validates a similarity name. The empty string selects
+// ModelBM25.
+func ParseModel(s string) (Model, error) {
+	switch m := Model(s); m {
+	case "":
+		return ModelBM25, nil
+	case ModelBM25, ModelBM25Plus, ModelTFIDF, ModelDFR, ModelLMDirichlet:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown similarity %q", s)
+}
+
+// Default similarity parameters.
+const (
+	DefaultK1    = 1.2
+	DefaultB     = 0.75
+	DefaultDelta = 1.0
+	DefaultC     = 1.0
+	DefaultMu    = 2000.0
+)
+
+// SimilarityParams tunes the similarities. K1 and B are BM25's term
+// frequency saturation and length normalisation (from 0 for none to 1 for
+// full), which FieldK1 and FieldB override per field; Delta is BM25+'s
+// lower bound of a match's normalised frequency, C the DFR length
+// normalisation and Mu the LM Dirichlet smoothing. Zero values, and a nil
+// B, select the defaults.
+type SimilarityParams struct {
+	K1      float64
+	B       *float64
+	FieldK1 map[string]float64
+	FieldB  map[string]float64
+	Delta   float64
+	C       float64
+	Mu      float64
+}
+
+// Similarity returns the similarity of model tuned by p. Unknown models
+// select BM25.
+func (p SimilarityParams) Similarity(model Model) Similarity {
+	bm25 := BM25{K1: p.K1, B: DefaultB, FieldK1: p.FieldK1, FieldB: p.FieldB}
+	if bm25.K1 <= 0 {
+		bm25.K1 = DefaultK1
+	}
+	if p.B != nil {
+		bm25.B = *p.B
+	}
+	switch model {
+	case ModelBM25Plus:
+		delta := p.Delta
+		if delta <= 0 {
+			delta = DefaultDelta
+		}
+		return BM25Plus{BM25: bm25, Delta: delta}
+	case ModelTFIDF:
+		return TFIDF{}
+	case ModelDFR:
+		c := p.C
+		if c <= 0 {
+			c = DefaultC
+		}
+		return DFR{C: c}
+	case ModelLMDirichlet:
+		mu := p.Mu
+		if mu <= 0 {
+			mu = DefaultMu
+		}
+		return LMDirichlet{Mu: mu}
+	}
+	return bm25
+}
+
+// CorpusStats holds the statistics of the corpus a term is weighted
+// against: its number of documents and their summed length.
+type CorpusStats struct {
+	Docs   int64
+	Tokens float64
+}
+
+// TermStats holds the statistics of a term in the corpus: the number of
+// documents it occurs in and its number of occurrences, across the fields
+// it is searched in.
+type TermStats struct {
+	DocFreq       int64
+	TotalTermFreq int64
+}
+
+// Similarity scores a term in a field, or in a document scored as one
+// stream, in two steps: a weight computed once per term from its corpus
+// statistics, and a score of every text the term occurs in. Scores must
+// not decrease with the frequency nor increase with the length, given a
+// non-negative weight, so that they can be bounded over blocks of
+// postings.
+type Similarity interface {
+	// Weight returns the weight of a term with the given statistics.
+	Weight(corpus CorpusStats, term TermStats) float64
+	// Score returns the score of a term of weight w occurring freq times
+	// in a field of the given length, whose average length in the corpus
+	// is avgLength, which is not zero. The field is empty when a document
+	// is scored as one stream.
+	Score(field string, w, freq, length, avgLength float64) float64
+	// ExplainWeight explains Weight.
+	ExplainWeight(corpus CorpusStats, term TermStats) Explanation
+	// ExplainScore explains Score, given the explanation of the weight.
+	ExplainScore(field string, w Explanation, freq, length, avgLength float64) Explanation
+}
+
+// FieldCombiner is implemented by similarities that combine the
+// frequencies of a term in several fields before scoring them, as BM25F
+// does: each frequency is length-normalised in its field and weighted by
+// its boost, and the sum is saturated once.
+type FieldCombiner interface {
+	Similarity
+	// Normalize returns the length-normalised frequency of a term
+	// occurring freq times in a field.
+	Normalize(field string, freq, length, avgLength float64) float64
+	// Saturate returns the score of a term of weight w with the combined
+	// frequency tf.
+	Saturate(w, tf float64) float64
+	// ExplainNormalize explains Normalize.
+	ExplainNormalize(field string, freq, length, avgLength float64) Explanation
+	// ExplainSaturate explains Saturate, given the explanations of the
+	// weight and the combined frequency.
+	ExplainSaturate(w, tf Explanation) Explanation
+}
+
+// BM25 is the Okapi BM25 similarity. FieldK1 and FieldB override K1 and B
+// for the fields they list; in BM25F combination, K1 saturates the
+// combined frequency and only FieldB applies.
+type BM25 struct {
+	K1      float64
+	B       float64
+	FieldK1 map[string]float64
+	FieldB  map[string]float64
+}
+
+// Weight returns the BM25 inverse document frequency.
+func (s BM25) Weight(corpus CorpusStats, term TermStats) float64 {
+	numerator := float64(corpus.Docs) - float64(term.DocFreq)
+	denominator := float64(term.DocFreq) + 0.5
+	return math.Log(numerator/denominator + 1)
+}
+
+// Score returns the IDF times the normalised term frequency.
+func (s BM25) Score(field string, w, freq, length, avgLength float64) float64 {
+	return w * s.tfNorm(field, freq, length, avgLength)
+}
+
+// Normalize divides freq by the field's length normalisation.
+func (s BM25) Normalize(field string, freq, length, avgLength float64) float64 {
+	b := s.b(field)
+	return freq / (1 - b + b*length/avgLength)
+}
+
+// Saturate returns the IDF times the saturated combined frequency.
+func (s BM25) Saturate(w, tf float64) float64 {
+	return w * ((tf * (s.K1 + 1)) / (tf + s.K1))
+}
+
+// tfNorm returns BM25's normalised term frequency.
+func (s BM25) tfNorm(field string, freq, length, avgLength float64) float64 {
+	k1, b := s.k1(field), s.b(field)
+	lengthRatio := length / avgLength
+	return (freq * (k1 + 1)) / (freq + k1*(1-b+b*lengthRatio))
+}
+
+// k1 returns the K1 of field.
+func (s BM25) k1(field string) float64 {
+	if k1, ok := s.FieldK1[field]; ok {
+		return k1
+	}
+	return s.K1
+}
+
+// b returns the B of field.
+func (s BM25) b(field string) float64 {
+	if b, ok := s.FieldB[field]; ok {
+		return b
+	}
+	return s.B
+}
+
+// ExplainWeight explains Weight.
+func (s BM25) ExplainWeight(corpus CorpusStats, term TermStats) Explanation {
+	return explain(s.Weight(corpus, term), "idf, computed as log(1 + (N - n) / (n + 0.5)) from:",
+		explain(float64(term.DocFreq), "n, number of documents containing the term"),
+		explain(float64(corpus.Docs), "N, total number of documents"),
+	)
+}
+
+// ExplainScore explains Score.
+func (s BM25) ExplainScore(field string, w Explanation, freq, length, avgLength float64) Explanation {
+	return explain(s.Score(field, w.Value, freq, length, avgLength), "score, computed as idf * tfNorm from:",
+		w,
+		s.explainTFNorm(field, freq, length, avgLength),
+	)
+}
+
+// ExplainNormalize explains Normalize.
+func (s BM25) ExplainNormalize(field string, freq, length, avgLength float64) Explanation {
+	return explain(s.Normalize(field, freq, length, avgLength), "normalised freq, computed as freq / (1 - b + b * length / avgLength) from:",
+		explain(freq, "freq, occurrences in the field"),
+		explain(s.b(field), "b, length normalisation"),
+		explainLength(field, length),
+		explainAvgLength(field, avgLength),
+	)
+}
+
+// ExplainSaturate explains Saturate.
+func (s BM25) ExplainSaturate(w, tf Explanation) Explanation {
+	return explain(s.Saturate(w.Value, tf.Value), "score, computed as idf * tf * (k1 + 1) / (tf + k1) from:",
+		w,
+		tf,
+		explain(s.K1, "k1, term saturation"),
+	)
+}
+
+// explainTFNorm explains tfNorm.
+func (s BM25) explainTFNorm(field string, freq, length, avgLength float64) Explanation {
+	return explain(s.tfNorm(field, freq, length, avgLength), "tfNorm, computed as freq * (k1 + 1) / (freq + k1 * (1 - b + b * length / avgLength)) from:",
+		explain(freq, "freq, occurrences of the term"),
+		explain(s.k1(field), "k1, term saturation"),
+		explain(s.b(field), "b, length normalisation"),
+		explainLength(field, length),
+		explainAvgLength(field, avgLength),
+	)
+}
+
+// BM25Plus is the BM25+ similarity: BM25 with Delta added to the
+// normalised frequency of every match.
+type BM25Plus struct {
+	BM25
+	Delta float64
+}
+
+// Score returns the IDF times the normalised term frequency plus Delta.
+func (s BM25Plus) Score(field string, w, freq, length, avgLength float64) float64 {
+	return w * (s.tfNorm(field, freq, length, avgLength) + s.Delta)
+}
+
+// Saturate returns the IDF times the saturated combined frequency plus
+// Delta.
+func (s BM25Plus) Saturate(w, tf float64) float64 {
+	return w * ((tf*(s.K1+1))/(tf+s.K1) + s.Delta)
+}
+
+// ExplainScore explains Score.
+func (s BM25Plus) ExplainScore(field string, w Explanation, freq, length, avgLength float64) Explanation {
+	return explain(s.Score(field, w.Value, freq, length, avgLength), "score, computed as idf * (tfNorm + delta) from:",
+		w,
+		s.explainTFNorm(field, freq, length, avgLength),
+		explain(s.Delta, "delta, lower bound of tfNorm"),
+	)
+}
+
+// ExplainSaturate explains Saturate.
+func (s BM25Plus) ExplainSaturate(w, tf Explanation) Explanation {
+	return explain(s.Saturate(w.Value, tf.Value), "score, computed as idf * (tf * (k1 + 1) / (tf + k1) + delta) from:",
+		w,
+		tf,
+		explain(s.K1, "k1, term saturation"),
+		explain(s.Delta, "delta, lower bound of the saturated tf"),
+	)
+}
+
+// TFIDF is the classic vector space similarity.
+type TFIDF struct{}
+
+// Weight returns the squared IDF, 1 + ln((N+1)/(df+1)).
+func (s TFIDF) Weight(corpus CorpusStats, term TermStats) float64 {
+	idf := s.idf(corpus, term)
+	return idf * idf
+}
+
+// Score returns the square root of freq times the weight, divided by the
+// square root of the length.
+func (TFIDF) Score(_ string, w, freq, length, _ float64) float64 {
+	return math.Sqrt(freq) * w / math.Sqrt(max(length, 1))
+}
+
+// ExplainWeight explains Weight.
+func (s TFIDF) ExplainWeight(corpus CorpusStats, term TermStats) Explanation {
+	return explain(s.Weight(corpus, term), "idf^2, from:",
+		explain(s.idf(corpus, term), "idf, computed as 1 + ln((N + 1) / (n + 1)) from:",
+			explain(float64(term.DocFreq), "n, number of documents containing the term"),
+			explain(float64(corpus.Docs), "N, total number of documents"),
+		),
+	)
+}
+
+// ExplainScore explains Score.
+func (s TFIDF) ExplainScore(field string, w Explanation, freq, length, avgLength float64) Explanation {
+	return explain(s.Score(field, w.Value, freq, length, avgLength), "score, computed as sqrt(freq) * idf^2 / sqrt(length) from:",
+		w,
+		explain(freq, "freq, occurrences of the term"),
+		explainLength(field, length),
+	)
+}
+
+// idf returns the inverse document frequency of a term.
+func (TFIDF) idf(corpus CorpusStats, term TermStats) float64 {
+	return 1 + math.Log(float64(corpus.Docs+1)/float64(term.DocFreq+1))
+}
+
+// DFR is the divergence from randomness similarity InL2. C scales the
+// average length in the H2 normalisation of the term frequency.
+type DFR struct {
+	C float64
+}
+
+// Weight returns the informative content of the I(n) basic model,
+// log2((N+1)/(df+0.5)).
+func (DFR) Weight(corpus CorpusStats, term TermStats) float64 {
+	return math.Log2(float64(corpus.Docs+1) / (float64(term.DocFreq) + 0.5))
+}
+
+// Score normalises freq to tfn = freq·log2(1 + C·avgLength/length) and
+// returns the weight times the Laplace after-effect tfn/(tfn+1).
+func (s DFR) Score(_ string, w, freq, length, avgLength float64) float64 {
+	tfn := s.tfn(freq, length, avgLength)
+	return w * tfn / (tfn + 1)
+}
+
+// ExplainWeight explains Weight.
+func (s DFR) ExplainWeight(corpus CorpusStats, term TermStats) Explanation {
+	return explain(s.Weight(corpus, term), "informative content, computed as log2((N + 1) / (n + 0.5)) from:",
+		explain(float64(term.DocFreq), "n, number of documents containing the term"),
+		explain(float64(corpus.Docs), "N, total number of documents"),
+	)
+}
+
+// ExplainScore explains Score.
+func (s DFR) ExplainScore(field string, w Explanation, freq, length, avgLength float64) Explanation {
+	return explain(s.Score(field, w.Value, freq, length, avgLength), "score, computed as informative content * tfn / (tfn + 1) from:",
+		w,
+		explain(s.tfn(freq, length, avgLength), "tfn, computed as freq * log2(1 + c * avgLength / length) from:",
+			explain(freq, "freq, occurrences of the term"),
+			explain(s.C, "c, length normalisation"),
+			explainLength(field, length),
+			explainAvgLength(field, avgLength),
+		),
+	)
+}
+
+// tfn returns the H2 normalised term frequency.
+func (s DFR) tfn(freq, length, avgLength float64) float64 {
+	return freq * math.Log2(1+s.C*avgLength/max(length, 1))
+}
+
+// LMDirichlet is the query likelihood similarity with Dirichlet smoothing
+// of parameter Mu.
+type LMDirichlet struct {
+	Mu float64
+}
+
+// Weight returns the probability of the term in the corpus, smoothed so
+// that it is never zero.
+func (LMDirichlet) Weight(corpus CorpusStats, term TermStats) float64 {
+	return (float64(term.TotalTermFreq) + 1) / (corpus.Tokens + 1)
+}
+
+// Score returns log(1 + freq/(Mu·p)) + log(Mu/(length+Mu)), where p is the
+// weight, or 0 if that is negative.
+func (s LMDirichlet) Score(_ string, w, freq, length, _ float64) float64 {
+	score := math.Log(1+freq/(s.Mu*w)) + math.Log(s.Mu/(length+s.Mu))
+	return max(score, 0)
+}
+
+// ExplainWeight explains Weight.
+func (s LMDirichlet) ExplainWeight(corpus CorpusStats, term TermStats) Explanation {
+	return explain(s.Weight(corpus, term), "collection probability, computed as (totalTermFreq + 1) / (tokens + 1) from:",
+		explain(float64(term.TotalTermFreq), "totalTermFreq, occurrences of the term in the corpus"),
+		explain(corpus.Tokens, "tokens, total length of the documents"),
+	)
+}
+
+// ExplainScore explains Score.
+func (s LMDirichlet) ExplainScore(field string, w Explanation, freq, length, avgLength float64) Explanation {
+	return explain(s.Score(field, w.Value, freq, length, avgLength), "score, computed as max(0, log(1 + freq / (mu * p)) + log(mu / (length + mu))) from:",
+		w,
+		explain(freq, "freq, occurrences of the term"),
+		explain(s.Mu, "mu, Dirichlet smoothing"),
+		explainLength(field, length),
+	)
+}
@@ -0,0 +1,142 @@
+package ranker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+)
+
+// ScoreField names the relevance score in a sort.
+const ScoreField = "_score"
+
+// SortField orders results by the values of a metadata key of the schema,
+// or by score for ScoreField, ascending unless Desc is set.
+type SortField struct {
+	Field string
+	Type  index.ValueType
+	Desc  bool
+}
+
+// String returns the sort field in the syntax ParseSort parses.
+func (f SortField) String() string {
+	if f.Desc {
+		return f.Field + ":desc"
+	}
+	return f.Field + ":asc"
+}
+
+// scoreSort is the default order: by descending score.
+var scoreSort = []SortField{{Field: ScoreField, Desc: true}}
+
+// ParseSort parses a comma-separated list of sort fields, each a metadata
+// key of schema or _score with an optional :asc or :desc. Keys sort
+// ascending and _score descending unless told otherwise.
+func ParseSort(s string, schema index.Schema) ([]SortField, error) {
+	var fields []SortField
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, dir, _ := strings.Cut(part, ":")
+		f := SortField{Field: name, Desc: name == ScoreField}
+		if name != ScoreField {
+			t, ok := schema[name]
+			if !ok {
+				return nil, fmt.Errorf("cannot sort on %q: not a metadata field of the schema", name)
+			}
+			f.Type = t
+		}
+		switch dir {
+		case "":
+		case "asc":
+			f.Desc = false
+		case "desc":
+			f.Desc = true
+		default:
+			return nil, fmt.Errorf("sort direction of %q must be asc or desc", name)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// sortKey is the value of a result for one sort field.
+type sortKey struct {
+	value   index.Value
+	missing bool
+}
+
+// compareKeys compares the sort keys of two results field by field,
+// returning -1 if a sorts first. Missing values sort last in either
+// direction.
+func compareKeys(fields []SortField, a, b []sortKey) int {
+	for i, f := range fields {
+		ka, kb := a[i], b[i]
+		switch {
+		case ka.missing && kb.missing:
+			continue
+		case ka.missing:
+			return 1
+		case kb.missing:
+			return -1
+		}
+		t := f.Type
+		if f.Field == ScoreField {
+			t = index.TypeFloat
+		}
+		c := t.Compare(ka.value, kb.value)
+		if f.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// sortValues returns the sort keys as they are returned with results: a
+// string for a keyword, a number otherwise, and nil if missing.
+func sortValues(fields []SortField, keys []sortKey) []any {
+	values := make([]any, len(keys))
+	for i, k := range keys {
+		switch {
+		case k.missing:
+		case fields[i].Field != ScoreField && !fields[i].Type.Numeric():
+			values[i] = k.value.Str
+		default:
+			values[i] = k.value.Num
+		}
+	}
+	return values
+}
+
+// cursorKeys converts the sort values of a cursor back into sort keys.
+func cursorKeys(fields []SortField, values []any) ([]sortKey, error) {
+	if len(values) != len(fields) {
+		return nil, fmt.Errorf("cursor has %d sort values, the sort has %d fields", len(values), len(fields))
+	}
+	keys := make([]sortKey, len(values))
+	for i, v := range values {
+		numeric := fields[i].Field == ScoreField || fields[i].Type.Numeric()
+		switch v := v.(type) {
+		case nil:
+			keys[i].missing = true
+		case float64:
+			if !numeric {
+				return nil, fmt.Errorf("cursor value %d of %s must be a string", i, fields[i].Field)
+			}
+			keys[i].value.Num = v
+		case string:
+			if numeric {
+				return nil, fmt.Errorf("cursor value %d of %s must be a number", i, fields[i].Field)
+			}
+			keys[i].value.Str = v
+		default:
+			return nil, fmt.Errorf("cursor value %d of %s has type %T", i, fields[i].Field, v)
+		}
+	}
+	return keys, nil
+}
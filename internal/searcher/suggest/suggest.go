@@ -0,0 +1,211 @@
+// Package suggest completes partial queries and corrects misspelt ones. It
+// keeps a trie of the terms in the index, as the words they were analysed
+// from and ranked by document frequency, and
+// of the queries searched for most often, ranked by the number of searches
+// that returned results, and rebuilds it periodically.
+package suggest
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/analytics"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/automaton"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/proto"
+)
+
+const (
+	// DefaultMaxItems is the most suggestions returned for a prefix unless
+	// configured otherwise.
+	DefaultMaxItems = 10
+	// DefaultPopularQueries is the number of popular queries suggested
+	// from unless configured otherwise.
+	DefaultPopularQueries = 1000
+	// DefaultRefreshInterval is how often Run refreshes suggestions if
+	// given no interval.
+	DefaultRefreshInterval = 30 * time.Second
+)
+
+// QuerySource supplies the queries searched for most often, such as the
+// analytics aggregator.
+type QuerySource interface {
+	PopularQueries(n int) []analytics.QueryCount
+}
+
+// Config controls what a Suggester suggests. Zero values select
+// DefaultMaxItems and DefaultPopularQueries.
+type Config struct {
+	// MaxItems is the most suggestions returned for a prefix.
+	MaxItems int
+	// PopularQueries is the number of popular queries suggested from.
+	PopularQueries int
+}
+
+// Suggester serves query completions and spelling corrections from a
+// snapshot of the indexed terms and popular queries, which Refresh
+// rebuilds.
+type Suggester struct {
+	engines  map[int]*indexer.Engine
+	analyzer analysis.Analyzer
+	queries  QuerySource
+	cfg      Config
+	trie     atomic.Pointer[trie]
+	logger   *slog.Logger
+}
+
+// New creates a Suggester over the terms of the given shard engines and
+// the queries of queries, which may be nil. Texts are normalised with
+// analyzer, the one the index was built with. It suggests nothing until
+// Refresh is called.
+func New(engines map[int]*indexer.Engine, analyzer analysis.Analyzer, queries QuerySource, cfg Config) *Suggester {
+	if cfg.MaxItems <= 0 {
+		cfg.MaxItems = DefaultMaxItems
+	}
+	if cfg.PopularQueries <= 0 {
+		cfg.PopularQueries = DefaultPopularQueries
+	}
+	s := &Suggester{
+		engines:  engines,
+		analyzer: analyzer,
+		queries:  queries,
+		cfg:      cfg,
+		logger:   slog.Default().With("component", "suggester"),
+	}
+	s.trie.Store(&trie{})
+	return s
+}
+
+// Refresh rebuilds the suggestions from the terms currently in every shard,
+// summing document frequencies over fields and shards, and from the
+// current popular queries. Each term is suggested as the word it was most
+// often analysed from, such as computer rather than its stem comput.
+func (s *Suggester) Refresh() {
+	start := time.Now()
+	t := &trie{terms: make(map[string]int64)}
+	forms := make(analysis.SurfaceForms)
+	for _, engine := range s.engines {
+		view := engine.AcquireView()
+		view.TermsWithPrefix("", func(key string, docFreq int) bool {
+			if _, term, ok := index.SplitFieldTerm(key); ok {
+				t.terms[term] += int64(docFreq)
+			}
+			return true
+		})
+		view.AddSurfaceForms(forms)
+		view.Close()
+	}
+	for term, docFreq := range t.terms {
+		text, ok := forms.Best(term)
+		if !ok {
+			text = term
+		}
+		t.add(text, docFreq, 0)
+	}
+	if s.queries != nil {
+		for _, qc := range s.queries.PopularQueries(s.cfg.PopularQueries) {
+			if text := s.normalize(qc.Query); text != "" {
+				t.add(text, 0, qc.Count)
+			}
+		}
+	}
+	t.rank(s.cfg.MaxItems)
+	s.trie.Store(t)
+	s.logger.Debug("suggestions refreshed",
+		"entries", t.entries,
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+}
+
+// Run calls Refresh every interval, or DefaultRefreshInterval if it is not
+// positive, until ctx is cancelled.
+func (s *Suggester) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Refresh()
+		}
+	}
+}
+
+// Suggest implements the Suggest RPC of SearchService. It returns up to
+// req.MaxItems completions of req.Prefix, capped at the configured
+// maximum, best ranked first. Indexed terms are suggested as the words
+// they were most often analysed from, normalised. A prefix of several words is also completed by
+// completing its last word.
+func (s *Suggester) Suggest(ctx context.Context, req *proto.SuggestRequest) (*proto.SuggestResponse, error) {
+	n := int(req.MaxItems)
+	if n <= 0 || n > s.cfg.MaxItems {
+		n = s.cfg.MaxItems
+	}
+	prefix := s.normalize(req.Prefix)
+	if prefix == "" {
+		return &proto.SuggestResponse{Suggestions: []string{}}, nil
+	}
+	if strings.HasSuffix(req.Prefix, " ") {
+		prefix += " "
+	}
+	return &proto.SuggestResponse{Suggestions: s.trie.Load().complete(prefix, n)}, nil
+}
+
+// DidYouMean returns the query of plan with every word whose term is not
+// in the index replaced by the closest word an indexed term was analysed
+// from, and whether any was. Words of up to 2 characters are left alone,
+// and words of up to 5 are corrected by 1 edit at most and longer ones by
+// 2; among the words equally close, the one whose term is in most
+// documents is chosen.
+func (s *Suggester) DidYouMean(plan *parser.QueryPlan) (string, bool) {
+	t := s.trie.Load()
+	return plan.Correct(func(word string, term parser.Term) (string, bool) {
+		if t.docFreq(term.Text) > 0 {
+			return "", false
+		}
+		text := s.normalize(word)
+		edits := maxEdits(text)
+		if edits == 0 {
+			return "", false
+		}
+		dfa, err := automaton.Levenshtein(text, edits)
+		if err != nil {
+			s.logger.Warn("spelling correction failed", "word", text, "error", err)
+			return "", false
+		}
+		e, ok := t.closest(dfa)
+		if !ok || e.text == text {
+			return "", false
+		}
+		return e.text, true
+	})
+}
+
+// maxEdits returns the most edits a word is corrected by, which grows with
+// its length so that short words are not corrected into unrelated ones.
+func maxEdits(word string) int {
+	switch n := utf8.RuneCountInString(word); {
+	case n <= 2:
+		return 0
+	case n <= 5:
+		return 1
+	}
+	return automaton.MaxEdits
+}
+
+// normalize prepares text for the trie: normalised as query terms are, with
+// runs of white space collapsed.
+func (s *Suggester) normalize(text string) string {
+	return strings.Join(strings.Fields(analysis.Normalize(s.analyzer, text)), " ")
+}
@@ -0,0 +1,169 @@
+package suggest
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/automaton"
+)
+
+// entry is a suggestion and the frequencies it is ranked by.
+type entry struct {
+	text string
+	// docFreq is the number of documents containing the indexed term the
+	// text stands for, summed over fields and shards, or 0 if it stands for
+	// none.
+	docFreq int64
+	// searches is the number of searches for the text that returned
+	// results.
+	searches int64
+}
+
+// weight is the frequency suggestions are ranked by.
+func (e *entry) weight() int64 {
+	return e.docFreq + e.searches
+}
+
+// before reports whether e ranks ahead of o: higher weight first, then in
+// text order.
+func (e *entry) before(o *entry) bool {
+	if e.weight() != o.weight() {
+		return e.weight() > o.weight()
+	}
+	return e.text < o.text
+}
+
+// node is a node of a trie, reached by the runes of the texts below it.
+type node struct {
+	children map[rune]*node
+	entry    *entry
+	// top holds the best ranked entries at or below the node, so that a
+	// prefix is completed without visiting its subtree.
+	top []*entry
+}
+
+// trie maps normalised suggestion texts to their entries. It is immutable
+// once built.
+type trie struct {
+	root    node
+	entries int
+	// terms maps each indexed term to its document frequency.
+	terms map[string]int64
+}
+
+// add adds frequencies to the entry for text, creating it if needed.
+func (t *trie) add(text string, docFreq, searches int64) {
+	n := &t.root
+	for _, r := range text {
+		child := n.children[r]
+		if child == nil {
+			if n.children == nil {
+				n.children = make(map[rune]*node)
+			}
+			child = &node{}
+			n.children[r] = child
+		}
+		n = child
+	}
+	if n.entry == nil {
+		n.entry = &entry{text: text}
+		t.entries++
+	}
+	n.entry.docFreq += docFreq
+	n.entry.searches += searches
+}
+
+// rank fills in the top entries of every node, keeping at most k per node.
+// It must be called once all entries are added.
+func (t *trie) rank(k int) {
+	var visit func(n *node)
+	visit = func(n *node) {
+		var top []*entry
+		if n.entry != nil {
+			top = append(top, n.entry)
+		}
+		for _, child := range n.children {
+			visit(child)
+			top = append(top, child.top...)
+		}
+		sort.Slice(top, func(i, j int) bool { return top[i].before(top[j]) })
+		if len(top) > k {
+			top = top[:k:k]
+		}
+		n.top = top
+	}
+	visit(&t.root)
+}
+
+// find returns the node reached by prefix, or nil.
+func (t *trie) find(prefix string) *node {
+	n := &t.root
+	for _, r := range prefix {
+		if n = n.children[r]; n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// complete returns up to n of the best ranked texts starting with prefix.
+// If prefix holds several words, the texts completing its last word with
+// one standing for an indexed term follow, with the words before it prepended.
+func (t *trie) complete(prefix string, n int) []string {
+	suggestions := make([]string, 0, n)
+	seen := make(map[string]bool)
+	addFrom := func(nd *node, lead string, termsOnly bool) {
+		if nd == nil {
+			return
+		}
+		for _, e := range nd.top {
+			if len(suggestions) == n {
+				return
+			}
+			if termsOnly && e.docFreq == 0 {
+				continue
+			}
+			if text := lead + e.text; !seen[text] {
+				seen[text] = true
+				suggestions = append(suggestions, text)
+			}
+		}
+	}
+	addFrom(t.find(prefix), "", false)
+	if i := strings.LastIndexByte(prefix, ' '); i >= 0 && i < len(prefix)-1 {
+		addFrom(t.find(prefix[i+1:]), prefix[:i+1], true)
+	}
+	return suggestions
+}
+
+// docFreq returns the document frequency of an indexed term.
+func (t *trie) docFreq(term string) int64 {
+	return t.terms[term]
+}
+
+// closest returns the text standing for an indexed term that the automaton
+// accepts with the lowest value, such as an edit distance, breaking ties by
+// document frequency and then text. It runs the automaton along the paths of the trie, leaving
+// those it rejects unvisited.
+func (t *trie) closest(dfa *automaton.DFA) (*entry, bool) {
+	var best *entry
+	bestValue := 0
+	var visit func(n *node, state int)
+	visit = func(n *node, state int) {
+		if e := n.entry; e != nil && e.docFreq > 0 {
+			if value, ok := dfa.Accept(state); ok {
+				if best == nil || value < bestValue ||
+					value == bestValue && (e.docFreq > best.docFreq || e.docFreq == best.docFreq && e.text < best.text) {
+					best, bestValue = e, value
+				}
+			}
+		}
+		for r, child := range n.children {
+			if next := dfa.Step(state, r); next >= 0 {
+				visit(child, next)
+			}
+		}
+	}
+	visit(&t.root, 0)
+	return best, best != nil
+}
@@ -81,21 +81,157 @@ type RedisConfig struct {
 }
 
 // IndexerConfig controls the indexing engine's memory thresholds, flush
-// intervals, and segment merge policy.
+// intervals, segment merge policy, write-ahead log durability, segment
+// verification, and text analysis.
 type IndexerConfig struct {
 	DataDir                string        `yaml:"dataDir"`
 	SegmentMaxSize         int64         `yaml:"segmentMaxSize"`
 	MergeInterval          time.Duration `yaml:"mergeInterval"`
 	FlushInterval          time.Duration `yaml:"flushInterval"`
 	MaxSegmentsBeforeMerge int           `yaml:"maxSegmentsBeforeMerge"`
+	// WALSyncPolicy is one of "always" (fsync every write, the default),
+	// "batch" (fsync every WALSyncBatch writes), or "interval" (fsync every
+	// WALSyncInterval).
+	WALSyncPolicy   string        `yaml:"walSyncPolicy"`
+	WALSyncBatch    int           `yaml:"walSyncBatch"`
+	WALSyncInterval time.Duration `yaml:"walSyncInterval"`
+	// Analyzer names the text analyzer applied to documents and queries:
+	// "english" (the default), "german", "french", "spanish", "standard",
+	// "whitespace", "keyword", "english_legacy" or one defined in Analyzers.
+	// The indexer and searcher must agree. The analyzer is recorded in each shard directory, and an
+	// index built with another one is refused: changing it requires
+	// reindexing. Indexes that predate the record were built with
+	// "english_legacy".
+	Analyzer string `yaml:"analyzer"`
+	// Analyzers defines analyzers by name, in addition to the built-in ones.
+	// Changing the definition of the analyzer an index was built with also
+	// requires reindexing.
+	Analyzers map[string]AnalyzerConfig `yaml:"analyzers"`
+	// SegmentVerify is how much of each segment is checksummed when it is
+	// opened: "off", "dictionary" (header, dictionary and document table;
+	// the default), or "full" (the whole file). Corrupt segments are moved
+	// to a corrupt/ subdirectory.
+	SegmentVerify string `yaml:"segmentVerify"`
+	// Metadata maps the metadata keys indexed as doc values, for filtering
+	// and sorting, to their type: "keyword", "integer", "float", "date" or
+	// "boolean". Other metadata is only stored. The indexer, searcher and
+	// ingestion service must agree; segments pick up a changed schema when
+	// they are merged, or when documents are reindexed.
+	Metadata map[string]string `yaml:"metadata"`
+	// ReadOnly opens engines for searching only: the write-ahead log is not
+	// replayed or written, and nothing is flushed. It is set by the searcher,
+	// which shares the data directory with the indexer.
+	ReadOnly bool `yaml:"-"`
 }
 
-// SearchConfig controls query execution limits and timeouts.
+// AnalyzerConfig defines an analyzer as a pipeline of named components,
+// applied in order.
+type AnalyzerConfig struct {
+	// CharFilters rewrite the text before it is tokenized: "nfkc", or
+	// "mapping", which replaces every occurrence of a key of Mappings with
+	// its value, longest keys first.
+	CharFilters []string          `yaml:"charFilters"`
+	Mappings    map[string]string `yaml:"mappings"`
+	// Tokenizer splits the text into terms: "standard" (Unicode word
+	// boundaries), "letter_digit" (runs of letters and digits),
+	// "whitespace" or "keyword" (the whole text as one term).
+	Tokenizer string `yaml:"tokenizer"`
+	// Filters transform the terms: "lowercase", "accent_folding",
+	// "cjk_bigram", "min_length" (drops terms shorter than MinLength
+	// characters, 2 if unset), "stop" (drops StopWords), "<language>_stop"
+	// and "<language>_stem" for english, german, french and spanish,
+	// "english_legacy_stem" and "french_elision".
+	Filters   []string `yaml:"filters"`
+	MinLength int      `yaml:"minLength"`
+	StopWords []string `yaml:"stopWords"`
+}
+
+// SearchConfig controls query execution limits, timeouts, relevance
+// ranking, term expansion, query suggestions and result highlighting.
 type SearchConfig struct {
-	MaxResults           int           `yaml:"maxResults"`
-	DefaultLimit         int           `yaml:"defaultLimit"`
-	TimeoutPerShard      time.Duration `yaml:"timeoutPerShard"`
-	MaxConcurrentQueries int           `yaml:"maxConcurrentQueries"`
+	MaxResults   int `yaml:"maxResults"`
+	DefaultLimit int `yaml:"defaultLimit"`
+	// MaxOffset is the most results a search may skip with offset; deeper
+	// pages are reached with search_after cursors.
+	MaxOffset            int             `yaml:"maxOffset"`
+	TimeoutPerShard      time.Duration   `yaml:"timeoutPerShard"`
+	MaxConcurrentQueries int             `yaml:"maxConcurrentQueries"`
+	Ranking              RankingConfig   `yaml:"ranking"`
+	Expansion            ExpansionConfig `yaml:"expansion"`
+	Suggest              SuggestConfig   `yaml:"suggest"`
+	Highlight            HighlightConfig `yaml:"highlight"`
+}
+
+// RankingConfig selects and tunes the relevance scoring function.
+type RankingConfig struct {
+	// Similarity is the scoring function: "bm25" (the default), "bm25plus",
+	// "tfidf", "dfr" or "lm_dirichlet". Searches may select another one.
+	Similarity string `yaml:"similarity"`
+	// Mode is "bm25f" (the default), which normalises and weights each
+	// field separately and, for bm25 and bm25plus, combines them before
+	// saturation, "fields", which sums the scores of each field, or
+	// "bm25", which scores a document as if its fields were one stream.
+	Mode string `yaml:"mode"`
+	// FieldBoosts weights matches in each field; fields not listed have a
+	// boost of 1.
+	FieldBoosts map[string]float64 `yaml:"fieldBoosts"`
+	// K1 is the term frequency saturation of bm25 and bm25plus, and B
+	// their length normalisation, from 0 for none to 1 for full.
+	K1 float64 `yaml:"k1"`
+	B  float64 `yaml:"b"`
+	// FieldK1 overrides K1 for each field it lists; it applies only in
+	// "fields" mode, as BM25F saturates the combined fields once.
+	FieldK1 map[string]float64 `yaml:"fieldK1"`
+	// FieldLengthNorm overrides B for each field it lists.
+	FieldLengthNorm map[string]float64 `yaml:"fieldLengthNorm"`
+	// Delta is the lower bound bm25plus gives the normalised term
+	// frequency of a match.
+	Delta float64 `yaml:"delta"`
+	// DFRC scales the average field length in dfr's length normalisation.
+	DFRC float64 `yaml:"dfrC"`
+	// Mu is the Dirichlet smoothing of lm_dirichlet, roughly the length of
+	// text its corpus statistics weigh as much as.
+	Mu float64 `yaml:"mu"`
+}
+
+// ExpansionConfig controls how prefix, wildcard and fuzzy query terms are
+// expanded into the terms of the index.
+type ExpansionConfig struct {
+	// MaxExpansions is the most index terms one such query term expands
+	// to; the closest fuzzy matches and then the most frequent terms are
+	// kept.
+	MaxExpansions int `yaml:"maxExpansions"`
+	// Rewrite is "constant_score" (the default), which gives every matching
+	// document the same score, or "scoring_boolean", which scores the
+	// expanded terms like the optional clauses of a Boolean query.
+	Rewrite string `yaml:"rewrite"`
+}
+
+// SuggestConfig controls query autocompletion and spelling correction.
+type SuggestConfig struct {
+	// MaxItems is the most completions returned for a prefix.
+	MaxItems int `yaml:"maxItems"`
+	// PopularQueries is the number of most searched queries, from the
+	// analytics aggregator, that are suggested besides indexed terms.
+	PopularQueries int `yaml:"popularQueries"`
+	// RefreshInterval is how often suggestions are rebuilt from the index
+	// and analytics.
+	RefreshInterval time.Duration `yaml:"refreshInterval"`
+}
+
+// HighlightConfig controls the fragments of stored text returned with
+// results when highlighting is requested.
+type HighlightConfig struct {
+	// PreTag and PostTag are put around every matched word.
+	PreTag  string `yaml:"preTag"`
+	PostTag string `yaml:"postTag"`
+	// FragmentSize is the length of a fragment in characters.
+	FragmentSize int `yaml:"fragmentSize"`
+	// NumFragments is the most fragments returned for a field.
+	NumFragments int `yaml:"numFragments"`
+	// Encoder is "html" (the default), which escapes the text around the
+	// tags, or "none".
+	Encoder string `yaml:"encoder"`
 }
 
 // LoggingConfig controls structured logging level and output format.
@@ -180,6 +316,35 @@ func defaultConfig() *Config {
 			PoolSize: 10,
 			CacheTTL: 60 * time.Second,
 		},
+		Search: SearchConfig{
+			MaxOffset: 10000,
+			Ranking: RankingConfig{
+				Similarity:  "bm25",
+				Mode:        "bm25f",
+				FieldBoosts: map[string]float64{"title": 2},
+				K1:          1.2,
+				B:           0.75,
+				Delta:       1,
+				DFRC:        1,
+				Mu:          2000,
+			},
+			Expansion: ExpansionConfig{
+				MaxExpansions: 64,
+				Rewrite:       "constant_score",
+			},
+			Suggest: SuggestConfig{
+				MaxItems:        10,
+				PopularQueries:  1000,
+				RefreshInterval: 30 * time.Second,
+			},
+			Highlight: HighlightConfig{
+				PreTag:       "<em>",
+				PostTag:      "</em>",
+				FragmentSize: 100,
+				NumFragments: 3,
+				Encoder:      "html",
+			},
+		},
 		Logging: LoggingConfig{
 			Level:  "info",
 			Format: "json",
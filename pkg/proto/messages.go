@@ -74,10 +74,11 @@ type SuggestResponse struct {
 
 // IndexRequest is the input to the IndexDocument RPC.
 type IndexRequest struct {
-	DocumentID string `json:"document_id"`
-	Title      string `json:"title"`
-	Body       string `json:"body"`
-	ShardID    int32  `json:"shard_id"`
+	DocumentID string            `json:"document_id"`
+	Title      string            `json:"title"`
+	Body       string            `json:"body"`
+	Fields     map[string]string `json:"fields,omitempty"`
+	ShardID    int32             `json:"shard_id"`
 }
 
 // IndexResponse is the output of the IndexDocument RPC.
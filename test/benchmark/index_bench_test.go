@@ -4,55 +4,62 @@ package benchmark
 
 import (
 	"fmt"
+	"path/filepath"
 	"testing"
 
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/segment"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/config"
 )
 
+// document returns a benchmark document with the given title and body.
+func document(title, body string) index.Document {
+	return index.Document{Fields: map[string]string{index.FieldTitle: title, index.FieldBody: body}}
+}
+
 // BenchmarkMemoryIndexAdd measures per-document insert throughput into the
 // in-memory inverted index.
 func BenchmarkMemoryIndexAdd(b *testing.B) {
-	mi := index.NewMemoryIndex()
+	mi := index.NewMemoryIndex(analyzer, nil)
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		docID := fmt.Sprintf("doc-%d", i)
-		mi.AddDocument(docID, "benchmark title", "this is a benchmark document with several terms for testing the indexing performance of our memory index")
+		mi.AddDocument(docID, document("benchmark title", "this is a benchmark document with several terms for testing the indexing performance of our memory index"))
 	}
 }
 
 // BenchmarkMemoryIndexSearch measures single-term lookup latency over 10 000
 // documents.
 func BenchmarkMemoryIndexSearch(b *testing.B) {
-	mi := index.NewMemoryIndex()
+	mi := index.NewMemoryIndex(analyzer, nil)
 	for i := 0; i < 10000; i++ {
 		docID := fmt.Sprintf("doc-%d", i)
-		mi.AddDocument(docID, "distributed search", "search engine with distributed indexing and query processing")
+		mi.AddDocument(docID, document("distributed search", "search engine with distributed indexing and query processing"))
 	}
 
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		results := mi.Search("search")
+		results := mi.Search(index.FieldTerm(index.FieldBody, "search"))
 		_ = results
 	}
 }
 
 // BenchmarkMemoryIndexSearchParallel measures concurrent read throughput.
 func BenchmarkMemoryIndexSearchParallel(b *testing.B) {
-	mi := index.NewMemoryIndex()
+	mi := index.NewMemoryIndex(analyzer, nil)
 	for i := 0; i < 10000; i++ {
 		docID := fmt.Sprintf("doc-%d", i)
-		mi.AddDocument(docID, "distributed search", "search engine with distributed indexing and query processing")
+		mi.AddDocument(docID, document("distributed search", "search engine with distributed indexing and query processing"))
 	}
 
 	b.ReportAllocs()
 	b.ResetTimer()
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
-			results := mi.Search("search")
+			results := mi.Search(index.FieldTerm(index.FieldBody, "search"))
 			_ = results
 		}
 	})
@@ -61,10 +68,10 @@ func BenchmarkMemoryIndexSearchParallel(b *testing.B) {
 // BenchmarkMemoryIndexSnapshot measures the cost of snapshotting the index
 // before a segment flush.
 func BenchmarkMemoryIndexSnapshot(b *testing.B) {
-	mi := index.NewMemoryIndex()
+	mi := index.NewMemoryIndex(analyzer, nil)
 	for i := 0; i < 5000; i++ {
 		docID := fmt.Sprintf("doc-%d", i)
-		mi.AddDocument(docID, "snapshot benchmark", "testing snapshot performance with multiple terms and documents")
+		mi.AddDocument(docID, document("snapshot benchmark", "testing snapshot performance with multiple terms and documents"))
 	}
 
 	b.ReportAllocs()
@@ -94,14 +101,14 @@ func BenchmarkEngineIndex(b *testing.B) {
 
 			for i := 0; i < preload; i++ {
 				docID := fmt.Sprintf("preload-%d", i)
-				engine.IndexDocument(docID, "preload doc", "preloading documents for benchmark warmup phase")
+				engine.IndexDocument(docID, document("preload doc", "preloading documents for benchmark warmup phase"))
 			}
 
 			b.ReportAllocs()
 			b.ResetTimer()
 			for i := 0; i < b.N; i++ {
 				docID := fmt.Sprintf("bench-%d", i)
-				err := engine.IndexDocument(docID, "benchmark title", "benchmark document body for measuring indexing throughput")
+				err := engine.IndexDocument(docID, document("benchmark title", "benchmark document body for measuring indexing throughput"))
 				if err != nil {
 					b.Fatal(err)
 				}
@@ -130,16 +137,135 @@ func BenchmarkEngineSearch(b *testing.B) {
 		title := fmt.Sprintf("document about %s and %s", terms[i%len(terms)], terms[(i+1)%len(terms)])
 		body := fmt.Sprintf("this document covers %s %s %s in production systems",
 			terms[i%len(terms)], terms[(i+2)%len(terms)], terms[(i+3)%len(terms)])
-		engine.IndexDocument(docID, title, body)
+		engine.IndexDocument(docID, document(title, body))
 	}
 
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		results, err := engine.Search(terms[i%len(terms)])
+		results, err := engine.Search(index.FieldBody, terms[i%len(terms)])
 		if err != nil {
 			b.Fatal(err)
 		}
 		_ = results
 	}
 }
+
+// segmentFormats lists the segment format versions compared by the segment
+// benchmarks: version 1 (JSON postings) is the baseline for version 2
+// (compressed binary postings), version 3 (block term dictionary), version 5
+// (separate postings per field) and version 6 (stored fields).
+var segmentFormats = []uint32{1, 2, 3, 5, 6}
+
+// buildSegmentCorpus indexes 10 000 documents into a memory index and returns
+// its snapshot, document lengths and stored fields.
+func buildSegmentCorpus() ([]index.TermEntry, []index.DocLength, map[string]index.Document) {
+	mi := index.NewMemoryIndex(analyzer, nil)
+	terms := []string{"distributed", "search", "analytics", "platform", "indexing", "query", "engine", "ranking"}
+	for i := 0; i < 10000; i++ {
+		docID := fmt.Sprintf("doc-%d", i)
+		title := fmt.Sprintf("document about %s and %s", terms[i%len(terms)], terms[(i+1)%len(terms)])
+		body := fmt.Sprintf("this document covers %s %s %s in production systems number %d",
+			terms[i%len(terms)], terms[(i+2)%len(terms)], terms[(i+3)%len(terms)], i%97)
+		mi.AddDocument(docID, document(title, body))
+	}
+	return mi.Snapshot(), mi.DocLengths(), mi.StoredDocuments()
+}
+
+// BenchmarkSegmentWrite measures flushing 10 000 documents to a segment in
+// each format version and reports the resulting file size.
+func BenchmarkSegmentWrite(b *testing.B) {
+	entries, docs, stored := buildSegmentCorpus()
+	for _, version := range segmentFormats {
+		b.Run(fmt.Sprintf("v%d", version), func(b *testing.B) {
+			dir := b.TempDir()
+			w, err := segment.NewVersionedWriter(dir, version)
+			if err != nil {
+				b.Fatal(err)
+			}
+			var size int64
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				name, err := w.Write(entries, docs, stored)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.StopTimer()
+				r, err := segment.OpenReader(filepath.Join(dir, name))
+				if err != nil {
+					b.Fatal(err)
+				}
+				size = r.Size()
+				r.Retire()
+				r.Close()
+				b.StartTimer()
+			}
+			b.ReportMetric(float64(size), "segment_bytes")
+		})
+	}
+}
+
+// BenchmarkSegmentSearch measures single-term lookups against a 10 000
+// document segment in each format version.
+func BenchmarkSegmentSearch(b *testing.B) {
+	entries, docs, stored := buildSegmentCorpus()
+	for _, version := range segmentFormats {
+		b.Run(fmt.Sprintf("v%d", version), func(b *testing.B) {
+			dir := b.TempDir()
+			w, err := segment.NewVersionedWriter(dir, version)
+			if err != nil {
+				b.Fatal(err)
+			}
+			name, err := w.Write(entries, docs, stored)
+			if err != nil {
+				b.Fatal(err)
+			}
+			r, err := segment.OpenReader(filepath.Join(dir, name))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer r.Close()
+
+			var terms []string
+			for _, term := range []string{"distribut", "search", "analyt", "platform", "index", "queri", "engin", "rank"} {
+				terms = append(terms, index.FieldTerm(index.FieldBody, term))
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				postings, err := r.Search(terms[i%len(terms)])
+				if err != nil {
+					b.Fatal(err)
+				}
+				_ = postings
+			}
+		})
+	}
+}
+
+// BenchmarkSegmentStoredFields measures fetching the stored fields of a
+// document from a 10 000 document segment.
+func BenchmarkSegmentStoredFields(b *testing.B) {
+	entries, docs, stored := buildSegmentCorpus()
+	dir := b.TempDir()
+	name, err := segment.NewWriter(dir, nil).Write(entries, docs, stored)
+	if err != nil {
+		b.Fatal(err)
+	}
+	r, err := segment.OpenReader(filepath.Join(dir, name))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer r.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		doc, ok, err := r.StoredByOrdinal(uint32(i * 7919 % len(docs)))
+		if err != nil || !ok {
+			b.Fatalf("stored fields missing: %v", err)
+		}
+		_ = doc
+	}
+}
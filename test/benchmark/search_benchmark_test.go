@@ -3,16 +3,96 @@ package benchmark
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/aggregation"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/executor"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/handler"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/highlight"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/suggest"
 	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/config"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/proto"
 )
 
+// newEngine opens an engine over a new directory that flushes only when
+// asked, with the given metadata keys as doc values, and closes it when b
+// and its sub-benchmarks end.
+func newEngine(b *testing.B, metadata map[string]string) *indexer.Engine {
+	b.Helper()
+	engine, err := indexer.NewEngine(config.IndexerConfig{
+		DataDir:        b.TempDir(),
+		SegmentMaxSize: 100 * 1024 * 1024,
+		FlushInterval:  0,
+		Metadata:       metadata,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { engine.Close() })
+	return engine
+}
+
+// indexDoc indexes doc under docID, failing b if it cannot.
+func indexDoc(b *testing.B, engine *indexer.Engine, docID string, doc index.Document) {
+	b.Helper()
+	if err := engine.IndexDocument(docID, doc); err != nil {
+		b.Fatalf("indexing %s: %v", docID, err)
+	}
+}
+
+// indexCorpus indexes doc(0) to doc(n-1) as doc0 to doc<n-1>. If flushAt is
+// positive, the first flushAt documents are flushed to a segment and the
+// others left in the memory index.
+func indexCorpus(b *testing.B, engine *indexer.Engine, n, flushAt int, doc func(d int) index.Document) {
+	b.Helper()
+	for d := 0; d < n; d++ {
+		indexDoc(b, engine, fmt.Sprintf("doc%d", d), doc(d))
+		if d+1 == flushAt {
+			if err := engine.Flush(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// corpusWords are the words of the documents of weightedDoc.
+var corpusWords = []string{"search", "analytics", "platform", "distributed", "indexing", "query", "ranking", "shard"}
+
+// weightedDoc returns document d of a corpus of varying length and term
+// frequency: its body holds each of corpusWords 0 to 3 times and up to 12
+// filler words, and its title two of corpusWords.
+func weightedDoc(d int) index.Document {
+	var body []string
+	for i, w := range corpusWords {
+		for n := (d*(i+3)/7 + i) % 4; n > 0; n-- {
+			body = append(body, w)
+		}
+	}
+	body = append(body, strings.Repeat("filler ", d%13))
+	title := corpusWords[d%len(corpusWords)] + " " + corpusWords[d/3%len(corpusWords)]
+	return document(title, strings.Join(body, " "))
+}
+
+// metadataKeys are the doc values of the documents of metadataDoc.
+var metadataKeys = map[string]string{"category": "keyword", "created_at": "date", "price": "float"}
+
+// metadataDoc returns document d of a corpus of identical text with one of
+// four categories, a date in 2026 and a price.
+func metadataDoc(d int) index.Document {
+	doc := document("distributed search", "search analytics platform with distributed indexing")
+	doc.Metadata = map[string]any{
+		"category":   []string{"news", "sports", "tech", "science"}[d%4],
+		"created_at": fmt.Sprintf("2026-%02d-%02d", d%12+1, d%28+1),
+		"price":      float64(d%500) / 4,
+	}
+	return doc
+}
+
 // BenchmarkQueryParse measures query parsing latency for queries of varying
 // complexity.
 func BenchmarkQueryParse(b *testing.B) {
@@ -26,14 +106,19 @@ func BenchmarkQueryParse(b *testing.B) {
 		{"with_not", "distributed NOT monolithic"},
 		{"complex", "search AND ranking OR analytics NOT deprecated"},
 		{"long", "distributed search analytics platform indexing query processing ranking caching sharding"},
+		{"phrase", `"distributed search" analytics`},
+		{"proximity", `title:"search platform"~3 NOT "legacy system"`},
+		{"grouped", "(search OR ranking OR caching)@2 +analytics -deprecated title:(platform^2 OR engine)"},
+		{"multi_term", "data* d?ta* databse~1 title:kafk~^2"},
 	}
 
 	for _, q := range queries {
 		b.Run(q.name, func(b *testing.B) {
 			b.ReportAllocs()
 			for i := 0; i < b.N; i++ {
-				plan := parser.Parse(q.query)
-				_ = plan
+				if _, err := parser.Parse(q.query, analyzer, nil); err != nil {
+					b.Fatal(err)
+				}
 			}
 		})
 	}
@@ -45,30 +130,33 @@ func BenchmarkBM25Ranking(b *testing.B) {
 	sizes := []int{100, 1000, 10000}
 	for _, numDocs := range sizes {
 		b.Run(fmt.Sprintf("docs_%d", numDocs), func(b *testing.B) {
-			postings := make(map[string]index.PostingList)
+			postings := make(map[string]ranker.FieldPostings)
 			term := "search"
-			pl := make(index.PostingList, numDocs)
+			pl := make(index.DocPostingList, numDocs)
+			docLengths := make([]int, numDocs)
 			for i := 0; i < numDocs; i++ {
-				pl[i] = index.Posting{
-					DocID:     fmt.Sprintf("doc-%d", i),
+				docLengths[i] = 100 + (len(fmt.Sprintf("doc-%d", i)) * 10)
+				pl[i] = index.DocPosting{
+					Doc:       uint32(i),
 					Frequency: (i % 10) + 1,
 					Positions: []int{0, 5, 10},
 				}
 			}
-			postings[term] = pl
+			postings[term] = ranker.FieldPostings{index.FieldBody: pl}
 
 			params := ranker.RankParams{
 				TotalDocs:    int64(numDocs * 2),
 				AvgDocLength: 150.0,
+				Config:       ranker.Config{Mode: ranker.ModeBM25},
 			}
-			getDocInfo := func(docID string) ranker.DocInfo {
-				return ranker.DocInfo{DocLength: 100 + (len(docID) * 10)}
+			getDocInfo := func(doc uint32) ranker.DocInfo {
+				return ranker.DocInfo{DocLength: docLengths[doc]}
 			}
 
 			b.ReportAllocs()
 			b.ResetTimer()
 			for i := 0; i < b.N; i++ {
-				ranked := ranker.Rank(postings, params, getDocInfo, 10)
+				ranked := ranker.Rank(postings, nil, params, getDocInfo, 10)
 				_ = ranked
 			}
 		})
@@ -81,32 +169,76 @@ func BenchmarkBM25MultiTerm(b *testing.B) {
 	termCount := []int{1, 3, 5, 10}
 	for _, tc := range termCount {
 		b.Run(fmt.Sprintf("terms_%d", tc), func(b *testing.B) {
-			postings := make(map[string]index.PostingList)
+			postings := make(map[string]ranker.FieldPostings)
 			for t := 0; t < tc; t++ {
 				term := fmt.Sprintf("term%d", t)
-				pl := make(index.PostingList, 500)
+				pl := make(index.DocPostingList, 500)
 				for i := 0; i < 500; i++ {
-					pl[i] = index.Posting{
-						DocID:     fmt.Sprintf("doc-%d", i),
+					pl[i] = index.DocPosting{
+						Doc:       uint32(i),
 						Frequency: (i % 5) + 1,
 						Positions: []int{t * 10},
 					}
 				}
-				postings[term] = pl
+				postings[term] = ranker.FieldPostings{index.FieldBody: pl}
 			}
 
 			params := ranker.RankParams{
 				TotalDocs:    5000,
 				AvgDocLength: 200.0,
+				Config:       ranker.Config{Mode: ranker.ModeBM25},
 			}
-			getDocInfo := func(docID string) ranker.DocInfo {
+			getDocInfo := func(doc uint32) ranker.DocInfo {
 				return ranker.DocInfo{DocLength: 180}
 			}
 
 			b.ReportAllocs()
 			b.ResetTimer()
 			for i := 0; i < b.N; i++ {
-				ranked := ranker.Rank(postings, params, getDocInfo, 10)
+				ranked := ranker.Rank(postings, nil, params, getDocInfo, 10)
+				_ = ranked
+			}
+		})
+	}
+}
+
+// BenchmarkBM25FRanking measures BM25F scoring of a term that occurs in the
+// title and body fields for different posting-list sizes.
+func BenchmarkBM25FRanking(b *testing.B) {
+	sizes := []int{100, 1000, 10000}
+	for _, numDocs := range sizes {
+		b.Run(fmt.Sprintf("docs_%d", numDocs), func(b *testing.B) {
+			titles := make(index.DocPostingList, 0, numDocs/4+1)
+			bodies := make(index.DocPostingList, numDocs)
+			fieldLengths := make([]map[string]int, numDocs)
+			for i := 0; i < numDocs; i++ {
+				fieldLengths[i] = map[string]int{index.FieldTitle: 4 + i%5, index.FieldBody: 100 + i%100}
+				bodies[i] = index.DocPosting{Doc: uint32(i), Frequency: (i % 10) + 1}
+				if i%4 == 0 {
+					titles = append(titles, index.DocPosting{Doc: uint32(i), Frequency: 1})
+				}
+			}
+			postings := map[string]ranker.FieldPostings{
+				"search": {index.FieldTitle: titles, index.FieldBody: bodies},
+			}
+
+			params := ranker.RankParams{
+				TotalDocs:       int64(numDocs * 2),
+				AvgDocLength:    155.0,
+				AvgFieldLengths: map[string]float64{index.FieldTitle: 6, index.FieldBody: 149.5},
+				Config: ranker.Config{
+					Mode:        ranker.ModeBM25F,
+					FieldBoosts: map[string]float64{index.FieldTitle: 2},
+				},
+			}
+			getDocInfo := func(doc uint32) ranker.DocInfo {
+				return ranker.DocInfo{FieldLengths: fieldLengths[doc]}
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ranked := ranker.Rank(postings, nil, params, getDocInfo, 10)
 				_ = ranked
 			}
 		})
@@ -121,32 +253,25 @@ func BenchmarkShardedExecutor(b *testing.B) {
 		b.Run(fmt.Sprintf("shards_%d", numShards), func(b *testing.B) {
 			engines := make(map[int]*indexer.Engine)
 			for s := 0; s < numShards; s++ {
-				cfg := config.IndexerConfig{
-					DataDir:        b.TempDir(),
-					SegmentMaxSize: 100 * 1024 * 1024,
-					FlushInterval:  0,
-				}
-				engine, err := indexer.NewEngine(cfg)
-				if err != nil {
-					b.Fatal(err)
-				}
-				defer engine.Close()
-
+				engine := newEngine(b, nil)
 				for d := 0; d < 1000; d++ {
 					docID := fmt.Sprintf("shard%d-doc%d", s, d)
-					engine.IndexDocument(docID, "distributed search",
-						"search analytics platform with distributed indexing and query ranking")
+					indexDoc(b, engine, docID, document("distributed search",
+						"search analytics platform with distributed indexing and query ranking"))
 				}
 				engines[s] = engine
 			}
 
-			exec := executor.NewSharded(engines)
-			plan := parser.Parse("distributed search")
+			exec := executor.NewSharded(engines, ranker.Config{}, executor.ExpansionConfig{})
+			plan, err := parser.Parse("distributed search", analyzer, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
 
 			b.ReportAllocs()
 			b.ResetTimer()
 			for i := 0; i < b.N; i++ {
-				result, err := exec.Execute(context.Background(), plan, 10)
+				result, err := exec.Execute(context.Background(), plan, executor.Options{Limit: 10})
 				if err != nil {
 					b.Fatal(err)
 				}
@@ -161,33 +286,26 @@ func BenchmarkShardedExecutor(b *testing.B) {
 func BenchmarkShardedExecutorParallel(b *testing.B) {
 	engines := make(map[int]*indexer.Engine)
 	for s := 0; s < 8; s++ {
-		cfg := config.IndexerConfig{
-			DataDir:        b.TempDir(),
-			SegmentMaxSize: 100 * 1024 * 1024,
-			FlushInterval:  0,
-		}
-		engine, err := indexer.NewEngine(cfg)
-		if err != nil {
-			b.Fatal(err)
-		}
-		defer engine.Close()
-
+		engine := newEngine(b, nil)
 		for d := 0; d < 1000; d++ {
 			docID := fmt.Sprintf("shard%d-doc%d", s, d)
-			engine.IndexDocument(docID, "distributed search analytics",
-				"platform with distributed search indexing query processing and ranking engine")
+			indexDoc(b, engine, docID, document("distributed search analytics",
+				"platform with distributed search indexing query processing and ranking engine"))
 		}
 		engines[s] = engine
 	}
 
-	exec := executor.NewSharded(engines)
-	plan := parser.Parse("distributed search")
+	exec := executor.NewSharded(engines, ranker.Config{}, executor.ExpansionConfig{})
+	plan, err := parser.Parse("distributed search", analyzer, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
 
 	b.ReportAllocs()
 	b.ResetTimer()
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
-			result, err := exec.Execute(context.Background(), plan, 10)
+			result, err := exec.Execute(context.Background(), plan, executor.Options{Limit: 10})
 			if err != nil {
 				b.Fatal(err)
 			}
@@ -195,3 +313,445 @@ func BenchmarkShardedExecutorParallel(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkPhraseQuery measures exact and sloppy phrase matching against
+// term positions, compared with the same terms as a plain AND query.
+func BenchmarkPhraseQuery(b *testing.B) {
+	engine := newEngine(b, nil)
+	bodies := []string{
+		"search analytics platform with distributed indexing and query ranking",
+		"query ranking for a distributed platform that can search analytics",
+		"distributed query processing with analytics search and ranking engine",
+	}
+	indexCorpus(b, engine, 3000, 0, func(d int) index.Document {
+		return document("distributed search", bodies[d%len(bodies)])
+	})
+	exec := executor.New(engine, ranker.Config{}, executor.ExpansionConfig{})
+
+	queries := []struct {
+		name  string
+		query string
+	}{
+		{"terms", "distributed query ranking"},
+		{"exact", `"distributed query"`},
+		{"exact_3", `"distributed query ranking"`},
+		{"slop_3", `"distributed query ranking"~3`},
+		{"slop_10", `"analytics ranking"~10`},
+	}
+	for _, q := range queries {
+		b.Run(q.name, func(b *testing.B) {
+			plan, err := parser.Parse(q.query, analyzer, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				result, err := exec.Execute(context.Background(), plan, executor.Options{Limit: 10})
+				if err != nil {
+					b.Fatal(err)
+				}
+				_ = result
+			}
+		})
+	}
+}
+
+// BenchmarkBooleanQuery measures evaluation of nested Boolean queries with
+// required, optional, prohibited and filter clauses.
+func BenchmarkBooleanQuery(b *testing.B) {
+	engine := newEngine(b, nil)
+	bodies := []string{
+		"search analytics platform with distributed indexing and query ranking",
+		"query ranking for a distributed platform that can search analytics",
+		"distributed query processing with analytics search and ranking engine",
+		"caching layer for a monolithic reporting engine",
+	}
+	indexCorpus(b, engine, 4000, 0, func(d int) index.Document {
+		return document("distributed search", bodies[d%len(bodies)])
+	})
+	exec := executor.New(engine, ranker.Config{}, executor.ExpansionConfig{})
+
+	queries := []struct {
+		name  string
+		query string
+	}{
+		{"and", "distributed query ranking"},
+		{"or", "indexing OR caching OR processing"},
+		{"nested", "+engine (query OR caching) -monolithic"},
+		{"min_should_match", "(indexing OR processing OR caching OR ranking)@2"},
+		{"filter", "#distributed analytics^2 OR ranking"},
+	}
+	for _, q := range queries {
+		b.Run(q.name, func(b *testing.B) {
+			plan, err := parser.Parse(q.query, analyzer, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				result, err := exec.Execute(context.Background(), plan, executor.Options{Limit: 10})
+				if err != nil {
+					b.Fatal(err)
+				}
+				_ = result
+			}
+		})
+	}
+}
+
+// BenchmarkTermExpansion measures prefix, wildcard and fuzzy queries over a
+// dictionary of distinct terms split between segments and the memory index,
+// with both rewrites.
+func BenchmarkTermExpansion(b *testing.B) {
+	engine := newEngine(b, nil)
+	indexCorpus(b, engine, 4000, 3000, func(d int) index.Document {
+		return document("distributed search", fmt.Sprintf("data%d stream%d database platform", d%500, d%50))
+	})
+
+	queries := []struct {
+		name  string
+		query string
+	}{
+		{"prefix", "data1*"},
+		{"wildcard", "str?am4*"},
+		{"fuzzy", "databse~1"},
+		{"fuzzy_2", "stream12~2"},
+	}
+	for _, rewrite := range []executor.Rewrite{executor.ConstantScore, executor.ScoringBoolean} {
+		exec := executor.New(engine, ranker.Config{}, executor.ExpansionConfig{Rewrite: rewrite})
+		for _, q := range queries {
+			b.Run(string(rewrite)+"/"+q.name, func(b *testing.B) {
+				plan, err := parser.Parse(q.query, analyzer, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := exec.Execute(context.Background(), plan, executor.Options{Limit: 10}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkSuggest measures prefix completion and spelling correction over
+// a trie of several thousand distinct terms.
+func BenchmarkSuggest(b *testing.B) {
+	engine := newEngine(b, nil)
+	indexCorpus(b, engine, 4000, 0, func(d int) index.Document {
+		return document("distributed search", fmt.Sprintf("data%d stream%d distributed database platform", d, d%50))
+	})
+	s := suggest.New(map[int]*indexer.Engine{0: engine}, analyzer, nil, suggest.Config{})
+	s.Refresh()
+
+	b.Run("refresh", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s.Refresh()
+		}
+	})
+	for _, prefix := range []string{"d", "data1", "distributed s"} {
+		b.Run("complete/"+prefix, func(b *testing.B) {
+			req := &proto.SuggestRequest{Prefix: prefix}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.Suggest(context.Background(), req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+	b.Run("did_you_mean", func(b *testing.B) {
+		plan, err := parser.Parse("distribted databse", analyzer, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, ok := s.DidYouMean(plan); !ok {
+				b.Fatal("no correction")
+			}
+		}
+	})
+}
+
+// BenchmarkHighlight measures the top-10 results of a query with and without
+// highlighted fragments of a few hundred words of body text.
+func BenchmarkHighlight(b *testing.B) {
+	engine := newEngine(b, nil)
+	body := strings.Repeat("the platform indexes documents in shards and merges segments in the background. ", 20) +
+		"distributed search ranks the results of every shard. " +
+		strings.Repeat("queries are cached and analytics events are streamed to kafka. ", 20)
+	indexCorpus(b, engine, 2000, 0, func(int) index.Document {
+		return document("distributed search", body)
+	})
+	exec := executor.New(engine, ranker.Config{}, executor.ExpansionConfig{})
+	plan, err := parser.Parse("distributed search shard*", analyzer, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, hl := range []*highlight.Config{nil, {}} {
+		name := "off"
+		if hl != nil {
+			name = "on"
+		}
+		b.Run(name, func(b *testing.B) {
+			opts := executor.Options{Limit: 10, Highlight: hl}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				result, err := exec.Execute(context.Background(), plan, opts)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if hl != nil && len(result.Results[0].Highlight["body"]) == 0 {
+					b.Fatal("no fragments")
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkPagination measures a page of 10 results deep into a ranking of
+// 4000 documents, reached with an offset and with a search_after cursor.
+func BenchmarkPagination(b *testing.B) {
+	engine := newEngine(b, nil)
+	indexCorpus(b, engine, 4000, 0, func(d int) index.Document {
+		return document("distributed search", "distributed search "+strings.Repeat("platform ", d%7))
+	})
+	exec := executor.New(engine, ranker.Config{}, executor.ExpansionConfig{})
+	plan, err := parser.Parse("distributed search", analyzer, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	previous, err := exec.Execute(context.Background(), plan, executor.Options{Limit: 1000})
+	if err != nil {
+		b.Fatal(err)
+	}
+	cursor := ranker.CursorOf(previous.Results[len(previous.Results)-1])
+
+	pages := []struct {
+		name string
+		opts executor.Options
+	}{
+		{"first", executor.Options{Limit: 10}},
+		{"offset_1000", executor.Options{Limit: 10, Offset: 1000}},
+		{"search_after_1000", executor.Options{Limit: 10, SearchAfter: &cursor}},
+	}
+	for _, p := range pages {
+		b.Run(p.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := exec.Execute(context.Background(), plan, p.opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkMetadataFilter measures keyword and date range filters and sorts
+// on doc values over 4000 documents, half of them flushed to a segment and
+// half in the memory index.
+func BenchmarkMetadataFilter(b *testing.B) {
+	engine := newEngine(b, metadataKeys)
+	indexCorpus(b, engine, 4000, 2000, metadataDoc)
+	exec := executor.New(engine, ranker.Config{}, executor.ExpansionConfig{})
+	schema := engine.Schema()
+	byDate, err := ranker.ParseSort("created_at:desc", schema)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	queries := []struct {
+		name  string
+		query string
+		sort  []ranker.SortField
+	}{
+		{"keyword", "search category:news", nil},
+		{"date_range", "search created_at:[2026-03-01 TO 2026-06-30]", nil},
+		{"open_range", "search price:{100 TO *]", nil},
+		{"only_filter", "category:(news OR tech)", nil},
+		{"sort", "search", byDate},
+		{"filter_and_sort", "search category:tech", byDate},
+	}
+	for _, q := range queries {
+		b.Run(q.name, func(b *testing.B) {
+			plan, err := parser.Parse(q.query, analyzer, schema)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := exec.Execute(context.Background(), plan, executor.Options{Limit: 10, Sort: q.sort}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkAggregations measures terms, date histogram and metric
+// aggregations over the doc values of 4000 matching documents, spread over
+// four shards for the sharded case.
+func BenchmarkAggregations(b *testing.B) {
+	single := newEngine(b, metadataKeys)
+	indexCorpus(b, single, 4000, 0, metadataDoc)
+	engines := make(map[int]*indexer.Engine)
+	for s := 0; s < 4; s++ {
+		engines[s] = newEngine(b, metadataKeys)
+	}
+	for d := 0; d < 4000; d++ {
+		indexDoc(b, engines[d%4], fmt.Sprintf("doc%d", d), metadataDoc(d))
+	}
+	aggs, err := aggregation.Parse(`{"by_category":{"terms":{"field":"category"}},`+
+		`"per_month":{"date_histogram":{"field":"created_at","interval":"month"}},`+
+		`"avg_price":{"avg":{"field":"price"}},"prices":{"cardinality":{"field":"price"}}}`, single.Schema())
+	if err != nil {
+		b.Fatal(err)
+	}
+	plan, err := parser.Parse("search", analyzer, single.Schema())
+	if err != nil {
+		b.Fatal(err)
+	}
+	executors := []struct {
+		name string
+		exec handler.SearchExecutor
+	}{
+		{"single", executor.New(single, ranker.Config{}, executor.ExpansionConfig{})},
+		{"sharded", executor.NewSharded(engines, ranker.Config{}, executor.ExpansionConfig{})},
+	}
+	for _, e := range executors {
+		b.Run(e.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := e.exec.Execute(context.Background(), plan, executor.Options{Limit: 10, Aggs: aggs}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkEarlyTermination measures top-10 searches over 20000 documents
+// of varying length and term frequency, document at a time with block-max
+// WAND, against the same searches scoring every match with an exact total.
+func BenchmarkEarlyTermination(b *testing.B) {
+	engine := newEngine(b, nil)
+	indexCorpus(b, engine, 20000, 10000, weightedDoc)
+	exec := executor.New(engine, ranker.Config{}, executor.ExpansionConfig{})
+
+	queries := []struct {
+		name  string
+		query string
+	}{
+		{"or_2", "analytics OR ranking"},
+		{"or_4", "analytics OR ranking OR indexing OR shard"},
+		{"and_or", "platform AND (query OR ranking)"},
+	}
+	for _, q := range queries {
+		plan, err := parser.Parse(q.query, analyzer, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, exact := range []bool{false, true} {
+			name := q.name + "/top_k"
+			if exact {
+				name = q.name + "/exact_total"
+			}
+			b.Run(name, func(b *testing.B) {
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := exec.Execute(context.Background(), plan, executor.Options{Limit: 10, ExactTotal: exact}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkSimilarity compares the similarities on top-10 searches over
+// 20000 documents, selected per request as the search API does, document
+// at a time and scoring every match, which shows how well each one's block
+// bounds skip documents.
+func BenchmarkSimilarity(b *testing.B) {
+	engine := newEngine(b, nil)
+	indexCorpus(b, engine, 20000, 10000, weightedDoc)
+	exec := executor.New(engine, ranker.Config{FieldBoosts: map[string]float64{index.FieldTitle: 2}}, executor.ExpansionConfig{})
+	plan, err := parser.Parse("analytics OR ranking OR shard", analyzer, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	models := []ranker.Model{ranker.ModelBM25, ranker.ModelBM25Plus, ranker.ModelTFIDF, ranker.ModelDFR, ranker.ModelLMDirichlet}
+	for _, model := range models {
+		for _, exact := range []bool{false, true} {
+			name := string(model) + "/top_k"
+			if exact {
+				name = string(model) + "/exact_total"
+			}
+			opts := executor.Options{Limit: 10, ExactTotal: exact, Similarity: model}
+			b.Run(name, func(b *testing.B) {
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := exec.Execute(context.Background(), plan, opts); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkExplain measures the cost of explaining the scores of a top-10
+// page over 4000 documents against the same search without explanations,
+// and of explaining one document's score.
+func BenchmarkExplain(b *testing.B) {
+	engine := newEngine(b, nil)
+	indexCorpus(b, engine, 4000, 0, func(d int) index.Document {
+		n := len(corpusWords)
+		body := corpusWords[d%n] + " " + corpusWords[d/3%n] + " " + strings.Repeat("filler ", d%13)
+		return document(corpusWords[d/7%n], body)
+	})
+	exec := executor.New(engine, ranker.Config{}, executor.ExpansionConfig{})
+	plan, err := parser.Parse(`analytics OR "query ranking" OR title:shard^2`, analyzer, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, explain := range []bool{false, true} {
+		name := "search"
+		if explain {
+			name = "search_explain"
+		}
+		opts := executor.Options{Limit: 10, Explain: explain}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := exec.Execute(context.Background(), plan, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+	b.Run("document", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := exec.Explain(context.Background(), plan, "doc1", executor.Options{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -5,9 +5,13 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/tokenizer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
 )
 
+// analyzer is the default analyzer, which the tokenisation benchmarks
+// measure.
+var analyzer = analysis.Default()
+
 var sampleTexts = map[string]string{
 	"short": "The quick brown fox jumps over the lazy dog",
 	"medium": `Distributed search engines process queries across multiple shards to achieve
@@ -34,7 +38,7 @@ func BenchmarkTokenize(b *testing.B) {
 			b.ReportAllocs()
 			b.SetBytes(int64(len(text)))
 			for i := 0; i < b.N; i++ {
-				tokens := tokenizer.Tokenize(text)
+				tokens := analyzer.Analyze(text)
 				_ = tokens
 			}
 		})
@@ -48,13 +52,13 @@ func BenchmarkTokenizeParallel(b *testing.B) {
 	b.SetBytes(int64(len(text)))
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
-			tokens := tokenizer.Tokenize(text)
+			tokens := analyzer.Analyze(text)
 			_ = tokens
 		}
 	})
 }
 
-// BenchmarkStemming measures the stemming path through Tokenize for a set of
+// BenchmarkStemming measures the stemming path through the analyzer for a set of
 // representative words.
 func BenchmarkStemming(b *testing.B) {
 	words := []string{
@@ -65,7 +69,7 @@ func BenchmarkStemming(b *testing.B) {
 	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		for _, w := range words {
-			tokens := tokenizer.Tokenize(w)
+			tokens := analyzer.Analyze(w)
 			_ = tokens
 		}
 	}
@@ -82,7 +86,7 @@ func BenchmarkTokenizeVaryingSize(b *testing.B) {
 			b.ReportAllocs()
 			b.SetBytes(int64(len(text)))
 			for i := 0; i < b.N; i++ {
-				tokens := tokenizer.Tokenize(text)
+				tokens := analyzer.Analyze(text)
 				_ = tokens
 			}
 		})
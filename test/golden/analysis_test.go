@@ -0,0 +1,83 @@
+package golden
+
+import (
+	"bufio"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files and fixtures")
+
+// goldenAnalyzers are the analyzers whose output is pinned by golden files.
+var goldenAnalyzers = []string{"standard", "english", "german", "french", "spanish"}
+
+// TestAnalyzers runs every line of testdata/analysis/input.txt through each
+// analyzer and compares the terms with testdata/analysis/<analyzer>.golden.
+// Run with -update to rewrite the golden files after an intended change.
+func TestAnalyzers(t *testing.T) {
+	inputs := readLines(t, filepath.Join("testdata", "analysis", "input.txt"))
+	for _, name := range goldenAnalyzers {
+		t.Run(name, func(t *testing.T) {
+			a, err := analysis.Get(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			for _, input := range inputs {
+				terms := make([]string, 0)
+				for _, tok := range a.Analyze(input) {
+					terms = append(terms, tok.Term)
+				}
+				b.WriteString(input + "\n\t" + strings.Join(terms, " | ") + "\n")
+			}
+			got := b.String()
+
+			path := filepath.Join("testdata", "analysis", name+".golden")
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read golden file (run with -update to create it): %v", err)
+			}
+			if got != string(want) {
+				gotLines, wantLines := strings.Split(got, "\n"), strings.Split(string(want), "\n")
+				for i := 0; i < len(gotLines) && i < len(wantLines); i++ {
+					if gotLines[i] != wantLines[i] {
+						t.Fatalf("%s line %d:\n got: %s\nwant: %s", path, i+1, gotLines[i], wantLines[i])
+					}
+				}
+				t.Fatalf("%s: got %d lines, want %d", path, len(gotLines), len(wantLines))
+			}
+		})
+	}
+}
+
+// readLines returns the non-empty lines of the file at path.
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return lines
+}
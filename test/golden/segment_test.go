@@ -0,0 +1,104 @@
+package golden
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/segment"
+)
+
+// v1Docs and v1Entries are the content of testdata/segment/v1.spdx.
+var (
+	v1Docs = []index.DocLength{
+		{DocID: "doc1", Fields: map[string]int{index.FieldBody: 4}},
+		{DocID: "doc2", Fields: map[string]int{index.FieldBody: 3}},
+		{DocID: "doc3", Fields: map[string]int{index.FieldBody: 5}},
+	}
+	v1Entries = []index.TermEntry{
+		{Term: index.FieldTerm(index.FieldBody, "analyt"), Postings: index.PostingList{
+			{DocID: "doc1", Frequency: 1, Positions: []int{1}},
+			{DocID: "doc3", Frequency: 2, Positions: []int{0, 4}},
+		}},
+		{Term: index.FieldTerm(index.FieldBody, "distribut"), Postings: index.PostingList{
+			{DocID: "doc1", Frequency: 1, Positions: []int{0}},
+			{DocID: "doc2", Frequency: 1, Positions: []int{2}},
+		}},
+		{Term: index.FieldTerm(index.FieldBody, "platform"), Postings: index.PostingList{
+			{DocID: "doc1", Frequency: 2, Positions: []int{2, 3}},
+		}},
+		{Term: index.FieldTerm(index.FieldBody, "search"), Postings: index.PostingList{
+			{DocID: "doc2", Frequency: 2, Positions: []int{0, 1}},
+			{DocID: "doc3", Frequency: 3, Positions: []int{1, 2, 3}},
+		}},
+	}
+)
+
+// TestVersion1Segment checks that a version 1 segment, written when
+// postings and the dictionary were JSON, still opens and reads back its
+// terms, postings and documents. Run with -update to rewrite the fixture
+// from v1Entries.
+func TestVersion1Segment(t *testing.T) {
+	path := filepath.Join("testdata", "segment", "v1.spdx")
+	if *update {
+		dir := t.TempDir()
+		w, err := segment.NewVersionedWriter(dir, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name, err := w.Write(v1Entries, v1Docs, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Open a copy, so that deletions would not touch the fixture.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read fixture (run with -update to create it): %v", err)
+	}
+	copyPath := filepath.Join(t.TempDir(), "seg_1.spdx")
+	if err := os.WriteFile(copyPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := segment.OpenVerifiedReader(copyPath, segment.VerifyFull)
+	if err != nil {
+		t.Fatalf("opening version 1 segment: %v", err)
+	}
+	defer r.Close()
+
+	if v := r.Header().Version; v != 1 {
+		t.Fatalf("fixture is version %d, want 1", v)
+	}
+	if got := r.Docs(); !reflect.DeepEqual(got, v1Docs) {
+		t.Errorf("documents %v, want %v", got, v1Docs)
+	}
+	entries, err := r.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entries, v1Entries) {
+		t.Errorf("entries\n%v\nwant\n%v", entries, v1Entries)
+	}
+	for _, want := range v1Entries {
+		postings, err := r.Search(want.Term)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(postings, want.Postings) {
+			t.Errorf("%s: postings %v, want %v", want.Term, postings, want.Postings)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Package golden contains golden-file tests that compare the output of the
+// text-analysis code with reference data kept under testdata.
+//
+// The Snowball vocabularies in testdata/snowball hold one "word<TAB>stem"
+// pair per line, produced by the reference Snowball implementation of each
+// language. The files in testdata/analysis hold the terms each built-in
+// analyzer produces for the lines of input.txt; regenerate them with
+// -update after an intended change to an analyzer.
+//
+// Run with:
+//
+//	go test -v ./test/golden/...
+package golden
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis/snowball"
+)
+
+// TestSnowballVocabularies stems every word of each language's reference
+// vocabulary and compares it with the reference stem.
+func TestSnowballVocabularies(t *testing.T) {
+	for _, language := range snowball.Languages() {
+		t.Run(language, func(t *testing.T) {
+			stem, _ := snowball.Get(language)
+			f, err := os.Open(filepath.Join("testdata", "snowball", language+".txt"))
+			if err != nil {
+				t.Fatalf("open vocabulary: %v", err)
+			}
+			defer f.Close()
+
+			scanner := bufio.NewScanner(f)
+			words, failures := 0, 0
+			for line := 1; scanner.Scan(); line++ {
+				word, want, ok := strings.Cut(scanner.Text(), "\t")
+				if !ok {
+					t.Fatalf("line %d: missing tab", line)
+				}
+				words++
+				if got := stem(word); got != want {
+					failures++
+					if failures <= 20 {
+						t.Errorf("line %d: stem(%q) = %q, want %q", line, word, got, want)
+					}
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				t.Fatalf("read vocabulary: %v", err)
+			}
+			if words == 0 {
+				t.Fatal("empty vocabulary")
+			}
+			if failures > 0 {
+				t.Errorf("%d of %d words stemmed incorrectly", failures, words)
+			}
+		})
+	}
+}
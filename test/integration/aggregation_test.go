@@ -0,0 +1,279 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/aggregation"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/executor"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/handler"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/highlight"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/config"
+)
+
+// aggregatedSchema is the metadata schema of the aggregation corpus.
+var aggregatedSchema = map[string]string{"category": "keyword", "price": "float", "published": "date"}
+
+// aggregatedDoc returns doc d of the aggregation corpus. Categories are
+// unevenly common, and some documents lack a category, a price or a date.
+func aggregatedDoc(d int) index.Document {
+	doc := index.Document{
+		Fields: map[string]string{
+			index.FieldTitle: []string{"even", "odd"}[d%2] + " report",
+			index.FieldBody:  "search aggregation",
+		},
+		Metadata: map[string]any{},
+	}
+	if d%10 != 9 {
+		doc.Metadata["category"] = []string{"news", "news", "news", "sports", "sports", "tech"}[d%6]
+	}
+	if d%8 != 0 {
+		doc.Metadata["price"] = float64(d%7) * 1.5
+	}
+	if d%11 != 0 {
+		doc.Metadata["published"] = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, d*5).Format(time.DateOnly)
+	}
+	return doc
+}
+
+// aggregatedExecutors returns executors over the same 60 documents, of
+// which doc5 and doc25 are deleted: one engine holding them in two
+// segments and its memory index, and three shards.
+func aggregatedExecutors(t *testing.T) (map[string]handler.SearchExecutor, index.Schema) {
+	t.Helper()
+	open := func() *indexer.Engine {
+		engine, err := indexer.NewEngine(config.IndexerConfig{
+			DataDir:        t.TempDir(),
+			SegmentMaxSize: 100 * 1024 * 1024,
+			Metadata:       aggregatedSchema,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { engine.Close() })
+		return engine
+	}
+	single := open()
+	shards := map[int]*indexer.Engine{0: open(), 1: open(), 2: open()}
+	for d := 0; d < 60; d++ {
+		docID := fmt.Sprintf("doc%d", d)
+		for _, e := range []*indexer.Engine{single, shards[d%3]} {
+			if err := e.IndexDocument(docID, aggregatedDoc(d)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if d == 19 || d == 39 {
+			if err := single.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for _, d := range []int{5, 25} {
+		docID := fmt.Sprintf("doc%d", d)
+		deleteDoc(t, single, docID)
+		deleteDoc(t, shards[d%3], docID)
+	}
+	return map[string]handler.SearchExecutor{
+		"single":  executor.New(single, ranker.Config{}, executor.ExpansionConfig{}),
+		"sharded": executor.NewSharded(shards, ranker.Config{}, executor.ExpansionConfig{}),
+	}, single.Schema()
+}
+
+// aggregatedRequests are the aggregations the tests request.
+const aggregatedRequests = `{
+	"top_categories": {"terms": {"field": "category", "size": 2}},
+	"categories": {"terms": {"field": "category"}},
+	"price_ranges": {"range": {"field": "price", "ranges": [{"to": 3}, {"from": 3, "to": 6}, {"from": 6}]}},
+	"per_month": {"date_histogram": {"field": "published", "interval": "month"}},
+	"min_price": {"min": {"field": "price"}},
+	"max_price": {"max": {"field": "price"}},
+	"avg_price": {"avg": {"field": "price"}},
+	"sum_price": {"sum": {"field": "price"}},
+	"prices": {"cardinality": {"field": "price"}},
+	"first_published": {"min": {"field": "published"}}
+}`
+
+// TestAggregationsMatchAcrossShards checks that aggregations over one
+// engine and over the same documents split across shards are identical,
+// and equal to those computed directly from the live matching documents.
+func TestAggregationsMatchAcrossShards(t *testing.T) {
+	executors, schema := aggregatedExecutors(t)
+	aggs, err := aggregation.Parse(aggregatedRequests, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queries := map[string]func(d int) bool{
+		"search": func(int) bool { return true },
+		"odd":    func(d int) bool { return d%2 == 1 },
+	}
+	for query, matches := range queries {
+		plan, err := parser.Parse(query, analysis.Default(), schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results := map[string]map[string]aggregation.Result{}
+		for name, exec := range executors {
+			res, err := exec.Execute(context.Background(), plan, executor.Options{Limit: 5, Aggs: aggs})
+			if err != nil {
+				t.Fatal(err)
+			}
+			results[name] = res.Aggregations
+		}
+		if !reflect.DeepEqual(results["single"], results["sharded"]) {
+			t.Errorf("%s: single engine aggregations\n%v\nsharded\n%v", query, results["single"], results["sharded"])
+		}
+		checkAggregations(t, query, results["sharded"], matches)
+	}
+}
+
+// checkAggregations compares the results of aggregatedRequests with those
+// computed from the live documents of the corpus that match.
+func checkAggregations(t *testing.T, query string, got map[string]aggregation.Result, matches func(d int) bool) {
+	t.Helper()
+	categories := map[string]int64{}
+	months := map[string]int64{}
+	prices := map[float64]bool{}
+	var ranges [3]int64
+	var docs, priced int64
+	var sum float64
+	minPrice, maxPrice := math.Inf(1), math.Inf(-1)
+	first := ""
+	for d := 0; d < 60; d++ {
+		if d == 5 || d == 25 || !matches(d) {
+			continue
+		}
+		docs++
+		meta := aggregatedDoc(d).Metadata
+		if c, ok := meta["category"].(string); ok {
+			categories[c]++
+		}
+		if p, ok := meta["price"].(float64); ok {
+			priced++
+			sum += p
+			minPrice, maxPrice = math.Min(minPrice, p), math.Max(maxPrice, p)
+			prices[p] = true
+			ranges[min(int(p/3), 2)]++
+		}
+		if date, ok := meta["published"].(string); ok {
+			months[date[:7]]++
+			if first == "" || date < first {
+				first = date
+			}
+		}
+	}
+
+	type bucket struct {
+		key   string
+		count int64
+	}
+	var wantCategories []bucket
+	for c, n := range categories {
+		wantCategories = append(wantCategories, bucket{c, n})
+	}
+	sort.Slice(wantCategories, func(i, j int) bool {
+		a, b := wantCategories[i], wantCategories[j]
+		return a.count > b.count || a.count == b.count && a.key < b.key
+	})
+	bucketsOf := func(name string) []bucket {
+		var buckets []bucket
+		for _, b := range got[name].Buckets {
+			key := fmt.Sprint(b.Key)
+			if b.KeyAsString != "" {
+				key = b.KeyAsString[:7]
+			}
+			buckets = append(buckets, bucket{key, b.DocCount})
+		}
+		return buckets
+	}
+	if buckets := bucketsOf("categories"); !reflect.DeepEqual(buckets, wantCategories) || got["categories"].SumOtherDocCount != 0 {
+		t.Errorf("%s: categories %v, other %d, want %v", query, buckets, got["categories"].SumOtherDocCount, wantCategories)
+	}
+	other := int64(0)
+	for _, b := range wantCategories[2:] {
+		other += b.count
+	}
+	if buckets := bucketsOf("top_categories"); !reflect.DeepEqual(buckets, wantCategories[:2]) || got["top_categories"].SumOtherDocCount != other {
+		t.Errorf("%s: top categories %v, other %d, want %v, other %d", query, buckets, got["top_categories"].SumOtherDocCount, wantCategories[:2], other)
+	}
+	wantRanges := []bucket{{"*-3", ranges[0]}, {"3-6", ranges[1]}, {"6-*", ranges[2]}}
+	if buckets := bucketsOf("price_ranges"); !reflect.DeepEqual(buckets, wantRanges) {
+		t.Errorf("%s: price ranges %v, want %v", query, buckets, wantRanges)
+	}
+	var wantMonths []bucket
+	for m, n := range months {
+		wantMonths = append(wantMonths, bucket{m, n})
+	}
+	sort.Slice(wantMonths, func(i, j int) bool { return wantMonths[i].key < wantMonths[j].key })
+	if buckets := bucketsOf("per_month"); !reflect.DeepEqual(buckets, wantMonths) {
+		t.Errorf("%s: months %v, want %v", query, buckets, wantMonths)
+	}
+
+	metrics := map[string]float64{
+		"min_price": minPrice,
+		"max_price": maxPrice,
+		"avg_price": sum / float64(priced),
+		"sum_price": sum,
+		"prices":    float64(len(prices)),
+	}
+	for name, want := range metrics {
+		if v := got[name].Value; v == nil || *v != want {
+			t.Errorf("%s: %s = %v, want %v", query, name, v, want)
+		}
+	}
+	if s := got["first_published"].ValueAsString; len(s) < 10 || s[:10] != first {
+		t.Errorf("%s: first published %q, want %s", query, s, first)
+	}
+}
+
+// TestEmptyAggregations checks that the aggregations of a search matching
+// no documents, and of a query with no terms left after analysis, render
+// empty buckets and null or zero metrics on one engine and across shards.
+func TestEmptyAggregations(t *testing.T) {
+	executors, schema := aggregatedExecutors(t)
+	want := map[string]any{
+		"top_categories":  map[string]any{"buckets": []any{}},
+		"categories":      map[string]any{"buckets": []any{}},
+		"price_ranges":    map[string]any{"buckets": []any{map[string]any{"key": "*-3", "to": 3.0, "doc_count": 0.0}, map[string]any{"key": "3-6", "from": 3.0, "to": 6.0, "doc_count": 0.0}, map[string]any{"key": "6-*", "from": 6.0, "doc_count": 0.0}}},
+		"per_month":       map[string]any{"buckets": []any{}},
+		"min_price":       map[string]any{"value": nil},
+		"max_price":       map[string]any{"value": nil},
+		"avg_price":       map[string]any{"value": nil},
+		"sum_price":       map[string]any{"value": 0.0},
+		"prices":          map[string]any{"value": 0.0},
+		"first_published": map[string]any{"value": nil},
+	}
+	for name, exec := range executors {
+		h := handler.New(exec, analysis.Default(), schema, nil, nil, nil, nil, highlight.Config{}, 10, 100, 100)
+		for _, query := range []string{"unmatched", "the"} {
+			rec := httptest.NewRecorder()
+			params := url.Values{"q": {query}, "aggs": {aggregatedRequests}}
+			h.Search(rec, httptest.NewRequest(http.MethodGet, "/api/v1/search?"+params.Encode(), nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%s %q: status %d: %s", name, query, rec.Code, rec.Body)
+			}
+			var body struct {
+				Aggregations map[string]any `json:"aggregations"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(body.Aggregations, want) {
+				t.Errorf("%s %q: aggregations\n%v\nwant\n%v", name, query, body.Aggregations, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis/norm"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis/uax29"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/executor"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/config"
+)
+
+// TestWordBoundaries checks the segments text is split into at UAX #29
+// word boundaries. The ASCII cases are also split with a non-ASCII word
+// appended, which takes the general path, and must give the same segments
+// before it.
+func TestWordBoundaries(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"can't stop", []string{"can't", " ", "stop"}},
+		{"'quoted'", []string{"'", "quoted", "'"}},
+		{"rock 'n' roll", []string{"rock", " ", "'", "n", "'", " ", "roll"}},
+		{"e.g. end.", []string{"e.g", ".", " ", "end", "."}},
+		{"3.14 1,000,000 2:30", []string{"3.14", " ", "1,000,000", " ", "2", ":", "30"}},
+		{"1.5x v2 3,", []string{"1.5x", " ", "v2", " ", "3", ","}},
+		{"foo_bar __init__", []string{"foo_bar", " ", "__init__"}},
+		{"a  b\r\nc\n\nd", []string{"a", "  ", "b", "\r\n", "c", "\n", "\n", "d"}},
+		{"hi!?", []string{"hi", "!", "?"}},
+		{"naïve l’été", []string{"naïve", " ", "l’été"}},
+		{"👨‍👩‍👧 family", []string{"👨‍👩‍👧", " ", "family"}},
+		{"a👍🏽b", []string{"a", "👍🏽", "b"}},
+		{"🇫🇷🇩🇪🇮", []string{"🇫🇷", "🇩🇪", "🇮"}},
+		{"été", []string{"été"}},
+		{"東京タワー", []string{"東", "京", "タワー"}},
+	}
+	for _, tc := range tests {
+		if got := uax29.Segments(tc.text); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Segments(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+		if len([]rune(tc.text)) != len(tc.text) {
+			continue
+		}
+		got := uax29.Segments(tc.text + " é")
+		if want := append(append([]string(nil), tc.want...), " ", "é"); !reflect.DeepEqual(got, want) {
+			t.Errorf("Segments(%q) = %q, want %q", tc.text+" é", got, want)
+		}
+	}
+	if got := uax29.Segments(""); got != nil {
+		t.Errorf("Segments(\"\") = %q, want nil", got)
+	}
+}
+
+// TestNFKC checks that compatibility characters are folded to their plain
+// forms and canonical sequences composed, and that ASCII is left alone.
+func TestNFKC(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"plain ascii, 100%", "plain ascii, 100%"},
+		{"ﬁle ﬂow", "file flow"},
+		{"Ｈｅｌｌｏ　１２３", "Hello 123"},
+		{"x² H₂O", "x2 H2O"},
+		{"e\u0301", "\u00e9"},
+		{"\u212b", "\u00c5"},
+		{"a\u0302\u0323", "\u1ead"},
+		{"\u1100\u1161\u11a8", "\uac01"},
+		{"ｶﾀｶﾅ", "カタカナ"},
+		{"①", "1"},
+	}
+	for _, tc := range tests {
+		if got := norm.NFKC(tc.text); got != tc.want {
+			t.Errorf("NFKC(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+// TestCJKBigramPositions checks that Chinese and Japanese runs are indexed
+// as overlapping bigrams at consecutive positions, and that the words
+// around them keep theirs.
+func TestCJKBigramPositions(t *testing.T) {
+	type token struct {
+		term string
+		pos  int
+	}
+	tests := []struct {
+		text string
+		want []token
+	}{
+		{"東京タワー is tall", []token{{"東京", 0}, {"京タ", 1}, {"タワ", 2}, {"ワー", 3}, {"is", 4}, {"tall", 5}}},
+		{"visit 日本 and 北京大学", []token{{"visit", 0}, {"日本", 1}, {"and", 2}, {"北京", 3}, {"京大", 4}, {"大学", 5}}},
+		{"東 and ｶﾀｶﾅ", []token{{"東", 0}, {"and", 1}, {"カタ", 2}, {"タカ", 3}, {"カナ", 4}}},
+		{"これはペンです", []token{{"これ", 0}, {"れは", 1}, {"はペ", 2}, {"ペン", 3}, {"ンで", 4}, {"です", 5}}},
+	}
+	standard := namedAnalyzer(t, "standard")
+	for _, tc := range tests {
+		var got []token
+		for _, tok := range standard.Analyze(tc.text) {
+			got = append(got, token{tok.Term, tok.Position})
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Analyze(%q) = %v, want %v", tc.text, got, tc.want)
+		}
+	}
+}
+
+// configuredAnalyzerYAML defines the analyzer of TestConfiguredAnalyzer,
+// with the data directory left to fill in.
+const configuredAnalyzerYAML = `
+indexer:
+  dataDir: %s
+  analyzer: product
+  analyzers:
+    product:
+      charFilters: [nfkc, mapping]
+      mappings: {"&": " and ", "C++": cplusplus}
+      tokenizer: whitespace
+      filters: [lowercase, stop, min_length, english_stem, accent_folding]
+      stopWords: [the, of, and]
+      minLength: 3
+`
+
+// loadIndexerConfig loads a configuration file holding yaml and returns
+// its indexer configuration.
+func loadIndexerConfig(t *testing.T, yaml string) config.IndexerConfig {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg.Indexer
+}
+
+// TestConfiguredAnalyzer checks that an analyzer defined in the
+// configuration applies its char filters, tokenizer and token filters in
+// order, that the engine indexes documents with it and the searcher, which
+// builds it from the same configuration, analyses queries the same way, and
+// that invalid definitions are rejected.
+func TestConfiguredAnalyzer(t *testing.T) {
+	cfg := loadIndexerConfig(t, fmt.Sprintf(configuredAnalyzerYAML, t.TempDir()))
+	searcher, err := analysis.Build(cfg.Analyzer, cfg.Analyzers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := indexer.NewEngine(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+
+	// NFKC unfolds the ligature and the mappings apply before the text is
+	// split at white space, which keeps the hyphen and the comma; the stop
+	// words and the terms shorter than 3 characters are dropped, and the
+	// rest stemmed and folded.
+	const text = "The ﬁle-Names of Café & Bar runners, written in C++ by Us"
+	want := []string{"file-nam", "cafe", "bar", "runners,", "written", "cplusplus"}
+	for name, a := range map[string]analysis.Analyzer{"searcher": searcher, "engine": engine.Analyzer()} {
+		var got []string
+		for i, tok := range a.Analyze(text) {
+			if tok.Position != i {
+				t.Errorf("%s: %q at position %d, want %d", name, tok.Term, tok.Position, i)
+			}
+			got = append(got, tok.Term)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Analyze(%q) = %q, want %q", name, text, got, want)
+		}
+	}
+
+	bodies := []string{
+		"The ﬁle-Names of Café & Bar runners",
+		"Written in C++ & Rust",
+		"file names, cafe",
+	}
+	for d, body := range bodies {
+		doc := index.Document{Fields: map[string]string{index.FieldBody: body}}
+		if err := engine.IndexDocument(fmt.Sprintf("doc%d", d), doc); err != nil {
+			t.Fatal(err)
+		}
+		if d == 0 {
+			if err := engine.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	exec := executor.New(engine, ranker.Config{}, executor.ExpansionConfig{})
+	queries := []struct {
+		query string
+		want  []string
+	}{
+		{"CAFÉ", []string{"doc0", "doc2"}},
+		{"Files", []string{"doc2"}},
+		{"names", nil},
+		{"file-names", []string{"doc0"}},
+		{`"C++"`, []string{"doc1"}},
+		{"cplusplus", []string{"doc1"}},
+		{"bar", []string{"doc0"}},
+		{"runners", []string{"doc0"}},
+		{"the", nil},
+	}
+	for _, tc := range queries {
+		plan, err := parser.Parse(tc.query, searcher, nil)
+		if err != nil {
+			t.Fatalf("parsing %q: %v", tc.query, err)
+		}
+		res, err := exec.Execute(context.Background(), plan, executor.Options{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := docIDs(res.Results)
+		sort.Strings(got)
+		if len(got) != len(tc.want) || len(got) > 0 && !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q matches %v, want %v", tc.query, got, tc.want)
+		}
+	}
+
+	invalid := map[string]config.AnalyzerConfig{
+		"no tokenizer":         {Filters: []string{"lowercase"}},
+		"unknown tokenizer":    {Tokenizer: "letter"},
+		"unknown char filter":  {CharFilters: []string{"html_strip"}, Tokenizer: "standard"},
+		"unknown filter":       {Tokenizer: "standard", Filters: []string{"lowercase", "klingon_stem"}},
+		"stop without words":   {Tokenizer: "standard", Filters: []string{"stop"}},
+		"mapping without keys": {CharFilters: []string{"mapping"}, Tokenizer: "standard"},
+	}
+	for name, def := range invalid {
+		if _, err := analysis.Build("custom", map[string]config.AnalyzerConfig{"custom": def}); err == nil {
+			t.Errorf("%s: Build succeeds", name)
+		}
+		bad := cfg
+		bad.DataDir = t.TempDir()
+		bad.Analyzers = map[string]config.AnalyzerConfig{"product": def}
+		if e, err := indexer.NewEngine(bad); err == nil {
+			e.Close()
+			t.Errorf("%s: NewEngine succeeds", name)
+		}
+	}
+	redefined := map[string]config.AnalyzerConfig{"english": {Tokenizer: "whitespace"}}
+	if _, err := analysis.Build("english", redefined); err == nil {
+		t.Error("redefining a built-in analyzer succeeds")
+	}
+}
@@ -0,0 +1,133 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/executor"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+)
+
+// fieldScores indexes docs, of title and body, with four unrelated
+// documents of the same field lengths, half of them flushed, and returns
+// the scores of the documents matching query under cfg and model.
+func fieldScores(t *testing.T, docs map[string][2]string, cfg ranker.Config, model ranker.Model, query string) map[string]float64 {
+	t.Helper()
+	engine := openEngine(t, t.TempDir())
+	t.Cleanup(func() { engine.Close() })
+	for d := 0; d < 4; d++ {
+		doc := index.Document{Fields: map[string]string{index.FieldTitle: "red green blue", index.FieldBody: "cyan magenta yellow"}}
+		if err := engine.IndexDocument(fmt.Sprintf("filler%d", d), doc); err != nil {
+			t.Fatal(err)
+		}
+		if d == 1 {
+			if err := engine.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for docID, fields := range docs {
+		doc := index.Document{Fields: map[string]string{index.FieldTitle: fields[0], index.FieldBody: fields[1]}}
+		if err := engine.IndexDocument(docID, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	plan, err := parser.Parse(query, analysis.Default(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := executor.New(engine, cfg, executor.ExpansionConfig{}).Execute(context.Background(), plan, executor.Options{Limit: 10, Similarity: model})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scores := map[string]float64{}
+	for _, r := range res.Results {
+		scores[r.DocID] = r.Score
+	}
+	if len(scores) != len(docs) {
+		t.Fatalf("%q matches %v, want %d documents", query, scores, len(docs))
+	}
+	return scores
+}
+
+// TestFieldBoostsRankWeightedFields checks, for every similarity and both
+// modes that weight fields, that a match in a field ranks above the same
+// match in a field of the same length weighted lower, and ties with it
+// when the weights are equal.
+func TestFieldBoostsRankWeightedFields(t *testing.T) {
+	docs := map[string][2]string{
+		"titled": {"quantum orange purple", "black white grey"},
+		"bodied": {"black white grey", "quantum orange purple"},
+	}
+	models := []ranker.Model{ranker.ModelBM25, ranker.ModelBM25Plus, ranker.ModelTFIDF, ranker.ModelDFR, ranker.ModelLMDirichlet}
+	for _, mode := range []ranker.Mode{ranker.ModeBM25F, ranker.ModeFields} {
+		for _, model := range models {
+			boosts := []struct {
+				title, body float64
+				first       string
+			}{
+				{1, 1, ""},
+				{3, 1, "titled"},
+				{1, 3, "bodied"},
+				{0.5, 1, "bodied"},
+			}
+			for _, b := range boosts {
+				cfg := ranker.Config{Mode: mode, FieldBoosts: map[string]float64{index.FieldTitle: b.title, index.FieldBody: b.body}}
+				scores := fieldScores(t, docs, cfg, model, "quantum")
+				where := fmt.Sprintf("%s/%s, title^%v body^%v", mode, model, b.title, b.body)
+				switch b.first {
+				case "":
+					if scores["titled"] != scores["bodied"] {
+						t.Errorf("%s: scores %v differ", where, scores)
+					}
+				case "titled":
+					if scores["titled"] <= scores["bodied"] {
+						t.Errorf("%s: scores %v, want titled first", where, scores)
+					}
+				case "bodied":
+					if scores["bodied"] <= scores["titled"] {
+						t.Errorf("%s: scores %v, want bodied first", where, scores)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestFieldLengthsNormaliseFields checks that BM25F normalises a match by
+// the length of its field, so that of two documents of the same length a
+// match in a short body outscores one in a long body, that it does not
+// where a field's normalisation is turned off, and that scoring the fields
+// as one stream, as ModeBM25 does, ties them.
+func TestFieldLengthsNormaliseFields(t *testing.T) {
+	docs := map[string][2]string{
+		"short": {"black white grey brown pink olive teal navy", "quantum maroon"},
+		"long":  {"black white", "quantum maroon lime coral ivory amber azure beige"},
+	}
+	noBodyNorm := ranker.SimilarityParams{FieldB: map[string]float64{index.FieldBody: 0}}
+	tests := []struct {
+		name     string
+		cfg      ranker.Config
+		shortWin bool
+	}{
+		{"bm25f", ranker.Config{Mode: ranker.ModeBM25F}, true},
+		{"fields", ranker.Config{Mode: ranker.ModeFields}, true},
+		{"bm25f without body normalisation", ranker.Config{Mode: ranker.ModeBM25F, Params: noBodyNorm}, false},
+		{"one stream", ranker.Config{Mode: ranker.ModeBM25}, false},
+	}
+	for _, tc := range tests {
+		for _, model := range []ranker.Model{ranker.ModelBM25, ranker.ModelBM25Plus} {
+			scores := fieldScores(t, docs, tc.cfg, model, "quantum")
+			if tc.shortWin && scores["short"] <= scores["long"] {
+				t.Errorf("%s/%s: scores %v, want the short body first", tc.name, model, scores)
+			}
+			if !tc.shortWin && scores["short"] != scores["long"] {
+				t.Errorf("%s/%s: scores %v, want a tie", tc.name, model, scores)
+			}
+		}
+	}
+}
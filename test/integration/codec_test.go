@@ -0,0 +1,191 @@
+package integration
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/segment"
+)
+
+// writeSegment writes entries of the body field over docs as a segment of
+// the given format version and opens it.
+func writeSegment(t *testing.T, version uint32, entries []index.TermEntry, docs []index.DocLength) *segment.Reader {
+	t.Helper()
+	dir := t.TempDir()
+	w, err := segment.NewVersionedWriter(dir, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := w.Write(entries, docs, nil)
+	if err != nil {
+		t.Fatalf("writing version %d segment: %v", version, err)
+	}
+	r, err := segment.OpenReader(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("opening version %d segment: %v", version, err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+// bodyEntry returns the entry of term in the body field.
+func bodyEntry(term string, postings index.PostingList) index.TermEntry {
+	return index.TermEntry{Term: index.FieldTerm(index.FieldBody, term), Postings: postings}
+}
+
+// positionsOf returns the positions whose gaps, the first from 0, are gaps.
+func positionsOf(gaps []int) []int {
+	positions := make([]int, len(gaps))
+	last := 0
+	for i, g := range gaps {
+		last += g
+		positions[i] = last
+	}
+	return positions
+}
+
+// assertEntries checks that r holds exactly entries, in order, and that
+// searching for each term returns its postings.
+func assertEntries(t *testing.T, r *segment.Reader, entries []index.TermEntry) {
+	t.Helper()
+	version := r.Header().Version
+	got, err := r.Entries()
+	if err != nil {
+		t.Fatalf("version %d: reading entries: %v", version, err)
+	}
+	if len(got) != len(entries) || r.Terms() != len(entries) {
+		t.Fatalf("version %d: %d entries, %d terms, want %d", version, len(got), r.Terms(), len(entries))
+	}
+	for i, want := range entries {
+		if got[i].Term != want.Term || !reflect.DeepEqual(got[i].Postings, want.Postings) {
+			t.Errorf("version %d: entry %d is %q with %d postings, want %q with %d", version, i, got[i].Term, len(got[i].Postings), want.Term, len(want.Postings))
+			continue
+		}
+		postings, err := r.Search(want.Term)
+		if err != nil {
+			t.Fatalf("version %d: searching %q: %v", version, want.Term, err)
+		}
+		if !reflect.DeepEqual(postings, want.Postings) {
+			t.Errorf("version %d: %q postings differ from those written", version, want.Term)
+		}
+	}
+}
+
+// TestPostingStreamsRoundTrip writes posting lists whose document, frequency
+// and position streams need every bit width from 0 to 32, blocks with a few
+// or many outliers stored as exceptions, and final partial blocks of every
+// length class, and reads them back from binary segments.
+func TestPostingStreamsRoundTrip(t *testing.T) {
+	docs := make([]index.DocLength, 600)
+	for i := range docs {
+		docs[i] = index.DocLength{DocID: fmt.Sprintf("d%03d", i), Fields: map[string]int{index.FieldBody: 1000}}
+	}
+	var entries []index.TermEntry
+
+	// Position gaps of 2^w-1, in one full block and a partial one, need a
+	// width of w; zero gaps need none.
+	for w := 0; w <= 32; w++ {
+		gaps := make([]int, 130)
+		for i := range gaps {
+			gaps[i] = 1<<w - 1
+		}
+		entries = append(entries, bodyEntry(fmt.Sprintf("width%02d", w), index.PostingList{
+			{DocID: "d000", Frequency: len(gaps), Positions: positionsOf(gaps)},
+		}))
+	}
+
+	// Small gaps with outliers every k values, and a block of outliers
+	// only, in three full blocks and a partial one.
+	for _, k := range []int{1, 2, 17, 64, 127, 1000} {
+		gaps := make([]int, 3*128+5)
+		for i := range gaps {
+			gaps[i] = 1 + i%3
+			if i%k == k-1 {
+				gaps[i] = 1<<(16+i%16) + i
+			}
+		}
+		entries = append(entries, bodyEntry(fmt.Sprintf("outliers%04d", k), index.PostingList{
+			{DocID: "d001", Frequency: len(gaps), Positions: positionsOf(gaps)},
+		}))
+	}
+
+	// Terms in n documents, with document gaps of 1 to 3, frequencies of 1
+	// to 4 and outlying frequencies and ordinals.
+	for _, n := range []int{1, 2, 127, 128, 129, 255, 256, 257, 299} {
+		postings := make(index.PostingList, n)
+		for i := range postings {
+			ord := 2*i + i%2
+			if i == n-1 {
+				ord = 599
+			}
+			freq := 1 + i*5%4
+			if i%50 == 49 {
+				freq = 300
+			}
+			gaps := make([]int, freq)
+			for j := range gaps {
+				gaps[j] = 1 + (i+j)%3
+			}
+			postings[i] = index.Posting{DocID: docs[ord].DocID, Frequency: freq, Positions: positionsOf(gaps)}
+		}
+		entries = append(entries, bodyEntry(fmt.Sprintf("count%03d", n), postings))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Term < entries[j].Term })
+
+	for _, version := range []uint32{2, segment.FormatVersion} {
+		assertEntries(t, writeSegment(t, version, entries, docs), entries)
+	}
+}
+
+// TestDictionaryRoundTrip writes dictionaries of one term and of terms
+// sharing prefixes of every length, including prefixes ending inside a
+// multi-byte character and prefixes that are whole terms, and reads them
+// back from segments of every dictionary format.
+func TestDictionaryRoundTrip(t *testing.T) {
+	docs := []index.DocLength{
+		{DocID: "a", Fields: map[string]int{index.FieldBody: 4}},
+		{DocID: "b", Fields: map[string]int{index.FieldBody: 4}},
+	}
+	postings := func(i int) index.PostingList {
+		if i%2 == 0 {
+			return index.PostingList{{DocID: "a", Frequency: 1, Positions: []int{i % 4}}}
+		}
+		return index.PostingList{
+			{DocID: "a", Frequency: 1, Positions: []int{0}},
+			{DocID: "b", Frequency: 2, Positions: []int{1, i%2 + 2}},
+		}
+	}
+	long := strings.Repeat("x", 300)
+	dictionaries := map[string][]string{
+		"single":  {"solo"},
+		"prefix":  {"a", "ab", "abc", "abcd", "abd", "b", "ba"},
+		"unicode": {"è", "é", "éa", "éé", "ü"},
+		"long":    {long, long + "1", long + "2", long + "2" + long},
+	}
+	for name, terms := range dictionaries {
+		var entries []index.TermEntry
+		for i, term := range terms {
+			entries = append(entries, bodyEntry(term, postings(i)))
+		}
+		for _, version := range []uint32{1, 2, 3, segment.FormatVersion} {
+			t.Run(fmt.Sprintf("%s/v%d", name, version), func(t *testing.T) {
+				r := writeSegment(t, version, entries, docs)
+				assertEntries(t, r, entries)
+				missing := index.FieldTerm(index.FieldBody, terms[0]+"\x00")
+				if postings, err := r.Search(missing); err != nil || postings != nil {
+					t.Errorf("searching a missing term: %v, %v", postings, err)
+				}
+			})
+		}
+	}
+
+	w := segment.NewWriter(t.TempDir(), nil)
+	if _, err := w.Write(nil, nil, nil); err == nil {
+		t.Error("writing an empty dictionary succeeded")
+	}
+}
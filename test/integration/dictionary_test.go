@@ -0,0 +1,138 @@
+package integration
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/segment"
+)
+
+// TestDictionaryLookups checks exact, prefix and range lookups in
+// dictionaries of several blocks, with every version's dictionary format,
+// against a scan of the sorted terms: at block boundaries, for missing
+// terms, for the empty prefix and for prefixes of 0xff bytes.
+func TestDictionaryLookups(t *testing.T) {
+	docs := []index.DocLength{{DocID: "doc", Fields: map[string]int{index.FieldBody: 1}}}
+	var plain []string
+	for i := 0; i < 150; i++ {
+		plain = append(plain, fmt.Sprintf("t%03d", i*2))
+	}
+	plain = append(plain, "a", "ab", "abc", "u")
+	// Terms of 0xff bytes, which have no prefix successor, are not valid
+	// UTF-8, which version 1 dictionaries, being JSON, cannot hold.
+	all := append(append([]string(nil), plain...), "u\xff", "u\xff\xff", "u\xff\xffz", "\xff", "\xff\xff")
+	sort.Strings(plain)
+	sort.Strings(all)
+	key := func(term string) string { return index.FieldTerm(index.FieldBody, term) }
+
+	for _, version := range []uint32{1, 2, 3, segment.FormatVersion} {
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			terms := all
+			if version == 1 {
+				terms = plain
+			}
+			entries := make([]index.TermEntry, len(terms))
+			keys := make([]string, len(terms))
+			for i, term := range terms {
+				entries[i] = bodyEntry(term, index.PostingList{{DocID: "doc", Frequency: 1, Positions: []int{0}}})
+				keys[i] = entries[i].Term
+			}
+
+			// Dictionary blocks hold 64 terms; boundary holds the terms
+			// around the start of each block.
+			var boundary []string
+			for _, i := range []int{0, 1, 63, 64, 65, 127, 128, 129, len(terms) - 1} {
+				boundary = append(boundary, terms[i])
+			}
+
+			var prefixes []string
+			for _, term := range boundary {
+				for n := 0; n <= len(term); n++ {
+					prefixes = append(prefixes, key(term[:n]))
+				}
+			}
+			prefixes = append(prefixes, "", "body", "bod", "title:", key("t1"), key("t2"), key("t3"), key("zz"), key("t0011"), key("\xff"), key("u\xff"), "\xff")
+
+			type bounds struct{ lo, hi string }
+			var ranges []bounds
+			for _, term := range boundary {
+				ranges = append(ranges,
+					bounds{key(term), ""},
+					bounds{"", key(term)},
+					bounds{key(term), key(term + "\x00")},
+					bounds{key(term + "\x00"), key("t200")},
+				)
+			}
+			ranges = append(ranges, bounds{"", ""}, bounds{key("t126"), key("t130")}, bounds{key("t129"), key("t128")}, bounds{key("t3"), key("t4")})
+
+			r := writeSegment(t, version, entries, docs)
+			for _, term := range append(boundary, "", "t001", "t299", "t300", "t", "abcd", "u\xff\xff\xff", "\xff\xff\xff") {
+				postings, err := r.Search(key(term))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := slices.Contains(terms, term); (postings != nil) != want {
+					t.Errorf("Search(%q) = %v, want found %v", key(term), postings, want)
+				}
+			}
+
+			for _, prefix := range prefixes {
+				var got []string
+				if err := r.TermsWithPrefix(prefix, func(ti segment.TermInfo) bool {
+					got = append(got, ti.Term)
+					return true
+				}); err != nil {
+					t.Fatal(err)
+				}
+				var want []string
+				for _, k := range keys {
+					if strings.HasPrefix(k, prefix) {
+						want = append(want, k)
+					}
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("TermsWithPrefix(%q) = %q, want %q", prefix, got, want)
+				}
+			}
+
+			for _, b := range ranges {
+				var got []string
+				if err := r.TermsInRange(b.lo, b.hi, func(ti segment.TermInfo) bool {
+					if ti.DocFreq != 1 {
+						t.Errorf("%q has document frequency %d, want 1", ti.Term, ti.DocFreq)
+					}
+					got = append(got, ti.Term)
+					return true
+				}); err != nil {
+					t.Fatal(err)
+				}
+				var want []string
+				for _, k := range keys {
+					if k >= b.lo && (b.hi == "" || k < b.hi) {
+						want = append(want, k)
+					}
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("TermsInRange(%q, %q) = %q, want %q", b.lo, b.hi, got, want)
+				}
+			}
+
+			// Scans stop when fn returns false, across a block boundary.
+			var got []string
+			if err := r.TermsInRange(key(terms[62]), "", func(ti segment.TermInfo) bool {
+				got = append(got, ti.Term)
+				return len(got) < 4
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, keys[62:66]) {
+				t.Errorf("stopped scan = %q, want %q", got, keys[62:66])
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/automaton"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/executor"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/handler"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/config"
+)
+
+// TestLevenshteinAutomaton checks the edit distances a Levenshtein
+// automaton accepts terms at. A transposition is two edits.
+func TestLevenshteinAutomaton(t *testing.T) {
+	tests := []struct {
+		term     string
+		maxEdits int
+		accepted map[string]int
+		rejected []string
+	}{
+		{"search", 0, map[string]int{"search": 0}, []string{"serch", "searches", ""}},
+		{"search", 1,
+			map[string]int{"search": 0, "serch": 1, "searchs": 1, "seerch": 1, "xsearch": 1, "earch": 1},
+			[]string{"saerch", "serc", "searches", "sea"}},
+		{"search", 2,
+			map[string]int{"saerch": 2, "serc": 2, "srch": 2, "xsearchx": 2, "searches": 2, "seerch": 1},
+			[]string{"sea", "sxrxh", "hcraes"}},
+		{"café", 1, map[string]int{"cafe": 1, "café": 0, "cafés": 1}, []string{"caffe"}},
+		{"ab", 2, map[string]int{"": 2, "ba": 2, "abcd": 2, "b": 1, "cd": 2}, []string{"abcde", "cde"}},
+		{"search", 5, map[string]int{"saerch": 2}, []string{"sea"}},
+	}
+	for _, tc := range tests {
+		dfa, err := automaton.Levenshtein(tc.term, tc.maxEdits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for term, want := range tc.accepted {
+			if got, ok := dfa.Match(term); !ok || got != want {
+				t.Errorf("Levenshtein(%q, %d).Match(%q) = %d, %v, want %d", tc.term, tc.maxEdits, term, got, ok, want)
+			}
+		}
+		for _, term := range tc.rejected {
+			if got, ok := dfa.Match(term); ok {
+				t.Errorf("Levenshtein(%q, %d) accepts %q at %d", tc.term, tc.maxEdits, term, got)
+			}
+		}
+	}
+}
+
+// TestWildcardAutomaton checks the terms wildcard patterns match, with ?,
+// *, a leading * and escapes, and their literal prefixes.
+func TestWildcardAutomaton(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		prefix   string
+		plain    bool
+		accepted []string
+		rejected []string
+	}{
+		{"d?ta", "d", false, []string{"data", "dota"}, []string{"dta", "daata", "datas"}},
+		{"da*", "da", true, []string{"da", "data", "database"}, []string{"d", "adata"}},
+		{"*ing", "", false, []string{"ing", "searching", "inging"}, []string{"in", "ingest"}},
+		{"*", "", true, []string{"", "anything"}, nil},
+		{"a*b?c", "a", false, []string{"abxc", "aaabxc", "ab_b_c"}, []string{"abc", "abxcd", "bxc"}},
+		{"??", "", false, []string{"ab", "éé"}, []string{"a", "abc"}},
+		{`a\*b`, "a*b", false, []string{"a*b"}, []string{"ab", "axb"}},
+		{`a\?*`, "a?", true, []string{"a?", "a?b"}, []string{"ab"}},
+	}
+	for _, tc := range tests {
+		dfa, err := automaton.Wildcard(tc.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, term := range tc.accepted {
+			if _, ok := dfa.Match(term); !ok {
+				t.Errorf("Wildcard(%q) rejects %q", tc.pattern, term)
+			}
+		}
+		for _, term := range tc.rejected {
+			if _, ok := dfa.Match(term); ok {
+				t.Errorf("Wildcard(%q) accepts %q", tc.pattern, term)
+			}
+		}
+		if prefix, plain := automaton.LiteralPrefix(tc.pattern); prefix != tc.prefix || plain != tc.plain {
+			t.Errorf("LiteralPrefix(%q) = %q, %v, want %q, %v", tc.pattern, prefix, plain, tc.prefix, tc.plain)
+		}
+	}
+}
+
+// expansionBodies are the bodies of the expansion corpus, by document ID.
+// doc3 and doc9 are deleted, from a segment and from the memory index.
+var expansionBodies = []string{
+	"apple", "apply apply", "ample", "applez",
+	"apply", "maple", "applet", "appel",
+	"apply", "applex", "apples", "grape",
+}
+
+// expansionExecutors returns executors over the expansion corpus, analysed
+// with the standard analyzer, with the given maximum number of expansions:
+// one over a single engine holding doc0 to doc3 in one segment, doc4 to
+// doc7 in another and the rest in its memory index, and one over two
+// shards with two segments each.
+func expansionExecutors(t *testing.T, maxExpansions int) map[string]handler.SearchExecutor {
+	t.Helper()
+	open := func() *indexer.Engine {
+		engine, err := indexer.NewEngine(config.IndexerConfig{
+			DataDir:        t.TempDir(),
+			SegmentMaxSize: 100 * 1024 * 1024,
+			Analyzer:       "standard",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { engine.Close() })
+		return engine
+	}
+	single := open()
+	shards := map[int]*indexer.Engine{0: open(), 1: open()}
+	for d, body := range expansionBodies {
+		docID := fmt.Sprintf("doc%d", d)
+		doc := index.Document{Fields: map[string]string{index.FieldBody: body}}
+		for _, e := range []*indexer.Engine{single, shards[d%2]} {
+			if err := e.IndexDocument(docID, doc); err != nil {
+				t.Fatal(err)
+			}
+		}
+		flushed := []*indexer.Engine{shards[d%2]}
+		if d == 3 || d == 7 {
+			flushed = append(flushed, single)
+		}
+		if d == 2 || d == 3 || d == 6 || d == 7 {
+			for _, e := range flushed {
+				if err := e.Flush(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	for _, d := range []int{3, 9} {
+		docID := fmt.Sprintf("doc%d", d)
+		for _, e := range []*indexer.Engine{single, shards[d%2]} {
+			deleteDoc(t, e, docID)
+		}
+	}
+	expansion := executor.ExpansionConfig{MaxExpansions: maxExpansions, Rewrite: executor.ScoringBoolean}
+	return map[string]handler.SearchExecutor{
+		"single":  executor.New(single, ranker.Config{}, expansion),
+		"sharded": executor.NewSharded(shards, ranker.Config{}, expansion),
+	}
+}
+
+// TestTermExpansion checks the live documents fuzzy, prefix and wildcard
+// queries match across segments and the memory index, and which terms are
+// kept when a query matches more than MaxExpansions of them: the fewest
+// edits first, then the most documents, then in term order.
+func TestTermExpansion(t *testing.T) {
+	tests := []struct {
+		query         string
+		maxExpansions int
+		want          []string
+	}{
+		{"apple~1", 0, []string{"doc0", "doc1", "doc2", "doc4", "doc6", "doc8", "doc10"}},
+		{"appel~1", 0, []string{"doc7"}},
+		{"appel~2", 0, []string{"doc0", "doc1", "doc4", "doc6", "doc7", "doc8", "doc10"}},
+		{"apple~2", 0, []string{"doc0", "doc1", "doc2", "doc4", "doc5", "doc6", "doc7", "doc8", "doc10"}},
+		{"app*", 0, []string{"doc0", "doc1", "doc4", "doc6", "doc7", "doc8", "doc10"}},
+		{"a?ple", 0, []string{"doc0", "doc2"}},
+		{"*ple", 0, []string{"doc0", "doc2", "doc5"}},
+		{"*ple?", 0, []string{"doc6", "doc10"}},
+		{"body:appl?*", 0, []string{"doc0", "doc1", "doc4", "doc6", "doc8", "doc10"}},
+		{"title:app*", 0, nil},
+
+		// apple is exact; apply is in the most documents of those one edit
+		// away, and ample, apples, applet and the deleted applez in one
+		// each.
+		{"apple~1", 1, []string{"doc0"}},
+		{"apple~1", 2, []string{"doc0", "doc1", "doc4", "doc8"}},
+		{"apple~1", 3, []string{"doc0", "doc1", "doc2", "doc4", "doc8"}},
+		{"apple~1", 4, []string{"doc0", "doc1", "doc2", "doc4", "doc8", "doc10"}},
+		{"app*", 1, []string{"doc1", "doc4", "doc8"}},
+		{"app*", 2, []string{"doc1", "doc4", "doc7", "doc8"}},
+	}
+	for _, maxExpansions := range []int{0, 1, 2, 3, 4} {
+		executors := expansionExecutors(t, maxExpansions)
+		for _, tc := range tests {
+			if tc.maxExpansions != maxExpansions {
+				continue
+			}
+			for name, exec := range executors {
+				got := expansionResults(t, exec, tc.query)
+				if !sameDocs(got, tc.want) {
+					t.Errorf("%s: %s with at most %d expansions matched %v, want %v", name, tc.query, maxExpansions, got, tc.want)
+				}
+			}
+		}
+	}
+}
+
+// expansionResults returns the IDs of the documents query, analysed with
+// the standard analyzer, matches.
+func expansionResults(t *testing.T, exec handler.SearchExecutor, query string) []string {
+	t.Helper()
+	plan, err := parser.Parse(query, namedAnalyzer(t, "standard"), nil)
+	if err != nil {
+		t.Fatalf("parsing %q: %v", query, err)
+	}
+	res, err := exec.Execute(context.Background(), plan, executor.Options{Limit: 100})
+	if err != nil {
+		t.Fatalf("searching %q: %v", query, err)
+	}
+	return docIDs(res.Results)
+}
@@ -0,0 +1,154 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/executor"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/handler"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+)
+
+// explainedDoc returns doc d of the explanation corpus, whose documents
+// hold the words of words in varying numbers and fields.
+func explainedDoc(d int) index.Document {
+	words := []string{"search", "analytics", "platform", "query", "ranking", "shard"}
+	var body []string
+	for i, w := range words {
+		for n := (d*(i+2)/5 + i) % 3; n > 0; n-- {
+			body = append(body, w)
+		}
+	}
+	body = append(body, strings.Repeat("filler ", d%5))
+	return index.Document{Fields: map[string]string{
+		index.FieldTitle: words[d%len(words)] + " " + words[d/4%len(words)],
+		index.FieldBody:  strings.Join(body, " "),
+	}}
+}
+
+// explainExecutors returns executors over the same 40 documents, ranking
+// titles twice as high as bodies and expanding terms as scoring Boolean
+// queries: one engine holding them in a segment and its memory index, and
+// two shards.
+func explainExecutors(t *testing.T) map[string]handler.SearchExecutor {
+	t.Helper()
+	single := openEngine(t, t.TempDir())
+	t.Cleanup(func() { single.Close() })
+	shards := map[int]*indexer.Engine{}
+	for s := 0; s < 2; s++ {
+		shard := openEngine(t, t.TempDir())
+		t.Cleanup(func() { shard.Close() })
+		shards[s] = shard
+	}
+	for d := 0; d < 40; d++ {
+		docID := fmt.Sprintf("doc%d", d)
+		if err := single.IndexDocument(docID, explainedDoc(d)); err != nil {
+			t.Fatal(err)
+		}
+		if err := shards[d%2].IndexDocument(docID, explainedDoc(d)); err != nil {
+			t.Fatal(err)
+		}
+		if d == 19 {
+			if err := single.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	cfg := ranker.Config{FieldBoosts: map[string]float64{index.FieldTitle: 2}}
+	expansion := executor.ExpansionConfig{Rewrite: executor.ScoringBoolean}
+	return map[string]handler.SearchExecutor{
+		"single":  executor.New(single, cfg, expansion),
+		"sharded": executor.NewSharded(shards, cfg, expansion),
+	}
+}
+
+// TestExplainMatchesScores checks, for every similarity, that the
+// explanation of each result of a search, and that Explain gives of its
+// document, add up to the result's score.
+func TestExplainMatchesScores(t *testing.T) {
+	executors := explainExecutors(t)
+	queries := []string{
+		"analytics",
+		`analytics OR "query ranking" OR title:shard^2`,
+		"+platform (search OR ranking)^1.5 -filler",
+		"#search analytics OR shar* OR ranknig~1",
+	}
+	models := []ranker.Model{ranker.ModelBM25, ranker.ModelBM25Plus, ranker.ModelTFIDF, ranker.ModelDFR, ranker.ModelLMDirichlet}
+	ctx := context.Background()
+	for name, exec := range executors {
+		for _, model := range models {
+			for _, query := range queries {
+				plan, err := parser.Parse(query, analysis.Default(), nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				opts := executor.Options{Limit: 10, Similarity: model}
+				plain, err := exec.Execute(ctx, plan, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				opts.Explain = true
+				explained, err := exec.Execute(ctx, plan, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(explained.Results) == 0 || len(explained.Results) != len(plain.Results) {
+					t.Fatalf("%s/%s %q: %d results explained, %d without", name, model, query, len(explained.Results), len(plain.Results))
+				}
+				for i, r := range explained.Results {
+					where := fmt.Sprintf("%s/%s %q, %s", name, model, query, r.DocID)
+					if r.DocID != plain.Results[i].DocID || r.Score != plain.Results[i].Score {
+						t.Errorf("%s: result %d is %s scoring %v, %s scoring %v without explain",
+							where, i, r.DocID, r.Score, plain.Results[i].DocID, plain.Results[i].Score)
+					}
+					if r.Explanation == nil {
+						t.Errorf("%s: no explanation", where)
+					} else if got := ranker.RoundScore(r.Explanation.Value); got != r.Score {
+						t.Errorf("%s: explanation adds up to %v, score %v", where, got, r.Score)
+					}
+
+					e, err := exec.Explain(ctx, plan, r.DocID, executor.Options{Similarity: model})
+					if err != nil {
+						t.Fatalf("%s: %v", where, err)
+					}
+					if !e.Matched || e.Score != r.Score {
+						t.Errorf("%s: Explain matched=%v score %v, want a match scoring %v", where, e.Matched, e.Score, r.Score)
+					}
+					if got := ranker.RoundScore(e.Explanation.Value); got != r.Score {
+						t.Errorf("%s: Explain adds up to %v, score %v", where, got, r.Score)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestExplainUnmatchedAndMissing checks that a document the query does not
+// match is explained with matched false and no score, and that a missing
+// one is reported.
+func TestExplainUnmatchedAndMissing(t *testing.T) {
+	for name, exec := range explainExecutors(t) {
+		plan, err := parser.Parse("title:analytics", analysis.Default(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// doc0's title is "search search".
+		e, err := exec.Explain(context.Background(), plan, "doc0", executor.Options{})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if e.Matched || e.Score != 0 {
+			t.Errorf("%s: unmatched document explained with matched=%v score %v", name, e.Matched, e.Score)
+		}
+		if _, err := exec.Explain(context.Background(), plan, "doc99", executor.Options{}); !errors.Is(err, executor.ErrDocNotFound) {
+			t.Errorf("%s: explaining a missing document: %v, want ErrDocNotFound", name, err)
+		}
+	}
+}
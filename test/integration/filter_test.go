@@ -0,0 +1,216 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/executor"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/config"
+)
+
+// openSchemaEngine opens an engine over dir with the metadata schema
+// metadata.
+func openSchemaEngine(t *testing.T, dir string, metadata map[string]string) *indexer.Engine {
+	t.Helper()
+	engine, err := indexer.NewEngine(config.IndexerConfig{
+		DataDir:        dir,
+		SegmentMaxSize: 100 * 1024 * 1024,
+		Metadata:       metadata,
+	})
+	if err != nil {
+		t.Fatalf("opening engine: %v", err)
+	}
+	return engine
+}
+
+// filteredDoc returns doc d of the filtering corpus, with rank d and a
+// price that repeats, and that every sixth document lacks.
+func filteredDoc(d int) index.Document {
+	doc := index.Document{
+		Fields:   map[string]string{index.FieldBody: []string{"even", "odd"}[d%2] + " search"},
+		Metadata: map[string]any{"rank": float64(d)},
+	}
+	if d%6 != 5 {
+		doc.Metadata["price"] = float64(d%9) * 1.25
+	}
+	return doc
+}
+
+// TestFilterAndSortAcrossSegments filters and sorts documents held in
+// segments and the memory index, some in a segment written when price was a
+// keyword rather than a float, which holds no float values of it.
+func TestFilterAndSortAcrossSegments(t *testing.T) {
+	dir := t.TempDir()
+	keywordPrices := openSchemaEngine(t, dir, map[string]string{"price": "keyword", "rank": "integer"})
+	for d := 0; d < 10; d++ {
+		if err := keywordPrices.IndexDocument(fmt.Sprintf("doc%02d", d), filteredDoc(d)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := keywordPrices.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	keywordPrices.Close()
+
+	engine := openSchemaEngine(t, dir, map[string]string{"price": "float", "rank": "integer"})
+	defer engine.Close()
+	for d := 10; d < 25; d++ {
+		if err := engine.IndexDocument(fmt.Sprintf("doc%02d", d), filteredDoc(d)); err != nil {
+			t.Fatal(err)
+		}
+		if d == 17 {
+			if err := engine.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	deleteDoc(t, engine, "doc12")
+	deleteDoc(t, engine, "doc20")
+	// Updating doc03 moves it out of the keyword segment.
+	if err := engine.UpdateDocument("doc03", filteredDoc(3)); err != nil {
+		t.Fatal(err)
+	}
+
+	// ranks holds the rank of each live document, and prices the prices
+	// typed as floats.
+	ranks := map[string]float64{}
+	prices := map[string]float64{}
+	for d := 0; d < 25; d++ {
+		docID := fmt.Sprintf("doc%02d", d)
+		if d == 12 || d == 20 {
+			continue
+		}
+		meta := filteredDoc(d).Metadata
+		ranks[docID] = meta["rank"].(float64)
+		if p, ok := meta["price"].(float64); ok && (d >= 10 || d == 3) {
+			prices[docID] = p
+		}
+	}
+	inRange := func(values map[string]float64, r index.Range) []string {
+		var ids []string
+		for docID, v := range values {
+			if r.Contains(index.TypeFloat, index.Value{Num: v}) {
+				ids = append(ids, docID)
+			}
+		}
+		sort.Strings(ids)
+		return ids
+	}
+	bound := func(n float64) *index.Value { return &index.Value{Num: n} }
+
+	view := engine.AcquireView()
+	defer view.Close()
+	for docID, rank := range ranks {
+		doc, ok := view.DocNumber(docID)
+		if !ok {
+			t.Fatalf("%s is not in the view", docID)
+		}
+		if v, ok := view.Value("rank", doc); !ok || v.Num != rank {
+			t.Errorf("%s: rank %v, %v, want %v", docID, v.Num, ok, rank)
+		}
+		p, priced := prices[docID]
+		if v, ok := view.Value("price", doc); ok != priced || v.Num != p {
+			t.Errorf("%s: price %v, %v, want %v, %v", docID, v.Num, ok, p, priced)
+		}
+	}
+	ranges := []struct {
+		key    string
+		values map[string]float64
+		r      index.Range
+	}{
+		{"rank", ranks, index.Range{Lower: bound(5), Upper: bound(15), IncludeLower: true}},
+		{"rank", ranks, index.Range{Upper: bound(3), IncludeUpper: true}},
+		{"rank", ranks, index.Range{Lower: bound(17)}},
+		{"price", prices, index.Range{Lower: bound(2.5), Upper: bound(6.25), IncludeLower: true, IncludeUpper: true}},
+		{"price", prices, index.Range{Lower: bound(0)}},
+		{"price", prices, index.Range{}},
+	}
+	for _, tc := range ranges {
+		docs := view.RangeDocs(tc.key, tc.r)
+		if !sort.SliceIsSorted(docs, func(i, j int) bool { return docs[i] < docs[j] }) {
+			t.Errorf("RangeDocs(%s) = %v, not sorted", tc.key, docs)
+		}
+		got := make([]string, len(docs))
+		for i, doc := range docs {
+			got[i] = view.DocID(doc)
+		}
+		sort.Strings(got)
+		if want := inRange(tc.values, tc.r); !reflect.DeepEqual(got, want) {
+			t.Errorf("RangeDocs(%s, %+v) = %v, want %v", tc.key, tc.r, got, want)
+		}
+	}
+
+	// ordered returns ids sorted by values, those without one last, ties
+	// broken by document ID.
+	ordered := func(ids []string, values map[string]float64, desc bool) []string {
+		sort.Slice(ids, func(i, j int) bool {
+			a, aok := values[ids[i]]
+			b, bok := values[ids[j]]
+			switch {
+			case aok != bok:
+				return aok
+			case a != b:
+				return a < b != desc
+			}
+			return ids[i] < ids[j]
+		})
+		return ids
+	}
+	all := inRange(ranks, index.Range{})
+	var odd []string
+	for _, docID := range all {
+		if int(ranks[docID])%2 == 1 {
+			odd = append(odd, docID)
+		}
+	}
+	exec := executor.New(engine, ranker.Config{}, executor.ExpansionConfig{})
+	searches := []struct {
+		query, sort string
+		want        []string
+	}{
+		{"search", "price", ordered(append([]string(nil), all...), prices, false)},
+		{"search", "price:desc", ordered(append([]string(nil), all...), prices, true)},
+		{"search", "rank:desc", ordered(append([]string(nil), all...), ranks, true)},
+		{"odd price:[2.5 TO 6.25]", "price:desc", ordered(intersect(odd, inRange(prices, ranges[3].r)), prices, true)},
+		{"search rank:{3 TO 17]", "price", ordered(inRange(ranks, index.Range{Lower: bound(3), Upper: bound(17), IncludeUpper: true}), prices, false)},
+	}
+	for _, tc := range searches {
+		plan, err := parser.Parse(tc.query, analysis.Default(), engine.Schema())
+		if err != nil {
+			t.Fatal(err)
+		}
+		sortFields, err := ranker.ParseSort(tc.sort, engine.Schema())
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := exec.Execute(context.Background(), plan, executor.Options{Limit: 100, Sort: sortFields, ExactTotal: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := docIDs(res.Results); !reflect.DeepEqual(got, tc.want) || res.TotalHits != len(tc.want) {
+			t.Errorf("%q sorted by %s: %d hits\n%v\nwant\n%v", tc.query, tc.sort, res.TotalHits, got, tc.want)
+		}
+	}
+}
+
+// intersect returns the IDs of a, sorted, that are also in b.
+func intersect(a, b []string) []string {
+	var ids []string
+	for _, id := range a {
+		for _, other := range b {
+			if id == other {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	return ids
+}
@@ -133,6 +133,7 @@ func TestUnauthenticatedRequestRejected(t *testing.T) {
 		path   string
 	}{
 		{"GET", "/api/v1/search?q=test"},
+		{"GET", "/api/v1/suggest?prefix=te"},
 		{"GET", "/api/v1/documents"},
 		{"GET", "/api/v1/analytics"},
 	}
@@ -0,0 +1,160 @@
+package integration
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/highlight"
+)
+
+// wordSpans returns the span of every space-separated word of text, one
+// position per word.
+func wordSpans(text string) []analysis.Span {
+	var spans []analysis.Span
+	start := -1
+	for i, r := range text + " " {
+		switch {
+		case r == ' ' && start >= 0:
+			spans = append(spans, analysis.Span{Start: start, End: i})
+			start = -1
+		case r != ' ' && start < 0:
+			start = i
+		}
+	}
+	return spans
+}
+
+// wordPosition returns the position of the first occurrence of word in text.
+func wordPosition(t *testing.T, text, word string) int {
+	t.Helper()
+	for pos, w := range strings.Fields(text) {
+		if w == word {
+			return pos
+		}
+	}
+	t.Fatalf("%q not in %q", word, text)
+	return 0
+}
+
+func TestFragmentsBalanceMultibyteContext(t *testing.T) {
+	tests := []struct {
+		name, text, target string
+	}{
+		{"greek", strings.Repeat("άλφα ", 20) + "στόχος" + strings.Repeat(" ωμέγα", 20), "στόχος"},
+		{"cyrillic", strings.Repeat("слово ", 20) + "цель" + strings.Repeat(" текст", 20), "цель"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			text, target := tc.text, tc.target
+			cfg := highlight.Config{FragmentSize: 60, Encoder: highlight.NoEncoder}
+			got := highlight.Fragments(text, wordSpans(text), []int{wordPosition(t, text, target)}, cfg)
+			if len(got) != 1 {
+				t.Fatalf("got %d fragments, want 1: %q", len(got), got)
+			}
+			before, after, ok := strings.Cut(got[0], "<em>"+target+"</em>")
+			if !ok {
+				t.Fatalf("fragment %q does not mark %q", got[0], target)
+			}
+			nb, na := utf8.RuneCountInString(before), utf8.RuneCountInString(after)
+			if nb < 20 || na < 20 {
+				t.Errorf("context of %d runes before and %d after, want about 25 each: %q", nb, na, got[0])
+			}
+			if n := utf8.RuneCountInString(got[0]) - len("<em></em>"); n > cfg.FragmentSize {
+				t.Errorf("fragment of %d runes, want at most %d: %q", n, cfg.FragmentSize, got[0])
+			}
+		})
+	}
+}
+
+func TestFragmentsContextAtEdges(t *testing.T) {
+	text := "ένα δύο τρία τέσσερα πέντε έξι επτά οκτώ εννέα δέκα"
+	cfg := highlight.Config{FragmentSize: 30, Encoder: highlight.NoEncoder}
+	tests := []struct {
+		word string
+		want string
+	}{
+		// Context missing before the match is taken after it, and the
+		// other way round.
+		{"ένα", "<em>ένα</em> δύο τρία τέσσερα πέντε έξι"},
+		{"δέκα", "πέντε έξι επτά οκτώ εννέα <em>δέκα</em>"},
+	}
+	for _, tc := range tests {
+		got := highlight.Fragments(text, wordSpans(text), []int{wordPosition(t, text, tc.word)}, cfg)
+		if !reflect.DeepEqual(got, []string{tc.want}) {
+			t.Errorf("Fragments around %q = %q, want %q", tc.word, got, tc.want)
+		}
+	}
+}
+
+func TestFragmentsRankedByMatches(t *testing.T) {
+	filler := strings.Repeat("filler ", 30)
+	text := "alpha start " + filler + "beta gamma beta " + filler + "gamma end"
+	spans := wordSpans(text)
+	words := strings.Fields(text)
+	var positions []int
+	for pos, w := range words {
+		if w == "alpha" || w == "beta" || w == "gamma" {
+			positions = append(positions, pos)
+		}
+	}
+	cfg := highlight.Config{FragmentSize: 30, NumFragments: 2, Encoder: highlight.NoEncoder}
+	got := highlight.Fragments(text, spans, positions, cfg)
+	if len(got) != 2 {
+		t.Fatalf("got %d fragments, want 2: %q", len(got), got)
+	}
+	if !strings.Contains(got[0], "<em>beta</em> <em>gamma</em> <em>beta</em>") {
+		t.Errorf("first fragment %q, want the one with three matches", got[0])
+	}
+	if n := strings.Count(got[1], "<em>"); n != 1 {
+		t.Errorf("second fragment %q has %d matches, want 1", got[1], n)
+	}
+
+	// Fewer fragments are returned if asked for.
+	cfg.NumFragments = 1
+	if got := highlight.Fragments(text, spans, positions, cfg); len(got) != 1 {
+		t.Errorf("got %d fragments with NumFragments 1: %q", len(got), got)
+	}
+}
+
+func TestFragmentsMergeOverlaps(t *testing.T) {
+	text := "the data-stream platform streams data"
+	spans := []analysis.Span{
+		{Start: 0, End: 3},   // the
+		{Start: 4, End: 15},  // data-stream
+		{Start: 4, End: 8},   // data
+		{Start: 9, End: 15},  // stream
+		{Start: 16, End: 24}, // platform
+		{Start: 25, End: 32}, // streams
+		{Start: 33, End: 37}, // data
+	}
+	cfg := highlight.Config{FragmentSize: 100, Encoder: highlight.NoEncoder}
+
+	// Overlapping and repeated matches are marked once.
+	got := highlight.Fragments(text, spans, []int{2, 1, 3, 3, 6}, cfg)
+	want := []string{"the <em>data-stream</em> platform streams <em>data</em>"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fragments = %q, want %q", got, want)
+	}
+
+	// Fragments around nearby matches overlap, so a single one is kept.
+	cfg.NumFragments = 3
+	if got := highlight.Fragments(text, spans, []int{0, 4, 6}, cfg); len(got) != 1 {
+		t.Errorf("got %d fragments of overlapping windows, want 1: %q", len(got), got)
+	}
+}
+
+func TestFragmentsEncoding(t *testing.T) {
+	text := "a <b> & c"
+	spans := wordSpans(text)
+	got := highlight.Fragments(text, spans, []int{1}, highlight.Config{})
+	want := []string{"a <em>&lt;b&gt;</em> &amp; c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Fragments = %q, want %q", got, want)
+	}
+	if got := highlight.Fragments(text, spans, []int{-1, 9}, highlight.Config{}); got != nil {
+		t.Errorf("Fragments of unlocated positions = %q, want nil", got)
+	}
+}
@@ -0,0 +1,812 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/segment"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/executor"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/config"
+)
+
+// ---------------------------------------------------------------------------
+// Helpers
+// ---------------------------------------------------------------------------
+
+// openEngine opens an indexer engine over dir that merges every 3 segments
+// and only flushes when asked.
+func openEngine(t *testing.T, dir string) *indexer.Engine {
+	t.Helper()
+	engine, err := indexer.NewEngine(config.IndexerConfig{
+		DataDir:                dir,
+		SegmentMaxSize:         100 * 1024 * 1024,
+		MaxSegmentsBeforeMerge: 3,
+	})
+	if err != nil {
+		t.Fatalf("opening engine: %v", err)
+	}
+	return engine
+}
+
+// indexDocs indexes doc<from> to doc<to-1> and flushes them to a segment.
+func indexDocs(t *testing.T, engine *indexer.Engine, from, to int) {
+	t.Helper()
+	for d := from; d < to; d++ {
+		doc := index.Document{Fields: map[string]string{
+			index.FieldTitle: fmt.Sprintf("document %d", d),
+			index.FieldBody:  "segment merge recovery",
+		}}
+		if err := engine.IndexDocument(fmt.Sprintf("doc%d", d), doc); err != nil {
+			t.Fatalf("indexing doc%d: %v", d, err)
+		}
+	}
+	if err := engine.Flush(); err != nil {
+		t.Fatalf("flushing: %v", err)
+	}
+}
+
+// deleteDoc deletes docID, which must be live.
+func deleteDoc(t *testing.T, engine *indexer.Engine, docID string) {
+	t.Helper()
+	found, err := engine.DeleteDocument(docID)
+	if err != nil {
+		t.Fatalf("deleting %s: %v", docID, err)
+	}
+	if !found {
+		t.Fatalf("deleting %s: not found", docID)
+	}
+}
+
+// assertLive checks that, of doc0 to doc<n-1>, exactly want are live, and
+// that the engine has segments segments.
+func assertLive(t *testing.T, engine *indexer.Engine, n int, want []string, segments int) {
+	t.Helper()
+	view := engine.AcquireView()
+	defer view.Close()
+	var live []string
+	for d := 0; d < n; d++ {
+		docID := fmt.Sprintf("doc%d", d)
+		if _, ok := view.DocNumber(docID); ok {
+			live = append(live, docID)
+		}
+	}
+	sort.Strings(live)
+	sort.Strings(want)
+	if strings.Join(live, ",") != strings.Join(want, ",") {
+		t.Errorf("live documents = %v, want %v", live, want)
+	}
+	if view.TotalDocs() != int64(len(want)) {
+		t.Errorf("total docs = %d, want %d", view.TotalDocs(), len(want))
+	}
+	if got := engine.SegmentCount(); got != segments {
+		t.Errorf("segments = %d, want %d", got, segments)
+	}
+}
+
+// docsExcept returns doc0 to doc<n-1> without the given IDs.
+func docsExcept(n int, deleted ...string) []string {
+	skip := make(map[string]bool, len(deleted))
+	for _, docID := range deleted {
+		skip[docID] = true
+	}
+	var docs []string
+	for d := 0; d < n; d++ {
+		if docID := fmt.Sprintf("doc%d", d); !skip[docID] {
+			docs = append(docs, docID)
+		}
+	}
+	return docs
+}
+
+// copyDir replaces the regular files of dst with those of src.
+func copyDir(t *testing.T, src, dst string) {
+	t.Helper()
+	if err := os.RemoveAll(dst); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(src, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dst, entry.Name()), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// segmentFiles returns the names of the segment files in dir.
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, "*.spdx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = filepath.Base(m)
+	}
+	return names
+}
+
+// ---------------------------------------------------------------------------
+// Tests
+// ---------------------------------------------------------------------------
+
+// TestMergeKeepsDeletionsAcrossRestart deletes documents while three
+// segments are merged and after the merge, and checks that none of them is
+// live after a restart.
+func TestMergeKeepsDeletionsAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+	engine := openEngine(t, dir)
+	for s := 0; s < 3; s++ {
+		indexDocs(t, engine, s*10, s*10+10)
+	}
+	assertLive(t, engine, 30, docsExcept(30), 3)
+
+	// Each deletion lands before the merge starts, while it runs, or
+	// after the swap; all of them must survive it.
+	during := []string{"doc1", "doc11", "doc21"}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, docID := range during {
+			deleteDoc(t, engine, docID)
+		}
+	}()
+	merged, err := engine.MaybeMerge()
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("merging: %v", err)
+	}
+	if !merged {
+		t.Fatal("three segments were not merged")
+	}
+	assertLive(t, engine, 30, docsExcept(30, during...), 1)
+
+	deleteDoc(t, engine, "doc2")
+	indexDocs(t, engine, 30, 35)
+	deleteDoc(t, engine, "doc31")
+	want := docsExcept(35, "doc1", "doc11", "doc21", "doc2", "doc31")
+	assertLive(t, engine, 35, want, 2)
+	if err := engine.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	engine = openEngine(t, dir)
+	defer engine.Close()
+	assertLive(t, engine, 35, want, 2)
+	if files := segmentFiles(t, dir); len(files) != 2 {
+		t.Errorf("segment files = %v, want 2", files)
+	}
+}
+
+// TestMergeRecoveryKeepsDeletions recreates a crash between writing a
+// merged segment and swapping it for its inputs, with a deletion made in
+// an input in between and the write-ahead log truncated since. The merged
+// segment must take the deletion over when it supersedes its inputs.
+func TestMergeRecoveryKeepsDeletions(t *testing.T) {
+	dir := t.TempDir()
+	inputs := t.TempDir()
+	engine := openEngine(t, dir)
+	for s := 0; s < 3; s++ {
+		indexDocs(t, engine, s*10, s*10+10)
+	}
+	deleteDoc(t, engine, "doc3")
+	if err := engine.Close(); err != nil {
+		t.Fatal(err)
+	}
+	copyDir(t, dir, inputs)
+
+	// Merge, and keep the merged segment aside.
+	engine = openEngine(t, dir)
+	if merged, err := engine.MaybeMerge(); err != nil || !merged {
+		t.Fatalf("merging: merged=%v err=%v", merged, err)
+	}
+	if err := engine.Close(); err != nil {
+		t.Fatal(err)
+	}
+	files := segmentFiles(t, dir)
+	if len(files) != 1 {
+		t.Fatalf("segment files after merge = %v, want 1", files)
+	}
+	mergedName := files[0]
+	mergedData, err := os.ReadFile(filepath.Join(dir, mergedName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Go back to the inputs, delete from one of them, and flush a new
+	// segment, which truncates the log holding the deletion.
+	copyDir(t, inputs, dir)
+	engine = openEngine(t, dir)
+	deleteDoc(t, engine, "doc15")
+	indexDocs(t, engine, 30, 32)
+	if err := engine.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The merged segment reappears, as if the crash came before the swap.
+	if err := os.WriteFile(filepath.Join(dir, mergedName), mergedData, 0644); err != nil {
+		t.Fatal(err)
+	}
+	want := docsExcept(32, "doc3", "doc15")
+	engine = openEngine(t, dir)
+	assertLive(t, engine, 32, want, 2)
+	if err := engine.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if files := segmentFiles(t, dir); len(files) != 2 {
+		t.Errorf("segment files after recovery = %v, want the merged and the new segment", files)
+	}
+
+	// The carried-over deletion is durable.
+	engine = openEngine(t, dir)
+	defer engine.Close()
+	assertLive(t, engine, 32, want, 2)
+}
+
+// TestRestartsDoNotAccumulateLogFiles reopens an engine with nothing to
+// recover several times, and checks that a single write-ahead log file is
+// left, which the engine keeps writing to.
+func TestRestartsDoNotAccumulateLogFiles(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 4; i++ {
+		engine := openEngine(t, dir)
+		if err := engine.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logs, err := filepath.Glob(filepath.Join(dir, "wal_*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 {
+		t.Errorf("log files after 4 restarts = %v, want 1", logs)
+	}
+
+	engine := openEngine(t, dir)
+	defer engine.Close()
+	doc := index.Document{Fields: map[string]string{index.FieldBody: "logged"}}
+	if err := engine.IndexDocument("doc0", doc); err != nil {
+		t.Fatal(err)
+	}
+	assertLive(t, engine, 1, []string{"doc0"}, 0)
+}
+
+// TestTokenlessDocumentsAreNotCounted checks that documents without any
+// tokens count as neither in memory nor after a flush, as segments leave them
+// out, that an update to such content removes the earlier version, and that
+// flushing only such documents leaves nothing to replay on restart.
+func TestTokenlessDocumentsAreNotCounted(t *testing.T) {
+	dir := t.TempDir()
+	engine := openEngine(t, dir)
+	indexDocs(t, engine, 0, 3)
+	empty := index.Document{Fields: map[string]string{index.FieldTitle: "", index.FieldBody: "... !!!"}}
+	if err := engine.IndexDocument("doc3", empty); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.UpdateDocument("doc0", empty); err != nil {
+		t.Fatal(err)
+	}
+	assertLive(t, engine, 4, []string{"doc1", "doc2"}, 1)
+
+	if err := engine.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	assertLive(t, engine, 4, []string{"doc1", "doc2"}, 1)
+	logs, err := filepath.Glob(filepath.Join(dir, "wal_*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 {
+		t.Errorf("log files after flushing = %v, want 1", logs)
+	}
+	if err := engine.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	engine = openEngine(t, dir)
+	defer engine.Close()
+	assertLive(t, engine, 4, []string{"doc1", "doc2"}, 1)
+}
+
+// TestCorruptSegmentIsQuarantined flips a byte of the first of two
+// segments, in a section each verification mode checksums, and checks that
+// the engine moves the segment to corrupt/, starts, and serves the other
+// segment and new documents.
+func TestCorruptSegmentIsQuarantined(t *testing.T) {
+	tests := []struct {
+		mode   string
+		offset func(segment.SegmentHeader) int64
+	}{
+		{"", func(h segment.SegmentHeader) int64 { return h.DictOffset + h.DictSize/2 }},
+		{"dictionary", func(h segment.SegmentHeader) int64 { return h.DocsOffset + h.DocsSize/2 }},
+		{"full", func(h segment.SegmentHeader) int64 { return h.PostOffset + h.PostSize/2 }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.mode, func(t *testing.T) {
+			dir := t.TempDir()
+			engine := openEngine(t, dir)
+			indexDocs(t, engine, 0, 5)
+			indexDocs(t, engine, 5, 10)
+			if err := engine.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			names := segmentFiles(t, dir)
+			if len(names) != 2 {
+				t.Fatalf("segments = %v, want 2", names)
+			}
+			sort.Strings(names)
+			path := filepath.Join(dir, names[0])
+			r, err := segment.OpenReader(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			offset := tc.offset(r.Header())
+			r.Close()
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data[offset] ^= 0x40
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			engine, err = indexer.NewEngine(config.IndexerConfig{
+				DataDir:                dir,
+				SegmentMaxSize:         100 * 1024 * 1024,
+				MaxSegmentsBeforeMerge: 3,
+				SegmentVerify:          tc.mode,
+			})
+			if err != nil {
+				t.Fatalf("opening engine over a corrupt segment: %v", err)
+			}
+			defer engine.Close()
+			assertLive(t, engine, 10, docsExcept(10, "doc0", "doc1", "doc2", "doc3", "doc4"), 1)
+			if _, err := os.Stat(filepath.Join(dir, segment.CorruptDir, names[0])); err != nil {
+				t.Errorf("corrupt segment not quarantined: %v", err)
+			}
+			if got := segmentFiles(t, dir); len(got) != 1 || got[0] != names[1] {
+				t.Errorf("segments after quarantine = %v, want [%s]", got, names[1])
+			}
+
+			view := engine.AcquireView()
+			postings, err := view.Postings(index.FieldTerm(index.FieldBody, "segment"))
+			view.Close()
+			if err != nil || len(postings) != 5 {
+				t.Errorf("postings of the remaining segment: %d, %v, want 5", len(postings), err)
+			}
+			indexDocs(t, engine, 10, 12)
+			assertLive(t, engine, 12, docsExcept(12, "doc0", "doc1", "doc2", "doc3", "doc4"), 2)
+		})
+	}
+}
+
+// TestDeletesAndUpdatesAcrossRestartAndReload deletes and updates flushed
+// documents and checks that searches return only the live, newest versions:
+// at once, after the writing engine restarts, and in a read-only engine
+// over the same directory once it reloads the deletion bitmaps.
+func TestDeletesAndUpdatesAcrossRestartAndReload(t *testing.T) {
+	dir := t.TempDir()
+	engine := openEngine(t, dir)
+	indexDocs(t, engine, 0, 5)
+	indexDocs(t, engine, 5, 10)
+	reader, err := indexer.NewEngine(config.IndexerConfig{DataDir: dir, ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	search := func(engine *indexer.Engine, query string) []string {
+		t.Helper()
+		plan, err := parser.Parse(query, analysis.Default(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := executor.New(engine, ranker.Config{}, executor.ExpansionConfig{}).Execute(context.Background(), plan, executor.Options{Limit: 100, ExactTotal: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := docIDs(res.Results)
+		sort.Strings(ids)
+		return ids
+	}
+	assertSearch := func(name string, engine *indexer.Engine, old, updated []string) {
+		t.Helper()
+		if got := search(engine, "recovery"); !reflect.DeepEqual(got, old) {
+			t.Errorf("%s: old content matched by %v, want %v", name, got, old)
+		}
+		if got := search(engine, "replacement"); !reflect.DeepEqual(got, updated) {
+			t.Errorf("%s: new content matched by %v, want %v", name, got, updated)
+		}
+	}
+	all := docsExcept(10)
+	sort.Strings(all)
+	assertSearch("reader before changes", reader, all, []string{})
+
+	deleteDoc(t, engine, "doc2")
+	deleteDoc(t, engine, "doc7")
+	replacement := index.Document{Fields: map[string]string{index.FieldTitle: "document 4", index.FieldBody: "replacement"}}
+	if err := engine.UpdateDocument("doc4", replacement); err != nil {
+		t.Fatal(err)
+	}
+	old := docsExcept(10, "doc2", "doc4", "doc7")
+	sort.Strings(old)
+	assertSearch("updated in memory", engine, old, []string{"doc4"})
+	assertLive(t, engine, 10, docsExcept(10, "doc2", "doc7"), 2)
+	if err := engine.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	assertSearch("updated and flushed", engine, old, []string{"doc4"})
+
+	// The reader serves its bitmaps until it reloads them, with the new
+	// segment.
+	assertSearch("reader before reload", reader, all, []string{})
+	if n := reader.ReloadSegments(); n != 1 {
+		t.Errorf("reader loaded %d new segments, want 1", n)
+	}
+	assertSearch("reader after reload", reader, old, []string{"doc4"})
+	assertLive(t, reader, 10, docsExcept(10, "doc2", "doc7"), 3)
+
+	if err := engine.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range segmentFiles(t, dir)[:2] {
+		if _, err := os.Stat(filepath.Join(dir, strings.TrimSuffix(name, ".spdx")+segment.LiveDocsExt)); err != nil {
+			t.Errorf("deletion bitmap of %s: %v", name, err)
+		}
+	}
+	engine = openEngine(t, dir)
+	defer engine.Close()
+	assertSearch("restarted", engine, old, []string{"doc4"})
+	assertLive(t, engine, 10, docsExcept(10, "doc2", "doc7"), 3)
+}
+
+// indexStats are the collection and document statistics an engine scores
+// with.
+type indexStats struct {
+	Docs         int64
+	AvgDocLength float64
+	FieldTokens  map[string]int64
+	Fields       []string
+	DocLengths   map[string]int
+	FieldLengths map[string]map[string]int
+}
+
+// statsOf returns the statistics of engine, and the lengths of the live
+// documents of doc0 to doc<n-1>. Fields whose every token was deleted are
+// left out of FieldTokens.
+func statsOf(t *testing.T, engine *indexer.Engine, n int) indexStats {
+	t.Helper()
+	view := engine.AcquireView()
+	defer view.Close()
+	s := indexStats{
+		Docs:         view.TotalDocs(),
+		AvgDocLength: view.AvgDocLength(),
+		FieldTokens:  map[string]int64{},
+		Fields:       view.Fields(),
+		DocLengths:   map[string]int{},
+		FieldLengths: map[string]map[string]int{},
+	}
+	for field, tokens := range view.FieldTokens() {
+		if tokens != 0 {
+			s.FieldTokens[field] = tokens
+		}
+	}
+	for d := 0; d < n; d++ {
+		docID := fmt.Sprintf("doc%d", d)
+		if doc, ok := view.DocNumber(docID); ok {
+			s.DocLengths[docID] = view.DocLength(doc)
+			s.FieldLengths[docID] = view.FieldLengths(doc)
+			if got := engine.GetDocLength(docID); got != s.DocLengths[docID] {
+				t.Errorf("GetDocLength(%s) = %d, view has %d", docID, got, s.DocLengths[docID])
+			}
+		}
+	}
+	return s
+}
+
+// statsDoc returns a document of fields of varying lengths, with stop
+// words, and a summary field in some.
+func statsDoc(d int) index.Document {
+	doc := index.Document{Fields: map[string]string{
+		index.FieldTitle: strings.Repeat("statistics ", d%4+1),
+		index.FieldBody:  strings.Repeat("the length of a field ", d%5) + "persisted",
+	}}
+	if d%3 == 0 {
+		doc.Fields["summary"] = strings.Repeat("summary words ", d%7+1)
+	}
+	return doc
+}
+
+// TestPersistedStatsMatchMemory applies the same changes to an engine that
+// flushes, merges and restarts and to one that keeps every document in
+// memory, and checks that their statistics agree throughout.
+func TestPersistedStatsMatchMemory(t *testing.T) {
+	dir := t.TempDir()
+	engine := openEngine(t, dir)
+	memory := openEngine(t, t.TempDir())
+	defer memory.Close()
+	const n = 70
+	compare := func(stage string) {
+		t.Helper()
+		if got, want := statsOf(t, engine, n), statsOf(t, memory, n); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: statistics\n%+v\nwant\n%+v", stage, got, want)
+		}
+	}
+	add := func(from, to int) {
+		t.Helper()
+		for d := from; d < to; d++ {
+			for _, e := range []*indexer.Engine{engine, memory} {
+				if err := e.IndexDocument(fmt.Sprintf("doc%d", d), statsDoc(d)); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	flush := func() {
+		t.Helper()
+		if err := engine.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	add(0, 20)
+	compare("in memory")
+	flush()
+	compare("flushed")
+	add(20, 40)
+	flush()
+	add(40, 60)
+	flush()
+	compare("three segments")
+	if merged, err := engine.MaybeMerge(); err != nil || !merged {
+		t.Fatalf("merging: %v, %v", merged, err)
+	}
+	if got := engine.SegmentCount(); got != 1 {
+		t.Fatalf("segments after merging = %d, want 1", got)
+	}
+	compare("merged")
+
+	for _, e := range []*indexer.Engine{engine, memory} {
+		deleteDoc(t, e, "doc3")
+		deleteDoc(t, e, "doc45")
+		for _, d := range []int{10, 50} {
+			if err := e.UpdateDocument(fmt.Sprintf("doc%d", d), statsDoc(d+1)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	add(60, n)
+	compare("deleted and updated")
+	flush()
+	compare("deleted, updated and flushed")
+
+	if err := engine.Close(); err != nil {
+		t.Fatal(err)
+	}
+	engine = openEngine(t, dir)
+	defer engine.Close()
+	compare("restarted")
+
+	// The statistics the segments persist, less their deletions, add up to
+	// those of the live documents.
+	var docs int64
+	tokens := map[string]int64{}
+	for _, name := range segmentFiles(t, dir) {
+		r, err := segment.OpenReader(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats := r.Stats()
+		docs += int64(stats.Docs)
+		for field, n := range stats.FieldTokens {
+			if n != 0 {
+				tokens[field] += n
+			}
+		}
+		r.Close()
+	}
+	want := statsOf(t, memory, n)
+	if docs != want.Docs || !reflect.DeepEqual(tokens, want.FieldTokens) {
+		t.Errorf("segments persist %d documents of %v tokens, want %d of %v", docs, tokens, want.Docs, want.FieldTokens)
+	}
+}
+
+// TestViewDoesNotSeeLaterUpdates takes a view, then updates, removes and
+// adds documents, held in a segment or in memory, and checks that the view
+// never serves their new content while a later view does.
+func TestViewDoesNotSeeLaterUpdates(t *testing.T) {
+	for _, flushed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("flushed=%v", flushed), func(t *testing.T) {
+			engine := openEngine(t, t.TempDir())
+			defer engine.Close()
+			for d := 0; d < 4; d++ {
+				doc := index.Document{Fields: map[string]string{index.FieldBody: "original"}}
+				if err := engine.IndexDocument(fmt.Sprintf("doc%d", d), doc); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if flushed {
+				if err := engine.Flush(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before := engine.AcquireView()
+			defer before.Close()
+
+			replacement := index.Document{Fields: map[string]string{index.FieldBody: "replacement"}}
+			if err := engine.UpdateDocument("doc1", replacement); err != nil {
+				t.Fatal(err)
+			}
+			deleteDoc(t, engine, "doc2")
+			if err := engine.IndexDocument("doc2", replacement); err != nil {
+				t.Fatal(err)
+			}
+			if err := engine.IndexDocument("doc4", replacement); err != nil {
+				t.Fatal(err)
+			}
+			after := engine.AcquireView()
+			defer after.Close()
+
+			term := func(word string) string {
+				return index.FieldTerm(index.FieldBody, engine.Analyzer().Analyze(word)[0].Term)
+			}
+			matching := func(view *indexer.View, word string) []string {
+				postings, err := view.Postings(term(word))
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids := []string{}
+				for _, p := range postings {
+					doc, ok, err := view.Stored(p.Doc)
+					if err != nil || !ok || doc.Fields[index.FieldBody] != word {
+						t.Errorf("%s of a posting of %q is stored as %v, %v, %v", view.DocID(p.Doc), word, doc, ok, err)
+					}
+					ids = append(ids, view.DocID(p.Doc))
+				}
+				sort.Strings(ids)
+				return ids
+			}
+			tests := []struct {
+				name string
+				view *indexer.View
+				word string
+				want []string
+			}{
+				{"earlier view", before, "original", []string{"doc0", "doc3"}},
+				{"earlier view", before, "replacement", []string{}},
+				{"later view", after, "original", []string{"doc0", "doc3"}},
+				{"later view", after, "replacement", []string{"doc1", "doc2", "doc4"}},
+			}
+			for _, tc := range tests {
+				if got := matching(tc.view, tc.word); !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("%s: %q matches %v, want %v", tc.name, tc.word, got, tc.want)
+				}
+			}
+			for _, docID := range []string{"doc1", "doc2", "doc4"} {
+				if doc, ok := before.DocNumber(docID); ok {
+					stored, _, _ := before.Stored(doc)
+					t.Errorf("earlier view holds %s as %v", docID, stored)
+				}
+				if _, ok := after.DocNumber(docID); !ok {
+					t.Errorf("later view does not hold %s", docID)
+				}
+			}
+		})
+	}
+}
+
+// TestChangedAnalyzerIsRefused checks that an index cannot be opened with
+// another analyzer than the one it was built with, read-only or not, and
+// that an index whose segments predate the record of its analyzer opens
+// only with english_legacy, and matches its documents with it.
+func TestChangedAnalyzerIsRefused(t *testing.T) {
+	open := func(dir, analyzer string, readOnly bool) (*indexer.Engine, error) {
+		return indexer.NewEngine(config.IndexerConfig{
+			DataDir:        dir,
+			SegmentMaxSize: 100 * 1024 * 1024,
+			Analyzer:       analyzer,
+			ReadOnly:       readOnly,
+		})
+	}
+	build := func(dir, analyzer string) {
+		t.Helper()
+		engine, err := open(dir, analyzer, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc := index.Document{Fields: map[string]string{index.FieldBody: "The runners were running"}}
+		if err := engine.IndexDocument("doc0", doc); err != nil {
+			t.Fatal(err)
+		}
+		if err := engine.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		engine.Close()
+	}
+	check := func(dir, analyzer string, readOnly, ok bool) *indexer.Engine {
+		t.Helper()
+		engine, err := open(dir, analyzer, readOnly)
+		if ok && err != nil {
+			t.Fatalf("opening with %q (read-only %v): %v", analyzer, readOnly, err)
+		}
+		if !ok {
+			if !errors.Is(err, indexer.ErrAnalyzerChanged) {
+				t.Errorf("opening with %q (read-only %v): %v, want ErrAnalyzerChanged", analyzer, readOnly, err)
+			}
+			if engine != nil {
+				engine.Close()
+			}
+			return nil
+		}
+		return engine
+	}
+
+	dir := t.TempDir()
+	build(dir, "")
+	for _, readOnly := range []bool{false, true} {
+		check(dir, "", readOnly, true).Close()
+		check(dir, analysis.DefaultAnalyzer, readOnly, true).Close()
+		check(dir, "standard", readOnly, false)
+		check(dir, "english_legacy", readOnly, false)
+	}
+
+	// A read-only engine records nothing, so an indexer may still choose.
+	fresh := t.TempDir()
+	check(fresh, "whitespace", true, true).Close()
+	check(fresh, "standard", false, true).Close()
+	check(fresh, "whitespace", false, false)
+
+	legacy := t.TempDir()
+	build(legacy, "english_legacy")
+	if err := os.Remove(filepath.Join(legacy, "analyzer")); err != nil {
+		t.Fatal(err)
+	}
+	check(legacy, "", true, false)
+	check(legacy, "", false, false)
+	check(legacy, "english_legacy", true, true).Close()
+	engine := check(legacy, "english_legacy", false, true)
+	defer engine.Close()
+	plan, err := parser.Parse("runner", engine.Analyzer(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := executor.New(engine, ranker.Config{}, executor.ExpansionConfig{}).Execute(context.Background(), plan, executor.Options{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := docIDs(res.Results); !reflect.DeepEqual(got, []string{"doc0"}) {
+		t.Errorf("legacy index matches %v, want [doc0]", got)
+	}
+	if data, err := os.ReadFile(filepath.Join(legacy, "analyzer")); err != nil || strings.TrimSpace(string(data)) != "english_legacy" {
+		t.Errorf("analyzer recorded on opening a legacy index: %q, %v", data, err)
+	}
+}
@@ -0,0 +1,205 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/executor"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/handler"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/highlight"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/config"
+)
+
+// ---------------------------------------------------------------------------
+// Helpers
+// ---------------------------------------------------------------------------
+
+// openMetadataEngine opens an engine over a new directory that indexes
+// category as a keyword and price as a float.
+func openMetadataEngine(t *testing.T) *indexer.Engine {
+	t.Helper()
+	engine, err := indexer.NewEngine(config.IndexerConfig{
+		DataDir:        t.TempDir(),
+		SegmentMaxSize: 100 * 1024 * 1024,
+		Metadata:       map[string]string{"category": "keyword", "price": "float"},
+	})
+	if err != nil {
+		t.Fatalf("opening engine: %v", err)
+	}
+	t.Cleanup(func() { engine.Close() })
+	return engine
+}
+
+// pagedDoc returns doc d of the pagination corpus. Scores and sort values
+// repeat, so that ties are broken by document ID, and some documents lack
+// a category or a price.
+func pagedDoc(d int) index.Document {
+	doc := index.Document{Fields: map[string]string{
+		index.FieldTitle: "paged document",
+		index.FieldBody:  strings.Repeat("search ", d%4+1) + "results",
+	}}
+	doc.Metadata = map[string]any{}
+	if d%5 != 0 {
+		doc.Metadata["category"] = []string{"news", "sports", "tech"}[d%3]
+	}
+	if d%7 != 0 {
+		doc.Metadata["price"] = float64(d%6) / 2
+	}
+	return doc
+}
+
+// pagedExecutors returns executors over the same 60 documents: one engine
+// holding them in two segments and its memory index, and three shards.
+func pagedExecutors(t *testing.T) (map[string]handler.SearchExecutor, index.Schema) {
+	t.Helper()
+	single := openMetadataEngine(t)
+	shards := map[int]*indexer.Engine{}
+	for s := 0; s < 3; s++ {
+		shards[s] = openMetadataEngine(t)
+	}
+	for d := 0; d < 60; d++ {
+		docID := fmt.Sprintf("doc%02d", d)
+		if err := single.IndexDocument(docID, pagedDoc(d)); err != nil {
+			t.Fatal(err)
+		}
+		if err := shards[d%3].IndexDocument(docID, pagedDoc(d)); err != nil {
+			t.Fatal(err)
+		}
+		if d == 19 || d == 39 {
+			if err := single.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return map[string]handler.SearchExecutor{
+		"single":  executor.New(single, ranker.Config{}, executor.ExpansionConfig{}),
+		"sharded": executor.NewSharded(shards, ranker.Config{}, executor.ExpansionConfig{}),
+	}, single.Schema()
+}
+
+// docIDs returns the IDs of results.
+func docIDs(results []ranker.ScoredDoc) []string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.DocID
+	}
+	return ids
+}
+
+// ---------------------------------------------------------------------------
+// Tests
+// ---------------------------------------------------------------------------
+
+// TestSearchAfterReproducesRanking pages through every match with the
+// next_search_after cursors, passed through their string form as a client
+// would, and checks that the pages add up to the full ranking, for the
+// default order and for sorts on several keys with missing values.
+func TestSearchAfterReproducesRanking(t *testing.T) {
+	executors, schema := pagedExecutors(t)
+	plan, err := parser.Parse("search", analysis.Default(), schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sortStr := range []string{"", "category,price:desc", "price,_score", "category:desc,_score:asc"} {
+		var sort []ranker.SortField
+		if sortStr != "" {
+			if sort, err = ranker.ParseSort(sortStr, schema); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for name, exec := range executors {
+			t.Run(name+"/"+sortStr, func(t *testing.T) {
+				ctx := context.Background()
+				full, err := exec.Execute(ctx, plan, executor.Options{Sort: sort, ExactTotal: true})
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := docIDs(full.Results)
+				if len(want) != 60 {
+					t.Fatalf("full ranking holds %d documents, want 60", len(want))
+				}
+
+				var got []string
+				var after *ranker.Cursor
+				for page := 0; page < 20; page++ {
+					res, err := exec.Execute(ctx, plan, executor.Options{Limit: 7, Sort: sort, SearchAfter: after})
+					if err != nil {
+						t.Fatal(err)
+					}
+					got = append(got, docIDs(res.Results)...)
+					if len(res.Results) < 7 {
+						break
+					}
+					cursor, err := ranker.ParseCursor(ranker.CursorOf(res.Results[len(res.Results)-1]).String())
+					if err != nil {
+						t.Fatal(err)
+					}
+					after = &cursor
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("paged ranking\n%v\nwant\n%v", got, want)
+				}
+			})
+		}
+	}
+}
+
+// TestSearchPagingErrors checks that the search handler rejects an offset
+// above its maximum and a cursor taken under another sort with 400, and
+// hands out cursors it accepts.
+func TestSearchPagingErrors(t *testing.T) {
+	executors, schema := pagedExecutors(t)
+	h := handler.New(executors["sharded"], analysis.Default(), schema, nil, nil, nil, nil, highlight.Config{}, 10, 100, 20)
+	search := func(params url.Values) (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		h.Search(rec, httptest.NewRequest(http.MethodGet, "/api/v1/search?"+params.Encode(), nil))
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decoding response to %s: %v", params.Encode(), err)
+		}
+		return rec.Code, body
+	}
+	cursorFor := func(sortStr string) string {
+		t.Helper()
+		code, body := search(url.Values{"q": {"search"}, "limit": {"5"}, "sort": {sortStr}})
+		cursor, ok := body["next_search_after"].(string)
+		if code != http.StatusOK || !ok {
+			t.Fatalf("sort %q: status %d, next_search_after %v", sortStr, code, body["next_search_after"])
+		}
+		return cursor
+	}
+	byScore, byPrice := cursorFor(""), cursorFor("price,category")
+
+	tests := []struct {
+		name   string
+		params url.Values
+		status int
+	}{
+		{"offset at maximum", url.Values{"q": {"search"}, "offset": {"20"}}, http.StatusOK},
+		{"offset above maximum", url.Values{"q": {"search"}, "offset": {"21"}}, http.StatusBadRequest},
+		{"from above maximum", url.Values{"q": {"search"}, "from": {"1000"}}, http.StatusBadRequest},
+		{"matching cursor", url.Values{"q": {"search"}, "search_after": {byPrice}, "sort": {"price,category"}}, http.StatusOK},
+		{"score cursor under a sort", url.Values{"q": {"search"}, "search_after": {byScore}, "sort": {"price,category"}}, http.StatusBadRequest},
+		{"sort cursor in default order", url.Values{"q": {"search"}, "search_after": {byPrice}}, http.StatusBadRequest},
+		{"cursor of fewer keys", url.Values{"q": {"search"}, "search_after": {byPrice}, "sort": {"price,category,_score"}}, http.StatusBadRequest},
+		{"malformed cursor", url.Values{"q": {"search"}, "search_after": {"not a cursor"}}, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		code, body := search(tc.params)
+		if code != tc.status {
+			t.Errorf("%s: status %d (%v), want %d", tc.name, code, body["error"], tc.status)
+		}
+	}
+}
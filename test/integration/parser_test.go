@@ -0,0 +1,283 @@
+package integration
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+)
+
+// querySchema types the metadata keys of the parsed queries.
+var querySchema = index.Schema{
+	"lang":      index.TypeKeyword,
+	"price":     index.TypeInteger,
+	"rating":    index.TypeFloat,
+	"published": index.TypeDate,
+}
+
+// namedAnalyzer returns the registered analyzer name.
+func namedAnalyzer(t *testing.T, name string) analysis.Analyzer {
+	t.Helper()
+	a, err := analysis.Get(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// parseQuery parses query with the english analyzer, the default, and returns
+// the string of its root, or "" if it has none.
+func parseQuery(t *testing.T, query string) string {
+	t.Helper()
+	plan, err := parser.Parse(query, namedAnalyzer(t, "english"), querySchema)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", query, err)
+	}
+	if plan.Root == nil {
+		return ""
+	}
+	return plan.Root.String()
+}
+
+func TestParsePrecedence(t *testing.T) {
+	tests := []struct {
+		query, want string
+	}{
+		{"apple banana", "+appl +banana"},
+		{"apple AND banana", "+appl +banana"},
+		{"apple and banana", "+appl +banana"},
+		{"apple OR banana", "appl OR banana"},
+		// AND binds tighter than OR, on either side.
+		{"apple banana OR cherry", "(+appl +banana) OR cherri"},
+		{"apple OR banana cherry", "appl OR (+banana +cherri)"},
+		{"apple AND NOT banana OR cherry", "(+appl -banana) OR cherri"},
+		{"(apple OR banana) cherry", "+(appl OR banana) +cherri"},
+		// NOT and the prefixes apply to the next clause only.
+		{"NOT apple banana", "-appl +banana"},
+		{"apple -banana", "+appl -banana"},
+		{"+apple banana", "+appl +banana"},
+		{"#apple banana", "#appl +banana"},
+		{"+apple OR banana", "+appl OR banana"},
+		// Stop words are dropped, and words of several terms require
+		// them all.
+		{"the apple", "appl"},
+		{"the", ""},
+		{"", ""},
+	}
+	for _, tc := range tests {
+		if got := parseQuery(t, tc.query); got != tc.want {
+			t.Errorf("Parse(%q) = %s, want %s", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestParseGroupsAndBoosts(t *testing.T) {
+	tests := []struct {
+		query, want string
+	}{
+		{"(apple OR banana OR cherry)@2", "(appl OR banana OR cherri)@2"},
+		{"apple^2", "appl^2"},
+		{"apple^2 banana", "+appl^2 +banana"},
+		{"apple^2 (banana cherry)^1.5", "+appl^2 +(+banana +cherri)^1.5"},
+		{`"quick fox"^3`, `"quick fox"^3`},
+		{"(apple OR banana)@1^2", "(appl OR banana)@1^2"},
+	}
+	for _, tc := range tests {
+		if got := parseQuery(t, tc.query); got != tc.want {
+			t.Errorf("Parse(%q) = %s, want %s", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestParseFieldsAndRanges(t *testing.T) {
+	tests := []struct {
+		query, want string
+	}{
+		{"title:apple", "title:appl"},
+		{"TITLE:apple", "title:appl"},
+		{"title:(apple OR banana)", "title:appl OR title:banana"},
+		{`title:"quick fox"~2`, `title:"quick fox"~2`},
+		{"data*", "data*"},
+		{"d?ta", "d?ta"},
+		{"databse~1", "databs~1"},
+		{"lang:en", "lang:en"},
+		{`lang:"en gb"`, `lang:en\ gb`},
+		{"price:[10 TO 20}", "price:[10 TO 20}"},
+		{"price:{* TO 5]", "price:{* TO 5]"},
+		{"published:2026-01-31", "published:[2026-01-31T00:00:00Z TO 2026-02-01T00:00:00Z}"},
+		{"apple price:[10 TO 20]", "+appl +price:[10 TO 20]"},
+	}
+	for _, tc := range tests {
+		if got := parseQuery(t, tc.query); got != tc.want {
+			t.Errorf("Parse(%q) = %s, want %s", tc.query, got, tc.want)
+		}
+	}
+}
+
+// TestParseRangeBounds checks the values that ranges of each type hold at
+// and around their bounds, inclusive, exclusive and open, and that a date
+// without a time stands for the whole day.
+func TestParseRangeBounds(t *testing.T) {
+	tests := []struct {
+		query, want string
+		in, out     []string
+	}{
+		{"price:[10 TO 20]", "price:[10 TO 20]", []string{"10", "15", "20"}, []string{"9", "21"}},
+		{"price:{10 TO 20}", "price:{10 TO 20}", []string{"11", "19"}, []string{"10", "20"}},
+		{"price:[10 TO *]", "price:[10 TO *]", []string{"10", "9007199254740992"}, []string{"9", "-10"}},
+		{"price:{* TO 10}", "price:{* TO 10}", []string{"9", "-9007199254740992"}, []string{"10", "11"}},
+		{"price:[* TO *]", "price:[* TO *]", []string{"0", "-5", "5"}, nil},
+		{`price:"-3"`, `price:\-3`, []string{"-3"}, []string{"-2", "-4"}},
+		{"rating:[-1.5 TO 2.25}", "rating:[-1.5 TO 2.25}", []string{"-1.5", "0", "2.2499"}, []string{"-1.5001", "2.25"}},
+		{"rating:{0.1 TO 1e3]", "rating:{0.1 TO 1000]", []string{"0.10001", "1000"}, []string{"0.1", "1000.5"}},
+		{"lang:[de TO fr}", "lang:[de TO fr}", []string{"de", "en", "en-gb"}, []string{"da", "fr", "fra"}},
+		{`lang:{"" TO b]`, `lang:{"" TO b]`, []string{"a", "b"}, []string{"", "ba"}},
+		{
+			"published:[2026-01-01 TO 2026-01-31]",
+			"published:[2026-01-01T00:00:00Z TO 2026-02-01T00:00:00Z}",
+			[]string{"2026-01-01T00:00:00Z", "2026-01-31T23:59:59.999Z"},
+			[]string{"2025-12-31T23:59:59.999Z", "2026-02-01T00:00:00Z"},
+		},
+		{
+			"published:{2026-01-01 TO 2026-01-31}",
+			"published:[2026-01-02T00:00:00Z TO 2026-01-31T00:00:00Z}",
+			[]string{"2026-01-02", "2026-01-30T23:59:59.999Z"},
+			[]string{"2026-01-01T23:59:59.999Z", "2026-01-31"},
+		},
+		{
+			"published:{2026-01-01T12:00:00Z TO 2026-01-02T00:00:00+02:00]",
+			"published:{2026-01-01T12:00:00Z TO 2026-01-01T22:00:00Z]",
+			[]string{"2026-01-01T12:00:00.001Z", "2026-01-01T22:00:00Z"},
+			[]string{"2026-01-01T12:00:00Z", "2026-01-01T22:00:00.001Z"},
+		},
+		{"published:[2026-03-01 TO *]", "published:[2026-03-01T00:00:00Z TO *]", []string{"2026-03-01", "2100-01-01"}, []string{"2026-02-28T23:59:59.999Z"}},
+		{"published:{* TO 2026-03-01]", "published:{* TO 2026-03-02T00:00:00Z}", []string{"1970-01-01", "2026-03-01T23:59:59.999Z"}, []string{"2026-03-02"}},
+	}
+	for _, tc := range tests {
+		plan, err := parser.Parse(tc.query, namedAnalyzer(t, "english"), querySchema)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.query, err)
+		}
+		q, ok := plan.Root.(*parser.RangeQuery)
+		if !ok {
+			t.Fatalf("Parse(%q) = %v, want a *parser.RangeQuery", tc.query, plan.Root)
+		}
+		if got := q.String(); got != tc.want {
+			t.Errorf("Parse(%q) = %s, want %s", tc.query, got, tc.want)
+		}
+		for _, values := range []struct {
+			texts []string
+			want  bool
+		}{{tc.in, true}, {tc.out, false}} {
+			for _, text := range values.texts {
+				v, err := q.Type.Parse(text)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := q.Range.Contains(q.Type, v); got != values.want {
+					t.Errorf("%s contains %s = %v, want %v", tc.query, text, got, values.want)
+				}
+			}
+		}
+	}
+}
+
+func TestParseSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		query  string
+		column int
+		msg    string
+	}{
+		{"(apple", 7, "expected ')' to close the group opened at column 1"},
+		{"apple)", 6, "unexpected ')'"},
+		{"apple AND", 10, "expected a clause after AND"},
+		{"OR apple", 1, "expected a clause, found OR"},
+		{"()", 1, "empty group"},
+		{`"open`, 1, "unterminated phrase"},
+		{"apple^x", 6, "expected a number after '^'"},
+		{"databse~3", 8, "fuzzy edit distance must be 0, 1 or 2"},
+		{"price:[a TO 5]", 7, `"a" is not a integer value`},
+		{"(apple banana)@3", 15, "minimum_should_match 3 exceeds"},
+		{"(apple banana cherry)@2", 22, "exceeds the 0 optional clauses"},
+		// Columns count characters, not bytes.
+		{"ζήτα)", 5, "unexpected ')'"},
+	}
+	for _, tc := range tests {
+		_, err := parser.Parse(tc.query, namedAnalyzer(t, "english"), querySchema)
+		var se *parser.SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("Parse(%q) error = %v, want a *parser.SyntaxError", tc.query, err)
+			continue
+		}
+		if se.Column != tc.column || !strings.Contains(se.Msg, tc.msg) {
+			t.Errorf("Parse(%q) error at column %d: %q, want column %d: %q", tc.query, se.Column, se.Msg, tc.column, tc.msg)
+		}
+	}
+}
+
+// TestStringRoundTrip checks that a query parses to the same query from its
+// string, with an analyzer that leaves terms as they are written.
+func TestStringRoundTrip(t *testing.T) {
+	a := namedAnalyzer(t, "standard")
+	for _, query := range []string{
+		"apple banana OR cherry",
+		"apple OR banana cherry",
+		"apple AND NOT banana OR cherry",
+		"#apple +banana -cherry",
+		"+apple OR banana",
+		"(apple OR banana OR cherry)@2",
+		"apple^2 (banana cherry)^1.5",
+		`title:(apple OR banana) title:"quick fox"~2`,
+		"data* d?ta databse~1",
+		`lang:"en gb" price:{* TO 5] published:2026-01-31`,
+		`a\:b \and \(x\)`,
+	} {
+		plan, err := parser.Parse(query, a, querySchema)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", query, err)
+		}
+		s := plan.Root.String()
+		again, err := parser.Parse(s, a, querySchema)
+		if err != nil {
+			t.Errorf("Parse(%q), of the string of %q: %v", s, query, err)
+			continue
+		}
+		if got := again.Root.String(); got != s {
+			t.Errorf("string of %q = %s, parsed again = %s", query, s, got)
+		}
+	}
+}
+
+func TestQueryPlanCorrect(t *testing.T) {
+	fixes := map[string]string{"Computr": "computer", "sciense": "science", "and": "AND"}
+	tests := []struct {
+		query, want string
+		ok          bool
+	}{
+		{"Computr sciense", "computer science", true},
+		{"title:Computr^2 OR sciense", "title:computer^2 OR science", true},
+		{`"Computr sciense" -sciense`, `"Computr sciense" -sciense`, false},
+		{"computer", "computer", false},
+		// Replacements are escaped.
+		{`sciense \and`, `science \AND`, true},
+	}
+	for _, tc := range tests {
+		plan, err := parser.Parse(tc.query, namedAnalyzer(t, "standard"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := plan.Correct(func(word string, term parser.Term) (string, bool) {
+			if term.Text != strings.ToLower(word) {
+				t.Errorf("word %q has term %q", word, term.Text)
+			}
+			fix, ok := fixes[word]
+			return fix, ok
+		})
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("Correct(%q) = %q, %v, want %q, %v", tc.query, got, ok, tc.want, tc.ok)
+		}
+	}
+}
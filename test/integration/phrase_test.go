@@ -0,0 +1,157 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/executor"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/handler"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+)
+
+// phraseExecutor returns an executor over an engine holding the given
+// bodies, keyed by document ID, with titles from titles if present. The
+// first half of the documents, in ID order, is flushed to a segment.
+func phraseExecutor(t *testing.T, bodies, titles map[string]string) handler.SearchExecutor {
+	t.Helper()
+	engine := openEngine(t, t.TempDir())
+	t.Cleanup(func() { engine.Close() })
+	for d := 0; d < len(bodies); d++ {
+		docID := fmt.Sprintf("doc%d", d)
+		doc := index.Document{Fields: map[string]string{index.FieldBody: bodies[docID]}}
+		if title, ok := titles[docID]; ok {
+			doc.Fields[index.FieldTitle] = title
+		}
+		if err := engine.IndexDocument(docID, doc); err != nil {
+			t.Fatal(err)
+		}
+		if d == len(bodies)/2 {
+			if err := engine.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return executor.New(engine, ranker.Config{}, executor.ExpansionConfig{})
+}
+
+// phraseResults runs query and returns the IDs of the matching documents
+// in ranked order and their scores.
+func phraseResults(t *testing.T, exec handler.SearchExecutor, query string) ([]string, map[string]float64) {
+	t.Helper()
+	plan, err := parser.Parse(query, analysis.Default(), nil)
+	if err != nil {
+		t.Fatalf("parsing %q: %v", query, err)
+	}
+	res, err := exec.Execute(context.Background(), plan, executor.Options{Limit: 100})
+	if err != nil {
+		t.Fatalf("searching %q: %v", query, err)
+	}
+	scores := make(map[string]float64, len(res.Results))
+	for _, r := range res.Results {
+		scores[r.DocID] = r.Score
+	}
+	return docIDs(res.Results), scores
+}
+
+// TestPhraseMatching checks which documents exact and sloppy phrases match,
+// within one field, with repeated and reordered terms, and with the stop
+// words analysis drops on both sides.
+func TestPhraseMatching(t *testing.T) {
+	exec := phraseExecutor(t, map[string]string{
+		"doc0": "quick brown fox jumps",
+		"doc1": "brown quick fox",
+		"doc2": "quick red brown fox",
+		"doc3": "quick red green brown fox",
+		"doc4": "fox brown quick",
+		"doc5": "buffalo",
+		"doc6": "buffalo buffalo",
+		"doc7": "quick",
+		"doc8": "state of the art",
+	}, map[string]string{
+		"doc7": "brown fox",
+	})
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{`"quick brown fox"`, []string{"doc0"}},
+		{`"brown fox"`, []string{"doc0", "doc2", "doc3", "doc7"}},
+		{`"fox quick"`, nil},
+		{`"quick brown"~1`, []string{"doc0", "doc2"}},
+		{`"quick brown"~2`, []string{"doc0", "doc1", "doc2", "doc3", "doc4"}},
+		{`"quick fox"~2`, []string{"doc0", "doc1", "doc2"}},
+		{`"quick fox"~3`, []string{"doc0", "doc1", "doc2", "doc3", "doc4"}},
+		{`"fox quick"~2`, []string{"doc1", "doc4"}},
+		{`"fox quick"~3`, []string{"doc0", "doc1", "doc4"}},
+		{`"fox quick"~4`, []string{"doc0", "doc1", "doc2", "doc4"}},
+		{`"buffalo buffalo"`, []string{"doc6"}},
+		{`"buffalo buffalo"~5`, []string{"doc6"}},
+		{`"quick brown fox"~5`, []string{"doc0", "doc1", "doc2", "doc3", "doc4"}},
+		{`title:"brown fox"`, []string{"doc7"}},
+		{`"quick brown"~9 -body:red`, []string{"doc0", "doc1", "doc4"}},
+		{`"state art"`, []string{"doc8"}},
+		{`"state in the art"`, []string{"doc8"}},
+		{`"art state"`, nil},
+	}
+	for _, tc := range tests {
+		got, _ := phraseResults(t, exec, tc.query)
+		if !sameDocs(got, tc.want) {
+			t.Errorf("%s matched %v, want %v", tc.query, got, tc.want)
+		}
+	}
+}
+
+// sameDocs reports whether got and want hold the same document IDs, in any
+// order.
+func sameDocs(got, want []string) bool {
+	seen := make(map[string]int, len(want))
+	for _, docID := range want {
+		seen[docID]++
+	}
+	for _, docID := range got {
+		seen[docID]--
+	}
+	for _, n := range seen {
+		if n != 0 {
+			return false
+		}
+	}
+	return len(got) == len(want)
+}
+
+// TestPhraseProximityScoring checks that, in documents of the same length,
+// a sloppy phrase scores lower the further apart its terms are, that an
+// exact occurrence scores above any sloppy one and that two occurrences
+// score above one.
+func TestPhraseProximityScoring(t *testing.T) {
+	exec := phraseExecutor(t, map[string]string{
+		"doc0": "alpha beta xx yy zz ww",
+		"doc1": "alpha xx beta yy zz ww",
+		"doc2": "alpha xx yy beta zz ww",
+		"doc3": "alpha xx yy zz beta ww",
+		"doc4": "alpha xx yy zz ww beta",
+		"doc5": "alpha beta xx alpha beta ww",
+		"doc6": "gamma xx yy zz ww vv",
+		"doc7": "delta xx yy zz ww vv",
+		"doc8": "gamma delta yy zz ww vv",
+		"doc9": "delta gamma yy zz ww vv",
+	}, nil)
+	got, scores := phraseResults(t, exec, `"alpha beta"~4`)
+	want := []string{"doc5", "doc0", "doc1", "doc2", "doc3", "doc4"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ranking %v, want %v", got, want)
+	}
+	for i := 1; i < len(got); i++ {
+		if scores[got[i]] >= scores[got[i-1]] {
+			t.Errorf("%s scores %v, not below %s at %v", got[i], scores[got[i]], got[i-1], scores[got[i-1]])
+		}
+	}
+	if got, _ := phraseResults(t, exec, `"alpha beta"~3`); !sameDocs(got, want[:5]) {
+		t.Errorf(`"alpha beta"~3 matched %v, want %v`, got, want[:5])
+	}
+}
@@ -0,0 +1,243 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/segment"
+)
+
+// buildSegtool builds cmd/segtool and returns the path of the binary.
+func buildSegtool(t *testing.T) string {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "segtool")
+	out, err := exec.Command("go", "build", "-o", bin, "github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/cmd/segtool").CombinedOutput()
+	if err != nil {
+		t.Fatalf("building segtool: %v\n%s", err, out)
+	}
+	return bin
+}
+
+// runSegtool runs segtool with args, which must succeed, and opens the one
+// segment it writes to out.
+func runSegtool(t *testing.T, bin, out string, args ...string) *segment.Reader {
+	t.Helper()
+	output, err := exec.Command(bin, args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("segtool %v: %v\n%s", args, err, output)
+	}
+	names := segmentFiles(t, out)
+	if len(names) != 1 {
+		t.Fatalf("segtool %v wrote %v, want one segment", args, names)
+	}
+	r, err := segment.OpenVerifiedReader(filepath.Join(out, names[0]), segment.VerifyFull)
+	if err != nil {
+		t.Fatalf("opening the output of segtool %v: %v", args, err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+// viewEntries returns the live postings of every term of engine, by
+// document ID.
+func viewEntries(t *testing.T, engine *indexer.Engine) []index.TermEntry {
+	t.Helper()
+	view := engine.AcquireView()
+	defer view.Close()
+	seen := map[string]bool{}
+	view.TermsWithPrefix("", func(term string, _ int) bool {
+		seen[term] = true
+		return true
+	})
+	var entries []index.TermEntry
+	for term := range seen {
+		postings, err := view.Postings(term)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(postings) == 0 {
+			continue
+		}
+		entry := index.TermEntry{Term: term}
+		for _, p := range postings {
+			entry.Postings = append(entry.Postings, index.Posting{DocID: view.DocID(p.Doc), Frequency: p.Frequency, Positions: p.Positions})
+		}
+		sort.Slice(entry.Postings, func(i, j int) bool { return entry.Postings[i].DocID < entry.Postings[j].DocID })
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Term < entries[j].Term })
+	return entries
+}
+
+// TestSegtoolConvert converts a version 1 segment, with a deleted document,
+// to the current version and checks that the output holds the postings
+// and document lengths of the live documents.
+func TestSegtoolConvert(t *testing.T) {
+	bin := buildSegtool(t)
+	var docs []index.DocLength
+	var entries []index.TermEntry
+	for d := 0; d < 6; d++ {
+		docs = append(docs, index.DocLength{DocID: fmt.Sprintf("doc%d", d), Fields: map[string]int{index.FieldBody: d + 2}})
+	}
+	for i, term := range []string{"alpha", "beta", "gamma", "only2"} {
+		entry := bodyEntry(term, nil)
+		for d, doc := range docs {
+			if term == "only2" && d != 2 || (d+i)%3 == 0 {
+				continue
+			}
+			positions := []int{i % 2}
+			if d%2 == 1 {
+				positions = append(positions, d+1)
+			}
+			entry.Postings = append(entry.Postings, index.Posting{DocID: doc.DocID, Frequency: len(positions), Positions: positions})
+		}
+		entries = append(entries, entry)
+	}
+	in := t.TempDir()
+	w, err := segment.NewVersionedWriter(in, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := w.Write(entries, docs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := segment.OpenReader(filepath.Join(in, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := r.Delete("doc2"); !ok || err != nil {
+		t.Fatalf("deleting doc2: %v, %v", ok, err)
+	}
+	r.Close()
+
+	out := t.TempDir()
+	converted := runSegtool(t, bin, out, "convert", "-out", out, "-version", fmt.Sprint(segment.FormatVersion), filepath.Join(in, name))
+	if v := converted.Header().Version; v != segment.FormatVersion {
+		t.Errorf("converted segment is version %d, want %d", v, segment.FormatVersion)
+	}
+	var want []index.TermEntry
+	for _, entry := range entries {
+		live := index.TermEntry{Term: entry.Term}
+		for _, p := range entry.Postings {
+			if p.DocID != "doc2" {
+				live.Postings = append(live.Postings, p)
+			}
+		}
+		if len(live.Postings) > 0 {
+			want = append(want, live)
+		}
+	}
+	assertEntries(t, converted, want)
+	if got, wantDocs := converted.Docs(), append(docs[:2:2], docs[3:]...); !reflect.DeepEqual(got, wantDocs) {
+		t.Errorf("converted documents %v, want %v", got, wantDocs)
+	}
+	if got := converted.Sources(); !reflect.DeepEqual(got, []string{name}) {
+		t.Errorf("converted segment sources %v, want [%s]", got, name)
+	}
+}
+
+// TestSegtoolMerge merges the segments of an engine, which hold deleted and
+// updated documents, and checks that the output holds the postings, stored
+// fields and doc values the engine serves, and that the engine serves the
+// same documents from it alone.
+func TestSegtoolMerge(t *testing.T) {
+	bin := buildSegtool(t)
+	dir := t.TempDir()
+	metadata := map[string]string{"category": "keyword"}
+	engine := openSchemaEngine(t, dir, metadata)
+	doc := func(d int, body string) index.Document {
+		return index.Document{
+			Fields:   map[string]string{index.FieldTitle: fmt.Sprintf("document %d", d), index.FieldBody: body},
+			Metadata: map[string]any{"category": []string{"news", "sports"}[d%2]},
+		}
+	}
+	for d := 0; d < 12; d++ {
+		if err := engine.IndexDocument(fmt.Sprintf("doc%d", d), doc(d, "segment merge tool")); err != nil {
+			t.Fatal(err)
+		}
+		if d%4 == 3 {
+			if err := engine.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	deleteDoc(t, engine, "doc1")
+	deleteDoc(t, engine, "doc6")
+	if err := engine.UpdateDocument("doc2", doc(2, "rewritten merge")); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := viewEntries(t, engine)
+	live := docsExcept(12, "doc1", "doc6")
+	stored := map[string]index.Document{}
+	view := engine.AcquireView()
+	for _, docID := range live {
+		n, _ := view.DocNumber(docID)
+		stored[docID], _, _ = view.Stored(n)
+	}
+	view.Close()
+	if err := engine.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := segmentFiles(t, dir)
+	if len(inputs) != 4 {
+		t.Fatalf("segments = %v, want 4", inputs)
+	}
+	var paths []string
+	for _, name := range inputs {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	out := t.TempDir()
+	merged := runSegtool(t, bin, out, append([]string{"merge", "-out", out}, paths...)...)
+	assertEntries(t, merged, want)
+	var docIDs []string
+	for ord, d := range merged.Docs() {
+		docIDs = append(docIDs, d.DocID)
+		got, ok, err := merged.StoredByOrdinal(uint32(ord))
+		if err != nil || !ok || !reflect.DeepEqual(got, stored[d.DocID]) {
+			t.Errorf("%s: stored %v, %v, %v, want %v", d.DocID, got, ok, err, stored[d.DocID])
+		}
+	}
+	sort.Strings(live)
+	if !reflect.DeepEqual(docIDs, live) {
+		t.Errorf("merged documents %v, want %v", docIDs, live)
+	}
+
+	// Placed in the data directory, the merged segment supersedes its
+	// inputs.
+	mergedName := segmentFiles(t, out)[0]
+	data, err := os.ReadFile(filepath.Join(out, mergedName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, mergedName), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	engine = openSchemaEngine(t, dir, metadata)
+	defer engine.Close()
+	if got := engine.SegmentCount(); got != 1 {
+		t.Errorf("segments after placing the merged one = %d, want 1", got)
+	}
+	if got := viewEntries(t, engine); !reflect.DeepEqual(got, want) {
+		t.Errorf("engine over the merged segment serves other postings")
+	}
+	view = engine.AcquireView()
+	defer view.Close()
+	for _, docID := range live {
+		n, ok := view.DocNumber(docID)
+		if v, has := view.Value("category", n); !ok || !has || v.Str != stored[docID].Metadata["category"] {
+			t.Errorf("%s: category %q, %v, want %v", docID, v.Str, has, stored[docID].Metadata["category"])
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/executor"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/handler"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/highlight"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/config"
+)
+
+// similarities lists every similarity model.
+var similarities = []ranker.Model{ranker.ModelBM25, ranker.ModelBM25Plus, ranker.ModelTFIDF, ranker.ModelDFR, ranker.ModelLMDirichlet}
+
+// rankingFromYAML loads a configuration file holding yaml and returns the
+// ranking configuration it selects.
+func rankingFromYAML(t *testing.T, yaml string) (ranker.Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ranker.ParseConfig(cfg.Search.Ranking)
+}
+
+// similarityEngine returns an engine holding 30 documents in which the
+// query terms occur at varying frequencies and document lengths, in a
+// segment and the memory index.
+func similarityEngine(t *testing.T) *indexer.Engine {
+	t.Helper()
+	engine := openEngine(t, t.TempDir())
+	t.Cleanup(func() { engine.Close() })
+	words := []string{"search", "analytics", "platform", "filler"}
+	for d := 0; d < 30; d++ {
+		var body string
+		for i, w := range words {
+			for n := (d*(i+3)/7 + i) % (i + 3); n > 0; n-- {
+				body += w + " "
+			}
+		}
+		doc := index.Document{Fields: map[string]string{index.FieldTitle: words[d%3], index.FieldBody: body}}
+		if err := engine.IndexDocument(fmt.Sprintf("doc%02d", d), doc); err != nil {
+			t.Fatal(err)
+		}
+		if d == 14 {
+			if err := engine.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return engine
+}
+
+// similarityScores returns the scores of the results of query, keyed by
+// document ID.
+func similarityScores(t *testing.T, exec *executor.Executor, engine *indexer.Engine, query string, model ranker.Model) map[string]float64 {
+	t.Helper()
+	plan, err := parser.Parse(query, engine.Analyzer(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := exec.Execute(context.Background(), plan, executor.Options{Limit: 30, Similarity: model})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scores := map[string]float64{}
+	for _, r := range res.Results {
+		scores[r.DocID] = r.Score
+	}
+	return scores
+}
+
+// TestSimilaritySelection checks that each similarity can be selected in
+// the configuration and per search, the latter taking precedence, with
+// the same scores, that the similarities score differently, and that an
+// unknown one is rejected in both places.
+func TestSimilaritySelection(t *testing.T) {
+	engine := similarityEngine(t)
+	const query = "search analytics OR platform"
+	base, err := rankingFromYAML(t, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults := executor.New(engine, base, executor.ExpansionConfig{})
+
+	byModel := map[ranker.Model]map[string]float64{}
+	for _, model := range similarities {
+		cfg, err := rankingFromYAML(t, fmt.Sprintf("search:\n  ranking:\n    similarity: %s\n", model))
+		if err != nil {
+			t.Fatalf("%s: %v", model, err)
+		}
+		if cfg.Similarity != model {
+			t.Fatalf("configuring %s selects %q", model, cfg.Similarity)
+		}
+		configured := similarityScores(t, executor.New(engine, cfg, executor.ExpansionConfig{}), engine, query, "")
+		requested := similarityScores(t, defaults, engine, query, model)
+		if len(configured) == 0 {
+			t.Fatalf("%s: no results", model)
+		}
+		if !reflect.DeepEqual(configured, requested) {
+			t.Errorf("%s: configured scores\n%v\ndiffer from requested ones\n%v", model, configured, requested)
+		}
+		// A search's similarity overrides the configured one.
+		for _, other := range similarities {
+			if got := similarityScores(t, executor.New(engine, cfg, executor.ExpansionConfig{}), engine, query, other); other != model && reflect.DeepEqual(got, configured) {
+				t.Errorf("requesting %s under a configured %s does not change the scores", other, model)
+			}
+		}
+		byModel[model] = configured
+	}
+	for i, a := range similarities {
+		for _, b := range similarities[i+1:] {
+			if reflect.DeepEqual(byModel[a], byModel[b]) {
+				t.Errorf("%s and %s score the same: %v", a, b, byModel[a])
+			}
+		}
+	}
+
+	if _, err := rankingFromYAML(t, "search:\n  ranking:\n    similarity: bm26\n"); err == nil {
+		t.Error("an unknown configured similarity is accepted")
+	}
+	if _, err := ranker.ParseModel("BM25"); err == nil {
+		t.Error("a similarity name in upper case is accepted")
+	}
+
+	h := handler.New(defaults, engine.Analyzer(), engine.Schema(), nil, nil, nil, nil, highlight.Config{}, 30, 100, 100)
+	for _, tc := range []struct {
+		similarity string
+		status     int
+	}{
+		{"", http.StatusOK},
+		{"dfr", http.StatusOK},
+		{"lm_dirichlet", http.StatusOK},
+		{"bm26", http.StatusBadRequest},
+		{"bm25 ", http.StatusBadRequest},
+	} {
+		params := url.Values{"q": {query}, "limit": {"30"}}
+		if tc.similarity != "" {
+			params.Set("similarity", tc.similarity)
+		}
+		rec := httptest.NewRecorder()
+		h.Search(rec, httptest.NewRequest(http.MethodGet, "/api/v1/search?"+params.Encode(), nil))
+		if rec.Code != tc.status {
+			t.Errorf("similarity=%q: status %d, want %d", tc.similarity, rec.Code, tc.status)
+			continue
+		}
+		if tc.status != http.StatusOK {
+			continue
+		}
+		var body struct {
+			Results []ranker.ScoredDoc `json:"results"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		model, _ := ranker.ParseModel(tc.similarity)
+		want := similarityScores(t, defaults, engine, query, model)
+		got := map[string]float64{}
+		for _, r := range body.Results {
+			got[r.DocID] = r.Score
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("similarity=%q: scores\n%v\nwant\n%v", tc.similarity, got, want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/executor"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/handler"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/highlight"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/config"
+)
+
+// storedDoc returns doc d of the stored fields corpus, version v of it,
+// with metadata that some documents lack.
+func storedDoc(d, v int) index.Document {
+	doc := index.Document{
+		Fields: map[string]string{
+			index.FieldTitle: fmt.Sprintf("Stored title %d, version %d", d, v),
+			index.FieldBody:  fmt.Sprintf("The stored body of document %d — «héllo»", d),
+		},
+		Metadata: map[string]any{"category": []string{"news", "tech"}[d%2]},
+	}
+	if d%3 != 0 {
+		doc.Metadata["price"] = float64(d) + 0.5
+	}
+	return doc
+}
+
+// TestStoredFieldsSurviveFlushAndMerge checks that the stored fields of
+// every document, flushed into segments, merged and updated, are those it
+// was last indexed with, and that a search returns only the fields it
+// names.
+func TestStoredFieldsSurviveFlushAndMerge(t *testing.T) {
+	engine, err := indexer.NewEngine(config.IndexerConfig{
+		DataDir:                t.TempDir(),
+		SegmentMaxSize:         100 * 1024 * 1024,
+		MaxSegmentsBeforeMerge: 2,
+		Metadata:               map[string]string{"category": "keyword", "price": "float"},
+	})
+	if err != nil {
+		t.Fatalf("opening engine: %v", err)
+	}
+	defer engine.Close()
+
+	want := map[string]index.Document{}
+	put := func(d, v int) {
+		t.Helper()
+		docID := fmt.Sprintf("doc%02d", d)
+		if err := engine.UpdateDocument(docID, storedDoc(d, v)); err != nil {
+			t.Fatal(err)
+		}
+		want[docID] = storedDoc(d, v)
+	}
+	check := func(stage string) {
+		t.Helper()
+		view := engine.AcquireView()
+		defer view.Close()
+		for docID, doc := range want {
+			n, ok := view.DocNumber(docID)
+			if !ok {
+				t.Errorf("%s: %s not found", stage, docID)
+				continue
+			}
+			got, ok, err := view.Stored(n)
+			if err != nil || !ok {
+				t.Errorf("%s: stored fields of %s: ok %v, %v", stage, docID, ok, err)
+				continue
+			}
+			if !reflect.DeepEqual(got, doc) {
+				t.Errorf("%s: stored fields of %s are\n%v\nwant\n%v", stage, docID, got, doc)
+			}
+		}
+	}
+
+	for d := 0; d < 12; d++ {
+		put(d, 1)
+		if d == 3 || d == 7 {
+			if err := engine.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// Update documents in each segment and in the memory index.
+	for _, d := range []int{1, 6, 10} {
+		put(d, 2)
+	}
+	check("flushed")
+	if err := engine.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	check("all flushed")
+	for {
+		merged, err := engine.MaybeMerge()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !merged {
+			break
+		}
+	}
+	if n := engine.SegmentCount(); n != 1 {
+		t.Fatalf("%d segments after the merge, want 1", n)
+	}
+	check("merged")
+
+	exec := executor.New(engine, ranker.Config{}, executor.ExpansionConfig{})
+	h := handler.New(exec, engine.Analyzer(), engine.Schema(), nil, nil, nil, nil, highlight.Config{}, 20, 100, 100)
+	type result struct {
+		DocID    string            `json:"doc_id"`
+		Fields   map[string]string `json:"fields"`
+		Metadata map[string]any    `json:"metadata"`
+	}
+	search := func(fields string) (int, []result) {
+		params := url.Values{"q": {"stored"}, "limit": {"20"}}
+		if fields != "" {
+			params.Set("fields", fields)
+		}
+		rec := httptest.NewRecorder()
+		h.Search(rec, httptest.NewRequest(http.MethodGet, "/api/v1/search?"+params.Encode(), nil))
+		var body struct {
+			Results []result `json:"results"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decoding response to fields=%q: %v", fields, err)
+		}
+		return rec.Code, body.Results
+	}
+
+	tests := []struct {
+		fields string
+		// selected returns the part of doc the search should return.
+		selected func(doc index.Document) result
+	}{
+		{"", func(index.Document) result { return result{} }},
+		{"title", func(doc index.Document) result {
+			return result{Fields: map[string]string{index.FieldTitle: doc.Fields[index.FieldTitle]}}
+		}},
+		{"body, price", func(doc index.Document) result {
+			r := result{Fields: map[string]string{index.FieldBody: doc.Fields[index.FieldBody]}}
+			if p, ok := doc.Metadata["price"]; ok {
+				r.Metadata = map[string]any{"price": p}
+			}
+			return r
+		}},
+		{"category,missing", func(doc index.Document) result {
+			return result{Metadata: map[string]any{"category": doc.Metadata["category"]}}
+		}},
+		{"*", func(doc index.Document) result { return result{Fields: doc.Fields, Metadata: doc.Metadata} }},
+	}
+	for _, tc := range tests {
+		code, results := search(tc.fields)
+		if code != http.StatusOK {
+			t.Fatalf("fields=%q: status %d", tc.fields, code)
+		}
+		if len(results) != len(want) {
+			t.Errorf("fields=%q: %d results, want %d", tc.fields, len(results), len(want))
+		}
+		for _, r := range results {
+			expected := tc.selected(want[r.DocID])
+			expected.DocID = r.DocID
+			if !reflect.DeepEqual(r, expected) {
+				t.Errorf("fields=%q: result\n%+v\nwant\n%+v", tc.fields, r, expected)
+			}
+		}
+	}
+	if code, _ := search("title,not a field"); code != http.StatusBadRequest {
+		t.Errorf("an invalid field name: status %d, want 400", code)
+	}
+}
@@ -0,0 +1,78 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/suggest"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/pkg/proto"
+)
+
+// TestSuggestionsUseSurfaceForms checks that completions and corrections
+// are the words documents were written with rather than their stems, from
+// both segments and the memory index.
+func TestSuggestionsUseSurfaceForms(t *testing.T) {
+	engine := openEngine(t, t.TempDir())
+	defer engine.Close()
+	bodies := []string{
+		"Computer science at the university library",
+		"computer networks and computing",
+		"The University of Computer Science",
+	}
+	for d, body := range bodies {
+		doc := index.Document{Fields: map[string]string{index.FieldBody: body}}
+		if err := engine.IndexDocument(fmt.Sprintf("doc%d", d), doc); err != nil {
+			t.Fatal(err)
+		}
+		if d == 1 {
+			if err := engine.Flush(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	s := suggest.New(map[int]*indexer.Engine{0: engine}, engine.Analyzer(), nil, suggest.Config{})
+	s.Refresh()
+
+	completions := []struct {
+		prefix string
+		want   []string
+	}{
+		{"comp", []string{"computer"}},
+		{"univ", []string{"university"}},
+		{"libr", []string{"library"}},
+		{"computer sc", []string{"computer science"}},
+	}
+	for _, tc := range completions {
+		resp, err := s.Suggest(context.Background(), &proto.SuggestRequest{Prefix: tc.prefix})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(resp.Suggestions, tc.want) {
+			t.Errorf("Suggest(%q) = %v, want %v", tc.prefix, resp.Suggestions, tc.want)
+		}
+	}
+
+	corrections := []struct {
+		query string
+		want  string
+		ok    bool
+	}{
+		{"computr sciense", "computer science", true},
+		{"Universty -librery", "university -librery", true},
+		{"computers science", "computers science", false},
+	}
+	for _, tc := range corrections {
+		plan, err := parser.Parse(tc.query, engine.Analyzer(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, ok := s.DidYouMean(plan); got != tc.want || ok != tc.ok {
+			t.Errorf("DidYouMean(%q) = %q, %v, want %q, %v", tc.query, got, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/wal"
+)
+
+// appendDocs appends an index record for each of docIDs.
+func appendDocs(t *testing.T, l *wal.Log, docIDs ...string) {
+	t.Helper()
+	for _, docID := range docIDs {
+		rec := wal.Record{Op: wal.OpIndex, DocID: docID, Fields: map[string]string{"title": "t " + docID}}
+		if err := l.Append(rec); err != nil {
+			t.Fatalf("appending %s: %v", docID, err)
+		}
+	}
+}
+
+// walPath returns the path of the log file of dir with sequence seq.
+func walPath(dir string, seq uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("wal_%020d.log", seq))
+}
+
+// walFiles returns the names of the log files in dir.
+func walFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, "wal_*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = filepath.Base(m)
+	}
+	return names
+}
+
+// replayed returns the IDs of the records Replay delivers from dir.
+func replayed(t *testing.T, dir string) []string {
+	t.Helper()
+	var docIDs []string
+	n, err := wal.Replay(dir, func(rec wal.Record) error {
+		docIDs = append(docIDs, rec.DocID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("replaying: %v", err)
+	}
+	if n != len(docIDs) {
+		t.Fatalf("replay counted %d records, delivered %d", n, len(docIDs))
+	}
+	return docIDs
+}
+
+func TestReplay(t *testing.T) {
+	dir := t.TempDir()
+	l, err := wal.Open(dir, wal.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendDocs(t, l, "a", "b")
+	if err := l.Append(wal.Record{Op: wal.OpDelete, DocID: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	appendDocs(t, l, "c")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var recs []wal.Record
+	if _, err := wal.Replay(dir, func(rec wal.Record) error {
+		recs = append(recs, rec)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	want := []wal.Record{
+		{Op: wal.OpIndex, DocID: "a", Fields: map[string]string{"title": "t a"}},
+		{Op: wal.OpIndex, DocID: "b", Fields: map[string]string{"title": "t b"}},
+		{Op: wal.OpDelete, DocID: "a"},
+		{Op: wal.OpIndex, DocID: "c", Fields: map[string]string{"title": "t c"}},
+	}
+	if !reflect.DeepEqual(recs, want) {
+		t.Errorf("replayed %+v, want %+v", recs, want)
+	}
+
+	// A reopened log continues after the existing files.
+	l, err = wal.Open(dir, wal.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if l.Seq() != 3 {
+		t.Errorf("seq after reopening = %d, want 3", l.Seq())
+	}
+}
+
+func TestReplayTornTail(t *testing.T) {
+	tails := []struct {
+		name string
+		tail func(valid []byte) []byte
+	}{
+		{"partial header", func([]byte) []byte { return []byte{1, 2, 3} }},
+		{"partial payload", func(valid []byte) []byte { return valid[:len(valid)-2] }},
+		{"checksum mismatch", func(valid []byte) []byte {
+			torn := append([]byte(nil), valid...)
+			torn[len(torn)-1] ^= 0xff
+			return torn
+		}},
+		{"garbage length", func([]byte) []byte { return []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0} }},
+	}
+	for _, tc := range tails {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			l, err := wal.Open(dir, wal.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			appendDocs(t, l, "a")
+			path := walPath(dir, l.Seq())
+			record, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			appendDocs(t, l, "b")
+			if _, err := l.Rotate(); err != nil {
+				t.Fatal(err)
+			}
+			appendDocs(t, l, "c")
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Tear the tail of the first file; the second is still replayed.
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Write(tc.tail(record)); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+			if got, want := replayed(t, dir), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("replayed %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSyncPolicies(t *testing.T) {
+	t.Run("always", func(t *testing.T) {
+		dir := t.TempDir()
+		l, err := wal.Open(dir, wal.Options{Policy: wal.SyncAlways})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		appendDocs(t, l, "a")
+		if got := replayed(t, dir); len(got) != 1 {
+			t.Errorf("replayed %v after one append, want it on disk", got)
+		}
+	})
+	t.Run("batch", func(t *testing.T) {
+		dir := t.TempDir()
+		l, err := wal.Open(dir, wal.Options{Policy: wal.SyncBatch, BatchSize: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		appendDocs(t, l, "a", "b")
+		if got := replayed(t, dir); len(got) != 0 {
+			t.Errorf("replayed %v before the batch filled, want nothing", got)
+		}
+		appendDocs(t, l, "c")
+		if got := replayed(t, dir); len(got) != 3 {
+			t.Errorf("replayed %v after the batch filled, want 3 records", got)
+		}
+		appendDocs(t, l, "d")
+		if err := l.Sync(); err != nil {
+			t.Fatal(err)
+		}
+		if got := replayed(t, dir); len(got) != 4 {
+			t.Errorf("replayed %v after Sync, want 4 records", got)
+		}
+	})
+	t.Run("interval", func(t *testing.T) {
+		dir := t.TempDir()
+		l, err := wal.Open(dir, wal.Options{Policy: wal.SyncInterval, Interval: 10 * time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		appendDocs(t, l, "a", "b")
+		// Records reach the OS at once, and the sync loop fsyncs them
+		// while more are appended.
+		if got := replayed(t, dir); len(got) != 2 {
+			t.Errorf("replayed %v, want 2 records", got)
+		}
+		time.Sleep(30 * time.Millisecond)
+		appendDocs(t, l, "c")
+		if got := replayed(t, dir); len(got) != 3 {
+			t.Errorf("replayed %v after the sync loop ran, want 3 records", got)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, opts := range []wal.Options{
+			{Policy: wal.SyncBatch},
+			{Policy: wal.SyncInterval},
+			{Policy: "sometimes"},
+		} {
+			if _, err := wal.Open(t.TempDir(), opts); err == nil {
+				t.Errorf("Open(%+v) succeeded, want an error", opts)
+			}
+		}
+	})
+}
+
+func TestTruncateBefore(t *testing.T) {
+	dir := t.TempDir()
+	l, err := wal.Open(dir, wal.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	appendDocs(t, l, "a")
+	if _, err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	appendDocs(t, l, "b")
+	seq, err := l.Rotate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendDocs(t, l, "c")
+
+	if err := l.TruncateBefore(seq); err != nil {
+		t.Fatal(err)
+	}
+	if files, want := walFiles(t, dir), []string{filepath.Base(walPath(dir, seq))}; !reflect.DeepEqual(files, want) {
+		t.Errorf("files after truncation = %v, want %v", files, want)
+	}
+	if got, want := replayed(t, dir), []string{"c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed %v, want %v", got, want)
+	}
+}
+
+func TestRotateFailureKeepsLogWritable(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "wal")
+	l, err := wal.Open(dir, wal.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	appendDocs(t, l, "a")
+
+	// Without its directory, the log cannot create a new file.
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Rotate(); err == nil {
+		t.Fatal("Rotate succeeded without a directory")
+	}
+	if l.Seq() != 1 {
+		t.Errorf("seq after failed rotation = %d, want 1", l.Seq())
+	}
+	appendDocs(t, l, "b")
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	seq, err := l.Rotate()
+	if err != nil {
+		t.Fatalf("rotating once the directory is back: %v", err)
+	}
+	if seq != 2 {
+		t.Errorf("seq = %d, want 2", seq)
+	}
+	appendDocs(t, l, "c")
+	if got, want := replayed(t, dir), []string{"c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed %v, want %v", got, want)
+	}
+}
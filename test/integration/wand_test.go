@@ -0,0 +1,168 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/analysis"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/indexer/index"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/executor"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/handler"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/parser"
+	"github.com/Adithya-Monish-Kumar-K/Distributed-Search-Analytics-Platform/internal/searcher/ranker"
+)
+
+// skewedDoc returns doc d of the top-k corpus. Its words occur with
+// frequencies and in documents of lengths that vary widely, so that the
+// blocks of their postings have very different score bounds.
+func skewedDoc(d int) index.Document {
+	var body []string
+	for _, w := range []struct {
+		word  string
+		every int
+		most  int
+	}{{"common", 1, 3}, {"mid", 3, 5}, {"rare", 17, 9}, {"filler", 5, 1}} {
+		if d%w.every == 0 {
+			for n := d/w.every%w.most + 1; n > 0; n-- {
+				body = append(body, w.word)
+			}
+		}
+	}
+	body = append(body, strings.Repeat("padding ", d*7%13))
+	doc := index.Document{
+		Fields: map[string]string{
+			index.FieldTitle: []string{"common", "mid title", "rare common", "plain"}[d%4],
+			index.FieldBody:  strings.Join(body, " "),
+		},
+		Metadata: map[string]any{"category": []string{"news", "sports", "tech"}[d%3], "price": float64(d % 10)},
+	}
+	return doc
+}
+
+// skewedExecutors returns executors over the same 1000 documents, some of
+// them deleted or replaced: one engine holding them in three segments and
+// its memory index, and two shards.
+func skewedExecutors(t *testing.T) map[string]handler.SearchExecutor {
+	t.Helper()
+	single := openMetadataEngine(t)
+	shards := map[int]*indexer.Engine{0: openMetadataEngine(t), 1: openMetadataEngine(t)}
+	engines := []*indexer.Engine{single, shards[0], shards[1]}
+	for d := 0; d < 1000; d++ {
+		docID := fmt.Sprintf("doc%03d", d)
+		for i, e := range engines {
+			if i > 0 && d%2 != i-1 {
+				continue
+			}
+			if err := e.IndexDocument(docID, skewedDoc(d)); err != nil {
+				t.Fatal(err)
+			}
+			if d%300 == 299 {
+				if err := e.Flush(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	for d := 0; d < 1000; d += 11 {
+		docID := fmt.Sprintf("doc%03d", d)
+		for i, e := range engines {
+			if i > 0 && d%2 != i-1 {
+				continue
+			}
+			var err error
+			if d%2 == 0 {
+				_, err = e.DeleteDocument(docID)
+			} else {
+				err = e.UpdateDocument(docID, skewedDoc(d+1))
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	cfg := ranker.Config{FieldBoosts: map[string]float64{index.FieldTitle: 2}}
+	return map[string]handler.SearchExecutor{
+		"single":  executor.New(single, cfg, executor.ExpansionConfig{}),
+		"sharded": executor.NewSharded(shards, cfg, executor.ExpansionConfig{}),
+	}
+}
+
+// TestTopKMatchesExhaustiveRanking checks, for every similarity, that the
+// pages found by skipping documents with block-max WAND rank the same
+// documents with the same scores as scoring every match for an exact
+// total, and that the total they report is exact or a lower bound as
+// they say.
+func TestTopKMatchesExhaustiveRanking(t *testing.T) {
+	executors := skewedExecutors(t)
+	schema := index.Schema{"category": index.TypeKeyword, "price": index.TypeFloat}
+	queries := []string{
+		"common",
+		"rare",
+		"common rare",
+		"common^3 mid rare^0.5",
+		"title:common body:rare",
+		"+common rare mid",
+		"+common +mid",
+		"+mid -rare",
+		"common rare -filler",
+		"common mid #category:news",
+		"+rare #price:[2 TO 6]",
+		"(common OR rare)^2 -category:tech",
+	}
+	models := []ranker.Model{ranker.ModelBM25, ranker.ModelBM25Plus, ranker.ModelTFIDF, ranker.ModelDFR, ranker.ModelLMDirichlet}
+	pages := []executor.Options{{Limit: 1}, {Limit: 10}, {Limit: 10, Offset: 15}}
+	ctx := context.Background()
+	skipped := 0
+	for name, exec := range executors {
+		for _, model := range models {
+			for _, query := range queries {
+				plan, err := parser.Parse(query, analysis.Default(), schema)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, page := range pages {
+					where := fmt.Sprintf("%s/%s %q limit %d offset %d", name, model, query, page.Limit, page.Offset)
+					page.Similarity = model
+					topK, err := exec.Execute(ctx, plan, page)
+					if err != nil {
+						t.Fatal(err)
+					}
+					page.ExactTotal = true
+					exact, err := exec.Execute(ctx, plan, page)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(exact.Results) == 0 {
+						t.Fatalf("%s: no results", where)
+					}
+					if !reflect.DeepEqual(topK.Results, exact.Results) {
+						t.Errorf("%s: top-k results\n%v\nexhaustive\n%v", where, topK.Results, exact.Results)
+					}
+					if exact.TotalHitsRelation != executor.TotalHitsEqual {
+						t.Errorf("%s: exact total is %q", where, exact.TotalHitsRelation)
+					}
+					switch topK.TotalHitsRelation {
+					case executor.TotalHitsEqual:
+						if topK.TotalHits != exact.TotalHits {
+							t.Errorf("%s: top-k total %d is not the exact %d", where, topK.TotalHits, exact.TotalHits)
+						}
+					case executor.TotalHitsLowerBound:
+						skipped++
+						if topK.TotalHits > exact.TotalHits {
+							t.Errorf("%s: top-k lower bound %d above the exact total %d", where, topK.TotalHits, exact.TotalHits)
+						}
+					default:
+						t.Errorf("%s: total hits relation %q", where, topK.TotalHitsRelation)
+					}
+				}
+			}
+		}
+	}
+	if skipped == 0 {
+		t.Error("no search skipped any documents")
+	}
+}
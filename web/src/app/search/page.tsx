@@ -215,9 +215,12 @@ function ResultCard({ result, rank }: { result: SearchResult; rank: number }) {
             </h3>
           </div>
           {result.snippet && (
-            <p className="mt-2 line-clamp-2 text-sm leading-relaxed text-gray-600">
-              {result.snippet}
-            </p>
+            // The searcher escapes the text of snippets, so only its
+            // highlighting tags are markup.
+            <p
+              className="mt-2 line-clamp-2 text-sm leading-relaxed text-gray-600 [&_em]:font-semibold [&_em]:not-italic [&_em]:text-gray-900"
+              dangerouslySetInnerHTML={{ __html: result.snippet }}
+            />
           )}
           <div className="mt-3 flex items-center gap-3 text-xs text-gray-400">
             {result.id && (
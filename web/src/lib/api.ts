@@ -69,9 +69,11 @@ function transformSearchResponse(raw: RawSearchResponse): SearchResponse {
     cache_hit: raw.cache_hit ?? false,
     results: (raw.results ?? []).map((r) => ({
       id: r.doc_id ?? r.id ?? "",
-      title: r.title ?? "",
+      title: r.fields?.title ?? r.title ?? "",
       score: r.score ?? 0,
-      snippet: r.snippet,
+      // Highlighted fragments are HTML with the matched words in <em> tags.
+      snippet:
+        r.highlight?.body?.[0] ?? r.highlight?.title?.[0] ?? r.snippet,
       shard_id: r.shard_id,
     })),
   };
@@ -81,7 +83,12 @@ export async function search(
   query: string,
   limit = 10,
 ): Promise<SearchResponse> {
-  const params = new URLSearchParams({ q: query, limit: String(limit) });
+  const params = new URLSearchParams({
+    q: query,
+    limit: String(limit),
+    fields: "title",
+    highlight: "true",
+  });
   const raw = await fetchJSON<RawSearchResponse>(
     `${SEARCH_BASE}/api/v1/search?${params}`,
   );
@@ -39,6 +39,8 @@ export interface RawSearchResult {
   title?: string;
   snippet?: string;
   shard_id?: number;
+  fields?: Record<string, string>;
+  highlight?: Record<string, string[]>;
 }
 
 export interface RawSearchResponse {